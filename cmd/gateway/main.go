@@ -0,0 +1,82 @@
+// Command gateway serves an OpenAI-compatible API in front of the providers
+// listed in a JSON configuration file, routing each request by model name.
+//
+// Usage:
+//
+//	gateway -config gateway.json [-listen :8080]
+//
+// See gateway.example.json for a configuration with every option.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/gateway"
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/providers/openai"
+)
+
+// shutdownTimeout bounds how long in-flight requests may take to finish.
+const shutdownTimeout = 30 * time.Second
+
+// factories maps upstream types to provider constructors.
+var factories = map[string]interfaces.ProviderFactory{
+	"openai": openai.Factory,
+}
+
+func main() {
+	configPath := flag.String("config", "gateway.json", "path to the JSON configuration file")
+	listen := flag.String("listen", "", "address to listen on (overrides the config file)")
+	flag.Parse()
+
+	logger := log.New(os.Stderr, "gateway: ", log.LstdFlags)
+
+	config, err := gateway.LoadConfig(*configPath)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	if *listen != "" {
+		config.Listen = *listen
+	}
+	if config.Listen == "" {
+		config.Listen = gateway.DefaultListen
+	}
+
+	gw, err := gateway.New(config, factories, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	srv := &http.Server{
+		Addr:              config.Listen,
+		Handler:           gw.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ErrorLog:          logger,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		logger.Printf("serving %d models on %s", len(gw.Models()), config.Listen)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	logger.Print("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Printf("shutdown: %v", err)
+	}
+}
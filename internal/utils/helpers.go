@@ -0,0 +1,47 @@
+// Package utils contains small helpers shared by the library's packages.
+//
+// Nothing in this package is part of the public API.
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// NewID returns a random identifier with the given prefix (e.g., "msg_3f9a...").
+//
+// IDs are 16 random bytes encoded as hex. If the system random source fails,
+// a timestamp-based ID is returned instead so callers never receive an empty ID.
+func NewID(prefix string) string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
+	}
+	if prefix == "" {
+		return hex.EncodeToString(b[:])
+	}
+	return prefix + "_" + hex.EncodeToString(b[:])
+}
+
+// StringSlice converts a value decoded from JSON metadata into a []string.
+//
+// Values stored in metadata Custom maps are []string when set in-process, but
+// become []interface{} after a JSON round trip. Non-string elements are skipped.
+func StringSlice(v interface{}) []string {
+	switch s := v.(type) {
+	case []string:
+		return s
+	case []interface{}:
+		result := make([]string, 0, len(s))
+		for _, item := range s {
+			if str, ok := item.(string); ok {
+				result = append(result, str)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,31 @@
+// Package agent drives the tool-execution loop over any interfaces.ChatService.
+//
+// A Runner sends a request, executes any tool calls in the response with a
+// tools.Registry, appends the assistant message and RoleTool results to the
+// conversation, and calls the model again until it produces a final answer.
+//
+// The loop always terminates: it stops when the model responds without tool
+// calls, when MaxIterations model calls have been made, or when the cumulative
+// token usage exceeds the configured types.TokenBudget. Limits are reported as
+// a *LimitError that wraps ErrMaxIterations or ErrBudgetExceeded. Every step
+// is recorded in the returned Transcript, including when an error occurs.
+//
+// Example usage:
+//
+//	runner := agent.NewRunner(chatService, registry, &agent.Config{
+//	    MaxIterations: 8,
+//	    Budget:        types.NewTokenBudget(50000, 0),
+//	    Hooks: agent.Hooks{
+//	        AfterStep: func(ctx context.Context, step *agent.Step) error {
+//	            log.Printf("step %d: %d tool calls", step.Index, len(step.ToolResults))
+//	            return nil
+//	        },
+//	    },
+//	})
+//
+//	transcript, err := runner.Run(ctx, req)
+//	if errors.Is(err, agent.ErrMaxIterations) {
+//	    log.Printf("gave up after %d steps", len(transcript.Steps))
+//	}
+//	fmt.Println(transcript.FinalContent())
+package agent
@@ -0,0 +1,344 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/tools"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// DefaultMaxIterations is the default maximum number of model calls per run.
+const DefaultMaxIterations = 10
+
+var (
+	// ErrMaxIterations indicates the run stopped after MaxIterations model calls
+	// while the model was still requesting tools.
+	ErrMaxIterations = errors.New("agent: maximum iterations reached")
+
+	// ErrBudgetExceeded indicates the run stopped because cumulative token
+	// usage exceeded the configured budget.
+	ErrBudgetExceeded = errors.New("agent: token budget exceeded")
+)
+
+// StopReason describes why a run ended.
+type StopReason string
+
+const (
+	// StopReasonCompleted indicates the model returned a response without tool calls.
+	StopReasonCompleted StopReason = "completed"
+
+	// StopReasonMaxIterations indicates the iteration limit was reached.
+	StopReasonMaxIterations StopReason = "max_iterations"
+
+	// StopReasonBudgetExceeded indicates the token budget was exhausted.
+	StopReasonBudgetExceeded StopReason = "budget_exceeded"
+
+	// StopReasonError indicates the run stopped because of an error.
+	StopReasonError StopReason = "error"
+)
+
+// String returns the string representation of the StopReason.
+func (s StopReason) String() string {
+	return string(s)
+}
+
+// LimitError is returned when a run stops because a configured limit was hit.
+// It wraps ErrMaxIterations or ErrBudgetExceeded.
+type LimitError struct {
+	// Reason is the limit that was hit.
+	Reason StopReason
+
+	// Steps is the number of model calls made.
+	Steps int
+
+	// TokensUsed is the cumulative number of tokens used.
+	TokensUsed int
+
+	err error
+}
+
+// Error implements the error interface.
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s (steps: %d, tokens: %d)", e.err, e.Steps, e.TokensUsed)
+}
+
+// Unwrap returns ErrMaxIterations or ErrBudgetExceeded.
+func (e *LimitError) Unwrap() error {
+	return e.err
+}
+
+// Hooks are optional callbacks invoked during a run.
+//
+// An error returned from BeforeStep or AfterStep stops the run and is
+// returned from Run.
+type Hooks struct {
+	// BeforeStep is called before each model call. The request may be modified.
+	BeforeStep func(ctx context.Context, index int, req *types.ChatRequest) error
+
+	// AfterStep is called after each step completes, including tool execution.
+	AfterStep func(ctx context.Context, step *Step) error
+
+	// OnChunk is called for each stream chunk when Config.Stream is true.
+	OnChunk func(index int, chunk types.StreamChunk)
+}
+
+// Config configures a Runner.
+type Config struct {
+	// MaxIterations is the maximum number of model calls per run.
+	// Defaults to DefaultMaxIterations if zero.
+	MaxIterations int
+
+	// Budget limits the cumulative token usage (Usage.TotalTokens) of each run.
+	// Every run starts from a copy of Budget, recorded in Transcript.Budget, so
+	// Budget itself is never modified and concurrent runs do not share usage.
+	// If nil, usage is unbounded.
+	Budget *types.TokenBudget
+
+	// Stream uses CreateCompletionStream and assembles each response with a
+	// types.StreamAccumulator, so chunks can be observed through Hooks.OnChunk.
+	Stream bool
+
+	// Hooks are optional per-step callbacks.
+	Hooks Hooks
+}
+
+// Step records a single iteration of the loop.
+type Step struct {
+	// Index is the zero-based step number.
+	Index int
+
+	// Request is the request sent to the model.
+	Request *types.ChatRequest
+
+	// Response is the model's response.
+	Response *types.ChatResponse
+
+	// ToolResults contains the results of the tool calls requested in Response.
+	ToolResults []*tools.Result
+
+	// ToolMessages contains the RoleTool messages appended for ToolResults.
+	ToolMessages []*types.Message
+
+	// Duration is the wall time of the step, including tool execution.
+	Duration time.Duration
+}
+
+// Transcript records a complete run.
+type Transcript struct {
+	// Steps contains every step in order.
+	Steps []*Step
+
+	// Messages is the full conversation, including the original request
+	// messages, assistant messages and tool results.
+	Messages []*types.Message
+
+	// Usage is the cumulative token usage across all steps.
+	Usage *types.Usage
+
+	// Budget is the run's copy of Config.Budget, with Used increased by the
+	// tokens of every step. Nil if the run has no budget.
+	Budget *types.TokenBudget
+
+	// StopReason describes why the run ended.
+	StopReason StopReason
+}
+
+// FinalResponse returns the response of the last step, or nil if no step completed.
+func (t *Transcript) FinalResponse() *types.ChatResponse {
+	if len(t.Steps) == 0 {
+		return nil
+	}
+	return t.Steps[len(t.Steps)-1].Response
+}
+
+// FinalContent returns the text content of the final response.
+func (t *Transcript) FinalContent() string {
+	if resp := t.FinalResponse(); resp != nil {
+		return resp.GetFirstContent()
+	}
+	return ""
+}
+
+// Runner drives the model/tool loop.
+//
+// A Runner holds no per-run state and may be used for concurrent runs.
+type Runner struct {
+	service  interfaces.ChatService
+	registry *tools.Registry
+	config   Config
+}
+
+// NewRunner creates a Runner. If registry is nil, an empty registry is used and
+// any tool call is answered with a tool-not-found error. If config is nil,
+// defaults are used.
+func NewRunner(service interfaces.ChatService, registry *tools.Registry, config *Config) *Runner {
+	if registry == nil {
+		registry = tools.NewRegistry(nil)
+	}
+	r := &Runner{service: service, registry: registry}
+	if config != nil {
+		r.config = *config
+	}
+	if r.config.MaxIterations <= 0 {
+		r.config.MaxIterations = DefaultMaxIterations
+	}
+	return r
+}
+
+// Run executes the loop starting from req. The caller's request is not modified.
+//
+// If req.Tools is empty, the registry's tools are sent with every request.
+//
+// The transcript is always returned, even on error, so partial progress can be
+// inspected. A *LimitError is returned when MaxIterations or the budget is hit.
+func (r *Runner) Run(ctx context.Context, req *types.ChatRequest) (*Transcript, error) {
+	transcript := &Transcript{
+		Messages: append([]*types.Message(nil), req.Messages...),
+		Usage:    &types.Usage{},
+	}
+	if r.config.Budget != nil {
+		budget := *r.config.Budget
+		transcript.Budget = &budget
+	}
+
+	for index := 0; ; index++ {
+		if index >= r.config.MaxIterations {
+			return r.stop(transcript, StopReasonMaxIterations, ErrMaxIterations)
+		}
+		if b := transcript.Budget; b != nil && b.Remaining() <= 0 {
+			return r.stop(transcript, StopReasonBudgetExceeded, ErrBudgetExceeded)
+		}
+		if err := ctx.Err(); err != nil {
+			transcript.StopReason = StopReasonError
+			return transcript, err
+		}
+
+		step, err := r.step(ctx, index, req, transcript)
+		if step != nil {
+			transcript.Steps = append(transcript.Steps, step)
+		}
+		if err != nil {
+			transcript.StopReason = StopReasonError
+			return transcript, err
+		}
+
+		if hook := r.config.Hooks.AfterStep; hook != nil {
+			if err := hook(ctx, step); err != nil {
+				transcript.StopReason = StopReasonError
+				return transcript, err
+			}
+		}
+
+		if len(step.ToolResults) == 0 {
+			transcript.StopReason = StopReasonCompleted
+			return transcript, nil
+		}
+
+		if b := transcript.Budget; b != nil && b.Remaining() <= 0 {
+			return r.stop(transcript, StopReasonBudgetExceeded, ErrBudgetExceeded)
+		}
+	}
+}
+
+// step performs one model call and executes the requested tools.
+func (r *Runner) step(ctx context.Context, index int, base *types.ChatRequest, transcript *Transcript) (*Step, error) {
+	start := time.Now()
+
+	req := *base
+	req.Messages = append([]*types.Message(nil), transcript.Messages...)
+	if len(req.Tools) == 0 && r.registry.Len() > 0 {
+		req.Tools = r.registry.Tools()
+	}
+
+	if hook := r.config.Hooks.BeforeStep; hook != nil {
+		if err := hook(ctx, index, &req); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := r.complete(ctx, index, &req)
+	if err != nil {
+		return nil, fmt.Errorf("agent: step %d: %w", index, err)
+	}
+
+	step := &Step{Index: index, Request: &req, Response: resp}
+	r.recordUsage(transcript, resp.Usage)
+
+	msg := resp.GetFirstMessage()
+	if msg == nil {
+		step.Duration = time.Since(start)
+		return step, fmt.Errorf("agent: step %d: response contains no message", index)
+	}
+	if msg.Role == "" {
+		msg.Role = types.RoleAssistant
+	}
+	transcript.Messages = append(transcript.Messages, msg)
+
+	if calls := resp.GetToolCalls(); len(calls) > 0 {
+		step.ToolResults = r.registry.ExecuteAll(ctx, calls)
+		step.ToolMessages = r.registry.Messages(step.ToolResults)
+		transcript.Messages = append(transcript.Messages, step.ToolMessages...)
+	}
+
+	step.Duration = time.Since(start)
+	return step, nil
+}
+
+// complete performs the model call, streaming if configured.
+func (r *Runner) complete(ctx context.Context, index int, req *types.ChatRequest) (*types.ChatResponse, error) {
+	if !r.config.Stream {
+		return r.service.CreateCompletion(ctx, req)
+	}
+
+	req.Stream = true
+	stream, err := r.service.CreateCompletionStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := types.NewStreamAccumulator()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case chunk, ok := <-stream:
+			if !ok {
+				return acc.ToChatResponse(), nil
+			}
+			if hook := r.config.Hooks.OnChunk; hook != nil {
+				hook(index, chunk)
+			}
+			if ec, isErr := chunk.(*types.ErrorChunk); isErr {
+				return nil, ec.Err
+			}
+			acc.Add(chunk)
+		}
+	}
+}
+
+// recordUsage adds usage to the transcript and its budget.
+func (r *Runner) recordUsage(transcript *Transcript, usage *types.Usage) {
+	if usage == nil {
+		return
+	}
+	transcript.Usage.Add(usage)
+	if b := transcript.Budget; b != nil {
+		// Record actual consumption even if it overshoots; the loop stops on the next check.
+		b.Used += usage.TotalTokens
+	}
+}
+
+// stop ends the run with a limit error.
+func (r *Runner) stop(transcript *Transcript, reason StopReason, err error) (*Transcript, error) {
+	transcript.StopReason = reason
+	return transcript, &LimitError{
+		Reason:     reason,
+		Steps:      len(transcript.Steps),
+		TokensUsed: transcript.Usage.TotalTokens,
+		err:        err,
+	}
+}
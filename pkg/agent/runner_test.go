@@ -0,0 +1,409 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/tools"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// scriptedService returns the next scripted response or stream on each call
+// and records the requests it received.
+type scriptedService struct {
+	mu        sync.Mutex
+	responses []*types.ChatResponse
+	streams   [][]types.StreamChunk
+	requests  []*types.ChatRequest
+}
+
+func (s *scriptedService) CreateCompletion(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	if len(s.responses) == 0 {
+		return nil, errors.New("no more scripted responses")
+	}
+	resp := s.responses[0]
+	s.responses = s.responses[1:]
+	return resp, nil
+}
+
+func (s *scriptedService) CreateCompletionStream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	if len(s.streams) == 0 {
+		return nil, errors.New("no more scripted streams")
+	}
+	chunks := s.streams[0]
+	s.streams = s.streams[1:]
+	ch := make(chan types.StreamChunk, len(chunks))
+	for _, c := range chunks {
+		ch <- c
+	}
+	close(ch)
+	return ch, nil
+}
+
+// answer returns a final response without tool calls.
+func answer(content string, tokens int) *types.ChatResponse {
+	return &types.ChatResponse{
+		Choices: []*types.Choice{{Message: &types.Message{Role: types.RoleAssistant, Content: types.NewTextContent(content)}, FinishReason: types.FinishReasonStop}},
+		Usage:   &types.Usage{TotalTokens: tokens},
+	}
+}
+
+// toolCall returns a response requesting the echo tool.
+func toolCall(id, arguments string, tokens int) *types.ChatResponse {
+	return &types.ChatResponse{
+		Choices: []*types.Choice{{
+			Message: &types.Message{
+				Role:      types.RoleAssistant,
+				ToolCalls: []*types.ToolCall{{ID: id, Type: types.ToolTypeFunction, Function: types.FunctionCall{Name: "echo", Arguments: arguments}}},
+			},
+			FinishReason: types.FinishReasonToolCalls,
+		}},
+		Usage: &types.Usage{TotalTokens: tokens},
+	}
+}
+
+func echoRegistry() *tools.Registry {
+	r := tools.NewRegistry(nil)
+	r.MustRegister(tools.NewDefinition("echo", "Echo the arguments", nil), func(_ context.Context, arguments string) (string, error) {
+		return "echo:" + arguments, nil
+	})
+	return r
+}
+
+func userRequest() *types.ChatRequest {
+	return types.NewChatRequest("m", []*types.Message{{Role: types.RoleUser, Content: types.NewTextContent("hi")}})
+}
+
+// roles summarizes the transcript messages as role[:content] entries.
+func roles(messages []*types.Message) string {
+	out := make([]string, len(messages))
+	for i, msg := range messages {
+		out[i] = msg.Role.String()
+		if msg.Content != nil && msg.Content.String() != "" {
+			out[i] += ":" + msg.Content.String()
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+var errHook = errors.New("hook failed")
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     *Config
+		responses  []*types.ChatResponse
+		streams    [][]types.StreamChunk
+		wantSteps  int
+		wantReason StopReason
+		wantMsgs   string
+		wantTokens int
+		wantErr    error
+	}{
+		{
+			name:       "answer without tools",
+			responses:  []*types.ChatResponse{answer("done", 5)},
+			wantSteps:  1,
+			wantReason: StopReasonCompleted,
+			wantMsgs:   "user:hi,assistant:done",
+			wantTokens: 5,
+		},
+		{
+			name:       "tool calls are dispatched and answered",
+			responses:  []*types.ChatResponse{toolCall("c1", "a", 3), toolCall("c2", "b", 3), answer("done", 4)},
+			wantSteps:  3,
+			wantReason: StopReasonCompleted,
+			wantMsgs:   "user:hi,assistant,tool:echo:a,assistant,tool:echo:b,assistant:done",
+			wantTokens: 10,
+		},
+		{
+			name:       "max iterations",
+			config:     &Config{MaxIterations: 2},
+			responses:  []*types.ChatResponse{toolCall("c1", "a", 1), toolCall("c2", "b", 1), answer("never", 1)},
+			wantSteps:  2,
+			wantReason: StopReasonMaxIterations,
+			wantMsgs:   "user:hi,assistant,tool:echo:a,assistant,tool:echo:b",
+			wantTokens: 2,
+			wantErr:    ErrMaxIterations,
+		},
+		{
+			name:       "budget exceeded after a step",
+			config:     &Config{Budget: types.NewTokenBudget(15, 0)},
+			responses:  []*types.ChatResponse{toolCall("c1", "a", 10), toolCall("c2", "b", 10), answer("never", 1)},
+			wantSteps:  2,
+			wantReason: StopReasonBudgetExceeded,
+			wantMsgs:   "user:hi,assistant,tool:echo:a,assistant,tool:echo:b",
+			wantTokens: 20,
+			wantErr:    ErrBudgetExceeded,
+		},
+		{
+			name:       "final answer may overshoot the budget",
+			config:     &Config{Budget: types.NewTokenBudget(15, 0)},
+			responses:  []*types.ChatResponse{toolCall("c1", "a", 10), answer("done", 10)},
+			wantSteps:  2,
+			wantReason: StopReasonCompleted,
+			wantMsgs:   "user:hi,assistant,tool:echo:a,assistant:done",
+			wantTokens: 20,
+		},
+		{
+			name:       "budget already spent",
+			config:     &Config{Budget: &types.TokenBudget{TotalBudget: 10, Used: 10}},
+			responses:  []*types.ChatResponse{answer("never", 1)},
+			wantSteps:  0,
+			wantReason: StopReasonBudgetExceeded,
+			wantMsgs:   "user:hi",
+			wantErr:    ErrBudgetExceeded,
+		},
+		{
+			name:   "streamed tool call and answer",
+			config: &Config{Stream: true},
+			streams: [][]types.StreamChunk{
+				{
+					&types.ChatStreamChunk{Choices: []*types.StreamChoice{{Delta: &types.MessageDelta{Role: types.RoleAssistant, ToolCalls: []*types.ToolCallDelta{{ID: "c1", Type: types.ToolTypeFunction, Function: &types.FunctionCallDelta{Name: "echo", Arguments: `{"x"`}}}}}}},
+					&types.ChatStreamChunk{Choices: []*types.StreamChoice{{Delta: &types.MessageDelta{ToolCalls: []*types.ToolCallDelta{{Function: &types.FunctionCallDelta{Arguments: `:1}`}}}}, FinishReason: types.FinishReasonToolCalls}}},
+					&types.ChatStreamChunk{Choices: []*types.StreamChoice{}, Usage: &types.Usage{TotalTokens: 6}},
+				},
+				{
+					&types.ChatStreamChunk{Choices: []*types.StreamChoice{{Delta: &types.MessageDelta{Role: types.RoleAssistant, Content: "do"}}}},
+					&types.ChatStreamChunk{Choices: []*types.StreamChoice{{Delta: &types.MessageDelta{Content: "ne"}, FinishReason: types.FinishReasonStop}}, Usage: &types.Usage{TotalTokens: 4}},
+				},
+			},
+			wantSteps:  2,
+			wantReason: StopReasonCompleted,
+			wantMsgs:   `user:hi,assistant,tool:echo:{"x":1},assistant:done`,
+			wantTokens: 10,
+		},
+		{
+			name:   "stream error chunk ends the run",
+			config: &Config{Stream: true},
+			streams: [][]types.StreamChunk{{
+				&types.ChatStreamChunk{Choices: []*types.StreamChoice{{Delta: &types.MessageDelta{Content: "par"}}}},
+				types.NewErrorChunk(errHook),
+				&types.ChatStreamChunk{Choices: []*types.StreamChoice{{Delta: &types.MessageDelta{Content: "tial"}, FinishReason: types.FinishReasonStop}}},
+			}},
+			wantSteps:  0,
+			wantReason: StopReasonError,
+			wantMsgs:   "user:hi",
+			wantErr:    errHook,
+		},
+		{
+			name:       "response without a message",
+			responses:  []*types.ChatResponse{{Usage: &types.Usage{TotalTokens: 2}}},
+			wantSteps:  1,
+			wantReason: StopReasonError,
+			wantMsgs:   "user:hi",
+			wantTokens: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &scriptedService{responses: tt.responses, streams: tt.streams}
+			var budget types.TokenBudget
+			if tt.config != nil && tt.config.Budget != nil {
+				budget = *tt.config.Budget
+			}
+
+			transcript, err := NewRunner(svc, echoRegistry(), tt.config).Run(context.Background(), userRequest())
+			if tt.wantErr != nil || tt.wantReason == StopReasonError {
+				if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+					t.Fatalf("Run() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			var limitErr *LimitError
+			if errors.As(err, &limitErr) && (limitErr.Reason != tt.wantReason || limitErr.Steps != tt.wantSteps || limitErr.TokensUsed != tt.wantTokens) {
+				t.Errorf("LimitError = %+v, want reason %s, %d steps, %d tokens", limitErr, tt.wantReason, tt.wantSteps, tt.wantTokens)
+			}
+			if transcript.StopReason != tt.wantReason {
+				t.Errorf("StopReason = %s, want %s", transcript.StopReason, tt.wantReason)
+			}
+			if len(transcript.Steps) != tt.wantSteps {
+				t.Errorf("Run() took %d steps, want %d", len(transcript.Steps), tt.wantSteps)
+			}
+			if got := roles(transcript.Messages); got != tt.wantMsgs {
+				t.Errorf("Messages = %s, want %s", got, tt.wantMsgs)
+			}
+			if transcript.Usage.TotalTokens != tt.wantTokens {
+				t.Errorf("Usage.TotalTokens = %d, want %d", transcript.Usage.TotalTokens, tt.wantTokens)
+			}
+			if tt.config != nil && tt.config.Budget != nil {
+				if *tt.config.Budget != budget {
+					t.Errorf("Config.Budget = %+v after Run, want it unchanged at %+v", *tt.config.Budget, budget)
+				}
+				if transcript.Budget.Used != budget.Used+tt.wantTokens {
+					t.Errorf("Transcript.Budget.Used = %d, want %d", transcript.Budget.Used, budget.Used+tt.wantTokens)
+				}
+			}
+			if tt.config != nil && tt.config.Stream {
+				for _, req := range svc.requests {
+					if !req.Stream {
+						t.Error("streamed run sent a request without Stream set")
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRunRequests(t *testing.T) {
+	svc := &scriptedService{responses: []*types.ChatResponse{toolCall("c1", "a", 1), answer("done", 1)}}
+	req := userRequest()
+	if _, err := NewRunner(svc, echoRegistry(), nil).Run(context.Background(), req); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(req.Messages) != 1 {
+		t.Errorf("Run() modified the caller's request: %d messages", len(req.Messages))
+	}
+	if got := roles(svc.requests[1].Messages); got != "user:hi,assistant,tool:echo:a" {
+		t.Errorf("second request messages = %s", got)
+	}
+	for i, sent := range svc.requests {
+		if len(sent.Tools) != 1 || sent.Tools[0].Function.Name != "echo" {
+			t.Errorf("request %d tools = %v, want the registry's tools", i, sent.Tools)
+		}
+	}
+	if msg := svc.requests[1].Messages[2]; msg.ToolCallID != "c1" {
+		t.Errorf("tool message ToolCallID = %q, want c1", msg.ToolCallID)
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	tests := []struct {
+		name       string
+		hooks      Hooks
+		wantSteps  int
+		wantCalls  int
+		wantErr    error
+		checkModel bool
+	}{
+		{
+			name: "BeforeStep modifies the request",
+			hooks: Hooks{BeforeStep: func(_ context.Context, index int, req *types.ChatRequest) error {
+				req.Model = "hooked"
+				return nil
+			}},
+			wantSteps:  2,
+			wantCalls:  2,
+			checkModel: true,
+		},
+		{
+			name: "BeforeStep error stops before the model call",
+			hooks: Hooks{BeforeStep: func(_ context.Context, index int, _ *types.ChatRequest) error {
+				if index == 1 {
+					return errHook
+				}
+				return nil
+			}},
+			wantSteps: 1,
+			wantCalls: 1,
+			wantErr:   errHook,
+		},
+		{
+			name: "AfterStep error stops after the step",
+			hooks: Hooks{AfterStep: func(_ context.Context, step *Step) error {
+				if len(step.ToolResults) > 0 {
+					return errHook
+				}
+				return nil
+			}},
+			wantSteps: 1,
+			wantCalls: 1,
+			wantErr:   errHook,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &scriptedService{responses: []*types.ChatResponse{toolCall("c1", "a", 1), answer("done", 1)}}
+			transcript, err := NewRunner(svc, echoRegistry(), &Config{Hooks: tt.hooks}).Run(context.Background(), userRequest())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Run() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(transcript.Steps) != tt.wantSteps || len(svc.requests) != tt.wantCalls {
+				t.Errorf("Run() took %d steps and %d model calls, want %d and %d", len(transcript.Steps), len(svc.requests), tt.wantSteps, tt.wantCalls)
+			}
+			if tt.wantErr != nil && transcript.StopReason != StopReasonError {
+				t.Errorf("StopReason = %s, want error", transcript.StopReason)
+			}
+			if tt.checkModel {
+				for _, step := range transcript.Steps {
+					if step.Request.Model != "hooked" {
+						t.Errorf("step %d request model = %q, want hooked", step.Index, step.Request.Model)
+					}
+				}
+			}
+		})
+	}
+
+	// OnChunk sees every chunk of every streamed step with its step index.
+	var seen []int
+	svc := &scriptedService{streams: [][]types.StreamChunk{{
+		&types.ChatStreamChunk{Choices: []*types.StreamChoice{{Delta: &types.MessageDelta{Content: "a"}}}},
+		&types.ChatStreamChunk{Choices: []*types.StreamChoice{{Delta: &types.MessageDelta{Content: "b"}, FinishReason: types.FinishReasonStop}}},
+	}}}
+	config := &Config{Stream: true, Hooks: Hooks{OnChunk: func(index int, _ types.StreamChunk) { seen = append(seen, index) }}}
+	if _, err := NewRunner(svc, nil, config).Run(context.Background(), userRequest()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(seen) != 2 || seen[0] != 0 || seen[1] != 0 {
+		t.Errorf("OnChunk step indices = %v, want [0 0]", seen)
+	}
+}
+
+// loopService always requests the echo tool.
+type loopService struct{ scriptedService }
+
+func (s *loopService) CreateCompletion(context.Context, *types.ChatRequest) (*types.ChatResponse, error) {
+	return toolCall("c", "x", 10), nil
+}
+
+func TestRunBudgetIsPerRun(t *testing.T) {
+	config := &Config{Budget: types.NewTokenBudget(25, 0)}
+	runner := NewRunner(&loopService{}, echoRegistry(), config)
+
+	var wg sync.WaitGroup
+	steps := make([]int, 4)
+	for i := range steps {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			transcript, err := runner.Run(context.Background(), userRequest())
+			if !errors.Is(err, ErrBudgetExceeded) {
+				t.Errorf("run %d error = %v, want ErrBudgetExceeded", i, err)
+			}
+			steps[i] = len(transcript.Steps)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, n := range steps {
+		if n != 3 {
+			t.Errorf("run %d took %d steps, want 3 with its own budget", i, n)
+		}
+	}
+	if config.Budget.Used != 0 {
+		t.Errorf("Config.Budget.Used = %d after the runs, want 0", config.Budget.Used)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	transcript, err := NewRunner(&scriptedService{}, nil, nil).Run(ctx, userRequest())
+	if !errors.Is(err, context.Canceled) || transcript.StopReason != StopReasonError {
+		t.Errorf("Run() = %s, %v, want a context.Canceled error", transcript.StopReason, err)
+	}
+}
@@ -0,0 +1,350 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zacw/go-ai-types/internal/utils"
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Metadata keys written to MessageMetadata.Custom on summary messages.
+const (
+	// MetadataKeySummary marks a message as a compaction summary (value: true).
+	MetadataKeySummary = "compaction_summary"
+
+	// MetadataKeyReplacedIDs lists the IDs of the messages the summary replaced
+	// (value: []string). IDs replaced by earlier summaries are carried forward.
+	MetadataKeyReplacedIDs = "compaction_replaced_ids"
+
+	// MetadataKeyReplacedCount is the number of messages the summary directly
+	// replaced (value: int), including messages without an ID.
+	MetadataKeyReplacedCount = "compaction_replaced_count"
+
+	// MetadataKeySummaryModel is the model that produced the summary (value: string).
+	MetadataKeySummaryModel = "compaction_summary_model"
+)
+
+// DefaultKeepRecent is the default number of most recent messages that are
+// never compacted.
+const DefaultKeepRecent = 4
+
+// DefaultSummaryPrompt is the default instruction sent to the summarizing model.
+const DefaultSummaryPrompt = "You condense chat transcripts. Summarize the conversation below " +
+	"so it can replace the original messages. Preserve facts, decisions, user preferences, " +
+	"open questions and tool results that later turns may rely on. " +
+	"Write in the third person and do not add information that is not in the transcript."
+
+// DefaultSummaryPrefix is prepended to the generated summary text.
+const DefaultSummaryPrefix = "Summary of the earlier conversation:\n\n"
+
+// ErrNothingToCompact is returned when no messages are eligible for compaction,
+// for example because the history only contains system messages and the
+// messages protected by KeepRecent.
+var ErrNothingToCompact = errors.New("conversation: no messages eligible for compaction")
+
+// CompactorConfig configures a Compactor.
+type CompactorConfig struct {
+	// Model is the model used to generate summaries.
+	Model string
+
+	// Budget is the token budget for the conversation.
+	// Compaction is triggered once the history exceeds Budget.AvailableForPrompt()
+	// (scaled by TriggerRatio). If nil, CompactIfNeeded never compacts.
+	Budget *types.TokenBudget
+
+	// TriggerRatio is the fraction of the available prompt budget at which
+	// compaction is triggered (e.g., 0.8 compacts at 80% of the budget).
+	// Defaults to 1.0 if zero.
+	TriggerRatio float64
+
+	// KeepRecent is the number of most recent messages that are never compacted.
+	// Defaults to DefaultKeepRecent if zero. Use a negative value to allow
+	// compacting every non-system message.
+	KeepRecent int
+
+	// SummaryRole is the role of the generated summary message.
+	// Must be RoleSystem or RoleAssistant. Defaults to RoleSystem.
+	SummaryRole types.Role
+
+	// SummaryPrompt is the instruction sent to the summarizing model.
+	// Defaults to DefaultSummaryPrompt.
+	SummaryPrompt string
+
+	// SummaryPrefix is prepended to the summary text in the resulting message.
+	// Defaults to DefaultSummaryPrefix.
+	SummaryPrefix string
+
+	// MaxSummaryTokens limits the length of the generated summary.
+	// If zero, the provider default is used.
+	MaxSummaryTokens int
+}
+
+// CompactionResult describes a single compaction.
+type CompactionResult struct {
+	// Summary is the message that replaced the compacted span.
+	Summary *types.Message
+
+	// Replaced contains the messages that were replaced, in their original order.
+	Replaced []*types.Message
+
+	// ReplacedIDs lists the IDs recorded in the summary's metadata.
+	ReplacedIDs []string
+
+	// TokensBefore is the estimated token count of the history before compaction.
+	TokensBefore int
+
+	// TokensAfter is the estimated token count of the history after compaction.
+	TokensAfter int
+
+	// Usage is the token usage of the summarization request.
+	Usage *types.Usage
+}
+
+// Compactor condenses the oldest span of a conversation into a summary message.
+//
+// Leading system messages and the most recent KeepRecent messages are always
+// preserved. The compacted span never ends between an assistant tool call and
+// its tool results, so the remaining history stays valid for providers that
+// require every tool result to follow its call.
+//
+// A Compactor is safe for concurrent use if its ChatService and TokenCounter are.
+type Compactor struct {
+	service interfaces.ChatService
+	counter types.TokenCounter
+	config  CompactorConfig
+}
+
+// NewCompactor creates a new Compactor.
+//
+// If counter is nil, a types.HeuristicTokenCounter is used.
+// If config is nil, default settings are used and CompactIfNeeded never compacts.
+func NewCompactor(service interfaces.ChatService, counter types.TokenCounter, config *CompactorConfig) *Compactor {
+	if counter == nil {
+		counter = types.NewHeuristicTokenCounter()
+	}
+
+	c := &Compactor{service: service, counter: counter}
+	if config != nil {
+		c.config = *config
+	}
+	if c.config.TriggerRatio <= 0 {
+		c.config.TriggerRatio = 1.0
+	}
+	if c.config.KeepRecent == 0 {
+		c.config.KeepRecent = DefaultKeepRecent
+	}
+	if c.config.SummaryRole == "" {
+		c.config.SummaryRole = types.RoleSystem
+	}
+	if c.config.SummaryPrompt == "" {
+		c.config.SummaryPrompt = DefaultSummaryPrompt
+	}
+	if c.config.SummaryPrefix == "" {
+		c.config.SummaryPrefix = DefaultSummaryPrefix
+	}
+	return c
+}
+
+// Tokens returns the estimated token count of the given messages.
+func (c *Compactor) Tokens(messages []*types.Message) int {
+	return c.counter.CountMessagesTokens(messages)
+}
+
+// Threshold returns the token count above which compaction is triggered.
+// Returns 0 if no budget is configured.
+func (c *Compactor) Threshold() int {
+	if c.config.Budget == nil {
+		return 0
+	}
+	return int(float64(c.config.Budget.AvailableForPrompt()) * c.config.TriggerRatio)
+}
+
+// NeedsCompaction reports whether the messages exceed the configured threshold.
+func (c *Compactor) NeedsCompaction(messages []*types.Message) bool {
+	if c.config.Budget == nil {
+		return false
+	}
+	return c.Tokens(messages) > c.Threshold()
+}
+
+// CompactIfNeeded compacts the messages if they exceed the configured threshold.
+//
+// If no compaction is needed, the original slice is returned with a nil result.
+// ErrNothingToCompact is not treated as an error here: if the history is over
+// budget but nothing is eligible, the original slice is returned unchanged.
+func (c *Compactor) CompactIfNeeded(ctx context.Context, messages []*types.Message) ([]*types.Message, *CompactionResult, error) {
+	if !c.NeedsCompaction(messages) {
+		return messages, nil, nil
+	}
+
+	compacted, result, err := c.Compact(ctx, messages)
+	if errors.Is(err, ErrNothingToCompact) {
+		return messages, nil, nil
+	}
+	return compacted, result, err
+}
+
+// Compact summarizes the oldest eligible span of messages unconditionally.
+//
+// The returned slice is a new slice; the input is not modified. It contains
+// the leading system messages, the summary message, and the preserved tail.
+//
+// Returns ErrNothingToCompact if no messages are eligible, or an error if the
+// summarization request fails.
+func (c *Compactor) Compact(ctx context.Context, messages []*types.Message) ([]*types.Message, *CompactionResult, error) {
+	if c.service == nil {
+		return nil, nil, errors.New("conversation: compactor has no chat service")
+	}
+	if c.config.SummaryRole != types.RoleSystem && c.config.SummaryRole != types.RoleAssistant {
+		return nil, nil, types.NewValidationError("SummaryRole", "must be system or assistant")
+	}
+
+	start, end := c.span(messages)
+	if end <= start {
+		return nil, nil, ErrNothingToCompact
+	}
+	replaced := messages[start:end]
+
+	req := &types.ChatRequest{
+		Model: c.config.Model,
+		Messages: []*types.Message{
+			{Role: types.RoleSystem, Content: types.NewTextContent(c.config.SummaryPrompt)},
+			{Role: types.RoleUser, Content: types.NewTextContent(renderTranscript(replaced))},
+		},
+		MaxTokens: c.config.MaxSummaryTokens,
+	}
+
+	resp, err := c.service.CreateCompletion(ctx, req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("conversation: summarize %d messages: %w", len(replaced), err)
+	}
+	text := strings.TrimSpace(resp.GetFirstContent())
+	if text == "" {
+		return nil, nil, errors.New("conversation: summarization returned empty content")
+	}
+
+	replacedIDs := collectReplacedIDs(replaced)
+	model := resp.Model
+	if model == "" {
+		model = c.config.Model
+	}
+
+	summary := &types.Message{
+		Role:    c.config.SummaryRole,
+		Content: types.NewTextContent(c.config.SummaryPrefix + text),
+		Metadata: &types.MessageMetadata{
+			ID:        utils.NewID("msg"),
+			Timestamp: time.Now(),
+			Model:     model,
+			Custom: map[string]interface{}{
+				MetadataKeySummary:       true,
+				MetadataKeyReplacedIDs:   replacedIDs,
+				MetadataKeyReplacedCount: len(replaced),
+				MetadataKeySummaryModel:  model,
+			},
+		},
+	}
+
+	compacted := make([]*types.Message, 0, len(messages)-len(replaced)+1)
+	compacted = append(compacted, messages[:start]...)
+	compacted = append(compacted, summary)
+	compacted = append(compacted, messages[end:]...)
+
+	return compacted, &CompactionResult{
+		Summary:      summary,
+		Replaced:     replaced,
+		ReplacedIDs:  replacedIDs,
+		TokensBefore: c.Tokens(messages),
+		TokensAfter:  c.Tokens(compacted),
+		Usage:        resp.Usage,
+	}, nil
+}
+
+// span returns the [start, end) range of messages eligible for compaction.
+func (c *Compactor) span(messages []*types.Message) (int, int) {
+	start := 0
+	for start < len(messages) && messages[start] != nil && messages[start].Role == types.RoleSystem && !IsSummary(messages[start]) {
+		start++
+	}
+
+	keep := c.config.KeepRecent
+	if keep < 0 {
+		keep = 0
+	}
+	end := len(messages) - keep
+
+	// Never separate tool results from the assistant message that requested them.
+	for end > start && end < len(messages) && isToolResult(messages[end]) {
+		end--
+	}
+	return start, end
+}
+
+// IsSummary reports whether the message is a compaction summary.
+func IsSummary(msg *types.Message) bool {
+	if msg == nil || msg.Metadata == nil {
+		return false
+	}
+	v, _ := msg.Metadata.Custom[MetadataKeySummary].(bool)
+	return v
+}
+
+// ReplacedIDs returns the message IDs recorded in a summary message's metadata.
+// Returns nil if the message is not a compaction summary.
+func ReplacedIDs(msg *types.Message) []string {
+	if !IsSummary(msg) {
+		return nil
+	}
+	return utils.StringSlice(msg.Metadata.Custom[MetadataKeyReplacedIDs])
+}
+
+// collectReplacedIDs returns the IDs of the replaced messages, flattening the
+// provenance of any earlier summaries so the audit trail is never lost.
+func collectReplacedIDs(replaced []*types.Message) []string {
+	ids := make([]string, 0, len(replaced))
+	for _, msg := range replaced {
+		ids = append(ids, ReplacedIDs(msg)...)
+		if msg != nil && msg.Metadata != nil && msg.Metadata.ID != "" {
+			ids = append(ids, msg.Metadata.ID)
+		}
+	}
+	return ids
+}
+
+// isToolResult reports whether the message is a tool or function result.
+func isToolResult(msg *types.Message) bool {
+	return msg != nil && (msg.Role == types.RoleTool || msg.Role == types.RoleFunction)
+}
+
+// renderTranscript renders messages as plain text for the summarizing model.
+func renderTranscript(messages []*types.Message) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		if msg == nil {
+			continue
+		}
+		role := msg.Role.String()
+		if msg.Name != "" {
+			role += " (" + msg.Name + ")"
+		}
+		if msg.Content != nil {
+			if text := msg.Content.String(); text != "" {
+				fmt.Fprintf(&b, "%s: %s\n", role, text)
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			if call != nil {
+				fmt.Fprintf(&b, "%s called %s(%s)\n", role, call.Function.Name, call.Function.Arguments)
+			}
+		}
+		if msg.FunctionCall != nil {
+			fmt.Fprintf(&b, "%s called %s(%s)\n", role, msg.FunctionCall.Name, msg.FunctionCall.Arguments)
+		}
+	}
+	return b.String()
+}
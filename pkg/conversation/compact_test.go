@@ -0,0 +1,299 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// summarizer answers every completion request with a fixed summary and
+// records the requests it received.
+type summarizer struct {
+	summary  string
+	err      error
+	requests []*types.ChatRequest
+}
+
+func (s *summarizer) CreateCompletion(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+	s.requests = append(s.requests, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &types.ChatResponse{
+		Model: "summary-model",
+		Choices: []*types.Choice{{
+			Message: &types.Message{Role: types.RoleAssistant, Content: types.NewTextContent(s.summary)},
+		}},
+		Usage: &types.Usage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12},
+	}, nil
+}
+
+func (s *summarizer) CreateCompletionStream(context.Context, *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+// history builds messages from "role:text" specs, giving each an ID equal to its spec.
+// A "tool:" message answers the preceding assistant call.
+func history(specs ...string) []*types.Message {
+	messages := make([]*types.Message, 0, len(specs))
+	for _, spec := range specs {
+		role, text, _ := strings.Cut(spec, ":")
+		msg := &types.Message{
+			Role:     types.Role(role),
+			Content:  types.NewTextContent(text),
+			Metadata: &types.MessageMetadata{ID: spec},
+		}
+		if msg.Role == types.RoleTool {
+			msg.ToolCallID = "call"
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// roles returns the role of each message, marking summaries with "summary".
+func roles(messages []*types.Message) string {
+	out := make([]string, len(messages))
+	for i, msg := range messages {
+		out[i] = msg.Role.String()
+		if IsSummary(msg) {
+			out[i] = "summary"
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+func TestCompact(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    *CompactorConfig
+		messages  []*types.Message
+		wantRoles string
+		wantIDs   []string
+		wantErr   error
+	}{
+		{
+			name:      "keeps leading system messages and the recent tail",
+			config:    &CompactorConfig{KeepRecent: 2},
+			messages:  history("system:rules", "user:a", "assistant:b", "user:c", "assistant:d"),
+			wantRoles: "system,summary,user,assistant",
+			wantIDs:   []string{"user:a", "assistant:b"},
+		},
+		{
+			name:      "negative KeepRecent compacts every non-system message",
+			config:    &CompactorConfig{KeepRecent: -1},
+			messages:  history("system:rules", "user:a", "assistant:b"),
+			wantRoles: "system,summary",
+			wantIDs:   []string{"user:a", "assistant:b"},
+		},
+		{
+			name:      "span never ends between a tool call and its results",
+			config:    &CompactorConfig{KeepRecent: 2},
+			messages:  history("user:a", "assistant:call", "tool:r1", "tool:r2", "assistant:done"),
+			wantRoles: "summary,assistant,tool,tool,assistant",
+			wantIDs:   []string{"user:a"},
+		},
+		{
+			name:   "earlier summary is compacted with its provenance",
+			config: &CompactorConfig{KeepRecent: 1, SummaryRole: types.RoleAssistant},
+			messages: append([]*types.Message{{
+				Role:    types.RoleSystem,
+				Content: types.NewTextContent("old summary"),
+				Metadata: &types.MessageMetadata{ID: "s1", Custom: map[string]interface{}{
+					MetadataKeySummary:     true,
+					MetadataKeyReplacedIDs: []string{"m1", "m2"},
+				}},
+			}}, history("user:a", "assistant:b")...),
+			wantRoles: "summary,assistant",
+			wantIDs:   []string{"m1", "m2", "s1", "user:a"},
+		},
+		{
+			name:     "only protected messages",
+			config:   &CompactorConfig{KeepRecent: 4},
+			messages: history("system:rules", "user:a", "assistant:b"),
+			wantErr:  ErrNothingToCompact,
+		},
+		{
+			name:     "tail starts with tool results only",
+			config:   &CompactorConfig{KeepRecent: 1},
+			messages: history("assistant:call", "tool:r1"),
+			wantErr:  ErrNothingToCompact,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &summarizer{summary: "  they talked  "}
+			c := NewCompactor(svc, nil, tt.config)
+			got, result, err := c.Compact(context.Background(), tt.messages)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Compact() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(svc.requests) != 0 {
+					t.Errorf("Compact() sent %d requests, want none", len(svc.requests))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Compact() error = %v", err)
+			}
+
+			if r := roles(got); r != tt.wantRoles {
+				t.Errorf("Compact() roles = %s, want %s", r, tt.wantRoles)
+			}
+			if fmt.Sprint(result.ReplacedIDs) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("ReplacedIDs = %v, want %v", result.ReplacedIDs, tt.wantIDs)
+			}
+			if ids := ReplacedIDs(result.Summary); fmt.Sprint(ids) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("ReplacedIDs(summary) = %v, want %v", ids, tt.wantIDs)
+			}
+			if text := result.Summary.Content.String(); text != DefaultSummaryPrefix+"they talked" {
+				t.Errorf("summary content = %q", text)
+			}
+			if n := result.Summary.Metadata.Custom[MetadataKeyReplacedCount]; n != len(result.Replaced) {
+				t.Errorf("%s = %v, want %d", MetadataKeyReplacedCount, n, len(result.Replaced))
+			}
+			if result.Summary.Metadata.Model != "summary-model" || result.Usage.TotalTokens != 12 {
+				t.Errorf("summary model = %q, usage = %+v", result.Summary.Metadata.Model, result.Usage)
+			}
+			if len(got) != len(tt.messages)-len(result.Replaced)+1 {
+				t.Errorf("Compact() returned %d messages, want %d", len(got), len(tt.messages)-len(result.Replaced)+1)
+			}
+			for _, msg := range tt.messages {
+				if IsSummary(msg) && msg.Metadata.ID != "s1" {
+					t.Error("Compact() modified the input slice")
+				}
+			}
+		})
+	}
+}
+
+func TestCompactRequest(t *testing.T) {
+	svc := &summarizer{summary: "ok"}
+	c := NewCompactor(svc, nil, &CompactorConfig{Model: "small", KeepRecent: -1, MaxSummaryTokens: 50, SummaryPrompt: "condense"})
+	messages := history("user:hello")
+	messages = append(messages, &types.Message{
+		Role:      types.RoleAssistant,
+		ToolCalls: []*types.ToolCall{{ID: "call", Function: types.FunctionCall{Name: "lookup", Arguments: `{"q":"x"}`}}},
+	})
+
+	if _, _, err := c.Compact(context.Background(), messages); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	req := svc.requests[0]
+	if req.Model != "small" || req.MaxTokens != 50 {
+		t.Errorf("request model = %q, max tokens = %d", req.Model, req.MaxTokens)
+	}
+	if got := req.Messages[0].Content.String(); got != "condense" {
+		t.Errorf("system prompt = %q, want %q", got, "condense")
+	}
+	want := "user: hello\nassistant called lookup({\"q\":\"x\"})\n"
+	if got := req.Messages[1].Content.String(); got != want {
+		t.Errorf("transcript = %q, want %q", got, want)
+	}
+}
+
+func TestCompactErrors(t *testing.T) {
+	errDown := errors.New("down")
+	tests := []struct {
+		name    string
+		svc     *summarizer
+		config  *CompactorConfig
+		wantErr error
+	}{
+		{name: "summarization fails", svc: &summarizer{err: errDown}, wantErr: errDown},
+		{name: "empty summary", svc: &summarizer{summary: " \n "}},
+		{name: "invalid summary role", svc: &summarizer{summary: "ok"}, config: &CompactorConfig{SummaryRole: types.RoleUser}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			if config == nil {
+				config = &CompactorConfig{}
+			}
+			config.KeepRecent = -1
+			_, _, err := NewCompactor(tt.svc, nil, config).Compact(context.Background(), history("user:a"))
+			if err == nil {
+				t.Fatal("Compact() error = nil, want an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Compact() error = %v, want it to wrap %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, _, err := NewCompactor(nil, nil, nil).Compact(context.Background(), history("user:a")); err == nil {
+		t.Error("Compact() without a chat service error = nil, want an error")
+	}
+}
+
+func TestCompactIfNeeded(t *testing.T) {
+	counter := types.NewHeuristicTokenCounter()
+	messages := history("user:"+strings.Repeat("word ", 200), "assistant:ok", "user:more", "assistant:done")
+	tokens := counter.CountMessagesTokens(messages)
+
+	tests := []struct {
+		name        string
+		budget      *types.TokenBudget
+		ratio       float64
+		keepRecent  int
+		wantCompact bool
+	}{
+		{name: "no budget", keepRecent: 1},
+		{name: "under budget", budget: types.NewTokenBudget(tokens+100, 0), keepRecent: 1},
+		{name: "over budget", budget: types.NewTokenBudget(tokens-1, 0), keepRecent: 1, wantCompact: true},
+		{name: "over trigger ratio", budget: types.NewTokenBudget(tokens+100, 0), ratio: 0.5, keepRecent: 1, wantCompact: true},
+		{name: "reserved output counts", budget: types.NewTokenBudget(tokens+100, 200), keepRecent: 1, wantCompact: true},
+		{name: "over budget with nothing eligible", budget: types.NewTokenBudget(tokens-1, 0), keepRecent: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &summarizer{summary: "short"}
+			c := NewCompactor(svc, counter, &CompactorConfig{Budget: tt.budget, TriggerRatio: tt.ratio, KeepRecent: tt.keepRecent})
+			got, result, err := c.CompactIfNeeded(context.Background(), messages)
+			if err != nil {
+				t.Fatalf("CompactIfNeeded() error = %v", err)
+			}
+			if (result != nil) != tt.wantCompact {
+				t.Fatalf("CompactIfNeeded() result = %+v, want compaction %v", result, tt.wantCompact)
+			}
+			if !tt.wantCompact {
+				if len(got) != len(messages) {
+					t.Errorf("CompactIfNeeded() returned %d messages, want the original %d", len(got), len(messages))
+				}
+				return
+			}
+			if result.TokensBefore != tokens || result.TokensAfter >= result.TokensBefore {
+				t.Errorf("tokens before = %d (want %d), after = %d", result.TokensBefore, tokens, result.TokensAfter)
+			}
+		})
+	}
+}
+
+func TestConversationCompact(t *testing.T) {
+	conv := New()
+	conv.AddSystem("rules")
+	for i := 0; i < 6; i++ {
+		conv.AddUser(strings.Repeat("long question ", 50))
+	}
+	ids := []string{conv.Messages[1].Metadata.ID, conv.Messages[2].Metadata.ID}
+
+	c := NewCompactor(&summarizer{summary: "s"}, nil, &CompactorConfig{Budget: types.NewTokenBudget(100, 0), KeepRecent: 4})
+	result, err := conv.Compact(context.Background(), c)
+	if err != nil || result == nil {
+		t.Fatalf("Compact() = %v, %v, want a result", result, err)
+	}
+	if r := roles(conv.Messages); r != "system,summary,user,user,user,user" {
+		t.Errorf("roles after Compact() = %s", r)
+	}
+	if fmt.Sprint(ReplacedIDs(conv.Messages[1])) != fmt.Sprint(ids) {
+		t.Errorf("ReplacedIDs = %v, want %v", ReplacedIDs(conv.Messages[1]), ids)
+	}
+}
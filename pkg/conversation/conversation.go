@@ -0,0 +1,366 @@
+package conversation
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/zacw/go-ai-types/internal/utils"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// ErrMessageNotFound is returned when a message ID does not exist in a conversation.
+var ErrMessageNotFound = errors.New("conversation: message not found")
+
+// Conversation is an ordered chat history with an identity.
+//
+// Every message appended through a Conversation is assigned a
+// MessageMetadata.ID and Timestamp if it does not already have one, so
+// messages can be referenced when forking or auditing compaction.
+//
+// A Conversation is not safe for concurrent use.
+//
+// Example usage:
+//
+//	conv := conversation.New().WithSessionID("session-123")
+//	conv.AddSystem("You are a helpful assistant.")
+//	conv.AddUser("What is the capital of France?")
+//
+//	resp, err := chatService.CreateCompletion(ctx, conv.NewRequest("gpt-4"))
+//	if err != nil {
+//	    return err
+//	}
+//	if _, err := conv.AddResponse(resp); err != nil {
+//	    return err
+//	}
+type Conversation struct {
+	// ID is the unique identifier of the conversation.
+	ID string `json:"id"`
+
+	// SessionID is the session this conversation belongs to.
+	// It is copied to RequestMetadata.SessionID by NewRequest.
+	SessionID string `json:"session_id,omitempty"`
+
+	// ParentID is the ID of the conversation this one was forked from.
+	ParentID string `json:"parent_id,omitempty"`
+
+	// ForkedFrom is the ID of the message in the parent conversation at which
+	// this conversation was forked.
+	ForkedFrom string `json:"forked_from,omitempty"`
+
+	// Messages is the ordered message history.
+	Messages []*types.Message `json:"messages"`
+
+	// CreatedAt is when the conversation was created.
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt is when the conversation was last modified.
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Custom holds custom metadata fields.
+	Custom map[string]interface{} `json:"custom,omitempty"`
+}
+
+// New creates an empty Conversation with a generated ID.
+func New() *Conversation {
+	now := time.Now()
+	return &Conversation{
+		ID:        utils.NewID("conv"),
+		Messages:  []*types.Message{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// WithSessionID sets the session ID and returns the conversation.
+func (c *Conversation) WithSessionID(sessionID string) *Conversation {
+	c.SessionID = sessionID
+	return c
+}
+
+// Len returns the number of messages in the conversation.
+func (c *Conversation) Len() int {
+	return len(c.Messages)
+}
+
+// Last returns the last message, or nil if the conversation is empty.
+func (c *Conversation) Last() *types.Message {
+	if len(c.Messages) == 0 {
+		return nil
+	}
+	return c.Messages[len(c.Messages)-1]
+}
+
+// Add appends a message to the conversation and returns it.
+// The message is assigned an ID and timestamp if it does not have them.
+func (c *Conversation) Add(msg *types.Message) *types.Message {
+	if msg.Metadata == nil {
+		msg.Metadata = &types.MessageMetadata{}
+	}
+	if msg.Metadata.ID == "" {
+		msg.Metadata.ID = utils.NewID("msg")
+	}
+	if msg.Metadata.Timestamp.IsZero() {
+		msg.Metadata.Timestamp = time.Now()
+	}
+	c.Messages = append(c.Messages, msg)
+	c.UpdatedAt = time.Now()
+	return msg
+}
+
+// AddSystem appends a system message with text content.
+func (c *Conversation) AddSystem(text string) *types.Message {
+	return c.Add(&types.Message{Role: types.RoleSystem, Content: types.NewTextContent(text)})
+}
+
+// AddUser appends a user message with text content.
+func (c *Conversation) AddUser(text string) *types.Message {
+	return c.Add(&types.Message{Role: types.RoleUser, Content: types.NewTextContent(text)})
+}
+
+// AddUserContent appends a user message with arbitrary content (e.g., MultiContent).
+func (c *Conversation) AddUserContent(content types.Content) *types.Message {
+	return c.Add(&types.Message{Role: types.RoleUser, Content: content})
+}
+
+// AddAssistant appends an assistant message with text content.
+func (c *Conversation) AddAssistant(text string) *types.Message {
+	return c.Add(&types.Message{Role: types.RoleAssistant, Content: types.NewTextContent(text)})
+}
+
+// AddToolResult appends a tool result message for the given tool call ID.
+func (c *Conversation) AddToolResult(toolCallID, content string) *types.Message {
+	return c.Add(&types.Message{
+		Role:       types.RoleTool,
+		Content:    types.NewTextContent(content),
+		ToolCallID: toolCallID,
+	})
+}
+
+// AddResponse appends the assistant message from the first choice of a
+// ChatResponse, including any tool calls.
+//
+// The message is copied so later changes to the response do not affect the
+// conversation. Its metadata records the response model, and the message ID
+// defaults to the response ID when the response has one.
+//
+// Returns an error if the response contains no message.
+func (c *Conversation) AddResponse(resp *types.ChatResponse) (*types.Message, error) {
+	if resp == nil {
+		return nil, errors.New("conversation: nil response")
+	}
+	src := resp.GetFirstMessage()
+	if src == nil {
+		return nil, errors.New("conversation: response contains no message")
+	}
+
+	msg := copyMessage(src)
+	if msg.Role == "" {
+		msg.Role = types.RoleAssistant
+	}
+	if msg.Metadata.Model == "" {
+		msg.Metadata.Model = resp.Model
+	}
+	if msg.Metadata.ID == "" && resp.ID != "" && c.Find(resp.ID) < 0 {
+		msg.Metadata.ID = resp.ID
+	}
+	return c.Add(msg), nil
+}
+
+// Find returns the index of the message with the given ID, or -1 if not found.
+func (c *Conversation) Find(messageID string) int {
+	for i, msg := range c.Messages {
+		if msg != nil && msg.Metadata != nil && msg.Metadata.ID == messageID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Fork creates a new conversation containing the messages up to and including
+// the message with the given ID.
+//
+// The fork has a new ID, keeps the session ID, and records its parent.
+// Messages are copied, so appending to or editing either conversation does not
+// affect the other.
+//
+// Returns ErrMessageNotFound if the message does not exist.
+func (c *Conversation) Fork(messageID string) (*Conversation, error) {
+	idx := c.Find(messageID)
+	if idx < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMessageNotFound, messageID)
+	}
+	return c.ForkAt(idx)
+}
+
+// ForkAt creates a new conversation containing the messages up to and
+// including the given index. See Fork for details.
+func (c *Conversation) ForkAt(index int) (*Conversation, error) {
+	if index < 0 || index >= len(c.Messages) {
+		return nil, fmt.Errorf("conversation: fork index %d out of range [0, %d)", index, len(c.Messages))
+	}
+
+	fork := New()
+	fork.SessionID = c.SessionID
+	fork.ParentID = c.ID
+	if md := c.Messages[index].Metadata; md != nil {
+		fork.ForkedFrom = md.ID
+	}
+	fork.Messages = make([]*types.Message, 0, index+1)
+	for _, msg := range c.Messages[:index+1] {
+		fork.Messages = append(fork.Messages, copyMessage(msg))
+	}
+	if c.Custom != nil {
+		fork.Custom = make(map[string]interface{}, len(c.Custom))
+		for k, v := range c.Custom {
+			fork.Custom[k] = v
+		}
+	}
+	return fork, nil
+}
+
+// NewRequest creates a ChatRequest for the given model containing the
+// conversation's messages, tagged with the conversation's session ID.
+//
+// The returned request holds a copy of the message slice, so appending to the
+// request does not modify the conversation.
+func (c *Conversation) NewRequest(model string) *types.ChatRequest {
+	messages := make([]*types.Message, len(c.Messages))
+	copy(messages, c.Messages)
+
+	req := types.NewChatRequest(model, messages)
+	if c.SessionID != "" {
+		req.Metadata = &types.RequestMetadata{SessionID: c.SessionID}
+	}
+	return req
+}
+
+// Compact applies the compactor to the conversation's messages if they exceed
+// the compactor's threshold. Returns a nil result if no compaction was needed.
+func (c *Conversation) Compact(ctx context.Context, compactor *Compactor) (*CompactionResult, error) {
+	messages, result, err := compactor.CompactIfNeeded(ctx, c.Messages)
+	if err != nil || result == nil {
+		return nil, err
+	}
+	c.Messages = messages
+	c.UpdatedAt = time.Now()
+	return result, nil
+}
+
+// jsonlHeader is the first line of a JSONL-encoded conversation.
+type jsonlHeader struct {
+	ID         string                 `json:"id"`
+	SessionID  string                 `json:"session_id,omitempty"`
+	ParentID   string                 `json:"parent_id,omitempty"`
+	ForkedFrom string                 `json:"forked_from,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+	Custom     map[string]interface{} `json:"custom,omitempty"`
+}
+
+// WriteJSONL writes the conversation as JSON Lines: a header line containing
+// the conversation fields, followed by one line per message.
+func (c *Conversation) WriteJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+
+	header := jsonlHeader{
+		ID:         c.ID,
+		SessionID:  c.SessionID,
+		ParentID:   c.ParentID,
+		ForkedFrom: c.ForkedFrom,
+		CreatedAt:  c.CreatedAt,
+		UpdatedAt:  c.UpdatedAt,
+		Custom:     c.Custom,
+	}
+	if err := enc.Encode(header); err != nil {
+		return fmt.Errorf("conversation: encode header: %w", err)
+	}
+	for i, msg := range c.Messages {
+		if err := enc.Encode(msg); err != nil {
+			return fmt.Errorf("conversation: encode message %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ReadJSONL reads a conversation written by WriteJSONL.
+func ReadJSONL(r io.Reader) (*Conversation, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("conversation: read header: %w", err)
+		}
+		return nil, errors.New("conversation: empty JSONL input")
+	}
+
+	var header jsonlHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return nil, fmt.Errorf("conversation: decode header: %w", err)
+	}
+	conv := &Conversation{
+		ID:         header.ID,
+		SessionID:  header.SessionID,
+		ParentID:   header.ParentID,
+		ForkedFrom: header.ForkedFrom,
+		Messages:   []*types.Message{},
+		CreatedAt:  header.CreatedAt,
+		UpdatedAt:  header.UpdatedAt,
+		Custom:     header.Custom,
+	}
+
+	line := 1
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		msg := &types.Message{}
+		if err := json.Unmarshal(scanner.Bytes(), msg); err != nil {
+			return nil, fmt.Errorf("conversation: decode line %d: %w", line, err)
+		}
+		conv.Messages = append(conv.Messages, msg)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("conversation: read messages: %w", err)
+	}
+	return conv, nil
+}
+
+// copyMessage returns a copy of msg with its own metadata and tool call slice.
+func copyMessage(msg *types.Message) *types.Message {
+	if msg == nil {
+		return nil
+	}
+	cp := *msg
+	if msg.ToolCalls != nil {
+		cp.ToolCalls = make([]*types.ToolCall, len(msg.ToolCalls))
+		for i, call := range msg.ToolCalls {
+			if call != nil {
+				callCopy := *call
+				cp.ToolCalls[i] = &callCopy
+			}
+		}
+	}
+	if msg.FunctionCall != nil {
+		fc := *msg.FunctionCall
+		cp.FunctionCall = &fc
+	}
+	md := types.MessageMetadata{}
+	if msg.Metadata != nil {
+		md = *msg.Metadata
+		if msg.Metadata.Custom != nil {
+			md.Custom = make(map[string]interface{}, len(msg.Metadata.Custom))
+			for k, v := range msg.Metadata.Custom {
+				md.Custom[k] = v
+			}
+		}
+	}
+	cp.Metadata = &md
+	return &cp
+}
@@ -0,0 +1,351 @@
+package conversation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// sample returns a conversation with a system prompt, a tool call round trip
+// and custom metadata.
+func sample() *Conversation {
+	conv := New().WithSessionID("session-1")
+	conv.Custom = map[string]interface{}{"topic": "weather"}
+	conv.AddSystem("be brief")
+	conv.AddUser("weather in Paris?")
+	conv.Add(&types.Message{
+		Role:      types.RoleAssistant,
+		ToolCalls: []*types.ToolCall{{ID: "call-1", Type: types.ToolTypeFunction, Function: types.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}}},
+	})
+	conv.AddToolResult("call-1", `{"temp":21}`)
+	conv.AddAssistant("21 degrees.")
+	return conv
+}
+
+func TestAdd(t *testing.T) {
+	conv := New()
+	msg := conv.AddUser("hi")
+	if msg.Metadata == nil || msg.Metadata.ID == "" || msg.Metadata.Timestamp.IsZero() {
+		t.Fatalf("Add() metadata = %+v, want an ID and timestamp", msg.Metadata)
+	}
+	kept := conv.Add(&types.Message{Role: types.RoleUser, Metadata: &types.MessageMetadata{ID: "mine"}})
+	if kept.Metadata.ID != "mine" {
+		t.Errorf("Add() replaced the message ID with %q", kept.Metadata.ID)
+	}
+	if conv.Len() != 2 || conv.Last() != kept || conv.Find("mine") != 1 || conv.Find("missing") != -1 {
+		t.Errorf("Len() = %d, Find(mine) = %d", conv.Len(), conv.Find("mine"))
+	}
+}
+
+func TestAddResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    *types.ChatResponse
+		wantID  string
+		wantErr bool
+	}{
+		{
+			name:   "uses the response ID and model",
+			resp:   &types.ChatResponse{ID: "resp-1", Model: "m", Choices: []*types.Choice{{Message: &types.Message{Content: types.NewTextContent("hi")}}}},
+			wantID: "resp-1",
+		},
+		{
+			name:   "keeps the message ID",
+			resp:   &types.ChatResponse{ID: "resp-1", Model: "m", Choices: []*types.Choice{{Message: &types.Message{Role: types.RoleAssistant, Metadata: &types.MessageMetadata{ID: "msg-1"}}}}},
+			wantID: "msg-1",
+		},
+		{name: "nil response", wantErr: true},
+		{name: "no choices", resp: &types.ChatResponse{ID: "resp-1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conv := New()
+			msg, err := conv.AddResponse(tt.resp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if msg.Role != types.RoleAssistant || msg.Metadata.Model != "m" || msg.Metadata.ID != tt.wantID {
+				t.Errorf("AddResponse() = role %q, model %q, ID %q", msg.Role, msg.Metadata.Model, msg.Metadata.ID)
+			}
+			if msg == tt.resp.GetFirstMessage() {
+				t.Error("AddResponse() did not copy the response message")
+			}
+		})
+	}
+
+	// A second response with the same ID gets a generated message ID.
+	conv := New()
+	resp := &types.ChatResponse{ID: "resp-1", Choices: []*types.Choice{{Message: &types.Message{}}}}
+	conv.AddResponse(resp)
+	msg, _ := conv.AddResponse(resp)
+	if msg.Metadata.ID == "resp-1" {
+		t.Error("AddResponse() reused a message ID already in the conversation")
+	}
+}
+
+func TestFork(t *testing.T) {
+	conv := sample()
+	tests := []struct {
+		name    string
+		id      string
+		wantLen int
+		wantErr error
+	}{
+		{name: "first message", id: conv.Messages[0].Metadata.ID, wantLen: 1},
+		{name: "tool call", id: conv.Messages[2].Metadata.ID, wantLen: 3},
+		{name: "last message", id: conv.Last().Metadata.ID, wantLen: 5},
+		{name: "unknown message", id: "missing", wantErr: ErrMessageNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fork, err := conv.Fork(tt.id)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Fork() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fork() error = %v", err)
+			}
+			if fork.Len() != tt.wantLen {
+				t.Errorf("Fork() has %d messages, want %d", fork.Len(), tt.wantLen)
+			}
+			if fork.ID == conv.ID || fork.ParentID != conv.ID || fork.ForkedFrom != tt.id || fork.SessionID != conv.SessionID {
+				t.Errorf("Fork() = ID %q, parent %q, forked from %q, session %q", fork.ID, fork.ParentID, fork.ForkedFrom, fork.SessionID)
+			}
+			if fork.Last().Metadata.ID != tt.id {
+				t.Errorf("Fork() last message ID = %q, want %q", fork.Last().Metadata.ID, tt.id)
+			}
+		})
+	}
+}
+
+func TestForkIsIndependent(t *testing.T) {
+	conv := sample()
+	fork, err := conv.ForkAt(2)
+	if err != nil {
+		t.Fatalf("ForkAt() error = %v", err)
+	}
+
+	fork.AddUser("and tomorrow?")
+	fork.Messages[2].ToolCalls[0].Function.Name = "edited"
+	fork.Messages[1].Metadata.Custom = map[string]interface{}{"edited": true}
+	fork.Custom["topic"] = "edited"
+
+	if conv.Len() != 5 {
+		t.Errorf("parent has %d messages after appending to the fork, want 5", conv.Len())
+	}
+	if conv.Messages[2].ToolCalls[0].Function.Name != "weather" {
+		t.Error("editing a fork's tool call changed the parent")
+	}
+	if conv.Messages[1].Metadata.Custom != nil {
+		t.Error("editing a fork's metadata changed the parent")
+	}
+	if conv.Custom["topic"] != "weather" {
+		t.Error("editing a fork's custom fields changed the parent")
+	}
+
+	for _, index := range []int{-1, 5} {
+		if _, err := conv.ForkAt(index); err == nil {
+			t.Errorf("ForkAt(%d) error = nil, want an error", index)
+		}
+	}
+}
+
+func TestNewRequest(t *testing.T) {
+	conv := sample()
+	req := conv.NewRequest("gpt-4")
+	if req.Model != "gpt-4" || len(req.Messages) != conv.Len() {
+		t.Fatalf("NewRequest() = model %q with %d messages", req.Model, len(req.Messages))
+	}
+	if req.Metadata == nil || req.Metadata.SessionID != "session-1" {
+		t.Errorf("NewRequest() metadata = %+v, want session-1", req.Metadata)
+	}
+	req.Messages = append(req.Messages[:1], req.Messages[2:]...)
+	if conv.Messages[1].Content.String() != "weather in Paris?" {
+		t.Error("editing the request's message slice changed the conversation")
+	}
+}
+
+// equalConversations compares the fields preserved by serialization.
+func equalConversations(t *testing.T, got, want *Conversation) {
+	t.Helper()
+	if got.ID != want.ID || got.SessionID != want.SessionID || got.ParentID != want.ParentID || got.ForkedFrom != want.ForkedFrom {
+		t.Errorf("identity = %q/%q/%q/%q, want %q/%q/%q/%q", got.ID, got.SessionID, got.ParentID, got.ForkedFrom, want.ID, want.SessionID, want.ParentID, want.ForkedFrom)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Errorf("timestamps = %v/%v, want %v/%v", got.CreatedAt, got.UpdatedAt, want.CreatedAt, want.UpdatedAt)
+	}
+	if got.Custom["topic"] != want.Custom["topic"] {
+		t.Errorf("Custom = %v, want %v", got.Custom, want.Custom)
+	}
+	if got.Len() != want.Len() {
+		t.Fatalf("%d messages, want %d", got.Len(), want.Len())
+	}
+	for i, msg := range got.Messages {
+		w := want.Messages[i]
+		if msg.Role != w.Role || msg.Metadata.ID != w.Metadata.ID || msg.ToolCallID != w.ToolCallID || len(msg.ToolCalls) != len(w.ToolCalls) {
+			t.Errorf("message %d = %+v, want %+v", i, msg, w)
+		}
+		if contentText(msg) != contentText(w) {
+			t.Errorf("message %d content = %q, want %q", i, contentText(msg), contentText(w))
+		}
+	}
+}
+
+// contentText returns the message content, treating nil content as empty.
+func contentText(msg *types.Message) string {
+	if msg.Content == nil {
+		return ""
+	}
+	return msg.Content.String()
+}
+
+func TestJSONLRoundTrip(t *testing.T) {
+	conv := sample()
+	fork, _ := conv.ForkAt(3)
+
+	for _, c := range []*Conversation{conv, fork, New()} {
+		var buf bytes.Buffer
+		if err := c.WriteJSONL(&buf); err != nil {
+			t.Fatalf("WriteJSONL() error = %v", err)
+		}
+		if lines := strings.Count(buf.String(), "\n"); lines != c.Len()+1 {
+			t.Errorf("WriteJSONL() wrote %d lines, want a header and %d messages", lines, c.Len())
+		}
+		got, err := ReadJSONL(&buf)
+		if err != nil {
+			t.Fatalf("ReadJSONL() error = %v", err)
+		}
+		equalConversations(t, got, c)
+	}
+}
+
+func TestReadJSONLInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "empty", data: ""},
+		{name: "bad header", data: "{\n"},
+		{name: "bad message", data: `{"id":"c"}` + "\n" + `{"role":` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadJSONL(strings.NewReader(tt.data)); err == nil {
+				t.Error("ReadJSONL() error = nil, want an error")
+			}
+		})
+	}
+
+	conv, err := ReadJSONL(strings.NewReader(`{"id":"c"}` + "\n\n" + `{"role":"user","content":"hi"}` + "\n"))
+	if err != nil || conv.Len() != 1 {
+		t.Errorf("ReadJSONL() with a blank line = %v, %v, want one message", conv, err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"memory": func(*testing.T) Store { return NewMemoryStore() },
+		"file json": func(t *testing.T) Store {
+			s, err := NewFileStore(t.TempDir(), FormatJSON)
+			if err != nil {
+				t.Fatalf("NewFileStore() error = %v", err)
+			}
+			return s
+		},
+		"file jsonl": func(t *testing.T) Store {
+			s, err := NewFileStore(t.TempDir(), FormatJSONL)
+			if err != nil {
+				t.Fatalf("NewFileStore() error = %v", err)
+			}
+			return s
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newStore(t)
+			conv := sample()
+			fork, _ := conv.ForkAt(1)
+
+			for _, c := range []*Conversation{conv, fork} {
+				if err := s.Save(ctx, c); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+			}
+			got, err := s.Load(ctx, conv.ID)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			equalConversations(t, got, conv)
+
+			// Saving again replaces the stored conversation.
+			conv.AddUser("thanks")
+			if err := s.Save(ctx, conv); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			if got, _ := s.Load(ctx, conv.ID); got.Len() != 6 {
+				t.Errorf("Load() after a second Save has %d messages, want 6", got.Len())
+			}
+			got.AddUser("not saved")
+			if again, _ := s.Load(ctx, conv.ID); again.Len() != 6 {
+				t.Error("editing a loaded conversation changed the store")
+			}
+
+			ids, err := s.List(ctx)
+			want := []string{conv.ID, fork.ID}
+			if fork.ID < conv.ID {
+				want = []string{fork.ID, conv.ID}
+			}
+			if err != nil || strings.Join(ids, ",") != strings.Join(want, ",") {
+				t.Errorf("List() = %v, %v, want %v", ids, err, want)
+			}
+
+			if err := s.Delete(ctx, fork.ID); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if err := s.Delete(ctx, fork.ID); err != nil {
+				t.Errorf("Delete() of a missing conversation error = %v", err)
+			}
+			if _, err := s.Load(ctx, fork.ID); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Load() after Delete error = %v, want ErrNotFound", err)
+			}
+
+			canceled, cancel := context.WithCancel(ctx)
+			cancel()
+			if err := s.Save(canceled, conv); !errors.Is(err, context.Canceled) {
+				t.Errorf("Save() with a canceled context error = %v", err)
+			}
+		})
+	}
+}
+
+func TestFileStoreRejectsUnsafeIDs(t *testing.T) {
+	s, err := NewFileStore(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	for _, id := range []string{"", ".", "..", "../escape", `a\b`, "a/b", ".hidden"} {
+		conv := New()
+		conv.ID = id
+		var validationErr *types.ValidationError
+		if err := s.Save(context.Background(), conv); !errors.As(err, &validationErr) {
+			t.Errorf("Save(%q) error = %v, want a *ValidationError", id, err)
+		}
+	}
+	if _, err := NewFileStore(t.TempDir(), "yaml"); err == nil {
+		t.Error("NewFileStore() with an unknown format error = nil")
+	}
+}
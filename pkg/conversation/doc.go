@@ -0,0 +1,48 @@
+// Package conversation provides utilities for managing long-running chat
+// histories.
+//
+// A Conversation is an ordered message history with an identity. It can be
+// appended to (including directly from a ChatResponse), forked at any message,
+// tagged with a session ID, serialized to JSON or JSON Lines, and persisted
+// through a pluggable Store. FileStore is the default, file-based Store.
+//
+// The Compactor condenses the oldest span of a conversation into a single
+// summary message once the history no longer fits within a token budget.
+// The summary is produced by any interfaces.ChatService, so compaction works
+// the same way regardless of provider.
+//
+// Every summary records which messages it replaced in its
+// MessageMetadata.Custom map (see MetadataKeyReplacedIDs), so compaction
+// can be audited after the fact.
+//
+// Example usage:
+//
+//	store, err := conversation.NewFileStore("./conversations", conversation.FormatJSONL)
+//	if err != nil {
+//	    return err
+//	}
+//
+//	conv := conversation.New().WithSessionID(sessionID)
+//	conv.AddUser("Hello!")
+//	resp, err := chatService.CreateCompletion(ctx, conv.NewRequest("gpt-4"))
+//	if err != nil {
+//	    return err
+//	}
+//	conv.AddResponse(resp)
+//	err = store.Save(ctx, conv)
+//
+// Compaction example:
+//
+//	compactor := conversation.NewCompactor(chatService, nil, &conversation.CompactorConfig{
+//	    Model:  "gpt-4o-mini",
+//	    Budget: types.NewTokenBudget(8000, 1000),
+//	})
+//
+//	messages, result, err := compactor.CompactIfNeeded(ctx, messages)
+//	if err != nil {
+//	    return err
+//	}
+//	if result != nil {
+//	    log.Printf("compacted %d messages", len(result.Replaced))
+//	}
+package conversation
@@ -0,0 +1,294 @@
+package conversation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// ErrNotFound is returned by a Store when a conversation does not exist.
+var ErrNotFound = errors.New("conversation: not found")
+
+// Store persists conversations.
+//
+// Implementations must be safe for concurrent use. Load must return an error
+// wrapping ErrNotFound when the conversation does not exist.
+type Store interface {
+	// Save creates or replaces the stored conversation with the same ID.
+	Save(ctx context.Context, conv *Conversation) error
+
+	// Load returns the conversation with the given ID.
+	Load(ctx context.Context, id string) (*Conversation, error)
+
+	// Delete removes the conversation with the given ID.
+	// Deleting a conversation that does not exist is not an error.
+	Delete(ctx context.Context, id string) error
+
+	// List returns the IDs of all stored conversations in lexical order.
+	List(ctx context.Context) ([]string, error)
+}
+
+// Format is the on-disk encoding used by FileStore.
+type Format string
+
+const (
+	// FormatJSON stores each conversation as a single JSON document.
+	FormatJSON Format = "json"
+
+	// FormatJSONL stores each conversation as JSON Lines (see WriteJSONL).
+	FormatJSONL Format = "jsonl"
+)
+
+// String returns the string representation of the Format.
+func (f Format) String() string {
+	return string(f)
+}
+
+// extension returns the file extension for the format.
+func (f Format) extension() string {
+	if f == FormatJSONL {
+		return ".jsonl"
+	}
+	return ".json"
+}
+
+// FileStore is the default Store, keeping one file per conversation in a directory.
+//
+// Writes go to a temporary file that is renamed into place, so a crash never
+// leaves a partially written conversation behind.
+type FileStore struct {
+	dir    string
+	format Format
+	mu     sync.RWMutex
+}
+
+// NewFileStore creates a FileStore rooted at dir, creating the directory if needed.
+// If format is empty, FormatJSON is used.
+func NewFileStore(dir string, format Format) (*FileStore, error) {
+	if format == "" {
+		format = FormatJSON
+	}
+	if format != FormatJSON && format != FormatJSONL {
+		return nil, types.NewValidationError("format", "must be json or jsonl")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("conversation: create store directory: %w", err)
+	}
+	return &FileStore{dir: dir, format: format}, nil
+}
+
+// Save writes the conversation to disk.
+func (s *FileStore) Save(ctx context.Context, conv *Conversation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := s.path(conv.ID)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if s.format == FormatJSONL {
+		err = conv.WriteJSONL(&buf)
+	} else {
+		err = json.NewEncoder(&buf).Encode(conv)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp, err := os.CreateTemp(s.dir, ".tmp-"+conv.ID+"-*")
+	if err != nil {
+		return fmt.Errorf("conversation: create temp file: %w", err)
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("conversation: write %s: %w", conv.ID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("conversation: write %s: %w", conv.ID, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("conversation: write %s: %w", conv.ID, err)
+	}
+	return nil
+}
+
+// Load reads the conversation with the given ID from disk.
+func (s *FileStore) Load(ctx context.Context, id string) (*Conversation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	f, err := os.Open(path)
+	s.mu.RUnlock()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("conversation: open %s: %w", id, err)
+	}
+	defer f.Close()
+
+	if s.format == FormatJSONL {
+		return ReadJSONL(f)
+	}
+	conv := &Conversation{}
+	if err := json.NewDecoder(f).Decode(conv); err != nil {
+		return nil, fmt.Errorf("conversation: decode %s: %w", id, err)
+	}
+	return conv, nil
+}
+
+// Delete removes the conversation file.
+func (s *FileStore) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("conversation: delete %s: %w", id, err)
+	}
+	return nil
+}
+
+// List returns the IDs of all conversations in the store directory.
+func (s *FileStore) List(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	entries, err := os.ReadDir(s.dir)
+	s.mu.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("conversation: list store: %w", err)
+	}
+
+	ext := s.format.extension()
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(name, ext))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// path returns the file path for a conversation ID, rejecting IDs that would
+// escape the store directory.
+func (s *FileStore) path(id string) (string, error) {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return "", types.NewValidationError("id", "invalid conversation ID for file store: "+id)
+	}
+	return filepath.Join(s.dir, id+s.format.extension()), nil
+}
+
+// MemoryStore is an in-memory Store, useful for tests and short-lived processes.
+//
+// Conversations are stored as encoded JSON, so values returned by Load never
+// alias values passed to Save.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	items map[string][]byte
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: make(map[string][]byte)}
+}
+
+// Save stores a copy of the conversation.
+func (s *MemoryStore) Save(ctx context.Context, conv *Conversation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(conv)
+	if err != nil {
+		return fmt.Errorf("conversation: encode %s: %w", conv.ID, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[conv.ID] = data
+	return nil
+}
+
+// Load returns a copy of the stored conversation.
+func (s *MemoryStore) Load(ctx context.Context, id string) (*Conversation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	data, ok := s.items[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	conv := &Conversation{}
+	if err := json.Unmarshal(data, conv); err != nil {
+		return nil, fmt.Errorf("conversation: decode %s: %w", id, err)
+	}
+	return conv, nil
+}
+
+// Delete removes the stored conversation.
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, id)
+	return nil
+}
+
+// List returns the IDs of all stored conversations.
+func (s *MemoryStore) List(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.items))
+	for id := range s.items {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
@@ -0,0 +1,163 @@
+package converters
+
+import (
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// CohereRerankRequest is the body of a Cohere Rerank request. ReturnDocuments
+// is only accepted by the v1 endpoint: it is read by FromCohereRerankRequest
+// but never set by ToCohereRerankRequest, which targets v2.
+type CohereRerankRequest struct {
+	Model           string   `json:"model"`
+	Query           string   `json:"query"`
+	Documents       []string `json:"documents"`
+	TopN            int      `json:"top_n,omitempty"`
+	MaxTokensPerDoc int      `json:"max_tokens_per_doc,omitempty"`
+	ReturnDocuments bool     `json:"return_documents,omitempty"`
+}
+
+// CohereRerankResponse is the body of a Cohere Rerank response.
+type CohereRerankResponse struct {
+	ID      string                `json:"id,omitempty"`
+	Results []*CohereRerankResult `json:"results"`
+	Meta    *CohereMeta           `json:"meta,omitempty"`
+}
+
+// CohereRerankResult is one ranked document. Document is only returned by
+// the v1 endpoint when return_documents is set.
+type CohereRerankResult struct {
+	Index          int                   `json:"index"`
+	RelevanceScore float64               `json:"relevance_score"`
+	Document       *CohereRerankDocument `json:"document,omitempty"`
+}
+
+// CohereRerankDocument is a document echoed back in a rerank result.
+type CohereRerankDocument struct {
+	Text string `json:"text"`
+}
+
+// CohereMeta is the meta object of a Cohere response.
+type CohereMeta struct {
+	APIVersion  *CohereAPIVersion  `json:"api_version,omitempty"`
+	BilledUnits *CohereBilledUnits `json:"billed_units,omitempty"`
+	Tokens      *CohereTokens      `json:"tokens,omitempty"`
+}
+
+// CohereAPIVersion identifies the API version that served a response.
+type CohereAPIVersion struct {
+	Version string `json:"version"`
+}
+
+// CohereBilledUnits are the units a response was billed for.
+type CohereBilledUnits struct {
+	InputTokens  int `json:"input_tokens,omitempty"`
+	OutputTokens int `json:"output_tokens,omitempty"`
+	SearchUnits  int `json:"search_units,omitempty"`
+}
+
+// CohereTokens are the tokens a response consumed.
+type CohereTokens struct {
+	InputTokens  int `json:"input_tokens,omitempty"`
+	OutputTokens int `json:"output_tokens,omitempty"`
+}
+
+// ToCohereRerankRequest converts a RerankRequest to the Cohere v2 format.
+// The v2 endpoint rejects return_documents, so ReturnDocuments is not sent;
+// FromCohereRerankResponse fills the documents in from req instead.
+func ToCohereRerankRequest(req *types.RerankRequest) *CohereRerankRequest {
+	return &CohereRerankRequest{
+		Model:           req.Model,
+		Query:           req.Query,
+		Documents:       req.Documents,
+		TopN:            req.TopN,
+		MaxTokensPerDoc: req.MaxTokensPerDoc,
+	}
+}
+
+// FromCohereRerankRequest converts a Cohere rerank request to a
+// RerankRequest, validating it.
+func FromCohereRerankRequest(wire *CohereRerankRequest) (*types.RerankRequest, error) {
+	req := &types.RerankRequest{
+		Model:           wire.Model,
+		Query:           wire.Query,
+		Documents:       wire.Documents,
+		TopN:            wire.TopN,
+		MaxTokensPerDoc: wire.MaxTokensPerDoc,
+		ReturnDocuments: wire.ReturnDocuments,
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// ToCohereRerankResponse converts a RerankResponse to Cohere format.
+// Documents are included in results that have them.
+func ToCohereRerankResponse(resp *types.RerankResponse) *CohereRerankResponse {
+	wire := &CohereRerankResponse{
+		ID:      resp.ID,
+		Results: make([]*CohereRerankResult, 0, len(resp.Results)),
+	}
+	for _, r := range resp.Results {
+		if r == nil {
+			continue
+		}
+		res := &CohereRerankResult{Index: r.Index, RelevanceScore: r.RelevanceScore}
+		if r.Document != "" {
+			res.Document = &CohereRerankDocument{Text: r.Document}
+		}
+		wire.Results = append(wire.Results, res)
+	}
+	if resp.SearchUnits > 0 || resp.Usage != nil {
+		wire.Meta = &CohereMeta{}
+		if resp.SearchUnits > 0 {
+			wire.Meta.BilledUnits = &CohereBilledUnits{SearchUnits: resp.SearchUnits}
+		}
+		if resp.Usage != nil {
+			wire.Meta.Tokens = &CohereTokens{
+				InputTokens:  resp.Usage.PromptTokens,
+				OutputTokens: resp.Usage.CompletionTokens,
+			}
+		}
+	}
+	return wire
+}
+
+// FromCohereRerankResponse converts a Cohere rerank response to a
+// RerankResponse. Cohere does not echo the model, and the v2 endpoint does
+// not return documents, so both are taken from req when it is not nil.
+func FromCohereRerankResponse(wire *CohereRerankResponse, req *types.RerankRequest) *types.RerankResponse {
+	resp := &types.RerankResponse{
+		ID:      wire.ID,
+		Results: make([]*types.RerankResult, 0, len(wire.Results)),
+	}
+	for _, r := range wire.Results {
+		if r == nil {
+			continue
+		}
+		res := &types.RerankResult{Index: r.Index, RelevanceScore: r.RelevanceScore}
+		if r.Document != nil {
+			res.Document = r.Document.Text
+		} else if req != nil && req.ReturnDocuments && r.Index >= 0 && r.Index < len(req.Documents) {
+			res.Document = req.Documents[r.Index]
+		}
+		resp.Results = append(resp.Results, res)
+	}
+	if req != nil {
+		resp.Model = req.Model
+	}
+
+	if meta := wire.Meta; meta != nil {
+		if meta.BilledUnits != nil {
+			resp.SearchUnits = meta.BilledUnits.SearchUnits
+		}
+		if t := meta.Tokens; t != nil && (t.InputTokens > 0 || t.OutputTokens > 0) {
+			resp.Usage = &types.Usage{
+				PromptTokens:     t.InputTokens,
+				CompletionTokens: t.OutputTokens,
+				TotalTokens:      t.InputTokens + t.OutputTokens,
+			}
+		}
+	}
+	return resp
+}
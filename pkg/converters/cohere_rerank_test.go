@@ -0,0 +1,179 @@
+package converters
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+func TestCohereRerankRequestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *types.RerankRequest
+	}{
+		{
+			name: "all fields",
+			req:  &types.RerankRequest{Model: "rerank-v3.5", Query: "q", Documents: []string{"a", "b"}, TopN: 1, MaxTokensPerDoc: 512},
+		},
+		{
+			name: "return documents is not sent",
+			req:  &types.RerankRequest{Model: "rerank-v3.5", Query: "q", Documents: []string{"a"}, ReturnDocuments: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(ToCohereRerankRequest(tt.req))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if strings.Contains(string(data), "return_documents") {
+				t.Errorf("request body %s has return_documents, which the v2 endpoint rejects", data)
+			}
+
+			var wire CohereRerankRequest
+			if err := json.Unmarshal(data, &wire); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			got, err := FromCohereRerankRequest(&wire)
+			if err != nil {
+				t.Fatalf("FromCohereRerankRequest() error = %v", err)
+			}
+			want := *tt.req
+			want.ReturnDocuments = false
+			if !reflect.DeepEqual(got, &want) {
+				t.Errorf("round trip = %+v, want %+v", got, &want)
+			}
+		})
+	}
+}
+
+func TestFromCohereRerankRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantField string
+		wantDocs  bool
+	}{
+		{name: "v1 return_documents is read", body: `{"model":"m","query":"q","documents":["a"],"return_documents":true}`, wantDocs: true},
+		{name: "missing query", body: `{"model":"m","documents":["a"]}`, wantField: "query"},
+		{name: "no documents", body: `{"model":"m","query":"q","documents":[]}`, wantField: "documents"},
+		{name: "negative top_n", body: `{"model":"m","query":"q","documents":["a"],"top_n":-1}`, wantField: "top_n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wire CohereRerankRequest
+			if err := json.Unmarshal([]byte(tt.body), &wire); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			req, err := FromCohereRerankRequest(&wire)
+			if tt.wantField != "" {
+				var validationErr *types.ValidationError
+				if !errors.As(err, &validationErr) || validationErr.Field != tt.wantField {
+					t.Errorf("FromCohereRerankRequest() error = %v, want a *types.ValidationError for %s", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromCohereRerankRequest() error = %v", err)
+			}
+			if req.ReturnDocuments != tt.wantDocs {
+				t.Errorf("ReturnDocuments = %v, want %v", req.ReturnDocuments, tt.wantDocs)
+			}
+		})
+	}
+}
+
+func TestCohereRerankResponseRoundTrip(t *testing.T) {
+	req := &types.RerankRequest{Model: "rerank-v3.5", Query: "q", Documents: []string{"zero", "one", "two"}}
+	resp := &types.RerankResponse{
+		ID:    "r1",
+		Model: "rerank-v3.5",
+		Results: []*types.RerankResult{
+			{Index: 2, RelevanceScore: 0.9, Document: "two"},
+			{Index: 0, RelevanceScore: 0.1, Document: "zero"},
+		},
+		SearchUnits: 1,
+		Usage:       &types.Usage{PromptTokens: 30, TotalTokens: 30},
+	}
+
+	var wire CohereRerankResponse
+	viaJSON(t, ToCohereRerankResponse(resp), &wire)
+	if got := FromCohereRerankResponse(&wire, req); !reflect.DeepEqual(got, resp) {
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(resp)
+		t.Errorf("round trip =\n%s\nwant\n%s", gotJSON, wantJSON)
+	}
+}
+
+func TestFromCohereRerankResponse(t *testing.T) {
+	docs := []string{"zero", "one"}
+	tests := []struct {
+		name      string
+		body      string
+		req       *types.RerankRequest
+		wantDocs  []string
+		wantModel string
+		wantUsage *types.Usage
+	}{
+		{
+			name:      "v2 documents come from the request",
+			body:      `{"id":"r","results":[{"index":1,"relevance_score":0.8},{"index":0,"relevance_score":0.2}],"meta":{"api_version":{"version":"2"},"billed_units":{"search_units":1}}}`,
+			req:       &types.RerankRequest{Model: "m", Documents: docs, ReturnDocuments: true},
+			wantDocs:  []string{"one", "zero"},
+			wantModel: "m",
+		},
+		{
+			name:      "documents not requested are left empty",
+			body:      `{"results":[{"index":1,"relevance_score":0.8}]}`,
+			req:       &types.RerankRequest{Model: "m", Documents: docs},
+			wantDocs:  []string{""},
+			wantModel: "m",
+		},
+		{
+			name:     "v1 documents are read from the response",
+			body:     `{"results":[{"index":0,"relevance_score":0.5,"document":{"text":"echoed"}}]}`,
+			wantDocs: []string{"echoed"},
+		},
+		{
+			name:     "index out of range has no document",
+			body:     `{"results":[{"index":5,"relevance_score":0.5}]}`,
+			req:      &types.RerankRequest{Documents: docs, ReturnDocuments: true},
+			wantDocs: []string{""},
+		},
+		{
+			name:      "token usage",
+			body:      `{"results":[],"meta":{"tokens":{"input_tokens":12,"output_tokens":3}}}`,
+			wantDocs:  []string{},
+			wantUsage: &types.Usage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wire CohereRerankResponse
+			if err := json.Unmarshal([]byte(tt.body), &wire); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			resp := FromCohereRerankResponse(&wire, tt.req)
+			got := []string{}
+			for _, r := range resp.Results {
+				got = append(got, r.Document)
+			}
+			if !reflect.DeepEqual(got, tt.wantDocs) {
+				t.Errorf("documents = %q, want %q", got, tt.wantDocs)
+			}
+			if resp.Model != tt.wantModel {
+				t.Errorf("Model = %q, want %q", resp.Model, tt.wantModel)
+			}
+			if !reflect.DeepEqual(resp.Usage, tt.wantUsage) {
+				t.Errorf("Usage = %+v, want %+v", resp.Usage, tt.wantUsage)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package converters
+
+import (
+	"fmt"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Converter converts chat requests and responses to and from a provider's
+// wire format.
+type Converter interface {
+	// ToProvider converts a request to the provider's wire request type.
+	ToProvider(req *types.ChatRequest) (interface{}, error)
+
+	// FromProvider converts the provider's wire response type to a ChatResponse.
+	FromProvider(resp interface{}) (*types.ChatResponse, error)
+}
+
+// unsupportedTypeError reports a wire value of an unexpected type.
+func unsupportedTypeError(want string, got interface{}) error {
+	return fmt.Errorf("converters: expected %s, got %T", want, got)
+}
+
+// finishReasonPtr returns nil for an empty or in-progress finish reason, so it
+// marshals as JSON null.
+func finishReasonPtr(reason types.FinishReason) *string {
+	if reason == "" || reason == types.FinishReasonNull {
+		return nil
+	}
+	s := string(reason)
+	return &s
+}
+
+// finishReasonFromPtr converts a nullable finish reason.
+func finishReasonFromPtr(reason *string) types.FinishReason {
+	if reason == nil {
+		return ""
+	}
+	return types.FinishReason(*reason)
+}
@@ -0,0 +1,33 @@
+// Package converters translates between the provider-agnostic types in
+// pkg/types and provider wire formats.
+//
+// Each provider has a set of wire types that marshal to exactly what the
+// provider's HTTP API sends and accepts, together with functions converting
+// them to and from pkg/types in both directions. The client direction
+// (To*Request, From*Response) is used to call a provider; the server
+// direction (From*Request, To*Response) is used to serve a provider-
+// compatible API on top of any interfaces.ChatService.
+//
+// Example usage:
+//
+//	// Serve an OpenAI-compatible request.
+//	var wire converters.OpenAIChatRequest
+//	if err := json.NewDecoder(r.Body).Decode(&wire); err != nil {
+//	    return err
+//	}
+//	req, err := converters.FromOpenAIRequest(&wire)
+//	if err != nil {
+//	    return err
+//	}
+//	resp, err := chatService.CreateCompletion(ctx, req)
+//	if err != nil {
+//	    status, body := converters.ToOpenAIError(err)
+//	    w.WriteHeader(status)
+//	    return json.NewEncoder(w).Encode(body)
+//	}
+//	wireResp, err := converters.ToOpenAIResponse(resp)
+//	if err != nil {
+//	    return err
+//	}
+//	return json.NewEncoder(w).Encode(wireResp)
+package converters
@@ -0,0 +1,126 @@
+package converters
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Form is a multipart/form-data request body, used by endpoints that
+// upload files. Parts are encoded in the order they are added.
+type Form struct {
+	parts []formPart
+}
+
+// formPart is a field or, if file is set, a file of a Form.
+type formPart struct {
+	name  string
+	value string
+	file  *types.File
+}
+
+// AddField adds a text field. Empty values are skipped, so optional
+// request fields can be added unconditionally.
+func (f *Form) AddField(name, value string) {
+	if value != "" {
+		f.parts = append(f.parts, formPart{name: name, value: value})
+	}
+}
+
+// AddInt adds an integer field unless n is zero.
+func (f *Form) AddInt(name string, n int) {
+	if n != 0 {
+		f.AddField(name, strconv.Itoa(n))
+	}
+}
+
+// AddFile adds a file part.
+func (f *Form) AddFile(name string, file *types.File) {
+	f.parts = append(f.parts, formPart{name: name, file: file})
+}
+
+// Encode returns the encoded body and its Content-Type, including the
+// boundary.
+func (f *Form) Encode() ([]byte, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, p := range f.parts {
+		if p.file == nil {
+			if err := w.WriteField(p.name, p.value); err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(p.name), escapeQuotes(p.file.Name)))
+		header.Set("Content-Type", p.file.MimeType())
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(p.file.Data); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// formValue returns the first value of a parsed form field.
+func formValue(form *multipart.Form, name string) string {
+	if v := form.Value[name]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// formInt parses an optional integer form field.
+func formInt(form *multipart.Form, name string) (int, error) {
+	v := formValue(form, name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, types.NewValidationError(name, "must be an integer")
+	}
+	return n, nil
+}
+
+// formFiles reads the files uploaded under any of names.
+func formFiles(form *multipart.Form, names ...string) ([]*types.File, error) {
+	var files []*types.File
+	for _, name := range names {
+		for _, fh := range form.File[name] {
+			f, err := fh.Open()
+			if err != nil {
+				return nil, types.NewValidationError(name, "cannot read file: "+err.Error())
+			}
+			data, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				return nil, types.NewValidationError(name, "cannot read file: "+err.Error())
+			}
+			files = append(files, &types.File{
+				Name:        fh.Filename,
+				Data:        data,
+				ContentType: fh.Header.Get("Content-Type"),
+			})
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,789 @@
+package converters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// OpenAIChatRequest is the body of an OpenAI Chat Completions request.
+type OpenAIChatRequest struct {
+	Model               string                      `json:"model"`
+	Messages            []*OpenAIMessage            `json:"messages"`
+	Temperature         *float64                    `json:"temperature,omitempty"`
+	TopP                *float64                    `json:"top_p,omitempty"`
+	N                   int                         `json:"n,omitempty"`
+	Stream              bool                        `json:"stream,omitempty"`
+	StreamOptions       *OpenAIStreamOptions        `json:"stream_options,omitempty"`
+	Stop                interface{}                 `json:"stop,omitempty"` // string or []string
+	MaxTokens           int                         `json:"max_tokens,omitempty"`
+	MaxCompletionTokens int                         `json:"max_completion_tokens,omitempty"`
+	PresencePenalty     *float64                    `json:"presence_penalty,omitempty"`
+	FrequencyPenalty    *float64                    `json:"frequency_penalty,omitempty"`
+	LogitBias           map[string]float64          `json:"logit_bias,omitempty"`
+	LogProbs            bool                        `json:"logprobs,omitempty"`
+	TopLogProbs         int                         `json:"top_logprobs,omitempty"`
+	User                string                      `json:"user,omitempty"`
+	Tools               []*types.ToolDefinition     `json:"tools,omitempty"`
+	ToolChoice          interface{}                 `json:"tool_choice,omitempty"`
+	Functions           []*types.FunctionDefinition `json:"functions,omitempty"`
+	FunctionCall        interface{}                 `json:"function_call,omitempty"`
+	ResponseFormat      *types.ResponseFormat       `json:"response_format,omitempty"`
+	Seed                *int                        `json:"seed,omitempty"`
+	Metadata            map[string]string           `json:"metadata,omitempty"`
+}
+
+// OpenAIStreamOptions are the stream_options of a streaming request.
+type OpenAIStreamOptions struct {
+	// IncludeUsage requests a final chunk carrying token usage.
+	IncludeUsage bool `json:"include_usage,omitempty"`
+}
+
+// OpenAIMessage is a message in OpenAI format.
+//
+// Content is a JSON string, an array of OpenAIContentPart, or null.
+type OpenAIMessage struct {
+	Role         string              `json:"role"`
+	Content      json.RawMessage     `json:"content"`
+	Name         string              `json:"name,omitempty"`
+	ToolCallID   string              `json:"tool_call_id,omitempty"`
+	ToolCalls    []*types.ToolCall   `json:"tool_calls,omitempty"`
+	FunctionCall *types.FunctionCall `json:"function_call,omitempty"`
+	Refusal      string              `json:"refusal,omitempty"`
+}
+
+// OpenAIContentPart is an element of an array message content.
+type OpenAIContentPart struct {
+	Type       string            `json:"type"`
+	Text       string            `json:"text,omitempty"`
+	ImageURL   *OpenAIImageURL   `json:"image_url,omitempty"`
+	InputAudio *OpenAIInputAudio `json:"input_audio,omitempty"`
+}
+
+// OpenAIImageURL is the image_url of an image content part.
+type OpenAIImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// OpenAIInputAudio is the input_audio of an audio content part.
+type OpenAIInputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"`
+}
+
+// OpenAIChatResponse is the body of an OpenAI Chat Completions response.
+type OpenAIChatResponse struct {
+	ID                string          `json:"id"`
+	Object            string          `json:"object"`
+	Created           int64           `json:"created"`
+	Model             string          `json:"model"`
+	Choices           []*OpenAIChoice `json:"choices"`
+	Usage             *OpenAIUsage    `json:"usage,omitempty"`
+	SystemFingerprint string          `json:"system_fingerprint,omitempty"`
+}
+
+// OpenAIChoice is a choice in a response.
+type OpenAIChoice struct {
+	Index        int                   `json:"index"`
+	Message      *OpenAIMessage        `json:"message"`
+	FinishReason *string               `json:"finish_reason"`
+	LogProbs     *types.LogProbability `json:"logprobs"`
+}
+
+// OpenAIUsage is OpenAI's token usage object.
+type OpenAIUsage struct {
+	PromptTokens            int                            `json:"prompt_tokens"`
+	CompletionTokens        int                            `json:"completion_tokens"`
+	TotalTokens             int                            `json:"total_tokens"`
+	PromptTokensDetails     *OpenAIPromptTokensDetails     `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *OpenAICompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+// OpenAIPromptTokensDetails breaks down prompt tokens.
+type OpenAIPromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// OpenAICompletionTokensDetails breaks down completion tokens.
+type OpenAICompletionTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
+}
+
+// OpenAIChatChunk is a chat.completion.chunk streamed as a server-sent event.
+type OpenAIChatChunk struct {
+	ID                string               `json:"id"`
+	Object            string               `json:"object"`
+	Created           int64                `json:"created"`
+	Model             string               `json:"model"`
+	SystemFingerprint string               `json:"system_fingerprint,omitempty"`
+	Choices           []*OpenAIChunkChoice `json:"choices"`
+	Usage             *OpenAIUsage         `json:"usage,omitempty"`
+}
+
+// OpenAIChunkChoice is a choice in a stream chunk.
+type OpenAIChunkChoice struct {
+	Index        int                   `json:"index"`
+	Delta        *OpenAIDelta          `json:"delta"`
+	FinishReason *string               `json:"finish_reason"`
+	LogProbs     *types.LogProbability `json:"logprobs,omitempty"`
+}
+
+// OpenAIDelta is the incremental message of a stream chunk choice.
+type OpenAIDelta struct {
+	Role         string                   `json:"role,omitempty"`
+	Content      string                   `json:"content,omitempty"`
+	Refusal      string                   `json:"refusal,omitempty"`
+	ToolCalls    []*types.ToolCallDelta   `json:"tool_calls,omitempty"`
+	FunctionCall *types.FunctionCallDelta `json:"function_call,omitempty"`
+}
+
+// OpenAIErrorResponse is the body of an OpenAI error response, also sent
+// in-band as a stream event when a stream fails.
+type OpenAIErrorResponse struct {
+	Error *OpenAIError `json:"error"`
+}
+
+// OpenAIError describes an error in OpenAI format.
+type OpenAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Param   string `json:"param,omitempty"`
+	Code    string `json:"code,omitempty"`
+}
+
+// OpenAI object type names.
+const (
+	OpenAIObjectChatCompletion      = "chat.completion"
+	OpenAIObjectChatCompletionChunk = "chat.completion.chunk"
+)
+
+// openAIRoleDeveloper is OpenAI's newer name for the system role.
+const openAIRoleDeveloper = "developer"
+
+// OpenAIConverter implements Converter for the OpenAI Chat Completions API.
+type OpenAIConverter struct{}
+
+// ToProvider converts req to an *OpenAIChatRequest.
+func (OpenAIConverter) ToProvider(req *types.ChatRequest) (interface{}, error) {
+	return ToOpenAIRequest(req)
+}
+
+// FromProvider converts an *OpenAIChatResponse, or its JSON encoding as
+// []byte, to a ChatResponse.
+func (OpenAIConverter) FromProvider(resp interface{}) (*types.ChatResponse, error) {
+	switch r := resp.(type) {
+	case *OpenAIChatResponse:
+		return FromOpenAIResponse(r)
+	case []byte:
+		var wire OpenAIChatResponse
+		if err := json.Unmarshal(r, &wire); err != nil {
+			return nil, fmt.Errorf("converters: decode OpenAI response: %w", err)
+		}
+		return FromOpenAIResponse(&wire)
+	default:
+		return nil, unsupportedTypeError("*OpenAIChatResponse or []byte", resp)
+	}
+}
+
+// ToOpenAIRequest converts a ChatRequest to OpenAI format.
+//
+// RequestMetadata is not sent, except for Custom string values, which
+// become OpenAI metadata. TopK has no OpenAI equivalent and is dropped.
+func ToOpenAIRequest(req *types.ChatRequest) (*OpenAIChatRequest, error) {
+	wire := &OpenAIChatRequest{
+		Model:            req.Model,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		N:                req.N,
+		Stream:           req.Stream,
+		MaxTokens:        req.MaxTokens,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+		LogitBias:        req.LogitBias,
+		LogProbs:         req.LogProbs,
+		TopLogProbs:      req.TopLogProbs,
+		User:             req.User,
+		Tools:            req.Tools,
+		ToolChoice:       req.ToolChoice,
+		Functions:        req.Functions,
+		FunctionCall:     req.FunctionCall,
+		ResponseFormat:   req.ResponseFormat,
+		Seed:             req.Seed,
+	}
+	if len(req.Stop) > 0 {
+		wire.Stop = req.Stop
+	}
+	if req.Stream {
+		wire.StreamOptions = &OpenAIStreamOptions{IncludeUsage: true}
+	}
+	if req.Metadata != nil {
+		for k, v := range req.Metadata.Custom {
+			if s, ok := v.(string); ok {
+				if wire.Metadata == nil {
+					wire.Metadata = make(map[string]string)
+				}
+				wire.Metadata[k] = s
+			}
+		}
+	}
+
+	wire.Messages = make([]*OpenAIMessage, 0, len(req.Messages))
+	for i, msg := range req.Messages {
+		m, err := ToOpenAIMessage(msg)
+		if err != nil {
+			return nil, fmt.Errorf("converters: messages[%d]: %w", i, err)
+		}
+		wire.Messages = append(wire.Messages, m)
+	}
+	return wire, nil
+}
+
+// FromOpenAIRequest converts an OpenAI request to a ChatRequest.
+//
+// The developer role is mapped to RoleSystem, max_completion_tokens is used
+// when max_tokens is absent, and metadata is stored in RequestMetadata.Custom.
+func FromOpenAIRequest(wire *OpenAIChatRequest) (*types.ChatRequest, error) {
+	req := &types.ChatRequest{
+		Model:            wire.Model,
+		Temperature:      wire.Temperature,
+		TopP:             wire.TopP,
+		N:                wire.N,
+		Stream:           wire.Stream,
+		MaxTokens:        wire.MaxTokens,
+		PresencePenalty:  wire.PresencePenalty,
+		FrequencyPenalty: wire.FrequencyPenalty,
+		LogitBias:        wire.LogitBias,
+		LogProbs:         wire.LogProbs,
+		TopLogProbs:      wire.TopLogProbs,
+		User:             wire.User,
+		Tools:            wire.Tools,
+		ToolChoice:       wire.ToolChoice,
+		Functions:        wire.Functions,
+		FunctionCall:     wire.FunctionCall,
+		ResponseFormat:   wire.ResponseFormat,
+		Seed:             wire.Seed,
+	}
+	if req.MaxTokens == 0 {
+		req.MaxTokens = wire.MaxCompletionTokens
+	}
+
+	switch stop := wire.Stop.(type) {
+	case nil:
+	case string:
+		req.Stop = []string{stop}
+	case []string:
+		req.Stop = stop
+	case []interface{}:
+		for _, s := range stop {
+			str, ok := s.(string)
+			if !ok {
+				return nil, types.NewValidationError("stop", "must be a string or an array of strings")
+			}
+			req.Stop = append(req.Stop, str)
+		}
+	default:
+		return nil, types.NewValidationError("stop", "must be a string or an array of strings")
+	}
+
+	if len(wire.Metadata) > 0 {
+		req.Metadata = &types.RequestMetadata{Custom: make(map[string]interface{}, len(wire.Metadata))}
+		for k, v := range wire.Metadata {
+			req.Metadata.Custom[k] = v
+		}
+	}
+
+	req.Messages = make([]*types.Message, 0, len(wire.Messages))
+	for i, m := range wire.Messages {
+		msg, err := FromOpenAIMessage(m)
+		if err != nil {
+			return nil, fmt.Errorf("converters: messages[%d]: %w", i, err)
+		}
+		req.Messages = append(req.Messages, msg)
+	}
+	return req, nil
+}
+
+// ToOpenAIMessage converts a Message to OpenAI format. Metadata is dropped.
+func ToOpenAIMessage(msg *types.Message) (*OpenAIMessage, error) {
+	if msg == nil {
+		return nil, types.NewValidationError("message", "message is nil")
+	}
+	content, err := toOpenAIContent(msg.Content)
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAIMessage{
+		Role:         string(msg.Role),
+		Content:      content,
+		Name:         msg.Name,
+		ToolCallID:   msg.ToolCallID,
+		ToolCalls:    msg.ToolCalls,
+		FunctionCall: msg.FunctionCall,
+		Refusal:      msg.Refusal,
+	}, nil
+}
+
+// FromOpenAIMessage converts an OpenAI message to a Message.
+func FromOpenAIMessage(m *OpenAIMessage) (*types.Message, error) {
+	if m == nil {
+		return nil, types.NewValidationError("message", "message is nil")
+	}
+	content, err := fromOpenAIContent(m.Content)
+	if err != nil {
+		return nil, err
+	}
+	role := types.Role(m.Role)
+	if m.Role == openAIRoleDeveloper {
+		role = types.RoleSystem
+	}
+	return &types.Message{
+		Role:         role,
+		Content:      content,
+		Name:         m.Name,
+		ToolCallID:   m.ToolCallID,
+		ToolCalls:    m.ToolCalls,
+		FunctionCall: m.FunctionCall,
+		Refusal:      m.Refusal,
+	}, nil
+}
+
+// toOpenAIContent encodes message content as a JSON string, part array or null.
+func toOpenAIContent(content types.Content) (json.RawMessage, error) {
+	var v interface{}
+	switch c := content.(type) {
+	case nil:
+		return json.RawMessage("null"), nil
+	case *types.TextContent:
+		v = c.Text
+	case *types.MultiContent:
+		parts := make([]*OpenAIContentPart, 0, len(c.Parts))
+		for _, p := range c.Parts {
+			part, err := toOpenAIPart(p)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
+		}
+		v = parts
+	case *types.ImageContent:
+		v = []*OpenAIContentPart{{Type: "image_url", ImageURL: openAIImageURL(c)}}
+	case *types.AudioContent:
+		part, err := toOpenAIPart(types.NewAudioPart(c))
+		if err != nil {
+			return nil, err
+		}
+		v = []*OpenAIContentPart{part}
+	default:
+		v = content.String()
+	}
+	return json.Marshal(v)
+}
+
+// toOpenAIPart converts a ContentPart.
+func toOpenAIPart(p types.ContentPart) (*OpenAIContentPart, error) {
+	switch p.Type {
+	case types.ContentTypeText:
+		return &OpenAIContentPart{Type: "text", Text: p.Text}, nil
+	case types.ContentTypeImage, types.ContentTypeImageURL:
+		if p.ImageURL == nil {
+			return nil, types.NewValidationError("image_url", "image part has no URL")
+		}
+		return &OpenAIContentPart{Type: "image_url", ImageURL: &OpenAIImageURL{URL: p.ImageURL.URL, Detail: string(p.ImageURL.Detail)}}, nil
+	case types.ContentTypeAudio:
+		if p.Audio == nil || p.Audio.Data == "" {
+			return nil, types.NewValidationError("input_audio", "OpenAI audio parts require base64 data")
+		}
+		return &OpenAIContentPart{Type: "input_audio", InputAudio: &OpenAIInputAudio{Data: p.Audio.Data, Format: audioFormat(p.Audio.MimeType)}}, nil
+	default:
+		return nil, types.NewValidationError("type", "unsupported content part type: "+string(p.Type))
+	}
+}
+
+// fromOpenAIContent decodes a JSON string, part array or null.
+func fromOpenAIContent(raw json.RawMessage) (types.Content, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return types.NewTextContent(text), nil
+	}
+
+	var parts []*OpenAIContentPart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return nil, types.NewValidationError("content", "must be a string, an array of content parts or null")
+	}
+
+	multi := &types.MultiContent{Parts: make([]types.ContentPart, 0, len(parts))}
+	for _, p := range parts {
+		switch p.Type {
+		case "text":
+			multi.Parts = append(multi.Parts, types.NewTextPart(p.Text))
+		case "image_url":
+			if p.ImageURL == nil {
+				return nil, types.NewValidationError("image_url", "image part has no image_url")
+			}
+			multi.Parts = append(multi.Parts, types.NewImagePart(p.ImageURL.URL, types.ImageDetail(p.ImageURL.Detail)))
+		case "input_audio":
+			if p.InputAudio == nil {
+				return nil, types.NewValidationError("input_audio", "audio part has no input_audio")
+			}
+			multi.Parts = append(multi.Parts, types.NewAudioPart(&types.AudioContent{
+				Data:     p.InputAudio.Data,
+				MimeType: "audio/" + p.InputAudio.Format,
+			}))
+		default:
+			return nil, types.NewValidationError("type", "unsupported content part type: "+p.Type)
+		}
+	}
+	return multi, nil
+}
+
+// openAIImageURL converts ImageContent, inlining base64 data as a data URL.
+func openAIImageURL(img *types.ImageContent) *OpenAIImageURL {
+	url := img.URL
+	if url == "" && img.Data != "" {
+		mime := img.MimeType
+		if mime == "" {
+			mime = "image/png"
+		}
+		url = "data:" + mime + ";base64," + img.Data
+	}
+	return &OpenAIImageURL{URL: url, Detail: string(img.Detail)}
+}
+
+// audioFormat converts a MIME type such as "audio/wav" to an OpenAI audio format.
+func audioFormat(mimeType string) string {
+	format := strings.TrimPrefix(mimeType, "audio/")
+	switch format {
+	case "", "mpeg":
+		return "mp3"
+	case "x-wav", "wave":
+		return "wav"
+	default:
+		return format
+	}
+}
+
+// ToOpenAIResponse converts a ChatResponse to OpenAI format.
+// ResponseMetadata and MessageMetadata are not part of the format and are dropped.
+func ToOpenAIResponse(resp *types.ChatResponse) (*OpenAIChatResponse, error) {
+	wire := &OpenAIChatResponse{
+		ID:                resp.ID,
+		Object:            OpenAIObjectChatCompletion,
+		Created:           resp.Created,
+		Model:             resp.Model,
+		Usage:             ToOpenAIUsage(resp.Usage),
+		SystemFingerprint: resp.SystemFingerprint,
+		Choices:           make([]*OpenAIChoice, 0, len(resp.Choices)),
+	}
+	for _, choice := range resp.Choices {
+		if choice == nil {
+			continue
+		}
+		c := &OpenAIChoice{
+			Index:        choice.Index,
+			FinishReason: finishReasonPtr(choice.FinishReason),
+			LogProbs:     choice.LogProbs,
+		}
+		if choice.Message != nil {
+			msg, err := ToOpenAIMessage(choice.Message)
+			if err != nil {
+				return nil, fmt.Errorf("converters: choices[%d]: %w", choice.Index, err)
+			}
+			if msg.Role == "" {
+				msg.Role = string(types.RoleAssistant)
+			}
+			c.Message = msg
+		}
+		wire.Choices = append(wire.Choices, c)
+	}
+	return wire, nil
+}
+
+// FromOpenAIResponse converts an OpenAI response to a ChatResponse.
+func FromOpenAIResponse(wire *OpenAIChatResponse) (*types.ChatResponse, error) {
+	resp := &types.ChatResponse{
+		ID:                wire.ID,
+		Object:            wire.Object,
+		Created:           wire.Created,
+		Model:             wire.Model,
+		Usage:             FromOpenAIUsage(wire.Usage),
+		SystemFingerprint: wire.SystemFingerprint,
+		Choices:           make([]*types.Choice, 0, len(wire.Choices)),
+	}
+	for _, c := range wire.Choices {
+		if c == nil {
+			continue
+		}
+		choice := &types.Choice{
+			Index:        c.Index,
+			FinishReason: finishReasonFromPtr(c.FinishReason),
+			LogProbs:     c.LogProbs,
+		}
+		if c.Message != nil {
+			msg, err := FromOpenAIMessage(c.Message)
+			if err != nil {
+				return nil, fmt.Errorf("converters: choices[%d]: %w", c.Index, err)
+			}
+			choice.Message = msg
+		}
+		resp.Choices = append(resp.Choices, choice)
+	}
+	return resp, nil
+}
+
+// ToOpenAIChunk converts a stream chunk to OpenAI format.
+//
+// *types.ErrorChunk has no chunk representation and yields nil; use
+// ToOpenAIError for it.
+func ToOpenAIChunk(chunk types.StreamChunk) *OpenAIChatChunk {
+	if _, ok := chunk.(*types.ErrorChunk); ok {
+		return nil
+	}
+
+	wire := &OpenAIChatChunk{
+		ID:      chunk.GetID(),
+		Object:  OpenAIObjectChatCompletionChunk,
+		Model:   chunk.GetModel(),
+		Choices: make([]*OpenAIChunkChoice, 0, len(chunk.GetChoices())),
+	}
+	if c, ok := chunk.(*types.ChatStreamChunk); ok {
+		wire.Created = c.Created
+		wire.SystemFingerprint = c.SystemFingerprint
+	}
+	if c, ok := chunk.(types.UsageChunk); ok {
+		wire.Usage = ToOpenAIUsage(c.GetUsage())
+	}
+
+	for _, choice := range chunk.GetChoices() {
+		if choice == nil {
+			continue
+		}
+		c := &OpenAIChunkChoice{
+			Index:        choice.Index,
+			Delta:        &OpenAIDelta{},
+			FinishReason: finishReasonPtr(choice.FinishReason),
+			LogProbs:     choice.LogProbs,
+		}
+		if d := choice.Delta; d != nil {
+			c.Delta = &OpenAIDelta{
+				Role:         string(d.Role),
+				Content:      d.Content,
+				Refusal:      d.Refusal,
+				ToolCalls:    d.ToolCalls,
+				FunctionCall: d.FunctionCall,
+			}
+		}
+		wire.Choices = append(wire.Choices, c)
+	}
+	return wire
+}
+
+// FromOpenAIChunk converts an OpenAI stream chunk to a ChatStreamChunk.
+func FromOpenAIChunk(wire *OpenAIChatChunk) *types.ChatStreamChunk {
+	chunk := &types.ChatStreamChunk{
+		ID:                wire.ID,
+		Object:            wire.Object,
+		Created:           wire.Created,
+		Model:             wire.Model,
+		SystemFingerprint: wire.SystemFingerprint,
+		Usage:             FromOpenAIUsage(wire.Usage),
+		Choices:           make([]*types.StreamChoice, 0, len(wire.Choices)),
+	}
+	for _, c := range wire.Choices {
+		if c == nil {
+			continue
+		}
+		choice := &types.StreamChoice{
+			Index:        c.Index,
+			FinishReason: finishReasonFromPtr(c.FinishReason),
+			LogProbs:     c.LogProbs,
+		}
+		if d := c.Delta; d != nil {
+			choice.Delta = &types.MessageDelta{
+				Role:         types.Role(d.Role),
+				Content:      d.Content,
+				Refusal:      d.Refusal,
+				ToolCalls:    d.ToolCalls,
+				FunctionCall: d.FunctionCall,
+			}
+		}
+		chunk.Choices = append(chunk.Choices, choice)
+	}
+	return chunk
+}
+
+// ToOpenAIUsage converts Usage to OpenAI format.
+func ToOpenAIUsage(usage *types.Usage) *OpenAIUsage {
+	if usage == nil {
+		return nil
+	}
+	wire := &OpenAIUsage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	}
+	if usage.CachedTokens > 0 {
+		wire.PromptTokensDetails = &OpenAIPromptTokensDetails{CachedTokens: usage.CachedTokens}
+	}
+	if usage.ReasoningTokens > 0 {
+		wire.CompletionTokensDetails = &OpenAICompletionTokensDetails{ReasoningTokens: usage.ReasoningTokens}
+	}
+	return wire
+}
+
+// FromOpenAIUsage converts OpenAI usage to Usage.
+func FromOpenAIUsage(wire *OpenAIUsage) *types.Usage {
+	if wire == nil {
+		return nil
+	}
+	usage := &types.Usage{
+		PromptTokens:     wire.PromptTokens,
+		CompletionTokens: wire.CompletionTokens,
+		TotalTokens:      wire.TotalTokens,
+	}
+	if wire.PromptTokensDetails != nil {
+		usage.CachedTokens = wire.PromptTokensDetails.CachedTokens
+	}
+	if wire.CompletionTokensDetails != nil {
+		usage.ReasoningTokens = wire.CompletionTokensDetails.ReasoningTokens
+	}
+	return usage
+}
+
+// ToOpenAIError converts an error to an HTTP status code and OpenAI error body.
+//
+// AIErrors keep their type, code and status (defaulting by type);
+// ValidationErrors become 400 invalid_request_error with the field as param;
+// context cancellation and deadlines become 499 and 504; anything else is a
+// 500 server_error.
+func ToOpenAIError(err error) (int, *OpenAIErrorResponse) {
+	body := &OpenAIErrorResponse{Error: &OpenAIError{Message: err.Error()}}
+
+	var validationErr *types.ValidationError
+	var aiErr types.AIError
+	switch {
+	case errors.As(err, &validationErr):
+		body.Error.Type = string(types.ErrorTypeInvalidRequest)
+		body.Error.Param = validationErr.Field
+		return http.StatusBadRequest, body
+
+	case errors.As(err, &aiErr):
+		body.Error.Type = string(aiErr.Type())
+		body.Error.Code = aiErr.Code()
+		if providerErr, ok := aiErr.(*types.ProviderError); ok {
+			body.Error.Message = providerErr.Message
+			body.Error.Param = providerErr.Param
+		}
+		status := aiErr.StatusCode()
+		if status == 0 {
+			status = statusForErrorType(aiErr.Type())
+		}
+		return status, body
+
+	case errors.Is(err, context.DeadlineExceeded):
+		body.Error.Type = string(types.ErrorTypeTimeout)
+		return http.StatusGatewayTimeout, body
+
+	case errors.Is(err, context.Canceled):
+		body.Error.Type = string(types.ErrorTypeUnknown)
+		return StatusClientClosedRequest, body
+
+	default:
+		body.Error.Type = string(types.ErrorTypeServer)
+		return http.StatusInternalServerError, body
+	}
+}
+
+// FromOpenAIError converts an OpenAI error response to a ProviderError.
+// Rate limit and 5xx errors are marked retryable.
+func FromOpenAIError(status int, body []byte) *types.ProviderError {
+	var wire OpenAIErrorResponse
+	if err := json.Unmarshal(body, &wire); err != nil || wire.Error == nil {
+		wire.Error = &OpenAIError{Message: strings.TrimSpace(string(body))}
+		if wire.Error.Message == "" {
+			wire.Error.Message = http.StatusText(status)
+		}
+	}
+
+	errType := types.ErrorType(wire.Error.Type)
+	if errType == "" || !knownErrorType(errType) {
+		errType = errorTypeForStatus(status)
+	}
+	return &types.ProviderError{
+		ErrorType:    errType,
+		Message:      wire.Error.Message,
+		ErrorCode:    wire.Error.Code,
+		Param:        wire.Error.Param,
+		HTTPStatus:   status,
+		ProviderName: types.ProviderOpenAI,
+		IsRetryable:  status == http.StatusTooManyRequests || status >= 500,
+	}
+}
+
+// StatusClientClosedRequest is the non-standard status used when the client
+// went away before the response was complete.
+const StatusClientClosedRequest = 499
+
+// statusForErrorType returns the HTTP status conventionally used for an error type.
+func statusForErrorType(t types.ErrorType) int {
+	switch t {
+	case types.ErrorTypeInvalidRequest, types.ErrorTypeValidation, types.ErrorTypeContentFilter:
+		return http.StatusBadRequest
+	case types.ErrorTypeAuthentication:
+		return http.StatusUnauthorized
+	case types.ErrorTypePermission:
+		return http.StatusForbidden
+	case types.ErrorTypeNotFound:
+		return http.StatusNotFound
+	case types.ErrorTypeRateLimit, types.ErrorTypeQuotaExceeded:
+		return http.StatusTooManyRequests
+	case types.ErrorTypeTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorTypeForStatus infers an error type from an HTTP status.
+func errorTypeForStatus(status int) types.ErrorType {
+	switch {
+	case status == http.StatusUnauthorized:
+		return types.ErrorTypeAuthentication
+	case status == http.StatusForbidden:
+		return types.ErrorTypePermission
+	case status == http.StatusNotFound:
+		return types.ErrorTypeNotFound
+	case status == http.StatusTooManyRequests:
+		return types.ErrorTypeRateLimit
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return types.ErrorTypeTimeout
+	case status >= 400 && status < 500:
+		return types.ErrorTypeInvalidRequest
+	case status >= 500:
+		return types.ErrorTypeServer
+	default:
+		return types.ErrorTypeUnknown
+	}
+}
+
+// knownErrorType reports whether t is one of the defined error types.
+func knownErrorType(t types.ErrorType) bool {
+	switch t {
+	case types.ErrorTypeInvalidRequest, types.ErrorTypeAuthentication, types.ErrorTypePermission,
+		types.ErrorTypeNotFound, types.ErrorTypeRateLimit, types.ErrorTypeQuotaExceeded,
+		types.ErrorTypeServer, types.ErrorTypeTimeout, types.ErrorTypeContentFilter,
+		types.ErrorTypeValidation, types.ErrorTypeUnknown:
+		return true
+	default:
+		return false
+	}
+}
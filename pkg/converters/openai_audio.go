@@ -0,0 +1,235 @@
+package converters
+
+import (
+	"mime/multipart"
+	"strconv"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// OpenAITranscriptionResponse is the body of an OpenAI transcription
+// response in the json or verbose_json format.
+type OpenAITranscriptionResponse struct {
+	Task     string                        `json:"task,omitempty"`
+	Language string                        `json:"language,omitempty"`
+	Duration float64                       `json:"duration,omitempty"`
+	Text     string                        `json:"text"`
+	Segments []*OpenAITranscriptionSegment `json:"segments,omitempty"`
+	Words    []*OpenAITranscriptionWord    `json:"words,omitempty"`
+	Usage    *OpenAITranscriptionUsage     `json:"usage,omitempty"`
+}
+
+// OpenAITranscriptionSegment is a timed segment of a verbose transcription.
+type OpenAITranscriptionSegment struct {
+	ID               int     `json:"id"`
+	Seek             int     `json:"seek"`
+	Start            float64 `json:"start"`
+	End              float64 `json:"end"`
+	Text             string  `json:"text"`
+	Tokens           []int   `json:"tokens,omitempty"`
+	Temperature      float64 `json:"temperature"`
+	AvgLogProb       float64 `json:"avg_logprob"`
+	CompressionRatio float64 `json:"compression_ratio"`
+	NoSpeechProb     float64 `json:"no_speech_prob"`
+}
+
+// OpenAITranscriptionWord is a word timestamp of a verbose transcription.
+type OpenAITranscriptionWord struct {
+	Word  string  `json:"word"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// OpenAITranscriptionUsage is the usage object of a transcription. Type is
+// "tokens" for token-billed models, which set the token counts, or
+// "duration", which sets Seconds.
+type OpenAITranscriptionUsage struct {
+	Type         string `json:"type"`
+	InputTokens  int    `json:"input_tokens,omitempty"`
+	OutputTokens int    `json:"output_tokens,omitempty"`
+	TotalTokens  int    `json:"total_tokens,omitempty"`
+	Seconds      int    `json:"seconds,omitempty"`
+}
+
+// OpenAISpeechRequest is the body of an OpenAI speech request. The
+// response body is the audio itself.
+type OpenAISpeechRequest struct {
+	Model          string  `json:"model"`
+	Input          string  `json:"input"`
+	Voice          string  `json:"voice"`
+	ResponseFormat string  `json:"response_format,omitempty"`
+	Speed          float64 `json:"speed,omitempty"`
+	Instructions   string  `json:"instructions,omitempty"`
+}
+
+// Form field names of an OpenAI transcription request.
+const (
+	OpenAIFileField                   = "file"
+	OpenAITimestampGranularitiesField = "timestamp_granularities[]"
+)
+
+// ToOpenAITranscriptionForm converts a TranscriptionRequest to the
+// multipart form of the OpenAI transcriptions endpoint. It returns a
+// ValidationError if the request is invalid.
+func ToOpenAITranscriptionForm(req *types.TranscriptionRequest) (*Form, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	form := &Form{}
+	form.AddFile(OpenAIFileField, req.File)
+	form.AddField("model", req.Model)
+	form.AddField("language", req.Language)
+	form.AddField("prompt", req.Prompt)
+	form.AddField("response_format", string(req.ResponseFormat))
+	if req.Temperature != nil {
+		form.AddField("temperature", strconv.FormatFloat(*req.Temperature, 'f', -1, 64))
+	}
+	for _, g := range req.TimestampGranularities {
+		form.AddField(OpenAITimestampGranularitiesField, string(g))
+	}
+	return form, nil
+}
+
+// FromOpenAITranscriptionForm converts a parsed OpenAI transcription form
+// to a TranscriptionRequest, validating it.
+func FromOpenAITranscriptionForm(form *multipart.Form) (*types.TranscriptionRequest, error) {
+	files, err := formFiles(form, OpenAIFileField)
+	if err != nil {
+		return nil, err
+	}
+	req := &types.TranscriptionRequest{
+		Model:          formValue(form, "model"),
+		Language:       formValue(form, "language"),
+		Prompt:         formValue(form, "prompt"),
+		ResponseFormat: types.TranscriptionFormat(formValue(form, "response_format")),
+	}
+	if len(files) > 0 {
+		req.File = files[0]
+	}
+	if v := formValue(form, "temperature"); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, types.NewValidationError("temperature", "must be a number")
+		}
+		req.Temperature = &t
+	}
+	granularities := form.Value[OpenAITimestampGranularitiesField]
+	if len(granularities) == 0 {
+		granularities = form.Value["timestamp_granularities"]
+	}
+	for _, g := range granularities {
+		req.TimestampGranularities = append(req.TimestampGranularities, types.TimestampGranularity(g))
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// ToOpenAITranscriptionResponse converts a TranscriptionResponse to OpenAI
+// format. Token usage is reported with type "tokens".
+func ToOpenAITranscriptionResponse(resp *types.TranscriptionResponse) *OpenAITranscriptionResponse {
+	wire := &OpenAITranscriptionResponse{
+		Language: resp.Language,
+		Duration: resp.Duration,
+		Text:     resp.Text,
+	}
+	if len(resp.Segments) > 0 || resp.Duration > 0 {
+		wire.Task = "transcribe"
+	}
+	for _, s := range resp.Segments {
+		if s == nil {
+			continue
+		}
+		wire.Segments = append(wire.Segments, &OpenAITranscriptionSegment{
+			ID:           s.ID,
+			Start:        s.Start,
+			End:          s.End,
+			Text:         s.Text,
+			AvgLogProb:   s.AvgLogProb,
+			NoSpeechProb: s.NoSpeechProb,
+		})
+	}
+	for _, w := range resp.Words {
+		if w == nil {
+			continue
+		}
+		wire.Words = append(wire.Words, &OpenAITranscriptionWord{Word: w.Word, Start: w.Start, End: w.End})
+	}
+	if resp.Usage != nil {
+		wire.Usage = &OpenAITranscriptionUsage{
+			Type:         "tokens",
+			InputTokens:  resp.Usage.PromptTokens,
+			OutputTokens: resp.Usage.CompletionTokens,
+			TotalTokens:  resp.Usage.TotalTokens,
+		}
+	}
+	return wire
+}
+
+// FromOpenAITranscriptionResponse converts an OpenAI transcription response
+// to a TranscriptionResponse. Duration-based usage is not converted to
+// Usage, which counts tokens.
+func FromOpenAITranscriptionResponse(wire *OpenAITranscriptionResponse) *types.TranscriptionResponse {
+	resp := &types.TranscriptionResponse{
+		Text:     wire.Text,
+		Language: wire.Language,
+		Duration: wire.Duration,
+	}
+	for _, s := range wire.Segments {
+		if s == nil {
+			continue
+		}
+		resp.Segments = append(resp.Segments, &types.TranscriptionSegment{
+			ID:           s.ID,
+			Start:        s.Start,
+			End:          s.End,
+			Text:         s.Text,
+			AvgLogProb:   s.AvgLogProb,
+			NoSpeechProb: s.NoSpeechProb,
+		})
+	}
+	for _, w := range wire.Words {
+		if w == nil {
+			continue
+		}
+		resp.Words = append(resp.Words, &types.TranscriptionWord{Word: w.Word, Start: w.Start, End: w.End})
+	}
+	if u := wire.Usage; u != nil && u.Type != "duration" && (u.InputTokens > 0 || u.OutputTokens > 0) {
+		resp.Usage = &types.Usage{
+			PromptTokens:     u.InputTokens,
+			CompletionTokens: u.OutputTokens,
+			TotalTokens:      u.TotalTokens,
+		}
+	}
+	return resp
+}
+
+// ToOpenAISpeechRequest converts a SpeechRequest to OpenAI format.
+func ToOpenAISpeechRequest(req *types.SpeechRequest) *OpenAISpeechRequest {
+	return &OpenAISpeechRequest{
+		Model:          req.Model,
+		Input:          req.Input,
+		Voice:          req.Voice,
+		ResponseFormat: string(req.ResponseFormat),
+		Speed:          req.Speed,
+		Instructions:   req.Instructions,
+	}
+}
+
+// FromOpenAISpeechRequest converts an OpenAI speech request to a
+// SpeechRequest, validating it.
+func FromOpenAISpeechRequest(wire *OpenAISpeechRequest) (*types.SpeechRequest, error) {
+	req := &types.SpeechRequest{
+		Model:          wire.Model,
+		Input:          wire.Input,
+		Voice:          wire.Voice,
+		ResponseFormat: types.AudioFormat(wire.ResponseFormat),
+		Speed:          wire.Speed,
+		Instructions:   wire.Instructions,
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
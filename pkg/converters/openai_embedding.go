@@ -0,0 +1,222 @@
+package converters
+
+import (
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// OpenAIEmbeddingRequest is the body of an OpenAI Embeddings request.
+type OpenAIEmbeddingRequest struct {
+	// Input is a string, an array of strings, or an array of token arrays.
+	Input          interface{} `json:"input"`
+	Model          string      `json:"model"`
+	EncodingFormat string      `json:"encoding_format,omitempty"`
+	Dimensions     int         `json:"dimensions,omitempty"`
+	User           string      `json:"user,omitempty"`
+}
+
+// OpenAIEmbeddingResponse is the body of an OpenAI Embeddings response.
+type OpenAIEmbeddingResponse struct {
+	Object string                `json:"object"`
+	Data   []*OpenAIEmbedding    `json:"data"`
+	Model  string                `json:"model"`
+	Usage  *OpenAIEmbeddingUsage `json:"usage,omitempty"`
+}
+
+// OpenAIEmbedding is one embedding in a response. Embedding is an array of
+// floats, or a base64 string when encoding_format is "base64".
+type OpenAIEmbedding struct {
+	Object    string      `json:"object"`
+	Index     int         `json:"index"`
+	Embedding interface{} `json:"embedding"`
+}
+
+// OpenAIEmbeddingUsage is the usage object of an embeddings response.
+type OpenAIEmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// OpenAIModel is an entry in the OpenAI model list.
+type OpenAIModel struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// OpenAIModelList is the body of an OpenAI list models response.
+type OpenAIModelList struct {
+	Object string         `json:"object"`
+	Data   []*OpenAIModel `json:"data"`
+}
+
+// OpenAI object type names for embeddings and models.
+const (
+	OpenAIObjectList      = "list"
+	OpenAIObjectEmbedding = "embedding"
+	OpenAIObjectModel     = "model"
+)
+
+// ToOpenAIEmbeddingRequest converts an EmbeddingRequest to OpenAI format.
+func ToOpenAIEmbeddingRequest(req *types.EmbeddingRequest) *OpenAIEmbeddingRequest {
+	return &OpenAIEmbeddingRequest{
+		Input:          req.Input,
+		Model:          req.Model,
+		EncodingFormat: req.EncodingFormat,
+		Dimensions:     req.Dimensions,
+		User:           req.User,
+	}
+}
+
+// FromOpenAIEmbeddingRequest converts an OpenAI embeddings request to an
+// EmbeddingRequest. An array of strings decoded from JSON is returned as a
+// []string; token arrays are passed through unchanged.
+func FromOpenAIEmbeddingRequest(wire *OpenAIEmbeddingRequest) (*types.EmbeddingRequest, error) {
+	req := &types.EmbeddingRequest{
+		Model:          wire.Model,
+		Input:          wire.Input,
+		EncodingFormat: wire.EncodingFormat,
+		Dimensions:     wire.Dimensions,
+		User:           wire.User,
+	}
+
+	switch input := wire.Input.(type) {
+	case nil:
+		return nil, types.NewValidationError("input", "input is required")
+	case string:
+		if input == "" {
+			return nil, types.NewValidationError("input", "input must not be empty")
+		}
+	case []string:
+		if len(input) == 0 {
+			return nil, types.NewValidationError("input", "input must not be empty")
+		}
+	case []interface{}:
+		if len(input) == 0 {
+			return nil, types.NewValidationError("input", "input must not be empty")
+		}
+		if strs, ok := allStrings(input); ok {
+			req.Input = strs
+		}
+	default:
+		return nil, types.NewValidationError("input", "must be a string or an array")
+	}
+
+	switch wire.EncodingFormat {
+	case "", "float", "base64":
+	default:
+		return nil, types.NewValidationError("encoding_format", "must be \"float\" or \"base64\"")
+	}
+	if wire.Dimensions < 0 {
+		return nil, types.NewValidationError("dimensions", "must be positive")
+	}
+	return req, nil
+}
+
+// ToOpenAIEmbeddingResponse converts an EmbeddingResponse to OpenAI format.
+func ToOpenAIEmbeddingResponse(resp *types.EmbeddingResponse) *OpenAIEmbeddingResponse {
+	wire := &OpenAIEmbeddingResponse{
+		Object: OpenAIObjectList,
+		Model:  resp.Model,
+		Data:   make([]*OpenAIEmbedding, 0, len(resp.Data)),
+	}
+	for _, emb := range resp.Data {
+		if emb == nil {
+			continue
+		}
+		wire.Data = append(wire.Data, &OpenAIEmbedding{
+			Object:    OpenAIObjectEmbedding,
+			Index:     emb.Index,
+			Embedding: emb.Embedding,
+		})
+	}
+	if resp.Usage != nil {
+		wire.Usage = &OpenAIEmbeddingUsage{
+			PromptTokens: resp.Usage.PromptTokens,
+			TotalTokens:  resp.Usage.TotalTokens,
+		}
+	}
+	return wire
+}
+
+// FromOpenAIEmbeddingResponse converts an OpenAI embeddings response to an
+// EmbeddingResponse.
+func FromOpenAIEmbeddingResponse(wire *OpenAIEmbeddingResponse) *types.EmbeddingResponse {
+	resp := &types.EmbeddingResponse{
+		Object: wire.Object,
+		Model:  wire.Model,
+		Data:   make([]*types.Embedding, 0, len(wire.Data)),
+	}
+	for _, emb := range wire.Data {
+		if emb == nil {
+			continue
+		}
+		e := &types.Embedding{
+			Object:    emb.Object,
+			Index:     emb.Index,
+			Embedding: emb.Embedding,
+		}
+		switch v := emb.Embedding.(type) {
+		case []interface{}:
+			e.Dimensions = len(v)
+		case string:
+			e.EncodingFormat = types.EmbeddingFormatBase64
+		}
+		resp.Data = append(resp.Data, e)
+	}
+	if wire.Usage != nil {
+		resp.Usage = &types.Usage{
+			PromptTokens: wire.Usage.PromptTokens,
+			TotalTokens:  wire.Usage.TotalTokens,
+		}
+	}
+	return resp
+}
+
+// ToOpenAIModelList converts model information to an OpenAI model list.
+// The owning provider is reported as owned_by.
+func ToOpenAIModelList(models []*types.ModelInfo) *OpenAIModelList {
+	list := &OpenAIModelList{
+		Object: OpenAIObjectList,
+		Data:   make([]*OpenAIModel, 0, len(models)),
+	}
+	for _, m := range models {
+		if m == nil {
+			continue
+		}
+		list.Data = append(list.Data, ToOpenAIModel(m))
+	}
+	return list
+}
+
+// ToOpenAIModel converts model information to an OpenAI model entry.
+func ToOpenAIModel(info *types.ModelInfo) *OpenAIModel {
+	return &OpenAIModel{
+		ID:      info.ID,
+		Object:  OpenAIObjectModel,
+		OwnedBy: string(info.Provider),
+	}
+}
+
+// FromOpenAIModel converts an OpenAI model entry to model information
+// attributed to provider.
+func FromOpenAIModel(wire *OpenAIModel, provider types.Provider) *types.ModelInfo {
+	return &types.ModelInfo{
+		ID:       wire.ID,
+		Name:     wire.ID,
+		Provider: provider,
+	}
+}
+
+// allStrings converts a decoded JSON array to []string if every element is a string.
+func allStrings(values []interface{}) ([]string, bool) {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		strs[i] = s
+	}
+	return strs, true
+}
@@ -0,0 +1,225 @@
+package converters
+
+import (
+	"mime/multipart"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// OpenAIImageRequest is the body of an OpenAI image generation request.
+type OpenAIImageRequest struct {
+	Prompt         string `json:"prompt"`
+	Model          string `json:"model,omitempty"`
+	N              int    `json:"n,omitempty"`
+	Size           string `json:"size,omitempty"`
+	Quality        string `json:"quality,omitempty"`
+	Style          string `json:"style,omitempty"`
+	ResponseFormat string `json:"response_format,omitempty"`
+	OutputFormat   string `json:"output_format,omitempty"`
+	Background     string `json:"background,omitempty"`
+	User           string `json:"user,omitempty"`
+}
+
+// OpenAIImageResponse is the body of an OpenAI image generation or edit
+// response.
+type OpenAIImageResponse struct {
+	Created int64             `json:"created"`
+	Data    []*OpenAIImage    `json:"data"`
+	Usage   *OpenAIImageUsage `json:"usage,omitempty"`
+}
+
+// OpenAIImage is one generated image.
+type OpenAIImage struct {
+	URL           string `json:"url,omitempty"`
+	B64JSON       string `json:"b64_json,omitempty"`
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+// OpenAIImageUsage is the usage object of an image response. Only
+// token-billed models report it.
+type OpenAIImageUsage struct {
+	InputTokens        int                      `json:"input_tokens"`
+	OutputTokens       int                      `json:"output_tokens"`
+	TotalTokens        int                      `json:"total_tokens"`
+	InputTokensDetails *OpenAIImageTokenDetails `json:"input_tokens_details,omitempty"`
+}
+
+// OpenAIImageTokenDetails breaks down the input tokens of an image request.
+type OpenAIImageTokenDetails struct {
+	TextTokens  int `json:"text_tokens"`
+	ImageTokens int `json:"image_tokens"`
+}
+
+// Form field names of an OpenAI image edit request. A single image is sent
+// as "image"; several are sent as "image[]".
+const (
+	OpenAIImageField      = "image"
+	OpenAIImageArrayField = "image[]"
+	OpenAIMaskField       = "mask"
+)
+
+// ToOpenAIImageRequest converts an ImageGenerationRequest to OpenAI format.
+// Images and Mask are ignored; use ToOpenAIImageEditForm for edits.
+func ToOpenAIImageRequest(req *types.ImageGenerationRequest) *OpenAIImageRequest {
+	return &OpenAIImageRequest{
+		Prompt:         req.Prompt,
+		Model:          req.Model,
+		N:              req.N,
+		Size:           string(req.Size),
+		Quality:        string(req.Quality),
+		Style:          req.Style,
+		ResponseFormat: string(req.ResponseFormat),
+		OutputFormat:   req.OutputFormat,
+		Background:     req.Background,
+		User:           req.User,
+	}
+}
+
+// FromOpenAIImageRequest converts an OpenAI image generation request to an
+// ImageGenerationRequest, validating it.
+func FromOpenAIImageRequest(wire *OpenAIImageRequest) (*types.ImageGenerationRequest, error) {
+	req := &types.ImageGenerationRequest{
+		Prompt:         wire.Prompt,
+		Model:          wire.Model,
+		N:              wire.N,
+		Size:           types.ImageSize(wire.Size),
+		Quality:        types.ImageQuality(wire.Quality),
+		Style:          wire.Style,
+		ResponseFormat: types.ImageResponseFormat(wire.ResponseFormat),
+		OutputFormat:   wire.OutputFormat,
+		Background:     wire.Background,
+		User:           wire.User,
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// ToOpenAIImageEditForm converts an image edit request to the multipart
+// form of the OpenAI image edits endpoint. It returns a ValidationError if
+// the request is invalid or has no images.
+func ToOpenAIImageEditForm(req *types.ImageGenerationRequest) (*Form, error) {
+	if !req.IsEdit() {
+		return nil, types.NewValidationError("images", "an image to edit is required")
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	form := &Form{}
+	imageField := OpenAIImageField
+	if len(req.Images) > 1 {
+		imageField = OpenAIImageArrayField
+	}
+	for _, img := range req.Images {
+		form.AddFile(imageField, img)
+	}
+	if req.Mask != nil {
+		form.AddFile(OpenAIMaskField, req.Mask)
+	}
+	form.AddField("prompt", req.Prompt)
+	form.AddField("model", req.Model)
+	form.AddInt("n", req.N)
+	form.AddField("size", string(req.Size))
+	form.AddField("quality", string(req.Quality))
+	form.AddField("response_format", string(req.ResponseFormat))
+	form.AddField("output_format", req.OutputFormat)
+	form.AddField("background", req.Background)
+	form.AddField("user", req.User)
+	return form, nil
+}
+
+// FromOpenAIImageEditForm converts a parsed OpenAI image edit form to an
+// ImageGenerationRequest, validating it.
+func FromOpenAIImageEditForm(form *multipart.Form) (*types.ImageGenerationRequest, error) {
+	images, err := formFiles(form, OpenAIImageField, OpenAIImageArrayField)
+	if err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, types.NewValidationError("image", "an image to edit is required")
+	}
+	masks, err := formFiles(form, OpenAIMaskField)
+	if err != nil {
+		return nil, err
+	}
+	n, err := formInt(form, "n")
+	if err != nil {
+		return nil, err
+	}
+
+	req := &types.ImageGenerationRequest{
+		Prompt:         formValue(form, "prompt"),
+		Model:          formValue(form, "model"),
+		N:              n,
+		Size:           types.ImageSize(formValue(form, "size")),
+		Quality:        types.ImageQuality(formValue(form, "quality")),
+		ResponseFormat: types.ImageResponseFormat(formValue(form, "response_format")),
+		OutputFormat:   formValue(form, "output_format"),
+		Background:     formValue(form, "background"),
+		User:           formValue(form, "user"),
+		Images:         images,
+	}
+	if len(masks) > 0 {
+		req.Mask = masks[0]
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// ToOpenAIImageResponse converts an ImageGenerationResponse to OpenAI
+// format.
+func ToOpenAIImageResponse(resp *types.ImageGenerationResponse) *OpenAIImageResponse {
+	wire := &OpenAIImageResponse{
+		Created: resp.Created,
+		Data:    make([]*OpenAIImage, 0, len(resp.Data)),
+	}
+	for _, img := range resp.Data {
+		if img == nil {
+			continue
+		}
+		wire.Data = append(wire.Data, &OpenAIImage{
+			URL:           img.URL,
+			B64JSON:       img.B64JSON,
+			RevisedPrompt: img.RevisedPrompt,
+		})
+	}
+	if resp.Usage != nil {
+		wire.Usage = &OpenAIImageUsage{
+			InputTokens:  resp.Usage.PromptTokens,
+			OutputTokens: resp.Usage.CompletionTokens,
+			TotalTokens:  resp.Usage.TotalTokens,
+		}
+	}
+	return wire
+}
+
+// FromOpenAIImageResponse converts an OpenAI image response to an
+// ImageGenerationResponse.
+func FromOpenAIImageResponse(wire *OpenAIImageResponse) *types.ImageGenerationResponse {
+	resp := &types.ImageGenerationResponse{
+		Created: wire.Created,
+		Data:    make([]*types.GeneratedImage, 0, len(wire.Data)),
+	}
+	for _, img := range wire.Data {
+		if img == nil {
+			continue
+		}
+		resp.Data = append(resp.Data, &types.GeneratedImage{
+			URL:           img.URL,
+			B64JSON:       img.B64JSON,
+			RevisedPrompt: img.RevisedPrompt,
+		})
+	}
+	if u := wire.Usage; u != nil {
+		resp.Usage = &types.Usage{
+			PromptTokens:     u.InputTokens,
+			CompletionTokens: u.OutputTokens,
+			TotalTokens:      u.TotalTokens,
+		}
+	}
+	return resp
+}
@@ -0,0 +1,308 @@
+package converters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// viaJSON encodes v and decodes the result into out, as a wire round trip would.
+func viaJSON(t *testing.T, v, out interface{}) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		t.Fatalf("json.Unmarshal(%s) error = %v", data, err)
+	}
+}
+
+func float(f float64) *float64 { return &f }
+
+func TestOpenAIRequestRoundTrip(t *testing.T) {
+	seed := 7
+	tests := []struct {
+		name string
+		req  *types.ChatRequest
+	}{
+		{
+			name: "text messages and sampling options",
+			req: &types.ChatRequest{
+				Model: "gpt-4o",
+				Messages: []*types.Message{
+					{Role: types.RoleSystem, Content: types.NewTextContent("be brief")},
+					{Role: types.RoleUser, Content: types.NewTextContent("hi"), Name: "ann"},
+				},
+				Temperature: float(0.2),
+				TopP:        float(0.9),
+				MaxTokens:   100,
+				Stop:        []string{"END", "STOP"},
+				Seed:        &seed,
+				User:        "u1",
+			},
+		},
+		{
+			name: "multimodal content",
+			req: &types.ChatRequest{
+				Model: "gpt-4o",
+				Messages: []*types.Message{{
+					Role: types.RoleUser,
+					Content: types.NewMultiContent(
+						types.NewTextPart("what is this?"),
+						types.NewImagePart("https://example.com/cat.png", types.ImageDetailLow),
+						types.NewAudioPart(&types.AudioContent{Data: "UklGRg==", MimeType: "audio/wav"}),
+					),
+				}},
+			},
+		},
+		{
+			name: "tool calls and results",
+			req: &types.ChatRequest{
+				Model: "gpt-4o",
+				Messages: []*types.Message{
+					{Role: types.RoleUser, Content: types.NewTextContent("weather?")},
+					{Role: types.RoleAssistant, ToolCalls: []*types.ToolCall{{ID: "call-1", Type: types.ToolTypeFunction, Function: types.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}}}},
+					{Role: types.RoleTool, Content: types.NewTextContent(`{"temp":21}`), ToolCallID: "call-1"},
+				},
+				Tools:      []*types.ToolDefinition{{Type: types.ToolTypeFunction, Function: types.FunctionDefinition{Name: "weather", Description: "Get the weather"}}},
+				ToolChoice: "auto",
+			},
+		},
+		{
+			name: "metadata",
+			req: &types.ChatRequest{
+				Model:    "gpt-4o",
+				Messages: []*types.Message{{Role: types.RoleUser, Content: types.NewTextContent("hi")}},
+				Metadata: &types.RequestMetadata{Custom: map[string]interface{}{"team": "search"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wire, err := ToOpenAIRequest(tt.req)
+			if err != nil {
+				t.Fatalf("ToOpenAIRequest() error = %v", err)
+			}
+			var decoded OpenAIChatRequest
+			viaJSON(t, wire, &decoded)
+			got, err := FromOpenAIRequest(&decoded)
+			if err != nil {
+				t.Fatalf("FromOpenAIRequest() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.req) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.req)
+				t.Errorf("round trip =\n%s\nwant\n%s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestFromOpenAIRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		check   func(t *testing.T, req *types.ChatRequest)
+		wantErr bool
+	}{
+		{
+			name: "developer role is system",
+			body: `{"model":"m","messages":[{"role":"developer","content":"rules"}]}`,
+			check: func(t *testing.T, req *types.ChatRequest) {
+				if req.Messages[0].Role != types.RoleSystem {
+					t.Errorf("Role = %q, want system", req.Messages[0].Role)
+				}
+			},
+		},
+		{
+			name: "max_completion_tokens is used without max_tokens",
+			body: `{"model":"m","messages":[],"max_completion_tokens":50}`,
+			check: func(t *testing.T, req *types.ChatRequest) {
+				if req.MaxTokens != 50 {
+					t.Errorf("MaxTokens = %d, want 50", req.MaxTokens)
+				}
+			},
+		},
+		{
+			name: "single stop string",
+			body: `{"model":"m","messages":[],"stop":"END"}`,
+			check: func(t *testing.T, req *types.ChatRequest) {
+				if fmt.Sprint(req.Stop) != "[END]" {
+					t.Errorf("Stop = %v, want [END]", req.Stop)
+				}
+			},
+		},
+		{
+			name: "null content",
+			body: `{"model":"m","messages":[{"role":"assistant","content":null,"tool_calls":[{"id":"c","type":"function","function":{"name":"f","arguments":"{}"}}]}]}`,
+			check: func(t *testing.T, req *types.ChatRequest) {
+				if req.Messages[0].Content != nil || len(req.Messages[0].ToolCalls) != 1 {
+					t.Errorf("message = %+v, want no content and one tool call", req.Messages[0])
+				}
+			},
+		},
+		{name: "stop of numbers", body: `{"model":"m","messages":[],"stop":[1]}`, wantErr: true},
+		{name: "content of a number", body: `{"model":"m","messages":[{"role":"user","content":1}]}`, wantErr: true},
+		{name: "unknown part type", body: `{"model":"m","messages":[{"role":"user","content":[{"type":"video"}]}]}`, wantErr: true},
+		{name: "image part without url", body: `{"model":"m","messages":[{"role":"user","content":[{"type":"image_url"}]}]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wire OpenAIChatRequest
+			if err := json.Unmarshal([]byte(tt.body), &wire); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			req, err := FromOpenAIRequest(&wire)
+			if tt.wantErr {
+				var validationErr *types.ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Errorf("FromOpenAIRequest() error = %v, want a *types.ValidationError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromOpenAIRequest() error = %v", err)
+			}
+			tt.check(t, req)
+		})
+	}
+}
+
+func TestToOpenAIRequestStream(t *testing.T) {
+	wire, err := ToOpenAIRequest(&types.ChatRequest{Model: "m", Stream: true})
+	if err != nil {
+		t.Fatalf("ToOpenAIRequest() error = %v", err)
+	}
+	if wire.StreamOptions == nil || !wire.StreamOptions.IncludeUsage {
+		t.Errorf("StreamOptions = %+v, want usage requested for streams", wire.StreamOptions)
+	}
+	if _, err := ToOpenAIRequest(&types.ChatRequest{Model: "m", Messages: []*types.Message{nil}}); err == nil {
+		t.Error("ToOpenAIRequest() with a nil message error = nil")
+	}
+}
+
+func TestOpenAIResponseRoundTrip(t *testing.T) {
+	resp := &types.ChatResponse{
+		ID:                "chatcmpl-1",
+		Object:            OpenAIObjectChatCompletion,
+		Created:           1700000000,
+		Model:             "gpt-4o",
+		SystemFingerprint: "fp",
+		Choices: []*types.Choice{
+			{Index: 0, Message: &types.Message{Role: types.RoleAssistant, Content: types.NewTextContent("hello")}, FinishReason: types.FinishReasonStop,
+				LogProbs: &types.LogProbability{Content: []*types.TokenLogProb{{Token: "hello", LogProb: -0.1}}}},
+			{Index: 1, Message: &types.Message{Role: types.RoleAssistant, ToolCalls: []*types.ToolCall{{ID: "c", Type: types.ToolTypeFunction, Function: types.FunctionCall{Name: "f", Arguments: "{}"}}}}, FinishReason: types.FinishReasonToolCalls},
+			{Index: 2, Message: &types.Message{Role: types.RoleAssistant, Refusal: "no"}, FinishReason: types.FinishReasonContentFilter},
+		},
+		Usage: &types.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, CachedTokens: 4, ReasoningTokens: 2},
+	}
+
+	wire, err := ToOpenAIResponse(resp)
+	if err != nil {
+		t.Fatalf("ToOpenAIResponse() error = %v", err)
+	}
+	var decoded OpenAIChatResponse
+	viaJSON(t, wire, &decoded)
+	got, err := FromOpenAIResponse(&decoded)
+	if err != nil {
+		t.Fatalf("FromOpenAIResponse() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, resp) {
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(resp)
+		t.Errorf("round trip =\n%s\nwant\n%s", gotJSON, wantJSON)
+	}
+
+	// A message without a role is sent as an assistant message.
+	wire, _ = ToOpenAIResponse(&types.ChatResponse{Choices: []*types.Choice{{Message: &types.Message{Content: types.NewTextContent("x")}}}})
+	if wire.Choices[0].Message.Role != string(types.RoleAssistant) || wire.Choices[0].FinishReason != nil {
+		t.Errorf("choice = role %q, finish %v, want assistant and null", wire.Choices[0].Message.Role, wire.Choices[0].FinishReason)
+	}
+}
+
+func TestOpenAIChunkRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		chunk *types.ChatStreamChunk
+	}{
+		{
+			name: "content delta",
+			chunk: &types.ChatStreamChunk{ID: "c1", Object: OpenAIObjectChatCompletionChunk, Created: 1, Model: "m",
+				Choices: []*types.StreamChoice{{Index: 0, Delta: &types.MessageDelta{Role: types.RoleAssistant, Content: "he"}}}},
+		},
+		{
+			name: "tool call delta with finish reason",
+			chunk: &types.ChatStreamChunk{ID: "c1", Object: OpenAIObjectChatCompletionChunk, Model: "m",
+				Choices: []*types.StreamChoice{{Index: 0, Delta: &types.MessageDelta{ToolCalls: []*types.ToolCallDelta{{Index: 1, ID: "t", Type: types.ToolTypeFunction, Function: &types.FunctionCallDelta{Name: "f", Arguments: "{"}}}}, FinishReason: types.FinishReasonToolCalls}}},
+		},
+		{
+			name: "usage only",
+			chunk: &types.ChatStreamChunk{ID: "c1", Object: OpenAIObjectChatCompletionChunk, Model: "m",
+				Choices: []*types.StreamChoice{}, Usage: &types.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var decoded OpenAIChatChunk
+			viaJSON(t, ToOpenAIChunk(tt.chunk), &decoded)
+			if got := FromOpenAIChunk(&decoded); !reflect.DeepEqual(got, tt.chunk) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.chunk)
+				t.Errorf("round trip =\n%s\nwant\n%s", gotJSON, wantJSON)
+			}
+		})
+	}
+
+	if wire := ToOpenAIChunk(types.NewErrorChunk(errors.New("boom"))); wire != nil {
+		t.Errorf("ToOpenAIChunk(ErrorChunk) = %+v, want nil", wire)
+	}
+}
+
+func TestOpenAIErrorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantType   types.ErrorType
+		wantParam  string
+		retryable  bool
+	}{
+		{name: "validation", err: types.NewValidationError("model", "model is required"), wantStatus: http.StatusBadRequest, wantType: types.ErrorTypeInvalidRequest, wantParam: "model"},
+		{name: "provider error keeps its status", err: &types.ProviderError{ErrorType: types.ErrorTypeRateLimit, Message: "slow down", HTTPStatus: http.StatusTooManyRequests, ErrorCode: "rate_limit_exceeded"}, wantStatus: http.StatusTooManyRequests, wantType: types.ErrorTypeRateLimit, retryable: true},
+		{name: "provider error status from type", err: fmt.Errorf("wrapped: %w", &types.ProviderError{ErrorType: types.ErrorTypeAuthentication, Message: "bad key"}), wantStatus: http.StatusUnauthorized, wantType: types.ErrorTypeAuthentication},
+		{name: "deadline", err: context.DeadlineExceeded, wantStatus: http.StatusGatewayTimeout, wantType: types.ErrorTypeTimeout, retryable: true},
+		{name: "canceled", err: context.Canceled, wantStatus: StatusClientClosedRequest, wantType: types.ErrorTypeUnknown},
+		{name: "unknown", err: errors.New("boom"), wantStatus: http.StatusInternalServerError, wantType: types.ErrorTypeServer, retryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := ToOpenAIError(tt.err)
+			if status != tt.wantStatus {
+				t.Errorf("ToOpenAIError() status = %d, want %d", status, tt.wantStatus)
+			}
+			data, _ := json.Marshal(body)
+			got := FromOpenAIError(status, data)
+			if got.ErrorType != tt.wantType || got.Param != tt.wantParam || got.HTTPStatus != status || got.IsRetryable != tt.retryable {
+				t.Errorf("FromOpenAIError() = %+v, want type %s, param %q, retryable %v", got, tt.wantType, tt.wantParam, tt.retryable)
+			}
+			if got.Message == "" || got.ProviderName != types.ProviderOpenAI {
+				t.Errorf("FromOpenAIError() = %+v, want a message from OpenAI", got)
+			}
+		})
+	}
+
+	if got := FromOpenAIError(http.StatusBadGateway, []byte("<html>bad gateway</html>")); got.ErrorType != types.ErrorTypeServer || got.Message != "<html>bad gateway</html>" {
+		t.Errorf("FromOpenAIError() of a non-JSON body = %+v", got)
+	}
+}
@@ -0,0 +1,352 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Default batching settings.
+const (
+	// DefaultBatchSize is the maximum number of inputs per batch.
+	DefaultBatchSize = 100
+
+	// DefaultBatchParallelism is the number of batches sent at once.
+	DefaultBatchParallelism = 4
+)
+
+// Option keys accepted by Batcher.CreateEmbeddingBatch. Each overrides the
+// BatchOptions field of the same meaning.
+const (
+	// OptionBatchSize sets BatchOptions.BatchSize (value: int).
+	OptionBatchSize = "batch_size"
+
+	// OptionParallel sets BatchOptions.Parallelism (value: int).
+	OptionParallel = "parallel"
+
+	// OptionMaxBatchTokens sets BatchOptions.MaxBatchTokens (value: int).
+	OptionMaxBatchTokens = "max_batch_tokens"
+
+	// OptionPartialResults sets BatchOptions.PartialResults (value: bool).
+	OptionPartialResults = "partial_results"
+)
+
+// BatchOptions configures a Batcher.
+type BatchOptions struct {
+	// BatchSize is the maximum number of inputs per batch.
+	// Default is DefaultBatchSize.
+	BatchSize int
+
+	// MaxBatchTokens, if positive, limits the tokens per batch as counted
+	// by TokenCounter. An input over the limit on its own is sent alone.
+	MaxBatchTokens int
+
+	// TokenCounter counts input tokens for MaxBatchTokens.
+	// Default is types.NewHeuristicTokenCounter().
+	TokenCounter types.TokenCounter
+
+	// Parallelism is the number of batches sent at once.
+	// Default is DefaultBatchParallelism.
+	Parallelism int
+
+	// PartialResults keeps going when a batch fails. The embeddings that
+	// succeeded are returned together with a *PartialError listing the
+	// failed input indices. Without it, the first failure cancels the other
+	// batches and is returned as a *BatchError.
+	PartialResults bool
+}
+
+func (o BatchOptions) withDefaults() BatchOptions {
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchSize
+	}
+	if o.Parallelism <= 0 {
+		o.Parallelism = DefaultBatchParallelism
+	}
+	if o.MaxBatchTokens > 0 && o.TokenCounter == nil {
+		o.TokenCounter = types.NewHeuristicTokenCounter()
+	}
+	return o
+}
+
+// BatchError is the failure of one batch.
+type BatchError struct {
+	// Start and End are the input indices covered by the batch, [Start, End).
+	Start, End int
+
+	// Err is the error returned for the batch.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("embeddings: batch of inputs %d-%d: %v", e.Start, e.End-1, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// PartialError is returned with a partial response when some batches
+// failed in partial-results mode.
+type PartialError struct {
+	// Failed lists the input indices without an embedding, in order.
+	Failed []int
+
+	// Batches holds the error of each failed batch.
+	Batches []*BatchError
+
+	// Total is the number of inputs.
+	Total int
+}
+
+// Error implements the error interface.
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("embeddings: %d of %d inputs failed: %v", len(e.Failed), e.Total, e.Batches[0])
+}
+
+// Unwrap returns every batch error, so errors.Is and errors.As match any
+// of them.
+func (e *PartialError) Unwrap() []error {
+	errs := make([]error, len(e.Batches))
+	for i, b := range e.Batches {
+		errs[i] = b
+	}
+	return errs
+}
+
+// Batcher is an interfaces.EmbeddingServiceWithBatch that splits inputs
+// into batches for an underlying EmbeddingService.
+type Batcher struct {
+	service interfaces.EmbeddingService
+	options BatchOptions
+}
+
+// NewBatcher wraps service. Nil options use defaults.
+func NewBatcher(service interfaces.EmbeddingService, options *BatchOptions) *Batcher {
+	o := BatchOptions{}
+	if options != nil {
+		o = *options
+	}
+	return &Batcher{service: service, options: o.withDefaults()}
+}
+
+// CreateEmbedding implements interfaces.EmbeddingService, batching the
+// request's inputs with the batcher's options.
+func (b *Batcher) CreateEmbedding(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
+	return b.Embed(ctx, req, nil)
+}
+
+// CreateEmbeddingBatch implements interfaces.EmbeddingServiceWithBatch.
+// options may set any of the Option keys to override the batcher's options
+// for this call.
+func (b *Batcher) CreateEmbeddingBatch(ctx context.Context, model string, inputs []string, options map[string]interface{}) (*types.EmbeddingResponse, error) {
+	o, err := b.parseOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	return b.Embed(ctx, types.NewEmbeddingRequestFromStrings(model, inputs), &o)
+}
+
+// Embed embeds req's inputs in batches. Fields other than Input are sent
+// with every batch. Nil opts use the batcher's options. Requests whose
+// Input is not a string or list of strings are passed through unbatched.
+//
+// In partial-results mode a failed batch returns both the response, with
+// the embeddings that succeeded, and a *PartialError.
+func (b *Batcher) Embed(ctx context.Context, req *types.EmbeddingRequest, opts *BatchOptions) (*types.EmbeddingResponse, error) {
+	o := b.options
+	if opts != nil {
+		o = opts.withDefaults()
+	}
+
+	inputs := req.GetInputAsStrings()
+	if inputs == nil {
+		return b.service.CreateEmbedding(ctx, req)
+	}
+	batches := split(inputs, o)
+
+	batchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	responses := make([]*types.EmbeddingResponse, len(batches))
+	errs := make([]error, len(batches))
+	var firstErr error
+	var once sync.Once
+
+	sem := make(chan struct{}, o.Parallelism)
+	var wg sync.WaitGroup
+	for i, bt := range batches {
+		select {
+		case sem <- struct{}{}:
+		case <-batchCtx.Done():
+			errs[i] = batchCtx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int, bt batch) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			sub := *req
+			sub.Input = inputs[bt.start:bt.end]
+			resp, err := b.service.CreateEmbedding(batchCtx, &sub)
+			switch {
+			case err != nil:
+			case resp == nil:
+				err = fmt.Errorf("embeddings: got no response for %d inputs", bt.end-bt.start)
+			case len(resp.Data) != bt.end-bt.start:
+				err = fmt.Errorf("embeddings: got %d embeddings for %d inputs", len(resp.Data), bt.end-bt.start)
+			}
+			if err != nil {
+				errs[i] = err
+				if !o.PartialResults {
+					once.Do(func() {
+						firstErr = &BatchError{Start: bt.start, End: bt.end, Err: err}
+						cancel()
+					})
+				}
+				return
+			}
+			responses[i] = resp
+		}(i, bt)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	resp := merge(batches, responses)
+	var partial *PartialError
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if partial == nil {
+			partial = &PartialError{Total: len(inputs)}
+		}
+		bt := batches[i]
+		partial.Batches = append(partial.Batches, &BatchError{Start: bt.start, End: bt.end, Err: err})
+		for j := bt.start; j < bt.end; j++ {
+			partial.Failed = append(partial.Failed, j)
+		}
+	}
+	if partial != nil {
+		return resp, partial
+	}
+	return resp, nil
+}
+
+// parseOptions applies an options map to the batcher's options.
+func (b *Batcher) parseOptions(options map[string]interface{}) (BatchOptions, error) {
+	o := b.options
+	for key, v := range options {
+		var err error
+		switch key {
+		case OptionBatchSize:
+			o.BatchSize, err = intOption(key, v)
+		case OptionParallel:
+			o.Parallelism, err = intOption(key, v)
+		case OptionMaxBatchTokens:
+			o.MaxBatchTokens, err = intOption(key, v)
+		case OptionPartialResults:
+			var ok bool
+			if o.PartialResults, ok = v.(bool); !ok {
+				err = types.NewValidationError(key, fmt.Sprintf("must be a bool, got %T", v))
+			}
+		default:
+			err = types.NewValidationError(key, "unknown batch option")
+		}
+		if err != nil {
+			return o, err
+		}
+	}
+	return o.withDefaults(), nil
+}
+
+func intOption(key string, v interface{}) (int, error) {
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), nil
+		}
+	}
+	return 0, types.NewValidationError(key, fmt.Sprintf("must be an integer, got %v", v))
+}
+
+// batch is a range of inputs, [start, end).
+type batch struct {
+	start, end int
+}
+
+// split groups inputs into batches of at most BatchSize inputs and
+// MaxBatchTokens tokens.
+func split(inputs []string, o BatchOptions) []batch {
+	var batches []batch
+	start, tokens := 0, 0
+	for i, input := range inputs {
+		t := 0
+		if o.MaxBatchTokens > 0 {
+			t = o.TokenCounter.CountTokens(input)
+		}
+		full := i-start >= o.BatchSize || (o.MaxBatchTokens > 0 && tokens+t > o.MaxBatchTokens)
+		if full && i > start {
+			batches = append(batches, batch{start, i})
+			start, tokens = i, 0
+		}
+		tokens += t
+	}
+	if start < len(inputs) || len(inputs) == 0 {
+		batches = append(batches, batch{start, len(inputs)})
+	}
+	return batches
+}
+
+// merge combines batch responses in input order. Missing responses are
+// skipped.
+func merge(batches []batch, responses []*types.EmbeddingResponse) *types.EmbeddingResponse {
+	merged := &types.EmbeddingResponse{Object: "list"}
+	var usage *types.Usage
+	for i, resp := range responses {
+		if resp == nil {
+			continue
+		}
+		if merged.Model == "" {
+			merged.Model = resp.Model
+			if resp.Object != "" {
+				merged.Object = resp.Object
+			}
+			merged.Metadata = resp.Metadata
+		}
+		for _, emb := range resp.Data {
+			if emb == nil {
+				continue
+			}
+			e := *emb
+			e.Index += batches[i].start
+			merged.Data = append(merged.Data, &e)
+		}
+		if resp.Usage != nil {
+			if usage == nil {
+				usage = &types.Usage{}
+			}
+			usage.Add(resp.Usage)
+		}
+	}
+	sort.SliceStable(merged.Data, func(i, j int) bool { return merged.Data[i].Index < merged.Data[j].Index })
+	merged.Usage = usage
+	return merged
+}
@@ -0,0 +1,274 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// fakeEmbedder records the inputs of every call and embeds each input as
+// vectorOf(input), unless respond is set.
+type fakeEmbedder struct {
+	respond func(ctx context.Context, inputs []string) (*types.EmbeddingResponse, error)
+
+	mu    sync.Mutex
+	calls [][]string
+}
+
+func (f *fakeEmbedder) CreateEmbedding(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
+	inputs := req.GetInputAsStrings()
+	f.mu.Lock()
+	f.calls = append(f.calls, inputs)
+	f.mu.Unlock()
+	if f.respond != nil {
+		return f.respond(ctx, inputs)
+	}
+	return embed(req.Model, inputs), nil
+}
+
+func (f *fakeEmbedder) callInputs() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]string(nil), f.calls...)
+}
+
+// embed returns a response with vectorOf each input and one prompt token
+// per input.
+func embed(model string, inputs []string) *types.EmbeddingResponse {
+	resp := &types.EmbeddingResponse{Object: "list", Model: model, Usage: &types.Usage{PromptTokens: len(inputs), TotalTokens: len(inputs)}}
+	for i, input := range inputs {
+		resp.Data = append(resp.Data, &types.Embedding{Object: "embedding", Index: i, Embedding: vectorOf(input)})
+	}
+	return resp
+}
+
+// vectorOf is the fake embedding of input: its integer value and length.
+func vectorOf(input string) []float64 {
+	n, _ := strconv.Atoi(input)
+	return []float64{float64(n), float64(len(input))}
+}
+
+// numbers returns the inputs "0" through "n-1".
+func numbers(n int) []string {
+	inputs := make([]string, n)
+	for i := range inputs {
+		inputs[i] = strconv.Itoa(i)
+	}
+	return inputs
+}
+
+// checkEmbeddings fails unless resp holds vectorOf(inputs[i]) at Index i.
+func checkEmbeddings(t *testing.T, resp *types.EmbeddingResponse, inputs []string) {
+	t.Helper()
+	if len(resp.Data) != len(inputs) {
+		t.Fatalf("got %d embeddings, want %d", len(resp.Data), len(inputs))
+	}
+	for i, emb := range resp.Data {
+		if emb.Index != i || fmt.Sprint(emb.AsFloatVector()) != fmt.Sprint(vectorOf(inputs[i])) {
+			t.Errorf("Data[%d] = index %d, %v, want index %d, %v", i, emb.Index, emb.Embedding, i, vectorOf(inputs[i]))
+		}
+	}
+}
+
+// charCounter counts one token per byte.
+type charCounter struct {
+	types.TokenCounter
+}
+
+func (charCounter) CountTokens(text string) int { return len(text) }
+
+func TestBatcherOrderAndUsage(t *testing.T) {
+	tests := []struct {
+		name        string
+		inputs      []string
+		options     *BatchOptions
+		wantBatches string
+	}{
+		{
+			name:        "batch size",
+			inputs:      numbers(7),
+			options:     &BatchOptions{BatchSize: 3, Parallelism: 3},
+			wantBatches: "[[0 1 2] [3 4 5] [6]]",
+		},
+		{
+			name:        "token limit",
+			inputs:      []string{"1", "22", "333", "4444", "55555"},
+			options:     &BatchOptions{MaxBatchTokens: 5, TokenCounter: charCounter{}},
+			wantBatches: "[[1 22] [333] [4444] [55555]]",
+		},
+		{
+			name:        "input over the token limit is sent alone",
+			inputs:      []string{"1", "7777777", "2"},
+			options:     &BatchOptions{MaxBatchTokens: 3, TokenCounter: charCounter{}},
+			wantBatches: "[[1] [7777777] [2]]",
+		},
+		{
+			name:        "token limit and batch size together",
+			inputs:      []string{"1", "2", "3", "4444"},
+			options:     &BatchOptions{BatchSize: 2, MaxBatchTokens: 4, TokenCounter: charCounter{}},
+			wantBatches: "[[1 2] [3] [4444]]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Later batches answer first, so merging must restore input order.
+			svc := &fakeEmbedder{}
+			svc.respond = func(ctx context.Context, inputs []string) (*types.EmbeddingResponse, error) {
+				time.Sleep(time.Duration(10-len(svc.callInputs())) * time.Millisecond)
+				return embed("m", inputs), nil
+			}
+			resp, err := NewBatcher(svc, tt.options).CreateEmbedding(context.Background(), types.NewEmbeddingRequestFromStrings("m", tt.inputs))
+			if err != nil {
+				t.Fatalf("CreateEmbedding() error = %v", err)
+			}
+			checkEmbeddings(t, resp, tt.inputs)
+
+			calls := svc.callInputs()
+			if got := fmt.Sprint(sortedCalls(calls, tt.inputs)); got != tt.wantBatches {
+				t.Errorf("batches = %s, want %s", got, tt.wantBatches)
+			}
+			want := types.Usage{PromptTokens: len(tt.inputs), TotalTokens: len(tt.inputs)}
+			if resp.Usage == nil || *resp.Usage != want {
+				t.Errorf("Usage = %+v, want the sum %+v", resp.Usage, want)
+			}
+			if resp.Model != "m" {
+				t.Errorf("Model = %q, want m", resp.Model)
+			}
+		})
+	}
+}
+
+// sortedCalls orders calls by the position of their first input in inputs.
+func sortedCalls(calls [][]string, inputs []string) [][]string {
+	pos := make(map[string]int, len(inputs))
+	for i, input := range inputs {
+		pos[input] = i
+	}
+	sorted := append([][]string(nil), calls...)
+	sort.Slice(sorted, func(i, j int) bool { return pos[sorted[i][0]] < pos[sorted[j][0]] })
+	return sorted
+}
+
+func TestBatcherFirstErrorCancels(t *testing.T) {
+	errDown := errors.New("down")
+	var canceled sync.WaitGroup
+	svc := &fakeEmbedder{respond: func(ctx context.Context, inputs []string) (*types.EmbeddingResponse, error) {
+		if inputs[0] == "2" {
+			return nil, errDown
+		}
+		select {
+		case <-ctx.Done():
+			canceled.Done()
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return embed("m", inputs), nil
+		}
+	}}
+	canceled.Add(3)
+
+	start := time.Now()
+	_, err := NewBatcher(svc, &BatchOptions{BatchSize: 2, Parallelism: 4}).CreateEmbedding(context.Background(), types.NewEmbeddingRequestFromStrings("m", numbers(8)))
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Start != 2 || batchErr.End != 4 || !errors.Is(err, errDown) {
+		t.Fatalf("CreateEmbedding() error = %v, want a *BatchError for inputs 2-3 wrapping %v", err, errDown)
+	}
+	canceled.Wait()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("CreateEmbedding() took %v, want the other batches canceled", elapsed)
+	}
+}
+
+func TestBatcherPartialResults(t *testing.T) {
+	errDown := errors.New("down")
+	svc := &fakeEmbedder{respond: func(ctx context.Context, inputs []string) (*types.EmbeddingResponse, error) {
+		if inputs[0] == "2" || inputs[0] == "6" {
+			return nil, errDown
+		}
+		return embed("m", inputs), nil
+	}}
+
+	resp, err := NewBatcher(svc, nil).CreateEmbeddingBatch(context.Background(), "m", numbers(7), map[string]interface{}{
+		OptionBatchSize:      2,
+		OptionPartialResults: true,
+	})
+	var partial *PartialError
+	if !errors.As(err, &partial) || !errors.Is(err, errDown) {
+		t.Fatalf("CreateEmbeddingBatch() error = %v, want a *PartialError wrapping %v", err, errDown)
+	}
+	if fmt.Sprint(partial.Failed) != "[2 3 6]" || partial.Total != 7 || len(partial.Batches) != 2 {
+		t.Errorf("PartialError = %+v, want inputs 2, 3 and 6 failed in 2 batches", partial)
+	}
+	if resp == nil {
+		t.Fatal("CreateEmbeddingBatch() response = nil, want the successful embeddings")
+	}
+	var indices []int
+	for _, emb := range resp.Data {
+		indices = append(indices, emb.Index)
+	}
+	if fmt.Sprint(indices) != "[0 1 4 5]" {
+		t.Errorf("embedding indices = %v, want [0 1 4 5]", indices)
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 4 {
+		t.Errorf("Usage = %+v, want the successful batches' 4 tokens", resp.Usage)
+	}
+}
+
+func TestBatcherBadResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(ctx context.Context, inputs []string) (*types.EmbeddingResponse, error)
+	}{
+		{
+			name:    "nil response without an error",
+			respond: func(context.Context, []string) (*types.EmbeddingResponse, error) { return nil, nil },
+		},
+		{
+			name: "too few embeddings",
+			respond: func(_ context.Context, inputs []string) (*types.EmbeddingResponse, error) {
+				return embed("m", inputs[1:]), nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBatcher(&fakeEmbedder{respond: tt.respond}, &BatchOptions{BatchSize: 2})
+			_, err := b.CreateEmbedding(context.Background(), types.NewEmbeddingRequestFromStrings("m", numbers(3)))
+			var batchErr *BatchError
+			if !errors.As(err, &batchErr) {
+				t.Errorf("CreateEmbedding() error = %v, want a *BatchError", err)
+			}
+		})
+	}
+}
+
+func TestBatcherOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]interface{}
+		wantErr bool
+	}{
+		{name: "float batch size", options: map[string]interface{}{OptionBatchSize: 2.0}},
+		{name: "fractional batch size", options: map[string]interface{}{OptionBatchSize: 2.5}, wantErr: true},
+		{name: "partial results not a bool", options: map[string]interface{}{OptionPartialResults: "yes"}, wantErr: true},
+		{name: "unknown option", options: map[string]interface{}{"size": 2}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewBatcher(&fakeEmbedder{}, nil).CreateEmbeddingBatch(context.Background(), "m", numbers(3), tt.options)
+			var validationErr *types.ValidationError
+			if tt.wantErr != errors.As(err, &validationErr) || (!tt.wantErr && err != nil) {
+				t.Errorf("CreateEmbeddingBatch() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
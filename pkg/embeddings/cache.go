@@ -0,0 +1,237 @@
+package embeddings
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// CacheConfig configures a Cache.
+type CacheConfig struct {
+	// Store holds the cached embeddings.
+	// Default is NewMemoryStore(0).
+	Store Store
+
+	// KeyFunc returns the cache key of one input of req.
+	// Default is CacheKey.
+	KeyFunc func(req *types.EmbeddingRequest, input string) string
+}
+
+// CacheStats reports cache effectiveness. Hits and misses count inputs,
+// not requests.
+type CacheStats struct {
+	// Requests is the number of cacheable requests.
+	Requests int64
+
+	// FullHits is the number of requests served entirely from the cache.
+	FullHits int64
+
+	// Hits is the number of inputs served from the cache.
+	Hits int64
+
+	// Misses is the number of inputs not found in the cache. Repeated
+	// inputs in one request are sent to the provider once.
+	Misses int64
+
+	// StoreErrors is the number of failed store reads and writes. Failed
+	// reads count as misses.
+	StoreErrors int64
+
+	// Entries is the number of cached embeddings.
+	Entries int
+
+	// Bytes is the approximate size of the cached embeddings.
+	Bytes int64
+}
+
+// HitRate returns Hits as a fraction of all inputs.
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Cache is an interfaces.EmbeddingServiceWithCache that caches embeddings
+// per input. When only some inputs of a request are cached, only the
+// misses are sent to the provider and the results are stitched back in
+// input order. Cached embeddings are shared between callers and must be
+// treated as read-only.
+type Cache struct {
+	service interfaces.EmbeddingService
+	config  CacheConfig
+
+	requests    atomic.Int64
+	fullHits    atomic.Int64
+	hits        atomic.Int64
+	misses      atomic.Int64
+	storeErrors atomic.Int64
+}
+
+// NewCache wraps service. A nil config caches in memory without limits.
+func NewCache(service interfaces.EmbeddingService, config *CacheConfig) *Cache {
+	cfg := CacheConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore(0)
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = CacheKey
+	}
+	return &Cache{service: service, config: cfg}
+}
+
+// CacheKey hashes the model, dimensions, encoding format and input text.
+func CacheKey(req *types.EmbeddingRequest, input string) string {
+	h := sha256.New()
+	h.Write([]byte(req.Model))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.Itoa(req.Dimensions)))
+	h.Write([]byte{0})
+	h.Write([]byte(req.EncodingFormat))
+	h.Write([]byte{0})
+	h.Write([]byte(input))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CreateEmbedding implements interfaces.EmbeddingService.
+func (c *Cache) CreateEmbedding(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
+	resp, _, err := c.CreateEmbeddingWithCache(ctx, req)
+	return resp, err
+}
+
+// CreateEmbeddingWithCache implements interfaces.EmbeddingServiceWithCache.
+// cached is true only when every input was served from the cache. Usage
+// covers the inputs sent to the provider. Requests whose Input is not a
+// string or list of strings are not cached.
+func (c *Cache) CreateEmbeddingWithCache(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, bool, error) {
+	inputs := req.GetInputAsStrings()
+	if len(inputs) == 0 {
+		resp, err := c.service.CreateEmbedding(ctx, req)
+		return resp, false, err
+	}
+	c.requests.Add(1)
+
+	found := make([]*types.Embedding, len(inputs))
+	keys := make([]string, len(inputs))
+	missing := make(map[string][]int) // key to input indices
+	var missInputs []string
+	var missKeys []string
+	for i, input := range inputs {
+		keys[i] = c.config.KeyFunc(req, input)
+		emb, ok, err := c.config.Store.Get(keys[i])
+		if err != nil {
+			c.storeErrors.Add(1)
+		}
+		if ok {
+			found[i] = emb
+			c.hits.Add(1)
+			continue
+		}
+		c.misses.Add(1)
+		if _, seen := missing[keys[i]]; !seen {
+			missInputs = append(missInputs, input)
+			missKeys = append(missKeys, keys[i])
+		}
+		missing[keys[i]] = append(missing[keys[i]], i)
+	}
+
+	resp := &types.EmbeddingResponse{Object: "list", Model: req.Model}
+	if len(missInputs) > 0 {
+		sub := *req
+		if _, single := req.Input.(string); single && len(missInputs) == 1 {
+			sub.Input = missInputs[0]
+		} else {
+			sub.Input = missInputs
+		}
+		fresh, err := c.service.CreateEmbedding(ctx, &sub)
+		if err != nil {
+			return nil, false, err
+		}
+		if fresh == nil {
+			return nil, false, fmt.Errorf("embeddings: got no response for %d inputs", len(missInputs))
+		}
+		if len(fresh.Data) != len(missInputs) {
+			return nil, false, fmt.Errorf("embeddings: got %d embeddings for %d inputs", len(fresh.Data), len(missInputs))
+		}
+		data := append([]*types.Embedding(nil), fresh.Data...)
+		sort.SliceStable(data, func(i, j int) bool { return data[i].Index < data[j].Index })
+		for j, emb := range data {
+			stored := *emb
+			stored.Index = 0
+			if err := c.config.Store.Set(missKeys[j], &stored); err != nil {
+				c.storeErrors.Add(1)
+			}
+			for _, i := range missing[missKeys[j]] {
+				found[i] = emb
+			}
+		}
+		if fresh.Model != "" {
+			resp.Model = fresh.Model
+		}
+		resp.Usage = fresh.Usage
+		resp.Metadata = fresh.Metadata
+	} else {
+		c.fullHits.Add(1)
+		resp.Usage = &types.Usage{}
+	}
+
+	resp.Data = make([]*types.Embedding, len(inputs))
+	for i, emb := range found {
+		e := *emb
+		e.Index = i
+		resp.Data[i] = &e
+	}
+	return resp, len(missInputs) == 0, nil
+}
+
+// Stats returns the cache statistics.
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{
+		Requests:    c.requests.Load(),
+		FullHits:    c.fullHits.Load(),
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		StoreErrors: c.storeErrors.Load(),
+		Entries:     c.config.Store.Len(),
+		Bytes:       c.config.Store.Bytes(),
+	}
+}
+
+// ClearCache implements interfaces.EmbeddingServiceWithCache. It empties
+// the store and resets the statistics.
+func (c *Cache) ClearCache() {
+	c.requests.Store(0)
+	c.fullHits.Store(0)
+	c.hits.Store(0)
+	c.misses.Store(0)
+	c.storeErrors.Store(0)
+	if err := c.config.Store.Clear(); err != nil {
+		c.storeErrors.Add(1)
+	}
+}
+
+// GetCacheStats implements interfaces.EmbeddingServiceWithCache with the
+// keys it documents. Prefer Stats.
+func (c *Cache) GetCacheStats() map[string]interface{} {
+	s := c.Stats()
+	return map[string]interface{}{
+		"hits":         s.Hits,
+		"misses":       s.Misses,
+		"size":         s.Entries,
+		"memory_bytes": s.Bytes,
+		"requests":     s.Requests,
+		"full_hits":    s.FullHits,
+		"store_errors": s.StoreErrors,
+		"hit_rate":     s.HitRate(),
+	}
+}
@@ -0,0 +1,344 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+func TestCacheStitching(t *testing.T) {
+	tests := []struct {
+		name       string
+		primed     []string
+		inputs     []string
+		wantSent   string
+		wantCached bool
+		wantStats  CacheStats
+	}{
+		{
+			name:      "all misses",
+			inputs:    []string{"0", "1"},
+			wantSent:  "[[0 1]]",
+			wantStats: CacheStats{Requests: 1, Misses: 2, Entries: 2},
+		},
+		{
+			name:      "partial hit sends only the misses",
+			primed:    []string{"1", "3"},
+			inputs:    []string{"0", "1", "2", "3"},
+			wantSent:  "[[1 3] [0 2]]",
+			wantStats: CacheStats{Requests: 2, Hits: 2, Misses: 4, Entries: 4},
+		},
+		{
+			name:       "full hit",
+			primed:     []string{"2", "1"},
+			inputs:     []string{"1", "2"},
+			wantSent:   "[[2 1]]",
+			wantCached: true,
+			wantStats:  CacheStats{Requests: 2, FullHits: 1, Hits: 2, Misses: 2, Entries: 2},
+		},
+		{
+			name:      "duplicate inputs are sent once",
+			primed:    []string{"9"},
+			inputs:    []string{"5", "9", "5", "6", "6"},
+			wantSent:  "[[9] [5 6]]",
+			wantStats: CacheStats{Requests: 2, Hits: 1, Misses: 5, Entries: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeEmbedder{}
+			c := NewCache(svc, nil)
+			if tt.primed != nil {
+				if _, err := c.CreateEmbedding(context.Background(), types.NewEmbeddingRequestFromStrings("m", tt.primed)); err != nil {
+					t.Fatalf("CreateEmbedding() priming error = %v", err)
+				}
+			}
+
+			resp, cached, err := c.CreateEmbeddingWithCache(context.Background(), types.NewEmbeddingRequestFromStrings("m", tt.inputs))
+			if err != nil {
+				t.Fatalf("CreateEmbeddingWithCache() error = %v", err)
+			}
+			checkEmbeddings(t, resp, tt.inputs)
+			if cached != tt.wantCached {
+				t.Errorf("cached = %v, want %v", cached, tt.wantCached)
+			}
+
+			sent := svc.callInputs()
+			if fmt.Sprint(sent) != tt.wantSent {
+				t.Errorf("sent = %v, want %s", sent, tt.wantSent)
+			}
+			misses := len(sent[len(sent)-1])
+			if tt.wantCached {
+				misses = 0
+			}
+			if resp.Usage == nil || resp.Usage.TotalTokens != misses {
+				t.Errorf("Usage = %+v, want %d tokens for the inputs sent", resp.Usage, misses)
+			}
+
+			stats := c.Stats()
+			stats.Bytes = 0
+			if stats != tt.wantStats {
+				t.Errorf("Stats() = %+v, want %+v", stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestCacheSingleInput(t *testing.T) {
+	var sent []interface{}
+	svc := &fakeEmbedder{}
+	c := NewCache(embedderFunc(func(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
+		sent = append(sent, req.Input)
+		return svc.CreateEmbedding(ctx, req)
+	}), nil)
+
+	for range 2 {
+		resp, err := c.CreateEmbedding(context.Background(), types.NewEmbeddingRequest("m", "7"))
+		if err != nil {
+			t.Fatalf("CreateEmbedding() error = %v", err)
+		}
+		checkEmbeddings(t, resp, []string{"7"})
+	}
+	if len(sent) != 1 || sent[0] != "7" {
+		t.Errorf("sent inputs = %#v, want the single string once", sent)
+	}
+}
+
+func TestCacheErrors(t *testing.T) {
+	errDown := errors.New("down")
+	tests := []struct {
+		name    string
+		respond func(ctx context.Context, inputs []string) (*types.EmbeddingResponse, error)
+		wantErr error
+	}{
+		{
+			name:    "service error",
+			respond: func(context.Context, []string) (*types.EmbeddingResponse, error) { return nil, errDown },
+			wantErr: errDown,
+		},
+		{
+			name:    "nil response",
+			respond: func(context.Context, []string) (*types.EmbeddingResponse, error) { return nil, nil },
+		},
+		{
+			name: "too few embeddings",
+			respond: func(_ context.Context, inputs []string) (*types.EmbeddingResponse, error) {
+				return embed("m", inputs[1:]), nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(&fakeEmbedder{respond: tt.respond}, nil)
+			_, err := c.CreateEmbedding(context.Background(), types.NewEmbeddingRequestFromStrings("m", numbers(2)))
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("CreateEmbedding() error = %v, want an error", err)
+			}
+			if n := c.Stats().Entries; n != 0 {
+				t.Errorf("Stats().Entries = %d, want nothing cached", n)
+			}
+		})
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	c := NewCache(&fakeEmbedder{}, &CacheConfig{Store: NewMemoryStore(0)})
+	req := types.NewEmbeddingRequestFromStrings("m", numbers(3))
+	for range 2 {
+		if _, err := c.CreateEmbedding(context.Background(), req); err != nil {
+			t.Fatalf("CreateEmbedding() error = %v", err)
+		}
+	}
+
+	stats := c.Stats()
+	if stats.HitRate() != 0.5 || stats.Bytes <= 0 {
+		t.Errorf("Stats() = %+v, HitRate() = %v, want 0.5 and a size", stats, stats.HitRate())
+	}
+	m := c.GetCacheStats()
+	if m["hits"] != int64(3) || m["misses"] != int64(3) || m["size"] != 3 || m["full_hits"] != int64(1) || m["hit_rate"] != 0.5 {
+		t.Errorf("GetCacheStats() = %v", m)
+	}
+
+	c.ClearCache()
+	if stats := c.Stats(); stats != (CacheStats{}) || stats.HitRate() != 0 {
+		t.Errorf("Stats() after ClearCache = %+v, want zero", stats)
+	}
+}
+
+func TestCacheStoreErrors(t *testing.T) {
+	c := NewCache(&fakeEmbedder{}, &CacheConfig{Store: failingStore{}})
+	resp, err := c.CreateEmbedding(context.Background(), types.NewEmbeddingRequestFromStrings("m", numbers(2)))
+	if err != nil {
+		t.Fatalf("CreateEmbedding() error = %v, want store errors ignored", err)
+	}
+	checkEmbeddings(t, resp, numbers(2))
+	if stats := c.Stats(); stats.StoreErrors != 4 || stats.Misses != 2 {
+		t.Errorf("Stats() = %+v, want 4 store errors and 2 misses", stats)
+	}
+}
+
+func TestMemoryStoreEviction(t *testing.T) {
+	s := NewMemoryStore(2)
+	a := &types.Embedding{Embedding: []float64{1}}
+	b := &types.Embedding{Embedding: []float64{2, 2}}
+	c := &types.Embedding{Embedding: []float32{3}}
+
+	s.Set("a", a)
+	s.Set("b", b)
+	s.Get("a") // a is now the most recently used
+	s.Set("c", c)
+
+	for _, tt := range []struct {
+		key  string
+		want bool
+	}{{"a", true}, {"b", false}, {"c", true}} {
+		if _, ok, _ := s.Get(tt.key); ok != tt.want {
+			t.Errorf("Get(%q) found = %v, want %v", tt.key, ok, tt.want)
+		}
+	}
+	if want := embeddingSize(a) + embeddingSize(c); s.Len() != 2 || s.Bytes() != want {
+		t.Errorf("Len() = %d, Bytes() = %d, want 2 and %d", s.Len(), s.Bytes(), want)
+	}
+
+	// Replacing an entry updates its size without growing the store.
+	s.Set("a", b)
+	if want := embeddingSize(b) + embeddingSize(c); s.Len() != 2 || s.Bytes() != want {
+		t.Errorf("after replace Len() = %d, Bytes() = %d, want 2 and %d", s.Len(), s.Bytes(), want)
+	}
+
+	s.Clear()
+	if s.Len() != 0 || s.Bytes() != 0 {
+		t.Errorf("after Clear Len() = %d, Bytes() = %d, want 0", s.Len(), s.Bytes())
+	}
+}
+
+func TestDiskStore(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "store")
+	s, err := NewDiskStore(dir)
+	if err != nil {
+		t.Fatalf("NewDiskStore() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		emb  *types.Embedding
+		want string
+	}{
+		{name: "float64 is read back as float32", key: "k1", emb: &types.Embedding{Object: "embedding", Embedding: []float64{0.5, -2}}, want: "[0.5 -2]"},
+		{name: "base64 is kept", key: "k2", emb: &types.Embedding{Embedding: types.EncodeFloat32Base64([]float32{1.5})}, want: "[1.5]"},
+		{name: "quantized is kept", key: "k3", emb: &types.Embedding{Embedding: []int8{-1, 7}, EncodingFormat: types.EmbeddingFormatInt8}, want: "[-1 7]"},
+		{name: "single-character key", key: "x", emb: &types.Embedding{Embedding: []float64{1}}, want: "[1]"},
+		{name: "parent directory key", key: "../../escape", emb: &types.Embedding{Embedding: []float64{2}}, want: "[2]"},
+		{name: "absolute path key", key: "/tmp/escape", emb: &types.Embedding{Embedding: []float64{3}}, want: "[3]"},
+		{name: "dot key", key: "..", emb: &types.Embedding{Embedding: []float64{4}}, want: "[4]"},
+		{name: "long key", key: strings.Repeat("a", maxFileKey+1), emb: &types.Embedding{Embedding: []float64{5}}, want: "[5]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Set(tt.key, tt.emb); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			got, ok, err := s.Get(tt.key)
+			if err != nil || !ok {
+				t.Fatalf("Get() = %v, %v, want the stored embedding", ok, err)
+			}
+			v, err := got.Float64()
+			if err != nil || fmt.Sprint(v) != tt.want {
+				t.Errorf("Get() vector = %v, %v, want %s", v, err, tt.want)
+			}
+			if _, isFloat := tt.emb.Embedding.([]float64); isFloat {
+				if _, isFloat32 := got.Embedding.([]float32); !isFloat32 {
+					t.Errorf("Get() embedding is %T, want []float32", got.Embedding)
+				}
+			}
+			if got.EncodingFormat != tt.emb.EncodingFormat || got.Object != tt.emb.Object {
+				t.Errorf("Get() = %+v, want the fields of %+v", got, tt.emb)
+			}
+		})
+	}
+
+	// Every file stays inside the store's directory.
+	filepath.WalkDir(parent, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			t.Errorf("file %s written outside the store", path)
+		}
+		return nil
+	})
+	if n := s.Len(); n != len(tests) {
+		t.Errorf("Len() = %d, want %d", n, len(tests))
+	}
+	if s.Bytes() <= 0 {
+		t.Error("Bytes() = 0, want the file sizes")
+	}
+
+	if _, ok, err := s.Get("missing"); ok || err != nil {
+		t.Errorf("Get(missing) = %v, %v, want not found", ok, err)
+	}
+	corrupt := s.path("corrupt")
+	if err := os.MkdirAll(filepath.Dir(corrupt), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(corrupt, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := s.Get("corrupt"); ok || err == nil {
+		t.Errorf("Get(corrupt) = %v, %v, want an error", ok, err)
+	}
+
+	if err := s.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if n := s.Len(); n != 0 {
+		t.Errorf("Len() after Clear = %d, want 0", n)
+	}
+}
+
+func TestDiskStoreCache(t *testing.T) {
+	dir := t.TempDir()
+	inputs := numbers(3)
+	for i := range 2 {
+		store, err := NewDiskStore(dir)
+		if err != nil {
+			t.Fatalf("NewDiskStore() error = %v", err)
+		}
+		svc := &fakeEmbedder{}
+		resp, cached, err := NewCache(svc, &CacheConfig{Store: store}).CreateEmbeddingWithCache(context.Background(), types.NewEmbeddingRequestFromStrings("m", inputs))
+		if err != nil {
+			t.Fatalf("CreateEmbeddingWithCache() error = %v", err)
+		}
+		checkEmbeddings(t, resp, inputs)
+		if wantCached := i == 1; cached != wantCached || (len(svc.callInputs()) == 0) != wantCached {
+			t.Errorf("run %d: cached = %v with %d calls, want cached %v", i, cached, len(svc.callInputs()), wantCached)
+		}
+	}
+}
+
+// embedderFunc adapts a function to interfaces.EmbeddingService.
+type embedderFunc func(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error)
+
+func (f embedderFunc) CreateEmbedding(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
+	return f(ctx, req)
+}
+
+// failingStore fails every read and write.
+type failingStore struct{}
+
+func (failingStore) Get(string) (*types.Embedding, bool, error) {
+	return nil, false, errors.New("store down")
+}
+func (failingStore) Set(string, *types.Embedding) error { return errors.New("store down") }
+func (failingStore) Clear() error                       { return nil }
+func (failingStore) Len() int                           { return 0 }
+func (failingStore) Bytes() int64                       { return 0 }
@@ -0,0 +1,54 @@
+// Package embeddings provides wrappers that add batching, caching and
+// similarity reranking to any interfaces.EmbeddingService.
+//
+// Batcher implements interfaces.EmbeddingServiceWithBatch. It splits large
+// inputs into batches bounded by input count and, with a TokenCounter, by
+// tokens, sends them in parallel, and merges the results back in input
+// order with summed Usage. In partial-results mode, failed batches do not
+// fail the whole call; their input indices are reported in a
+// *PartialError alongside the embeddings that succeeded.
+//
+// Cache implements interfaces.EmbeddingServiceWithCache. Each input is
+// cached separately under a key derived from the model, dimensions,
+// encoding format and a hash of the text, so a request that is only partly
+// cached sends just the misses to the provider. Embeddings are kept in a
+// Store: MemoryStore for an LRU in memory or DiskStore for files that
+// survive restarts. Stats reports hits, misses and size as a CacheStats.
+//
+// Reranker implements interfaces.RerankService by embedding the query and
+// documents and ordering documents by cosine similarity, as a fallback
+// when the provider offers no reranking model.
+//
+// Example usage:
+//
+//	batcher := embeddings.NewBatcher(provider.EmbeddingService(), &embeddings.BatchOptions{
+//	    BatchSize:      256,
+//	    MaxBatchTokens: 8000,
+//	    TokenCounter:   types.NewHeuristicTokenCounter(),
+//	    Parallelism:    4,
+//	})
+//
+//	resp, err := batcher.CreateEmbeddingBatch(ctx, "text-embedding-3-small", chunks, nil)
+//	if err != nil {
+//	    return err
+//	}
+//	vectors, err := vector.Float32s(resp)
+//
+// Caching in front of the batcher avoids re-embedding unchanged chunks:
+//
+//	store, err := embeddings.NewDiskStore(".cache/embeddings")
+//	if err != nil {
+//	    return err
+//	}
+//	cached := embeddings.NewCache(batcher, &embeddings.CacheConfig{Store: store})
+//	resp, err := cached.CreateEmbedding(ctx, req)
+//	log.Printf("hit rate %.2f", cached.Stats().HitRate())
+//
+// Falling back to similarity reranking:
+//
+//	var reranker interfaces.RerankService = embeddings.NewReranker(cached, nil)
+//	if rp, ok := provider.(interfaces.RerankProvider); ok && rp.RerankService() != nil {
+//	    reranker = rp.RerankService()
+//	}
+//	resp, err := reranker.Rerank(ctx, types.NewRerankRequest(model, query, documents).WithTopN(5))
+package embeddings
@@ -0,0 +1,95 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+	"github.com/zacw/go-ai-types/pkg/vector"
+)
+
+// RerankerConfig configures a Reranker.
+type RerankerConfig struct {
+	// Model is the embedding model used to embed the query and documents.
+	// Default is the RerankRequest's Model.
+	Model string
+
+	// Dimensions, if positive, requests embeddings of this size.
+	Dimensions int
+}
+
+// Reranker is an interfaces.RerankService that ranks documents by the
+// cosine similarity of their embeddings to the query's. It is a local
+// fallback for providers without a reranking model: less accurate than a
+// cross-encoder, but it needs only an EmbeddingService. Wrapping the
+// service in a Cache avoids re-embedding documents that are ranked again.
+type Reranker struct {
+	service interfaces.EmbeddingService
+	config  RerankerConfig
+}
+
+// NewReranker creates a reranker over service. Nil config uses defaults.
+func NewReranker(service interfaces.EmbeddingService, config *RerankerConfig) *Reranker {
+	cfg := RerankerConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	return &Reranker{service: service, config: cfg}
+}
+
+// Rerank implements interfaces.RerankService. The query and documents are
+// embedded in one request, whose Usage is reported. MaxTokensPerDoc is
+// ignored.
+func (r *Reranker) Rerank(ctx context.Context, req *types.RerankRequest) (*types.RerankResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	model := r.config.Model
+	if model == "" {
+		model = req.Model
+	}
+
+	inputs := make([]string, 0, len(req.Documents)+1)
+	inputs = append(inputs, req.Query)
+	inputs = append(inputs, req.Documents...)
+	embReq := types.NewEmbeddingRequestFromStrings(model, inputs)
+	if r.config.Dimensions > 0 {
+		embReq.WithDimensions(r.config.Dimensions)
+	}
+	embReq.Metadata = req.Metadata
+
+	embResp, err := r.service.CreateEmbedding(ctx, embReq)
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := vector.Float32s(embResp)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != len(inputs) {
+		return nil, fmt.Errorf("embeddings: got %d embeddings for %d inputs", len(vectors), len(inputs))
+	}
+
+	query := vectors[0]
+	results := make([]*types.RerankResult, len(req.Documents))
+	for i, doc := range vectors[1:] {
+		results[i] = &types.RerankResult{
+			Index:          i,
+			RelevanceScore: float64(vector.Cosine(query, doc)),
+		}
+		if req.ReturnDocuments {
+			results[i].Document = req.Documents[i]
+		}
+	}
+
+	if embResp.Model != "" {
+		model = embResp.Model
+	}
+	return &types.RerankResponse{
+		Model:    model,
+		Results:  types.SortRerankResults(results, req.TopN),
+		Usage:    embResp.Usage,
+		Metadata: embResp.Metadata,
+	}, nil
+}
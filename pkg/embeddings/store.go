@@ -0,0 +1,309 @@
+package embeddings
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Store holds cached embeddings by key. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	// Get returns the embedding stored under key, and false if there is none.
+	Get(key string) (*types.Embedding, bool, error)
+
+	// Set stores emb under key.
+	Set(key string, emb *types.Embedding) error
+
+	// Clear removes every embedding.
+	Clear() error
+
+	// Len returns the number of stored embeddings.
+	Len() int
+
+	// Bytes returns the approximate size of the stored embeddings.
+	Bytes() int64
+}
+
+// MemoryStore is an in-memory Store with optional LRU eviction.
+type MemoryStore struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used at the front
+	bytes   int64
+}
+
+// memoryEntry is an element of MemoryStore.order.
+type memoryEntry struct {
+	key  string
+	emb  *types.Embedding
+	size int64
+}
+
+// NewMemoryStore creates a memory store holding at most maxEntries
+// embeddings, evicting the least recently used. Zero means no limit.
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(key string) (*types.Embedding, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	s.order.MoveToFront(el)
+	return el.Value.(*memoryEntry).emb, true, nil
+}
+
+// Set implements Store.
+func (s *MemoryStore) Set(key string, emb *types.Embedding) error {
+	size := embeddingSize(emb)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok {
+		e := el.Value.(*memoryEntry)
+		s.bytes += size - e.size
+		e.emb, e.size = emb, size
+		s.order.MoveToFront(el)
+		return nil
+	}
+	s.entries[key] = s.order.PushFront(&memoryEntry{key: key, emb: emb, size: size})
+	s.bytes += size
+	for s.maxEntries > 0 && s.order.Len() > s.maxEntries {
+		oldest := s.order.Back()
+		e := oldest.Value.(*memoryEntry)
+		s.order.Remove(oldest)
+		delete(s.entries, e.key)
+		s.bytes -= e.size
+	}
+	return nil
+}
+
+// Clear implements Store.
+func (s *MemoryStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = make(map[string]*list.Element)
+	s.order.Init()
+	s.bytes = 0
+	return nil
+}
+
+// Len implements Store.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// Bytes implements Store.
+func (s *MemoryStore) Bytes() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bytes
+}
+
+// DiskStore is a Store that keeps one JSON file per embedding in a
+// directory, so cached embeddings survive restarts. Float vectors are
+// stored as float32 and read back as []float32. Keys that are not safe file
+// names are hashed, so any key may be used.
+type DiskStore struct {
+	dir string
+}
+
+// NewDiskStore creates a disk store in dir, creating it if needed.
+func NewDiskStore(dir string) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("embeddings: disk store: %w", err)
+	}
+	return &DiskStore{dir: dir}, nil
+}
+
+// maxFileKey is the longest key DiskStore uses as a file name as is.
+const maxFileKey = 128
+
+// path returns the file for key, sharded by the first two characters of
+// its file name.
+func (s *DiskStore) path(key string) string {
+	name := fileName(key)
+	return filepath.Join(s.dir, name[:2], name+".json")
+}
+
+// fileName returns key if it is a safe file name, and its SHA-256 otherwise.
+// Keys from CacheKey are hex and used as is; any other key, such as one with
+// a path separator or "..", is hashed so it cannot leave the store's
+// directory.
+func fileName(key string) string {
+	safe := len(key) >= 2 && len(key) <= maxFileKey
+	for i := 0; safe && i < len(key); i++ {
+		c := key[i]
+		safe = c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+	}
+	if safe {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// diskEntry is the file format of a DiskStore entry. Float vectors are
+// moved to Vector as base64 float32, halving their size, and restored as
+// []float32 when read.
+type diskEntry struct {
+	Vector    string           `json:"vector,omitempty"`
+	Embedding *types.Embedding `json:"embedding"`
+}
+
+// Get implements Store.
+func (s *DiskStore) Get(key string) (*types.Embedding, bool, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("embeddings: disk store: %w", err)
+	}
+	var entry diskEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Embedding == nil {
+		return nil, false, fmt.Errorf("embeddings: disk store: corrupt entry %s", key)
+	}
+	if entry.Vector != "" {
+		v, err := types.DecodeFloat32Base64(entry.Vector)
+		if err != nil {
+			return nil, false, fmt.Errorf("embeddings: disk store: %s: %w", key, err)
+		}
+		entry.Embedding.Embedding = v
+	}
+	return entry.Embedding, true, nil
+}
+
+// Set implements Store. Files are written atomically.
+func (s *DiskStore) Set(key string, emb *types.Embedding) error {
+	stored := *emb
+	entry := diskEntry{Embedding: &stored}
+	if _, isString := emb.Embedding.(string); !isString && !isQuantized(emb.EncodingFormat) {
+		if v, err := emb.Float32(); err == nil {
+			entry.Vector = types.EncodeFloat32Base64(v)
+			stored.Embedding = nil
+		}
+	}
+	data, err := json.Marshal(&entry)
+	if err != nil {
+		return fmt.Errorf("embeddings: disk store: %w", err)
+	}
+
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("embeddings: disk store: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("embeddings: disk store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("embeddings: disk store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("embeddings: disk store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("embeddings: disk store: %w", err)
+	}
+	return nil
+}
+
+// Clear implements Store.
+func (s *DiskStore) Clear() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("embeddings: disk store: %w", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			if err := os.RemoveAll(filepath.Join(s.dir, e.Name())); err != nil {
+				return fmt.Errorf("embeddings: disk store: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Len implements Store by counting files, so it is slow for large stores.
+func (s *DiskStore) Len() int {
+	n, _ := s.walk()
+	return n
+}
+
+// Bytes implements Store by summing file sizes, so it is slow for large
+// stores.
+func (s *DiskStore) Bytes() int64 {
+	_, size := s.walk()
+	return size
+}
+
+func (s *DiskStore) walk() (int, int64) {
+	var n int
+	var size int64
+	filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			n++
+			size += info.Size()
+		}
+		return nil
+	})
+	return n, size
+}
+
+// embeddingSize approximates the memory used by an embedding.
+func embeddingSize(emb *types.Embedding) int64 {
+	const overhead = 64
+	switch v := emb.Embedding.(type) {
+	case []float64:
+		return overhead + int64(8*len(v))
+	case []float32:
+		return overhead + int64(4*len(v))
+	case []interface{}:
+		return overhead + int64(16*len(v))
+	case string:
+		return overhead + int64(len(v))
+	case []byte:
+		return overhead + int64(len(v))
+	case []int8:
+		return overhead + int64(len(v))
+	default:
+		return overhead
+	}
+}
+
+func isQuantized(format string) bool {
+	switch format {
+	case types.EmbeddingFormatInt8, types.EmbeddingFormatUint8,
+		types.EmbeddingFormatBinary, types.EmbeddingFormatUbinary:
+		return true
+	}
+	return false
+}
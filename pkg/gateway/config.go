@@ -0,0 +1,265 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/middleware"
+)
+
+// DefaultListen is the address served when Config.Listen is empty.
+const DefaultListen = ":8080"
+
+// Config is the gateway configuration file.
+type Config struct {
+	// Listen is the address to serve on.
+	// Default is DefaultListen.
+	Listen string `json:"listen,omitempty"`
+
+	// DefaultUpstream receives requests for models no upstream lists.
+	// If empty, such requests fail with a 404 model_not_found error.
+	DefaultUpstream string `json:"default_upstream,omitempty"`
+
+	// Upstreams are the providers requests are routed to. When several
+	// upstreams list the same model, the first one wins.
+	Upstreams []*UpstreamConfig `json:"upstreams"`
+
+	// Middleware is applied to every upstream that does not set its own.
+	// Each upstream gets separate instances, so rate limits and caches are
+	// per upstream.
+	Middleware *MiddlewareConfig `json:"middleware,omitempty"`
+
+	// HeartbeatInterval is the SSE heartbeat interval for streamed responses.
+	// Default is server.DefaultHeartbeatInterval.
+	HeartbeatInterval Duration `json:"heartbeat_interval,omitempty"`
+
+	// MaxBodyBytes limits request body sizes.
+	// Default is server.DefaultMaxBodyBytes.
+	MaxBodyBytes int64 `json:"max_body_bytes,omitempty"`
+}
+
+// UpstreamConfig configures one upstream provider.
+type UpstreamConfig struct {
+	// Name identifies the upstream in routes and logs.
+	Name string `json:"name"`
+
+	// Type selects the ProviderFactory that creates the provider.
+	Type string `json:"type"`
+
+	// BaseURL is the provider's API base URL.
+	BaseURL string `json:"base_url,omitempty"`
+
+	// APIKey is the provider API key. Prefer APIKeyEnv.
+	APIKey string `json:"api_key,omitempty"`
+
+	// APIKeyEnv names an environment variable holding the API key.
+	// It is used when APIKey is empty.
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+
+	// Organization is the provider organization ID, if any.
+	Organization string `json:"organization,omitempty"`
+
+	// Timeout bounds non-streaming upstream requests.
+	// Default is the provider's default.
+	Timeout Duration `json:"timeout,omitempty"`
+
+	// Models lists the models routed to this upstream.
+	// If empty, the provider's own Models list is used.
+	Models []string `json:"models,omitempty"`
+
+	// Custom is passed to the factory as ProviderConfig.Custom.
+	Custom map[string]interface{} `json:"custom,omitempty"`
+
+	// Middleware overrides Config.Middleware for this upstream.
+	Middleware *MiddlewareConfig `json:"middleware,omitempty"`
+}
+
+// MiddlewareConfig configures the middleware stack. Each section is
+// optional; a missing section disables that middleware. The stack is
+// applied in the order logging, cache, retry, rate limit, so cache hits are
+// not rate limited, each retry attempt takes its own rate limit token, and
+// retries are not logged separately.
+type MiddlewareConfig struct {
+	Logging   *LoggingConfig   `json:"logging,omitempty"`
+	Cache     *CacheConfig     `json:"cache,omitempty"`
+	RateLimit *RateLimitConfig `json:"rate_limit,omitempty"`
+	Retry     *RetryConfig     `json:"retry,omitempty"`
+}
+
+// LoggingConfig is the JSON form of interfaces.LoggingConfig.
+type LoggingConfig struct {
+	Requests   bool `json:"requests,omitempty"`
+	Responses  bool `json:"responses,omitempty"`
+	Errors     bool `json:"errors,omitempty"`
+	TokenUsage bool `json:"token_usage,omitempty"`
+	Timestamps bool `json:"timestamps,omitempty"`
+	RequestID  bool `json:"request_id,omitempty"`
+}
+
+// CacheConfig is the JSON form of interfaces.CacheConfig.
+type CacheConfig struct {
+	TTL            Duration `json:"ttl,omitempty"`
+	MaxSize        int      `json:"max_size,omitempty"`
+	MaxMemoryBytes int64    `json:"max_memory_bytes,omitempty"`
+}
+
+// RateLimitConfig is the JSON form of interfaces.RateLimitConfig.
+type RateLimitConfig struct {
+	RequestsPerSecond float64  `json:"requests_per_second,omitempty"`
+	RequestsPerMinute int      `json:"requests_per_minute,omitempty"`
+	TokensPerMinute   int      `json:"tokens_per_minute,omitempty"`
+	Burst             int      `json:"burst,omitempty"`
+	WaitTimeout       Duration `json:"wait_timeout,omitempty"`
+}
+
+// RetryConfig is the JSON form of interfaces.RetryConfig.
+type RetryConfig struct {
+	MaxRetries        int      `json:"max_retries,omitempty"`
+	InitialBackoff    Duration `json:"initial_backoff,omitempty"`
+	MaxBackoff        Duration `json:"max_backoff,omitempty"`
+	BackoffMultiplier float64  `json:"backoff_multiplier,omitempty"`
+}
+
+// Duration is a time.Duration written in JSON as a string such as "1.5s"
+// or as a number of nanoseconds.
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch value := v.(type) {
+	case float64:
+		*d = Duration(value)
+	case string:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+	default:
+		return fmt.Errorf("gateway: invalid duration %s", data)
+	}
+	return nil
+}
+
+// LoadConfig reads and validates a JSON configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("gateway: read config: %w", err)
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("gateway: parse config %s: %w", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks that upstreams are named, typed and unique, and that the
+// default upstream exists.
+func (c *Config) Validate() error {
+	if len(c.Upstreams) == 0 {
+		return fmt.Errorf("gateway: no upstreams configured")
+	}
+	seen := make(map[string]bool, len(c.Upstreams))
+	for i, u := range c.Upstreams {
+		switch {
+		case u == nil:
+			return fmt.Errorf("gateway: upstreams[%d] is null", i)
+		case u.Name == "":
+			return fmt.Errorf("gateway: upstreams[%d]: name is required", i)
+		case u.Type == "":
+			return fmt.Errorf("gateway: upstream %q: type is required", u.Name)
+		case seen[u.Name]:
+			return fmt.Errorf("gateway: duplicate upstream %q", u.Name)
+		}
+		seen[u.Name] = true
+	}
+	if c.DefaultUpstream != "" && !seen[c.DefaultUpstream] {
+		return fmt.Errorf("gateway: default upstream %q is not configured", c.DefaultUpstream)
+	}
+	return nil
+}
+
+// providerConfig converts the upstream settings for a ProviderFactory.
+func (u *UpstreamConfig) providerConfig() *interfaces.ProviderConfig {
+	apiKey := u.APIKey
+	if apiKey == "" && u.APIKeyEnv != "" {
+		apiKey = os.Getenv(u.APIKeyEnv)
+	}
+	custom := make(map[string]interface{}, len(u.Custom)+1)
+	for k, v := range u.Custom {
+		custom[k] = v
+	}
+	if _, ok := custom["models"]; !ok && len(u.Models) > 0 {
+		custom["models"] = u.Models
+	}
+	config := &interfaces.ProviderConfig{
+		APIKey:       apiKey,
+		BaseURL:      u.BaseURL,
+		Organization: u.Organization,
+		Custom:       custom,
+	}
+	if u.Timeout > 0 {
+		config.Timeout = max(1, int(time.Duration(u.Timeout)/time.Second))
+	}
+	return config
+}
+
+// build creates the middleware stack, outermost first.
+func (m *MiddlewareConfig) build(logger *log.Logger) []interfaces.Middleware {
+	if m == nil {
+		return nil
+	}
+	var stack []interfaces.Middleware
+	if l := m.Logging; l != nil {
+		stack = append(stack, middleware.NewLogging(&interfaces.LoggingConfig{
+			LogRequests:       l.Requests,
+			LogResponses:      l.Responses,
+			LogErrors:         l.Errors,
+			LogTokenUsage:     l.TokenUsage,
+			IncludeTimestamps: l.Timestamps,
+			IncludeRequestID:  l.RequestID,
+		}, logger))
+	}
+	if c := m.Cache; c != nil {
+		stack = append(stack, middleware.NewCache(&interfaces.CacheConfig{
+			TTL:            time.Duration(c.TTL),
+			MaxSize:        c.MaxSize,
+			MaxMemoryBytes: c.MaxMemoryBytes,
+		}))
+	}
+	if r := m.Retry; r != nil {
+		stack = append(stack, middleware.NewRetry(&interfaces.RetryConfig{
+			MaxRetries:        r.MaxRetries,
+			InitialBackoff:    time.Duration(r.InitialBackoff),
+			MaxBackoff:        time.Duration(r.MaxBackoff),
+			BackoffMultiplier: r.BackoffMultiplier,
+		}))
+	}
+	if r := m.RateLimit; r != nil {
+		stack = append(stack, middleware.NewRateLimit(&interfaces.RateLimitConfig{
+			RequestsPerSecond: r.RequestsPerSecond,
+			RequestsPerMinute: r.RequestsPerMinute,
+			TokensPerMinute:   r.TokensPerMinute,
+			Burst:             r.Burst,
+			WaitTimeout:       time.Duration(r.WaitTimeout),
+		}))
+	}
+	return stack
+}
@@ -0,0 +1,29 @@
+// Package gateway serves one OpenAI-compatible endpoint in front of several
+// providers.
+//
+// A Gateway is built from a JSON Config listing upstream providers, the
+// models each one serves, and the middleware stack (logging, cache, rate
+// limit, retry) applied to each upstream. Requests are routed by model name;
+// a model may also be addressed as "<upstream>/<model>" to pick an upstream
+// explicitly. The Gateway itself implements interfaces.Provider, and Handler
+// exposes it over HTTP at /v1/chat/completions, /v1/embeddings and
+// /v1/models.
+//
+// Upstream providers are created by interfaces.ProviderFactory functions
+// registered by type name, so the package does not depend on any provider
+// implementation.
+//
+// Example usage:
+//
+//	config, err := gateway.LoadConfig("gateway.json")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	gw, err := gateway.New(config, map[string]interfaces.ProviderFactory{
+//	    "openai": openai.Factory,
+//	}, log.Default())
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	log.Fatal(http.ListenAndServe(config.Listen, gw.Handler()))
+package gateway
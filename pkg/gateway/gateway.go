@@ -0,0 +1,385 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/middleware"
+	"github.com/zacw/go-ai-types/pkg/server"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Name is the provider name reported by a Gateway.
+const Name types.Provider = "gateway"
+
+// Gateway routes requests by model to upstream providers. It implements
+// interfaces.Provider, interfaces.ImageProvider, interfaces.AudioProvider
+// and interfaces.ModelLister.
+type Gateway struct {
+	config    Config
+	upstreams []*upstream
+	byName    map[string]*upstream
+	byModel   map[string]*upstream
+}
+
+// upstream is a configured provider with its middleware applied.
+type upstream struct {
+	name          string
+	provider      interfaces.Provider
+	models        []string
+	chat          interfaces.ChatService
+	embedding     interfaces.EmbeddingService
+	image         interfaces.ImageService
+	transcription interfaces.TranscriptionService
+	speech        interfaces.SpeechService
+}
+
+// New creates the upstream providers with the factory registered for each
+// upstream's type and wraps their services with the configured middleware.
+// A nil logger uses log.Default().
+func New(config *Config, factories map[string]interfaces.ProviderFactory, logger *log.Logger) (*Gateway, error) {
+	if config == nil {
+		return nil, fmt.Errorf("gateway: config is required")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	g := &Gateway{
+		config:  *config,
+		byName:  make(map[string]*upstream, len(config.Upstreams)),
+		byModel: make(map[string]*upstream),
+	}
+	for _, uc := range config.Upstreams {
+		factory, ok := factories[uc.Type]
+		if !ok {
+			return nil, fmt.Errorf("gateway: upstream %q: unknown type %q", uc.Name, uc.Type)
+		}
+		provider, err := factory(uc.providerConfig())
+		if err != nil {
+			return nil, fmt.Errorf("gateway: upstream %q: %w", uc.Name, err)
+		}
+
+		mwConfig := config.Middleware
+		if uc.Middleware != nil {
+			mwConfig = uc.Middleware
+		}
+		stack := mwConfig.build(log.New(logger.Writer(), logger.Prefix()+uc.Name+": ", logger.Flags()))
+
+		u := &upstream{name: uc.Name, provider: provider, models: uc.Models}
+		if len(u.models) == 0 {
+			u.models = provider.Models()
+		}
+		if svc := provider.ChatService(); svc != nil {
+			u.chat = middleware.Apply(svc, stack...)
+		}
+		if svc := provider.EmbeddingService(); svc != nil {
+			u.embedding = middleware.ApplyEmbedding(svc, stack...)
+		}
+		if ip, ok := provider.(interfaces.ImageProvider); ok {
+			u.image = ip.ImageService()
+		}
+		if ap, ok := provider.(interfaces.AudioProvider); ok {
+			u.transcription = ap.TranscriptionService()
+			u.speech = ap.SpeechService()
+		}
+
+		g.upstreams = append(g.upstreams, u)
+		g.byName[u.name] = u
+		for _, model := range u.models {
+			if _, taken := g.byModel[model]; !taken {
+				g.byModel[model] = u
+			}
+		}
+	}
+	return g, nil
+}
+
+// Handler returns an http.Handler serving the OpenAI-compatible API:
+// POST /v1/chat/completions, POST /v1/embeddings, GET /v1/models and
+// GET /v1/models/{model}.
+func (g *Gateway) Handler() http.Handler {
+	config := &server.HandlerConfig{
+		HeartbeatInterval: time.Duration(g.config.HeartbeatInterval),
+		MaxBodyBytes:      g.config.MaxBodyBytes,
+	}
+	models := server.NewModelsHandler(g, config)
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/chat/completions", server.NewChatHandler(g.ChatService(), config))
+	mux.Handle("/v1/embeddings", server.NewEmbeddingHandler(g.EmbeddingService(), config))
+	mux.Handle("/v1/models", models)
+	mux.Handle("/v1/models/{model...}", models)
+	return mux
+}
+
+// Name implements interfaces.Provider.
+func (g *Gateway) Name() types.Provider {
+	return Name
+}
+
+// Capabilities implements interfaces.Provider, returning the union of the
+// upstreams' capabilities.
+func (g *Gateway) Capabilities() []types.ModelCapability {
+	seen := make(map[types.ModelCapability]bool)
+	var caps []types.ModelCapability
+	for _, u := range g.upstreams {
+		for _, c := range u.provider.Capabilities() {
+			if !seen[c] {
+				seen[c] = true
+				caps = append(caps, c)
+			}
+		}
+	}
+	return caps
+}
+
+// Models implements interfaces.Provider, returning every routed model.
+func (g *Gateway) Models() []string {
+	var models []string
+	for _, u := range g.upstreams {
+		for _, m := range u.models {
+			if g.byModel[m] == u {
+				models = append(models, m)
+			}
+		}
+	}
+	return models
+}
+
+// ListModels implements interfaces.ModelLister. Each model is attributed to
+// the upstream provider serving it.
+func (g *Gateway) ListModels(ctx context.Context) ([]*types.ModelInfo, error) {
+	var models []*types.ModelInfo
+	for _, u := range g.upstreams {
+		for _, m := range u.models {
+			if g.byModel[m] == u {
+				models = append(models, &types.ModelInfo{
+					ID:           m,
+					Provider:     u.provider.Name(),
+					Capabilities: u.provider.Capabilities(),
+				})
+			}
+		}
+	}
+	return models, nil
+}
+
+// GetModel implements interfaces.ModelLister.
+func (g *Gateway) GetModel(ctx context.Context, modelID string) (*types.ModelInfo, error) {
+	u, model, err := g.route(modelID)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ModelInfo{ID: model, Provider: u.provider.Name(), Capabilities: u.provider.Capabilities()}, nil
+}
+
+// ChatService implements interfaces.Provider.
+func (g *Gateway) ChatService() interfaces.ChatService {
+	return &chatRouter{gateway: g}
+}
+
+// EmbeddingService implements interfaces.Provider.
+func (g *Gateway) EmbeddingService() interfaces.EmbeddingService {
+	return &embeddingRouter{gateway: g}
+}
+
+// ImageService implements interfaces.ImageProvider. Requests are routed to
+// upstreams that implement interfaces.ImageProvider and bypass the
+// middleware stack.
+func (g *Gateway) ImageService() interfaces.ImageService {
+	return &imageRouter{gateway: g}
+}
+
+// TranscriptionService implements interfaces.AudioProvider. Requests are
+// routed to upstreams that implement interfaces.AudioProvider and bypass
+// the middleware stack.
+func (g *Gateway) TranscriptionService() interfaces.TranscriptionService {
+	return &transcriptionRouter{gateway: g}
+}
+
+// SpeechService implements interfaces.AudioProvider. Requests are routed to
+// upstreams that implement interfaces.AudioProvider and bypass the
+// middleware stack.
+func (g *Gateway) SpeechService() interfaces.SpeechService {
+	return &speechRouter{gateway: g}
+}
+
+// route returns the upstream for a model and the model name to send it.
+// "<upstream>/<model>" selects an upstream explicitly when no upstream lists
+// the full name.
+func (g *Gateway) route(model string) (*upstream, string, error) {
+	if u, ok := g.byModel[model]; ok {
+		return u, model, nil
+	}
+	if name, rest, ok := strings.Cut(model, "/"); ok && rest != "" {
+		if u, ok := g.byName[name]; ok {
+			return u, rest, nil
+		}
+	}
+	if u, ok := g.byName[g.config.DefaultUpstream]; ok {
+		return u, model, nil
+	}
+	return nil, "", &types.ProviderError{
+		ErrorType:    types.ErrorTypeNotFound,
+		Message:      "the model '" + model + "' does not exist",
+		ErrorCode:    "model_not_found",
+		Param:        "model",
+		HTTPStatus:   http.StatusNotFound,
+		ProviderName: Name,
+	}
+}
+
+// unsupported is returned when the routed upstream lacks a service.
+func unsupported(u *upstream, what string) error {
+	return &types.ProviderError{
+		ErrorType:    types.ErrorTypeInvalidRequest,
+		Message:      "upstream " + u.name + " does not support " + what,
+		HTTPStatus:   http.StatusBadRequest,
+		ProviderName: Name,
+	}
+}
+
+// chatRouter dispatches chat requests to upstreams.
+type chatRouter struct {
+	gateway *Gateway
+}
+
+func (r *chatRouter) CreateCompletion(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+	u, model, err := r.gateway.route(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	if u.chat == nil {
+		return nil, unsupported(u, "chat completions")
+	}
+	routed := *req
+	routed.Model = model
+	return u.chat.CreateCompletion(ctx, &routed)
+}
+
+func (r *chatRouter) CreateCompletionStream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	u, model, err := r.gateway.route(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	if u.chat == nil {
+		return nil, unsupported(u, "chat completions")
+	}
+	routed := *req
+	routed.Model = model
+	return u.chat.CreateCompletionStream(ctx, &routed)
+}
+
+// embeddingRouter dispatches embedding requests to upstreams.
+type embeddingRouter struct {
+	gateway *Gateway
+}
+
+func (r *embeddingRouter) CreateEmbedding(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
+	u, model, err := r.gateway.route(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	if u.embedding == nil {
+		return nil, unsupported(u, "embeddings")
+	}
+	routed := *req
+	routed.Model = model
+	return u.embedding.CreateEmbedding(ctx, &routed)
+}
+
+// imageRouter dispatches image requests to upstreams.
+type imageRouter struct {
+	gateway *Gateway
+}
+
+func (r *imageRouter) CreateImage(ctx context.Context, req *types.ImageGenerationRequest) (*types.ImageGenerationResponse, error) {
+	u, routed, err := r.route(req)
+	if err != nil {
+		return nil, err
+	}
+	return u.image.CreateImage(ctx, routed)
+}
+
+func (r *imageRouter) EditImage(ctx context.Context, req *types.ImageGenerationRequest) (*types.ImageGenerationResponse, error) {
+	u, routed, err := r.route(req)
+	if err != nil {
+		return nil, err
+	}
+	return u.image.EditImage(ctx, routed)
+}
+
+func (r *imageRouter) route(req *types.ImageGenerationRequest) (*upstream, *types.ImageGenerationRequest, error) {
+	u, model, err := r.gateway.route(req.Model)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.image == nil {
+		return nil, nil, unsupported(u, "image generation")
+	}
+	routed := *req
+	routed.Model = model
+	return u, &routed, nil
+}
+
+// transcriptionRouter dispatches transcription requests to upstreams.
+type transcriptionRouter struct {
+	gateway *Gateway
+}
+
+func (r *transcriptionRouter) CreateTranscription(ctx context.Context, req *types.TranscriptionRequest) (*types.TranscriptionResponse, error) {
+	u, model, err := r.gateway.route(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	if u.transcription == nil {
+		return nil, unsupported(u, "transcription")
+	}
+	routed := *req
+	routed.Model = model
+	return u.transcription.CreateTranscription(ctx, &routed)
+}
+
+// speechRouter dispatches speech requests to upstreams.
+type speechRouter struct {
+	gateway *Gateway
+}
+
+func (r *speechRouter) CreateSpeech(ctx context.Context, req *types.SpeechRequest) (*types.SpeechResponse, error) {
+	u, routed, err := r.route(req)
+	if err != nil {
+		return nil, err
+	}
+	return u.speech.CreateSpeech(ctx, routed)
+}
+
+func (r *speechRouter) CreateSpeechStream(ctx context.Context, req *types.SpeechRequest) (io.ReadCloser, error) {
+	u, routed, err := r.route(req)
+	if err != nil {
+		return nil, err
+	}
+	return u.speech.CreateSpeechStream(ctx, routed)
+}
+
+func (r *speechRouter) route(req *types.SpeechRequest) (*upstream, *types.SpeechRequest, error) {
+	u, model, err := r.gateway.route(req.Model)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.speech == nil {
+		return nil, nil, unsupported(u, "speech synthesis")
+	}
+	routed := *req
+	routed.Model = model
+	return u, &routed, nil
+}
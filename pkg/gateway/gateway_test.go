@@ -0,0 +1,230 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/middleware"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// fakeProvider records the chat requests it receives and answers them with
+// complete, or with an empty response if complete is nil.
+type fakeProvider struct {
+	name     types.Provider
+	models   []string
+	complete func(req *types.ChatRequest) (*types.ChatResponse, error)
+
+	mu       sync.Mutex
+	requests []*types.ChatRequest
+}
+
+func (p *fakeProvider) Name() types.Provider                          { return p.name }
+func (p *fakeProvider) Capabilities() []types.ModelCapability         { return nil }
+func (p *fakeProvider) Models() []string                              { return p.models }
+func (p *fakeProvider) ChatService() interfaces.ChatService           { return p }
+func (p *fakeProvider) EmbeddingService() interfaces.EmbeddingService { return nil }
+
+func (p *fakeProvider) CreateCompletion(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+	p.mu.Lock()
+	p.requests = append(p.requests, req)
+	p.mu.Unlock()
+	if p.complete != nil {
+		return p.complete(req)
+	}
+	return &types.ChatResponse{Model: req.Model}, nil
+}
+
+func (p *fakeProvider) CreateCompletionStream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (p *fakeProvider) calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.requests)
+}
+
+// imageProvider is a fakeProvider that also implements ImageProvider.
+type imageProvider struct {
+	*fakeProvider
+}
+
+func (p imageProvider) ImageService() interfaces.ImageService { return p }
+
+func (p imageProvider) CreateImage(ctx context.Context, req *types.ImageGenerationRequest) (*types.ImageGenerationResponse, error) {
+	return &types.ImageGenerationResponse{}, nil
+}
+
+func (p imageProvider) EditImage(ctx context.Context, req *types.ImageGenerationRequest) (*types.ImageGenerationResponse, error) {
+	return &types.ImageGenerationResponse{}, nil
+}
+
+// newTestGateway creates a gateway whose upstream types name the given
+// providers.
+func newTestGateway(t *testing.T, config *Config, providers map[string]interfaces.Provider) *Gateway {
+	t.Helper()
+	factories := make(map[string]interfaces.ProviderFactory, len(providers))
+	for typ, p := range providers {
+		factories[typ] = func(*interfaces.ProviderConfig) (interfaces.Provider, error) { return p, nil }
+	}
+	g, err := New(config, factories, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return g
+}
+
+func TestGatewayRouting(t *testing.T) {
+	tests := []struct {
+		name         string
+		model        string
+		defaultName  string
+		wantUpstream string
+		wantModel    string
+		wantStatus   int
+	}{
+		{name: "listed model", model: "gpt-4o", wantUpstream: "openai", wantModel: "gpt-4o"},
+		{name: "first upstream wins a shared model", model: "shared", wantUpstream: "openai", wantModel: "shared"},
+		{name: "explicit upstream prefix", model: "local/shared", wantUpstream: "local", wantModel: "shared"},
+		{name: "listed name containing a slash", model: "org/llama", wantUpstream: "local", wantModel: "org/llama"},
+		{name: "unknown prefix is not stripped", model: "nowhere/x", defaultName: "local", wantUpstream: "local", wantModel: "nowhere/x"},
+		{name: "unlisted model goes to the default", model: "other", defaultName: "local", wantUpstream: "local", wantModel: "other"},
+		{name: "unlisted model without a default", model: "other", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := map[string]*fakeProvider{
+				"openai": {name: "openai", models: []string{"gpt-4o", "shared"}},
+				"local":  {name: "local", models: []string{"org/llama", "shared"}},
+			}
+			g := newTestGateway(t, &Config{
+				DefaultUpstream: tt.defaultName,
+				Upstreams: []*UpstreamConfig{
+					{Name: "openai", Type: "openai"},
+					{Name: "local", Type: "local"},
+				},
+			}, map[string]interfaces.Provider{"openai": providers["openai"], "local": providers["local"]})
+
+			_, err := g.ChatService().CreateCompletion(context.Background(), &types.ChatRequest{Model: tt.model})
+			if tt.wantStatus != 0 {
+				var providerErr *types.ProviderError
+				if !errors.As(err, &providerErr) || providerErr.HTTPStatus != tt.wantStatus {
+					t.Fatalf("CreateCompletion() error = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateCompletion() error = %v", err)
+			}
+			p := providers[tt.wantUpstream]
+			if p.calls() != 1 {
+				t.Fatalf("upstream %s got %d requests, want 1", tt.wantUpstream, p.calls())
+			}
+			if got := p.requests[0].Model; got != tt.wantModel {
+				t.Errorf("upstream got model %q, want %q", got, tt.wantModel)
+			}
+		})
+	}
+}
+
+func TestGatewayImageProvider(t *testing.T) {
+	g := newTestGateway(t, &Config{
+		Upstreams: []*UpstreamConfig{
+			{Name: "images", Type: "images", Models: []string{"dall-e-3"}},
+			{Name: "text", Type: "text", Models: []string{"gpt-4o"}},
+		},
+	}, map[string]interfaces.Provider{
+		"images": imageProvider{&fakeProvider{name: "images"}},
+		"text":   &fakeProvider{name: "text"},
+	})
+
+	images := g.ImageService()
+	if _, err := images.CreateImage(context.Background(), &types.ImageGenerationRequest{Model: "dall-e-3"}); err != nil {
+		t.Errorf("CreateImage() on an ImageProvider upstream error = %v", err)
+	}
+	_, err := images.CreateImage(context.Background(), &types.ImageGenerationRequest{Model: "gpt-4o"})
+	var providerErr *types.ProviderError
+	if !errors.As(err, &providerErr) || providerErr.HTTPStatus != http.StatusBadRequest {
+		t.Errorf("CreateImage() on a chat-only upstream error = %v, want a 400 error", err)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	unavailable := &types.ProviderError{ErrorType: types.ErrorTypeServer, Message: "unavailable", HTTPStatus: http.StatusServiceUnavailable, IsRetryable: true}
+	// One request a minute with no waiting: a single token is available.
+	rateLimit := &RateLimitConfig{RequestsPerMinute: 1, Burst: 1}
+	retry := &RetryConfig{MaxRetries: 2, InitialBackoff: Duration(time.Millisecond)}
+
+	tests := []struct {
+		name       string
+		middleware *MiddlewareConfig
+		failing    bool
+		requests   int
+		wantCalls  int
+		wantErr    error
+	}{
+		{
+			name:       "each retry takes a rate limit token",
+			middleware: &MiddlewareConfig{RateLimit: rateLimit, Retry: retry},
+			failing:    true,
+			requests:   1,
+			wantCalls:  1,
+			wantErr:    middleware.ErrRateLimited,
+		},
+		{
+			name:       "retries without a rate limit",
+			middleware: &MiddlewareConfig{Retry: retry},
+			failing:    true,
+			requests:   1,
+			wantCalls:  3,
+			wantErr:    unavailable,
+		},
+		{
+			name:       "cache hits are not rate limited",
+			middleware: &MiddlewareConfig{Cache: &CacheConfig{}, RateLimit: rateLimit, Retry: retry},
+			requests:   3,
+			wantCalls:  1,
+		},
+		{
+			name:       "repeated requests are rate limited without a cache",
+			middleware: &MiddlewareConfig{RateLimit: rateLimit},
+			requests:   2,
+			wantCalls:  1,
+			wantErr:    middleware.ErrRateLimited,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &fakeProvider{name: "p", models: []string{"m"}}
+			if tt.failing {
+				p.complete = func(*types.ChatRequest) (*types.ChatResponse, error) { return nil, unavailable }
+			}
+			g := newTestGateway(t, &Config{
+				Upstreams:  []*UpstreamConfig{{Name: "p", Type: "p"}},
+				Middleware: tt.middleware,
+			}, map[string]interfaces.Provider{"p": p})
+
+			var err error
+			for i := 0; i < tt.requests; i++ {
+				req := &types.ChatRequest{Model: "m", Messages: []*types.Message{{Role: types.RoleUser, Content: types.NewTextContent("Hi")}}}
+				_, err = g.ChatService().CreateCompletion(context.Background(), req)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("last CreateCompletion() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := p.calls(); got != tt.wantCalls {
+				t.Errorf("upstream got %d requests, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+// Package index provides an in-memory vector index for retrieval over
+// embeddings, without an external vector database.
+//
+// An Index stores entries with a string ID, a float32 vector and optional
+// metadata. Entries can be added, updated and deleted at any time, and
+// searched either exactly, by comparing the query with every entry, or
+// approximately through an HNSW (hierarchical navigable small world) graph
+// when Config.HNSW is set. Both kinds of search accept a Filter on entry
+// metadata; filtered HNSW searches only return matching entries while still
+// traversing the whole graph.
+//
+// Indexes are safe for concurrent use: any number of searches run in
+// parallel, and writes wait for them to finish. Save and Load persist an
+// index, including its HNSW graph, to a JSON file with base64-encoded
+// vectors. Metadata numbers are loaded back as float64.
+//
+// Example usage:
+//
+//	ix := index.New(&index.Config{HNSW: &index.HNSWConfig{}})
+//
+//	resp, err := embeddings.CreateEmbedding(ctx, types.NewEmbeddingRequestFromStrings(model, texts))
+//	if err != nil {
+//	    return err
+//	}
+//	if err := ix.AddEmbeddings(resp, ids, metadata); err != nil {
+//	    return err
+//	}
+//
+//	results, err := ix.Search(query, 5, &index.SearchOptions{
+//	    Filter: index.Eq("lang", "en"),
+//	})
+//	for _, r := range results {
+//	    fmt.Printf("%s %.3f\n", r.ID, r.Score)
+//	}
+//
+//	if err := ix.Save("docs.index.json"); err != nil {
+//	    return err
+//	}
+package index
@@ -0,0 +1,124 @@
+package index
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Filter reports whether an entry with the given metadata may be returned
+// by a search. Metadata may be nil.
+type Filter func(metadata map[string]interface{}) bool
+
+// Eq matches entries whose metadata[key] equals value. Numbers compare by
+// value, so 1 matches 1.0 after an index is loaded from disk.
+func Eq(key string, value interface{}) Filter {
+	return func(md map[string]interface{}) bool {
+		v, ok := md[key]
+		return ok && equal(v, value)
+	}
+}
+
+// In matches entries whose metadata[key] equals any of values.
+func In(key string, values ...interface{}) Filter {
+	return func(md map[string]interface{}) bool {
+		v, ok := md[key]
+		if !ok {
+			return false
+		}
+		for _, want := range values {
+			if equal(v, want) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Exists matches entries that have metadata[key].
+func Exists(key string) Filter {
+	return func(md map[string]interface{}) bool {
+		_, ok := md[key]
+		return ok
+	}
+}
+
+// Range matches entries whose metadata[key] is a number in [min, max].
+func Range(key string, min, max float64) Filter {
+	return func(md map[string]interface{}) bool {
+		f, ok := toFloat(md[key])
+		return ok && f >= min && f <= max
+	}
+}
+
+// And matches entries that match every filter.
+func And(filters ...Filter) Filter {
+	return func(md map[string]interface{}) bool {
+		for _, f := range filters {
+			if !f(md) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches entries that match any filter.
+func Or(filters ...Filter) Filter {
+	return func(md map[string]interface{}) bool {
+		for _, f := range filters {
+			if f(md) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches entries that do not match f.
+func Not(f Filter) Filter {
+	return func(md map[string]interface{}) bool {
+		return !f(md)
+	}
+}
+
+func equal(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
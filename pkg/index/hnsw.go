@@ -0,0 +1,265 @@
+package index
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// Default HNSW settings.
+const (
+	// DefaultHNSWM is the number of neighbors per node on upper layers.
+	DefaultHNSWM = 16
+
+	// DefaultHNSWEfConstruction is the candidate list size when adding.
+	DefaultHNSWEfConstruction = 200
+
+	// DefaultHNSWEfSearch is the candidate list size when searching.
+	DefaultHNSWEfSearch = 64
+)
+
+// HNSWConfig configures the HNSW graph. Larger values give better recall
+// at the cost of memory and speed.
+type HNSWConfig struct {
+	// M is the number of neighbors per node on upper layers; layer 0 keeps
+	// up to 2*M.
+	// Default is DefaultHNSWM.
+	M int `json:"m"`
+
+	// EfConstruction is the candidate list size when adding entries.
+	// Default is DefaultHNSWEfConstruction.
+	EfConstruction int `json:"ef_construction"`
+
+	// EfSearch is the candidate list size when searching, raised to k when
+	// smaller.
+	// Default is DefaultHNSWEfSearch.
+	EfSearch int `json:"ef_search"`
+
+	// Seed seeds the random layer assignment, making builds reproducible.
+	Seed int64 `json:"seed"`
+}
+
+func (c HNSWConfig) withDefaults() HNSWConfig {
+	if c.M < 2 {
+		c.M = DefaultHNSWM
+	}
+	if c.EfConstruction <= 0 {
+		c.EfConstruction = DefaultHNSWEfConstruction
+	}
+	if c.EfSearch <= 0 {
+		c.EfSearch = DefaultHNSWEfSearch
+	}
+	return c
+}
+
+// candidate is a node and its distance to a query.
+type candidate struct {
+	id   int32
+	dist float32
+}
+
+// link inserts node i into the graph. The caller must hold ix.mu.
+func (ix *Index) link(i int32) {
+	n := ix.nodes[i]
+	n.level = int(math.Floor(-math.Log(1-ix.rng.Float64()) * ix.levelMult))
+	n.friends = make([][]int32, n.level+1)
+
+	if ix.entry < 0 {
+		ix.entry, ix.maxLevel = i, n.level
+		return
+	}
+
+	ep := candidate{ix.entry, ix.distance(n.vector, ix.nodes[ix.entry].vector)}
+	for l := ix.maxLevel; l > n.level; l-- {
+		ep = ix.greedy(n.vector, ep, l)
+	}
+	all := func(int32) bool { return true }
+	for l := min(n.level, ix.maxLevel); l >= 0; l-- {
+		found := ix.searchLayer(n.vector, []candidate{ep}, ix.hnsw.EfConstruction, l, all)
+		neighbors := ix.selectNeighbors(found, ix.hnsw.M)
+		n.friends[l] = neighbors
+		for _, nb := range neighbors {
+			ix.connect(nb, i, l)
+		}
+		ep = found[0]
+	}
+	if n.level > ix.maxLevel {
+		ix.entry, ix.maxLevel = i, n.level
+	}
+}
+
+// connect adds a link from node from to node to on layer l, pruning from's
+// neighbors if it has too many.
+func (ix *Index) connect(from, to int32, l int) {
+	n := ix.nodes[from]
+	n.friends[l] = append(n.friends[l], to)
+	limit := ix.hnsw.M
+	if l == 0 {
+		limit = 2 * ix.hnsw.M
+	}
+	if len(n.friends[l]) <= limit {
+		return
+	}
+	cands := make([]candidate, len(n.friends[l]))
+	for j, f := range n.friends[l] {
+		cands[j] = candidate{f, ix.distance(n.vector, ix.nodes[f].vector)}
+	}
+	sort.Slice(cands, func(a, b int) bool { return cands[a].dist < cands[b].dist })
+	n.friends[l] = ix.selectNeighbors(cands, limit)
+}
+
+// greedy walks layer l towards q from ep and returns the closest node found.
+func (ix *Index) greedy(q []float32, ep candidate, l int) candidate {
+	for changed := true; changed; {
+		changed = false
+		for _, f := range ix.nodes[ep.id].friends[l] {
+			if d := ix.distance(q, ix.nodes[f].vector); d < ep.dist {
+				ep, changed = candidate{f, d}, true
+			}
+		}
+	}
+	return ep
+}
+
+// searchGraph returns up to ef accepted nodes closest to q, nearest first.
+func (ix *Index) searchGraph(q []float32, ef int, accept func(int32) bool) []candidate {
+	if ix.entry < 0 {
+		return nil
+	}
+	ep := candidate{ix.entry, ix.distance(q, ix.nodes[ix.entry].vector)}
+	for l := ix.maxLevel; l > 0; l-- {
+		ep = ix.greedy(q, ep, l)
+	}
+	return ix.searchLayer(q, []candidate{ep}, ef, 0, accept)
+}
+
+// searchLayer is a best-first search of layer l from eps. Every node is
+// traversed, but only accepted nodes are kept as results, so filtered
+// searches explore further until ef matches are found. Results are sorted
+// nearest first.
+func (ix *Index) searchLayer(q []float32, eps []candidate, ef, l int, accept func(int32) bool) []candidate {
+	visited := make([]uint64, (len(ix.nodes)+63)/64)
+	visit := func(id int32) bool {
+		word, bit := id/64, uint64(1)<<(id%64)
+		if visited[word]&bit != 0 {
+			return false
+		}
+		visited[word] |= bit
+		return true
+	}
+
+	cands := &minHeap{}
+	results := &maxHeap{}
+	for _, ep := range eps {
+		visit(ep.id)
+		heap.Push(cands, ep)
+		if accept(ep.id) {
+			results.push(ep)
+		}
+	}
+
+	for cands.Len() > 0 {
+		c := heap.Pop(cands).(candidate)
+		if results.Len() >= ef && c.dist > results.top().dist {
+			break
+		}
+		for _, f := range ix.nodes[c.id].friends[l] {
+			if !visit(f) {
+				continue
+			}
+			d := ix.distance(q, ix.nodes[f].vector)
+			if results.Len() >= ef && d >= results.top().dist {
+				continue
+			}
+			heap.Push(cands, candidate{f, d})
+			if accept(f) {
+				results.push(candidate{f, d})
+				if results.Len() > ef {
+					results.pop()
+				}
+			}
+		}
+	}
+	return results.sorted()
+}
+
+// selectNeighbors picks up to m neighbors from candidates sorted nearest
+// first, preferring candidates closer to the new node than to any already
+// selected neighbor so links spread in different directions. Pruned
+// candidates fill any remaining slots.
+func (ix *Index) selectNeighbors(cands []candidate, m int) []int32 {
+	selected := make([]int32, 0, m)
+	var pruned []int32
+	for _, c := range cands {
+		if len(selected) >= m {
+			break
+		}
+		diverse := true
+		for _, s := range selected {
+			if ix.distance(ix.nodes[c.id].vector, ix.nodes[s].vector) < c.dist {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			selected = append(selected, c.id)
+		} else {
+			pruned = append(pruned, c.id)
+		}
+	}
+	for _, p := range pruned {
+		if len(selected) >= m {
+			break
+		}
+		selected = append(selected, p)
+	}
+	return selected
+}
+
+// minHeap orders candidates nearest first.
+type minHeap []candidate
+
+func (h minHeap) Len() int           { return len(h) }
+func (h minHeap) Less(i, j int) bool { return h[i].dist < h[j].dist }
+func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// maxHeap orders candidates farthest first, keeping the nearest seen.
+type maxHeap []candidate
+
+func (h maxHeap) Len() int           { return len(h) }
+func (h maxHeap) Less(i, j int) bool { return h[i].dist > h[j].dist }
+func (h maxHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *maxHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+func (h *maxHeap) push(c candidate) { heap.Push(h, c) }
+func (h *maxHeap) pop() candidate   { return heap.Pop(h).(candidate) }
+func (h maxHeap) top() candidate    { return h[0] }
+func (h *maxHeap) replaceTop(c candidate) {
+	(*h)[0] = c
+	heap.Fix(h, 0)
+}
+
+// sorted returns the candidates nearest first.
+func (h maxHeap) sorted() []candidate {
+	out := append([]candidate(nil), h...)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].dist != out[j].dist {
+			return out[i].dist < out[j].dist
+		}
+		return out[i].id < out[j].id
+	})
+	return out
+}
@@ -0,0 +1,89 @@
+package index
+
+import (
+	"fmt"
+	"testing"
+)
+
+// recall returns the fraction of the exact top k found by the HNSW search,
+// averaged over queries.
+func recall(t *testing.T, ix *Index, queries []Entry, k int) float64 {
+	t.Helper()
+	found, total := 0, 0
+	for _, q := range queries {
+		exact, err := ix.Search(q.Vector, k, &SearchOptions{Exact: true})
+		if err != nil {
+			t.Fatalf("exact Search() error = %v", err)
+		}
+		approx, err := ix.Search(q.Vector, k, nil)
+		if err != nil {
+			t.Fatalf("HNSW Search() error = %v", err)
+		}
+		want := make(map[string]bool, len(exact))
+		for _, r := range exact {
+			want[r.ID] = true
+		}
+		for _, r := range approx {
+			if want[r.ID] {
+				found++
+			}
+		}
+		total += len(exact)
+	}
+	return float64(found) / float64(total)
+}
+
+func TestHNSWRecall(t *testing.T) {
+	tests := []struct {
+		metric Metric
+		n      int
+		dims   int
+		want   float64
+	}{
+		{metric: MetricCosine, n: 1000, dims: 32, want: 0.95},
+		{metric: MetricDot, n: 1000, dims: 32, want: 0.9},
+		{metric: MetricL2, n: 1000, dims: 32, want: 0.95},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.metric, tt.n), func(t *testing.T) {
+			ix := New(&Config{Metric: tt.metric, HNSW: &HNSWConfig{Seed: 1}})
+			if err := ix.Add(randomEntries(tt.n, tt.dims, 1)...); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+			if got := recall(t, ix, randomEntries(50, tt.dims, 2), 10); got < tt.want {
+				t.Errorf("recall@10 = %.3f, want at least %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHNSWRecallAfterUpdates(t *testing.T) {
+	ix := New(&Config{HNSW: &HNSWConfig{Seed: 1}})
+	entries := randomEntries(1500, 16, 3)
+	if err := ix.Add(entries...); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	// Replace a third of the vectors and delete another third, leaving
+	// tombstones in the graph.
+	replacements := randomEntries(500, 16, 4)
+	for i := range replacements {
+		replacements[i].ID = entries[i].ID
+	}
+	if err := ix.Upsert(replacements...); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	var ids []string
+	for _, e := range entries[500:1000] {
+		ids = append(ids, e.ID)
+	}
+	ix.Delete(ids...)
+
+	if got := recall(t, ix, randomEntries(50, 16, 5), 10); got < 0.9 {
+		t.Errorf("recall@10 with tombstones = %.3f, want at least 0.90", got)
+	}
+	ix.Compact()
+	if got := recall(t, ix, randomEntries(50, 16, 5), 10); got < 0.95 {
+		t.Errorf("recall@10 after Compact() = %.3f, want at least 0.95", got)
+	}
+}
@@ -0,0 +1,445 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+	"github.com/zacw/go-ai-types/pkg/vector"
+)
+
+var (
+	// ErrDuplicateID is returned by Add for an ID already in the index.
+	ErrDuplicateID = errors.New("index: duplicate id")
+
+	// ErrNotFound is returned by Update for an ID not in the index.
+	ErrNotFound = errors.New("index: id not found")
+
+	// ErrDimensions is returned for a vector whose length does not match
+	// the index dimensions.
+	ErrDimensions = errors.New("index: dimension mismatch")
+)
+
+// Metric is how vectors are compared.
+type Metric int
+
+const (
+	// MetricCosine ranks by cosine similarity. Vectors are normalized when
+	// added, so stored vectors have unit length.
+	MetricCosine Metric = iota
+
+	// MetricDot ranks by dot product.
+	MetricDot
+
+	// MetricL2 ranks by Euclidean distance, nearest first.
+	MetricL2
+)
+
+// String returns the metric name.
+func (m Metric) String() string {
+	switch m {
+	case MetricCosine:
+		return "cosine"
+	case MetricDot:
+		return "dot"
+	case MetricL2:
+		return "l2"
+	default:
+		return "unknown"
+	}
+}
+
+// parseMetric parses a name returned by Metric.String.
+func parseMetric(s string) (Metric, error) {
+	for _, m := range []Metric{MetricCosine, MetricDot, MetricL2} {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("index: unknown metric %q", s)
+}
+
+// Config configures an Index.
+type Config struct {
+	// Dimensions is the length of every vector.
+	// Default is the length of the first vector added.
+	Dimensions int
+
+	// Metric compares vectors.
+	// Default is MetricCosine.
+	Metric Metric
+
+	// HNSW, if set, maintains an HNSW graph for approximate search.
+	// If nil, every search is exact.
+	HNSW *HNSWConfig
+}
+
+// Entry is a vector stored in an Index.
+type Entry struct {
+	// ID identifies the entry.
+	ID string
+
+	// Vector is the embedding. The index keeps its own copy; vectors
+	// returned by Get and Search are shared with the index and must not be
+	// modified.
+	Vector []float32
+
+	// Metadata is matched by search filters and returned with results.
+	Metadata map[string]interface{}
+}
+
+// Result is an entry returned by Search.
+type Result struct {
+	Entry
+
+	// Score is the similarity to the query; higher is better. It is the
+	// cosine similarity for MetricCosine, the dot product for MetricDot and
+	// the negated Euclidean distance for MetricL2.
+	Score float64
+}
+
+// SearchOptions configures a search.
+type SearchOptions struct {
+	// Filter, if set, restricts results to entries whose metadata matches.
+	Filter Filter
+
+	// Exact compares the query with every entry even if the index has an
+	// HNSW graph.
+	Exact bool
+
+	// EfSearch overrides HNSWConfig.EfSearch for this search.
+	EfSearch int
+}
+
+// Index is an in-memory vector index. The zero value is not usable; create
+// indexes with New or Load.
+type Index struct {
+	mu     sync.RWMutex
+	config Config
+	hnsw   HNSWConfig // resolved; valid when config.HNSW is set
+
+	nodes   []*node
+	ids     map[string]int32
+	deleted int // tombstoned nodes
+
+	entry     int32 // HNSW entry point, -1 when empty
+	maxLevel  int
+	levelMult float64
+	rng       *rand.Rand
+}
+
+// node is a stored entry. Deleted and replaced entries stay in place as
+// tombstones so the HNSW graph remains connected, until compaction.
+type node struct {
+	id       string
+	vector   []float32
+	metadata map[string]interface{}
+	level    int
+	friends  [][]int32 // neighbors per layer, 0 through level
+	deleted  bool
+}
+
+// New creates an empty index. A nil config uses defaults.
+func New(config *Config) *Index {
+	cfg := Config{}
+	if config != nil {
+		cfg = *config
+	}
+	ix := &Index{config: cfg}
+	if cfg.HNSW != nil {
+		ix.hnsw = cfg.HNSW.withDefaults()
+		ix.config.HNSW = &ix.hnsw
+	}
+	ix.reset()
+	return ix
+}
+
+// reset empties the index, keeping its configuration.
+func (ix *Index) reset() {
+	ix.nodes = nil
+	ix.ids = make(map[string]int32)
+	ix.deleted = 0
+	ix.entry = -1
+	ix.maxLevel = 0
+	if ix.config.HNSW != nil {
+		ix.levelMult = 1 / math.Log(float64(ix.hnsw.M))
+		ix.rng = rand.New(rand.NewSource(ix.hnsw.Seed))
+	}
+}
+
+// Config returns the index configuration, with Dimensions set once known.
+func (ix *Index) Config() Config {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.config
+}
+
+// Len returns the number of entries.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.ids)
+}
+
+// Get returns the entry with the given ID. For MetricCosine the vector is
+// the normalized copy stored by the index.
+func (ix *Index) Get(id string) (Entry, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	i, ok := ix.ids[id]
+	if !ok {
+		return Entry{}, false
+	}
+	return ix.nodes[i].entry(), true
+}
+
+// IDs returns the IDs of every entry, sorted.
+func (ix *Index) IDs() []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	ids := make([]string, 0, len(ix.ids))
+	for id := range ix.ids {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Add adds entries, failing with ErrDuplicateID if any ID is already
+// present. Either every entry is added or none is.
+func (ix *Index) Add(entries ...Entry) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	seen := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if _, ok := ix.ids[e.ID]; ok || seen[e.ID] {
+			return fmt.Errorf("%w: %q", ErrDuplicateID, e.ID)
+		}
+		seen[e.ID] = true
+	}
+	return ix.put(entries)
+}
+
+// Update replaces entries, failing with ErrNotFound if any ID is missing.
+// Either every entry is replaced or none is.
+func (ix *Index) Update(entries ...Entry) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for _, e := range entries {
+		if _, ok := ix.ids[e.ID]; !ok {
+			return fmt.Errorf("%w: %q", ErrNotFound, e.ID)
+		}
+	}
+	return ix.put(entries)
+}
+
+// Upsert adds entries, replacing any with the same ID.
+func (ix *Index) Upsert(entries ...Entry) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return ix.put(entries)
+}
+
+// AddEmbeddings upserts the embeddings in resp, in Index order, under ids.
+// metadata may be nil or hold one map per embedding.
+func (ix *Index) AddEmbeddings(resp *types.EmbeddingResponse, ids []string, metadata []map[string]interface{}) error {
+	vectors, err := vector.Float32s(resp)
+	if err != nil {
+		return err
+	}
+	if len(ids) != len(vectors) {
+		return fmt.Errorf("index: %d ids for %d embeddings", len(ids), len(vectors))
+	}
+	if metadata != nil && len(metadata) != len(vectors) {
+		return fmt.Errorf("index: %d metadata maps for %d embeddings", len(metadata), len(vectors))
+	}
+	entries := make([]Entry, len(vectors))
+	for i, v := range vectors {
+		entries[i] = Entry{ID: ids[i], Vector: v}
+		if metadata != nil {
+			entries[i].Metadata = metadata[i]
+		}
+	}
+	return ix.Upsert(entries...)
+}
+
+// Delete removes entries and returns how many were present.
+func (ix *Index) Delete(ids ...string) int {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	n := 0
+	for _, id := range ids {
+		if i, ok := ix.ids[id]; ok {
+			ix.remove(i)
+			n++
+		}
+	}
+	ix.maybeCompact()
+	return n
+}
+
+// Compact drops deleted entries and, for HNSW indexes, rebuilds the graph.
+// It runs automatically once deleted entries outnumber live ones.
+func (ix *Index) Compact() {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.compact()
+}
+
+// put validates and inserts entries. The caller must hold ix.mu.
+func (ix *Index) put(entries []Entry) error {
+	dims := ix.config.Dimensions
+	for _, e := range entries {
+		if dims == 0 {
+			dims = len(e.Vector)
+		}
+		if len(e.Vector) != dims || dims == 0 {
+			return fmt.Errorf("%w: entry %q has %d dimensions, index has %d", ErrDimensions, e.ID, len(e.Vector), dims)
+		}
+	}
+	ix.config.Dimensions = dims
+
+	for _, e := range entries {
+		if i, ok := ix.ids[e.ID]; ok {
+			ix.remove(i)
+		}
+		v := append([]float32(nil), e.Vector...)
+		if ix.config.Metric == MetricCosine {
+			vector.Normalize(v)
+		}
+		ix.insert(&node{id: e.ID, vector: v, metadata: e.Metadata})
+	}
+	ix.maybeCompact()
+	return nil
+}
+
+// insert appends n and links it into the graph. The caller must hold ix.mu.
+func (ix *Index) insert(n *node) {
+	i := int32(len(ix.nodes))
+	ix.nodes = append(ix.nodes, n)
+	ix.ids[n.id] = i
+	if ix.config.HNSW != nil {
+		ix.link(i)
+	}
+}
+
+// remove tombstones node i. The caller must hold ix.mu.
+func (ix *Index) remove(i int32) {
+	n := ix.nodes[i]
+	delete(ix.ids, n.id)
+	n.deleted = true
+	n.metadata = nil
+	ix.deleted++
+}
+
+func (ix *Index) maybeCompact() {
+	if ix.deleted > len(ix.ids) {
+		ix.compact()
+	}
+}
+
+// compact rebuilds the index from its live nodes. The caller must hold ix.mu.
+func (ix *Index) compact() {
+	if ix.deleted == 0 {
+		return
+	}
+	live := make([]*node, 0, len(ix.ids))
+	for _, n := range ix.nodes {
+		if !n.deleted {
+			live = append(live, &node{id: n.id, vector: n.vector, metadata: n.metadata})
+		}
+	}
+	ix.reset()
+	for _, n := range live {
+		ix.insert(n)
+	}
+}
+
+// Search returns up to k entries most similar to query, best first.
+func (ix *Index) Search(query []float32, k int, opts *SearchOptions) ([]Result, error) {
+	o := SearchOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	if k <= 0 || len(ix.ids) == 0 {
+		return nil, nil
+	}
+	if len(query) != ix.config.Dimensions {
+		return nil, fmt.Errorf("%w: query has %d dimensions, index has %d", ErrDimensions, len(query), ix.config.Dimensions)
+	}
+	if ix.config.Metric == MetricCosine {
+		query = vector.Normalized(query)
+	}
+
+	accept := func(i int32) bool {
+		n := ix.nodes[i]
+		return !n.deleted && (o.Filter == nil || o.Filter(n.metadata))
+	}
+
+	var found []candidate
+	if ix.config.HNSW == nil || o.Exact {
+		found = ix.exact(query, k, accept)
+	} else {
+		ef := o.EfSearch
+		if ef <= 0 {
+			ef = ix.hnsw.EfSearch
+		}
+		found = ix.searchGraph(query, max(ef, k), accept)
+		if len(found) > k {
+			found = found[:k]
+		}
+	}
+
+	results := make([]Result, len(found))
+	for i, c := range found {
+		results[i] = Result{Entry: ix.nodes[c.id].entry(), Score: ix.score(c.dist)}
+	}
+	return results, nil
+}
+
+// exact compares query with every accepted node.
+func (ix *Index) exact(query []float32, k int, accept func(int32) bool) []candidate {
+	worst := &maxHeap{}
+	for i := range ix.nodes {
+		id := int32(i)
+		if !accept(id) {
+			continue
+		}
+		d := ix.distance(query, ix.nodes[i].vector)
+		if worst.Len() < k {
+			worst.push(candidate{id, d})
+		} else if d < worst.top().dist {
+			worst.replaceTop(candidate{id, d})
+		}
+	}
+	return worst.sorted()
+}
+
+// distance returns how far apart a and b are; lower is closer.
+func (ix *Index) distance(a, b []float32) float32 {
+	if ix.config.Metric == MetricL2 {
+		return vector.SquaredL2Distance(a, b)
+	}
+	return -vector.Dot(a, b)
+}
+
+// score converts a distance to a Result score.
+func (ix *Index) score(d float32) float64 {
+	if ix.config.Metric == MetricL2 {
+		return -math.Sqrt(float64(d))
+	}
+	return float64(-d)
+}
+
+// entry returns the node as an Entry.
+func (n *node) entry() Entry {
+	return Entry{ID: n.id, Vector: n.vector, Metadata: n.metadata}
+}
@@ -0,0 +1,188 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// randomEntries returns n entries with random vectors and an "i" and
+// "even" metadata field.
+func randomEntries(n, dims int, seed int64) []Entry {
+	rng := rand.New(rand.NewSource(seed))
+	entries := make([]Entry, n)
+	for i := range entries {
+		v := make([]float32, dims)
+		for j := range v {
+			v[j] = float32(rng.NormFloat64())
+		}
+		entries[i] = Entry{
+			ID:       fmt.Sprintf("e%d", i),
+			Vector:   v,
+			Metadata: map[string]interface{}{"i": i, "even": i%2 == 0},
+		}
+	}
+	return entries
+}
+
+func resultIDs(results []Result) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func TestSearchExact(t *testing.T) {
+	entries := []Entry{
+		{ID: "x", Vector: []float32{1, 0}},
+		{ID: "y", Vector: []float32{0, 2}},
+		{ID: "xy", Vector: []float32{3, 3}},
+	}
+
+	tests := []struct {
+		name      string
+		metric    Metric
+		query     []float32
+		wantIDs   []string
+		wantScore float64
+	}{
+		{name: "cosine ignores length", metric: MetricCosine, query: []float32{1, 0.1}, wantIDs: []string{"x", "xy", "y"}, wantScore: 1 / math.Sqrt(1.01)},
+		{name: "dot favors long vectors", metric: MetricDot, query: []float32{1, 0.1}, wantIDs: []string{"xy", "x", "y"}, wantScore: 3.3},
+		{name: "l2 ranks nearest first", metric: MetricL2, query: []float32{0, 1.5}, wantIDs: []string{"y", "x", "xy"}, wantScore: -0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ix := New(&Config{Metric: tt.metric})
+			if err := ix.Add(entries...); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+			results, err := ix.Search(tt.query, 3, nil)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if got := resultIDs(results); fmt.Sprint(got) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("Search() = %v, want %v", got, tt.wantIDs)
+			}
+			if math.Abs(results[0].Score-tt.wantScore) > 1e-5 {
+				t.Errorf("top score = %v, want %v", results[0].Score, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestIndexErrors(t *testing.T) {
+	ix := New(nil)
+	if err := ix.Add(Entry{ID: "a", Vector: []float32{1, 2}}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "duplicate id", err: ix.Add(Entry{ID: "a", Vector: []float32{1, 2}}), want: ErrDuplicateID},
+		{name: "wrong dimensions", err: ix.Add(Entry{ID: "b", Vector: []float32{1, 2, 3}}), want: ErrDimensions},
+		{name: "update missing id", err: ix.Update(Entry{ID: "c", Vector: []float32{1, 2}}), want: ErrNotFound},
+		{name: "query dimensions", err: func() error { _, err := ix.Search([]float32{1}, 1, nil); return err }(), want: ErrDimensions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, tt.want) {
+				t.Errorf("error = %v, want %v", tt.err, tt.want)
+			}
+		})
+	}
+	if ix.Len() != 1 {
+		t.Errorf("Len() = %d after rejected writes, want 1", ix.Len())
+	}
+}
+
+func TestDeleteAndCompact(t *testing.T) {
+	for _, hnsw := range []*HNSWConfig{nil, {Seed: 1}} {
+		t.Run(fmt.Sprintf("hnsw=%v", hnsw != nil), func(t *testing.T) {
+			ix := New(&Config{HNSW: hnsw})
+			entries := randomEntries(200, 8, 1)
+			if err := ix.Add(entries...); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+
+			var odd []string
+			for i := 1; i < len(entries); i += 2 {
+				odd = append(odd, entries[i].ID)
+			}
+			if n := ix.Delete(append(odd, "missing")...); n != len(odd) {
+				t.Fatalf("Delete() = %d, want %d", n, len(odd))
+			}
+			if ix.Len() != 100 {
+				t.Fatalf("Len() = %d, want 100", ix.Len())
+			}
+
+			check := func(stage string) {
+				for _, e := range entries[:20] {
+					results, err := ix.Search(e.Vector, 5, nil)
+					if err != nil {
+						t.Fatalf("%s: Search() error = %v", stage, err)
+					}
+					for _, r := range results {
+						if !r.Metadata["even"].(bool) {
+							t.Fatalf("%s: Search() returned deleted entry %s", stage, r.ID)
+						}
+					}
+				}
+				if _, ok := ix.Get(entries[1].ID); ok {
+					t.Errorf("%s: Get() found a deleted entry", stage)
+				}
+				if _, ok := ix.Get(entries[0].ID); !ok {
+					t.Errorf("%s: Get() lost a live entry", stage)
+				}
+			}
+			check("tombstoned")
+
+			ix.Compact()
+			if len(ix.nodes) != 100 || ix.deleted != 0 {
+				t.Errorf("after Compact() nodes = %d, deleted = %d, want 100 and 0", len(ix.nodes), ix.deleted)
+			}
+			check("compacted")
+
+			// Deleting more than half the live entries compacts automatically.
+			var more []string
+			for i := 0; i < 60; i++ {
+				more = append(more, entries[2*i].ID)
+			}
+			ix.Delete(more...)
+			if len(ix.nodes) != 40 {
+				t.Errorf("nodes = %d after deleting most entries, want automatic compaction to 40", len(ix.nodes))
+			}
+		})
+	}
+}
+
+func TestSearchFilter(t *testing.T) {
+	for _, hnsw := range []*HNSWConfig{nil, {Seed: 1}} {
+		t.Run(fmt.Sprintf("hnsw=%v", hnsw != nil), func(t *testing.T) {
+			ix := New(&Config{HNSW: hnsw})
+			entries := randomEntries(300, 8, 2)
+			if err := ix.Add(entries...); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+			filter := And(Eq("even", true), Range("i", 0, 99))
+			results, err := ix.Search(entries[1].Vector, 10, &SearchOptions{Filter: filter})
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if len(results) != 10 {
+				t.Fatalf("Search() returned %d results, want 10", len(results))
+			}
+			for _, r := range results {
+				if !filter(r.Metadata) {
+					t.Errorf("result %s does not match the filter: %v", r.ID, r.Metadata)
+				}
+			}
+		})
+	}
+}
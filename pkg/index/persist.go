@@ -0,0 +1,187 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// fileVersion is the version of the persisted format.
+const fileVersion = 1
+
+// indexFile is the persisted form of an Index.
+type indexFile struct {
+	Version    int         `json:"version"`
+	Dimensions int         `json:"dimensions"`
+	Metric     string      `json:"metric"`
+	HNSW       *HNSWConfig `json:"hnsw,omitempty"`
+	EntryPoint int32       `json:"entry_point"`
+	MaxLevel   int         `json:"max_level"`
+	Nodes      []nodeFile  `json:"nodes"`
+}
+
+// nodeFile is the persisted form of a node. Vector is base64 little-endian
+// float32, as produced by types.EncodeFloat32Base64.
+type nodeFile struct {
+	ID       string                 `json:"id"`
+	Vector   string                 `json:"vector"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Level    int                    `json:"level,omitempty"`
+	Friends  [][]int32              `json:"friends,omitempty"`
+	Deleted  bool                   `json:"deleted,omitempty"`
+}
+
+// Save writes the index to path, replacing it atomically.
+func (ix *Index) Save(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("index: save: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := ix.Write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("index: save: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("index: save: %w", err)
+	}
+	return nil
+}
+
+// Write writes the index to w.
+func (ix *Index) Write(w io.Writer) error {
+	ix.mu.RLock()
+	f := indexFile{
+		Version:    fileVersion,
+		Dimensions: ix.config.Dimensions,
+		Metric:     ix.config.Metric.String(),
+		HNSW:       ix.config.HNSW,
+		EntryPoint: ix.entry,
+		MaxLevel:   ix.maxLevel,
+		Nodes:      make([]nodeFile, len(ix.nodes)),
+	}
+	for i, n := range ix.nodes {
+		f.Nodes[i] = nodeFile{
+			ID:       n.id,
+			Vector:   types.EncodeFloat32Base64(n.vector),
+			Metadata: n.metadata,
+			Level:    n.level,
+			Friends:  n.friends,
+			Deleted:  n.deleted,
+		}
+	}
+	err := json.NewEncoder(w).Encode(&f)
+	ix.mu.RUnlock()
+
+	if err != nil {
+		return fmt.Errorf("index: write: %w", err)
+	}
+	return nil
+}
+
+// Load reads an index saved with Save.
+func Load(path string) (*Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("index: load: %w", err)
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+// Read reads an index written with Write.
+func Read(r io.Reader) (*Index, error) {
+	var f indexFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("index: read: %w", err)
+	}
+	if f.Version != fileVersion {
+		return nil, fmt.Errorf("index: read: unsupported version %d", f.Version)
+	}
+	metric, err := parseMetric(f.Metric)
+	if err != nil {
+		return nil, err
+	}
+
+	ix := New(&Config{Dimensions: f.Dimensions, Metric: metric, HNSW: f.HNSW})
+	ix.entry, ix.maxLevel = f.EntryPoint, f.MaxLevel
+	ix.nodes = make([]*node, len(f.Nodes))
+	for i, nf := range f.Nodes {
+		v, err := types.DecodeFloat32Base64(nf.Vector)
+		if err != nil {
+			return nil, fmt.Errorf("index: read: node %q: %w", nf.ID, err)
+		}
+		if len(v) != f.Dimensions {
+			return nil, fmt.Errorf("%w: node %q has %d dimensions, index has %d", ErrDimensions, nf.ID, len(v), f.Dimensions)
+		}
+		n := &node{id: nf.ID, vector: v, metadata: nf.Metadata, level: nf.Level, friends: nf.Friends, deleted: nf.Deleted}
+		if f.HNSW == nil {
+			n.level, n.friends = 0, nil
+		}
+		ix.nodes[i] = n
+		if n.deleted {
+			ix.deleted++
+			continue
+		}
+		if _, dup := ix.ids[n.id]; dup {
+			return nil, fmt.Errorf("index: read: %w: %q", ErrDuplicateID, n.id)
+		}
+		ix.ids[n.id] = int32(i)
+	}
+	if f.HNSW != nil {
+		if err := checkGraph(ix.nodes, f.EntryPoint, f.MaxLevel); err != nil {
+			return nil, err
+		}
+	}
+	return ix, nil
+}
+
+// checkGraph validates a loaded HNSW graph, so that searches starting at
+// entry on layer maxLevel only follow links to nodes present on each layer.
+func checkGraph(nodes []*node, entry int32, maxLevel int) error {
+	if len(nodes) == 0 {
+		if entry != -1 {
+			return fmt.Errorf("index: read: entry point %d in an empty index", entry)
+		}
+		return nil
+	}
+	if entry < 0 || int(entry) >= len(nodes) {
+		return fmt.Errorf("index: read: entry point %d out of range", entry)
+	}
+	if level := nodes[entry].level; level != maxLevel {
+		return fmt.Errorf("index: read: max level %d does not match entry point level %d", maxLevel, level)
+	}
+	for _, n := range nodes {
+		if err := checkFriends(n, nodes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFriends validates a loaded node's links: it has one friend list per
+// layer, and every node it links to on layer l exists and has layer l.
+func checkFriends(n *node, nodes []*node) error {
+	if n.level < 0 || len(n.friends) != n.level+1 {
+		return fmt.Errorf("index: read: node %q has %d layers, expected %d", n.id, len(n.friends), n.level+1)
+	}
+	for l, layer := range n.friends {
+		for _, f := range layer {
+			if f < 0 || int(f) >= len(nodes) {
+				return fmt.Errorf("index: read: node %q links to missing node %d", n.id, f)
+			}
+			if nodes[f].level < l {
+				return fmt.Errorf("index: read: node %q links to node %q on layer %d above its level %d", n.id, nodes[f].id, l, nodes[f].level)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package index
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+func TestSaveLoad(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{name: "exact", config: &Config{Metric: MetricL2}},
+		{name: "hnsw", config: &Config{HNSW: &HNSWConfig{M: 8, Seed: 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ix := New(tt.config)
+			entries := randomEntries(300, 8, 1)
+			if err := ix.Add(entries...); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+			ix.Delete(entries[0].ID, entries[1].ID)
+
+			path := filepath.Join(t.TempDir(), "index.json")
+			if err := ix.Save(path); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			loaded, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if loaded.Len() != ix.Len() {
+				t.Errorf("Len() = %d, want %d", loaded.Len(), ix.Len())
+			}
+			if got, want := loaded.Config(), ix.Config(); got.Metric != want.Metric || got.Dimensions != want.Dimensions || (got.HNSW == nil) != (want.HNSW == nil) {
+				t.Errorf("Config() = %+v, want %+v", got, want)
+			}
+			got, ok := loaded.Get(entries[2].ID)
+			if !ok || got.Metadata["i"] != float64(2) {
+				t.Errorf("Get() = %+v, %v, want metadata i=2 as float64", got, ok)
+			}
+			// The graph is restored, not rebuilt, so searches match exactly.
+			for _, q := range randomEntries(20, 8, 2) {
+				want, _ := ix.Search(q.Vector, 10, nil)
+				have, err := loaded.Search(q.Vector, 10, nil)
+				if err != nil {
+					t.Fatalf("Search() error = %v", err)
+				}
+				if fmt.Sprint(resultIDs(have)) != fmt.Sprint(resultIDs(want)) {
+					t.Fatalf("Search() after Load = %v, want %v", resultIDs(have), resultIDs(want))
+				}
+			}
+			// The loaded index accepts new entries.
+			if err := loaded.Add(Entry{ID: "new", Vector: entries[0].Vector}); err != nil {
+				t.Errorf("Add() after Load error = %v", err)
+			}
+		})
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	ix := New(&Config{HNSW: &HNSWConfig{Seed: 1}})
+	if err := ix.Add(randomEntries(3, 2, 1)...); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := ix.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	valid := buf.String()
+
+	// graph returns a valid two-layer graph whose entry point 0 links to
+	// nodes 1 and 2 on layer 0, modified by mutate.
+	graph := func(mutate func(f *indexFile)) string {
+		vec := types.EncodeFloat32Base64([]float32{1, 0})
+		f := indexFile{
+			Version: fileVersion, Dimensions: 2, Metric: MetricCosine.String(), HNSW: &HNSWConfig{},
+			EntryPoint: 0, MaxLevel: 1,
+			Nodes: []nodeFile{
+				{ID: "a", Vector: vec, Level: 1, Friends: [][]int32{{1, 2}, {}}},
+				{ID: "b", Vector: vec, Friends: [][]int32{{0, 2}}},
+				{ID: "c", Vector: vec, Friends: [][]int32{{0, 1}}},
+			},
+		}
+		if mutate != nil {
+			mutate(&f)
+		}
+		data, err := json.Marshal(f)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		return string(data)
+	}
+	if _, err := Read(strings.NewReader(graph(nil))); err != nil {
+		t.Fatalf("Read() of the unmodified graph error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "not json", data: "index"},
+		{name: "unknown version", data: strings.Replace(valid, `"version":1`, `"version":9`, 1)},
+		{name: "unknown metric", data: strings.Replace(valid, `"metric":"cosine"`, `"metric":"manhattan"`, 1)},
+		{name: "wrong dimensions", data: strings.Replace(valid, `"dimensions":2`, `"dimensions":3`, 1)},
+		{name: "entry point out of range", data: strings.Replace(valid, `"entry_point":`, `"entry_point":7`, 1)},
+		{name: "max level above the entry point", data: graph(func(f *indexFile) { f.MaxLevel = 3 })},
+		{name: "max level below the entry point", data: graph(func(f *indexFile) { f.MaxLevel = 0 })},
+		{name: "entry point in an empty graph", data: graph(func(f *indexFile) { f.Nodes = nil })},
+		{name: "layer count does not match level", data: graph(func(f *indexFile) { f.Nodes[1].Level = 1 })},
+		{name: "negative level", data: graph(func(f *indexFile) { f.Nodes[2].Level, f.Nodes[2].Friends = -1, nil })},
+		{name: "link to a missing node", data: graph(func(f *indexFile) { f.Nodes[1].Friends[0] = []int32{5} })},
+		{name: "link above the linked node's level", data: graph(func(f *indexFile) { f.Nodes[0].Friends[1] = []int32{1} })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.data == valid || tt.data == graph(nil) {
+				t.Fatal("test data was not modified")
+			}
+			if _, err := Read(strings.NewReader(tt.data)); err == nil {
+				t.Error("Read() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// TranscriptionService defines the interface for transcribing audio to
+// text.
+//
+// Example usage:
+//
+//	req := types.NewTranscriptionRequest("whisper-1", types.NewFile("call.mp3", audio)).
+//	    WithLanguage("en").
+//	    WithTimestamps(types.TimestampGranularityWord)
+//	resp, err := transcriptionService.CreateTranscription(ctx, req)
+//	if err != nil {
+//	    return err
+//	}
+//	for _, w := range resp.Words {
+//	    fmt.Printf("%6.2fs %s\n", w.Start, w.Word)
+//	}
+type TranscriptionService interface {
+	// CreateTranscription transcribes req.File.
+	//
+	// The fields of the response that are set depend on req.ResponseFormat:
+	// the text and subtitle formats set only Text, while verbose JSON adds
+	// the language, duration, segments and requested word timestamps.
+	//
+	// Returns an error if the request is invalid, the audio format is not
+	// supported, or the API call fails.
+	CreateTranscription(ctx context.Context, req *types.TranscriptionRequest) (*types.TranscriptionResponse, error)
+}
+
+// SpeechService defines the interface for synthesizing speech from text.
+//
+// Example usage:
+//
+//	req := types.NewSpeechRequest("gpt-4o-mini-tts", "Hello there!", "alloy").
+//	    WithFormat(types.AudioFormatOpus)
+//	audio, err := speechService.CreateSpeechStream(ctx, req)
+//	if err != nil {
+//	    return err
+//	}
+//	defer audio.Close()
+//	_, err = io.Copy(w, audio)
+type SpeechService interface {
+	// CreateSpeech synthesizes req.Input and returns the complete audio.
+	//
+	// Returns an error if the request is invalid or the API call fails.
+	CreateSpeech(ctx context.Context, req *types.SpeechRequest) (*types.SpeechResponse, error)
+
+	// CreateSpeechStream synthesizes req.Input and returns the audio as it
+	// is generated, so playback can start before synthesis finishes. The
+	// caller must close the returned reader. Errors after the stream starts
+	// are returned by Read.
+	//
+	// The context bounds the whole stream: canceling it stops the stream.
+	CreateSpeechStream(ctx context.Context, req *types.SpeechRequest) (io.ReadCloser, error)
+}
+
+// AudioProvider is an optional interface that providers can implement to
+// expose speech services.
+//
+// Discover it with a type assertion:
+//
+//	if ap, ok := provider.(interfaces.AudioProvider); ok {
+//	    if svc := ap.TranscriptionService(); svc != nil {
+//	        resp, err := svc.CreateTranscription(ctx, req)
+//	    }
+//	}
+type AudioProvider interface {
+	// TranscriptionService returns the transcription service for this
+	// provider. Returns nil if transcription is not available.
+	TranscriptionService() TranscriptionService
+
+	// SpeechService returns the speech synthesis service for this provider.
+	// Returns nil if speech synthesis is not available.
+	SpeechService() SpeechService
+}
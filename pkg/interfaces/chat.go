@@ -103,6 +103,22 @@ type ChatServiceWithCallback interface {
 	CreateCompletionStreamWithCallback(ctx context.Context, req *types.ChatRequest, handler StreamHandler) error
 }
 
+// ChatServiceWithResume extends ChatService with resumable streams.
+//
+// Providers whose streaming endpoint honours the server-sent events
+// Last-Event-ID header implement this interface so that an interrupted stream
+// can continue where it stopped instead of being regenerated.
+type ChatServiceWithResume interface {
+	ChatService
+
+	// ResumeCompletionStream re-opens the stream for req, delivering only the
+	// events after lastEventID (the EventID of the last chunk received).
+	//
+	// Returns an error if the stream can no longer be resumed, for example
+	// because the provider has discarded it.
+	ResumeCompletionStream(ctx context.Context, req *types.ChatRequest, lastEventID string) (<-chan types.StreamChunk, error)
+}
+
 // ChatServiceWithValidation extends ChatService with request validation.
 //
 // This interface allows clients to validate requests before making API calls,
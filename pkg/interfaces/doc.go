@@ -15,6 +15,16 @@
 //
 // EmbeddingService: Handles embedding generation requests.
 //
+// ImageService: Generates and edits images. Providers that offer it
+// implement the optional ImageProvider interface.
+//
+// TranscriptionService and SpeechService: Convert audio to text and text to
+// speech. Providers that offer them implement the optional AudioProvider
+// interface.
+//
+// RerankService: Orders documents by relevance to a query. Providers that
+// offer it implement the optional RerankProvider interface.
+//
 // StreamHandler: Processes streaming responses in a callback-based manner.
 //
 // Middleware: Enables composable request/response processing for cross-cutting
@@ -0,0 +1,58 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// ImageService defines the interface for generating and editing images.
+//
+// Example usage:
+//
+//	imageService := provider.(interfaces.ImageProvider).ImageService()
+//	req := types.NewImageGenerationRequest("dall-e-3", "A watercolor lighthouse at dawn").
+//	    WithSize(types.ImageSize1024).
+//	    WithResponseFormat(types.ImageResponseFormatB64JSON)
+//	resp, err := imageService.CreateImage(ctx, req)
+//	if err != nil {
+//	    return err
+//	}
+//	png, err := resp.Data[0].Bytes()
+type ImageService interface {
+	// CreateImage generates images from req.Prompt.
+	//
+	// Returns an error if the request is invalid, the prompt is rejected by
+	// the provider's content policy, or the API call fails.
+	CreateImage(ctx context.Context, req *types.ImageGenerationRequest) (*types.ImageGenerationResponse, error)
+
+	// EditImage edits req.Images according to req.Prompt, limited to the
+	// transparent areas of req.Mask if it is set.
+	//
+	// Example:
+	//   req := types.NewImageEditRequest("gpt-image-1", "Add a red door",
+	//       types.NewFile("house.png", houseData)).
+	//       WithMask(types.NewFile("mask.png", maskData))
+	//   resp, err := service.EditImage(ctx, req)
+	//
+	// Returns an error if req has no images, the request is invalid, or the
+	// API call fails.
+	EditImage(ctx context.Context, req *types.ImageGenerationRequest) (*types.ImageGenerationResponse, error)
+}
+
+// ImageProvider is an optional interface that providers can implement to
+// expose an ImageService.
+//
+// It is not part of Provider so that existing providers without image
+// support need no changes. Discover it with a type assertion:
+//
+//	if ip, ok := provider.(interfaces.ImageProvider); ok {
+//	    if svc := ip.ImageService(); svc != nil {
+//	        resp, err := svc.CreateImage(ctx, req)
+//	    }
+//	}
+type ImageProvider interface {
+	// ImageService returns the image generation service for this provider.
+	// Returns nil if image generation is not available.
+	ImageService() ImageService
+}
@@ -0,0 +1,52 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// RerankService defines the interface for reranking documents by relevance
+// to a query.
+//
+// Reranking is typically applied after vector retrieval: a cheap search
+// returns candidate chunks, and a reranking model orders them more
+// accurately before they are added to a prompt.
+//
+// Example usage:
+//
+//	req := types.NewRerankRequest("rerank-v3.5", "What is the capital of France?", documents).
+//	    WithTopN(3)
+//	resp, err := rerankService.Rerank(ctx, req)
+//	if err != nil {
+//	    return err
+//	}
+//	for _, r := range resp.Results {
+//	    fmt.Println(r.RelevanceScore, documents[r.Index])
+//	}
+type RerankService interface {
+	// Rerank orders req.Documents by relevance to req.Query.
+	//
+	// Results are sorted most relevant first and limited to req.TopN when it
+	// is positive. Each result's Index refers to req.Documents.
+	//
+	// Returns an error if the request is invalid or the provider call fails.
+	Rerank(ctx context.Context, req *types.RerankRequest) (*types.RerankResponse, error)
+}
+
+// RerankProvider is an optional interface that providers can implement to
+// expose a RerankService.
+//
+// It is not part of Provider because most providers do not offer
+// reranking. Discover it with a type assertion:
+//
+//	if rp, ok := provider.(interfaces.RerankProvider); ok {
+//	    if svc := rp.RerankService(); svc != nil {
+//	        resp, err := svc.Rerank(ctx, req)
+//	    }
+//	}
+type RerankProvider interface {
+	// RerankService returns the reranking service for this provider.
+	// Returns nil if reranking is not available.
+	RerankService() RerankService
+}
@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/streams"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// CacheStats reports cache effectiveness.
+type CacheStats struct {
+	// Hits is the number of requests served from the cache.
+	Hits int64
+
+	// Misses is the number of requests passed to the next handler.
+	Misses int64
+
+	// Entries is the number of cached responses.
+	Entries int
+
+	// Bytes is the approximate size of the cached responses.
+	Bytes int64
+}
+
+// Cache caches successful chat responses in memory with LRU eviction.
+//
+// Streaming requests share the cache: a hit is replayed as a stream with
+// streams.FromResponse, and a miss is accumulated and stored once the stream
+// completes without error. Cached responses are shared between callers and
+// must be treated as read-only.
+type Cache struct {
+	config interfaces.CacheConfig
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used at the front
+	bytes   int64
+	hits    int64
+	misses  int64
+}
+
+// cacheEntry is an element of Cache.order.
+type cacheEntry struct {
+	key     string
+	resp    *types.ChatResponse
+	size    int64
+	expires time.Time
+}
+
+// NewCache creates a caching middleware. A nil config caches every successful
+// response without expiry or size limits.
+func NewCache(config *interfaces.CacheConfig) *Cache {
+	cfg := interfaces.CacheConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = DefaultCacheKey
+	}
+	return &Cache{
+		config:  cfg,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// DefaultCacheKey hashes every field of the request that affects the
+// response. Stream and Metadata are ignored.
+func DefaultCacheKey(req *types.ChatRequest) string {
+	r := *req
+	r.Stream = false
+	r.Metadata = nil
+	data, err := json.Marshal(&r)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Wrap implements interfaces.Middleware.
+func (c *Cache) Wrap(next interfaces.Handler) interfaces.Handler {
+	return func(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+		key := c.config.KeyFunc(req)
+		if resp := c.get(key); resp != nil {
+			return resp, nil
+		}
+		resp, err := next(ctx, req)
+		if err == nil {
+			c.put(key, req, resp)
+		}
+		return resp, err
+	}
+}
+
+// WrapStream implements interfaces.StreamingMiddleware.
+func (c *Cache) WrapStream(next interfaces.StreamingHandler) interfaces.StreamingHandler {
+	return func(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+		key := c.config.KeyFunc(req)
+		if resp := c.get(key); resp != nil {
+			return streams.FromResponse(ctx, resp), nil
+		}
+		stream, err := next(ctx, req)
+		if err != nil || key == "" {
+			return stream, err
+		}
+
+		acc := types.NewStreamAccumulator()
+		failed, finished := false, false
+		return observe(ctx, stream, func(chunk types.StreamChunk) {
+			if _, ok := chunk.(*types.ErrorChunk); ok {
+				failed = true
+				return
+			}
+			acc.Add(chunk)
+			finished = finished || chunk.IsComplete()
+		}, func() {
+			if finished && !failed && ctx.Err() == nil {
+				c.put(key, req, acc.ToChatResponse())
+			}
+		}), nil
+	}
+}
+
+// Stats returns the cache statistics.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Entries: len(c.entries), Bytes: c.bytes}
+}
+
+// Clear removes every cached response.
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.bytes = 0
+}
+
+// get returns the cached response for key, or nil.
+func (c *Cache) get(key string) *types.ChatResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key != "" {
+		if elem, ok := c.entries[key]; ok {
+			entry := elem.Value.(*cacheEntry)
+			if entry.expires.IsZero() || time.Now().Before(entry.expires) {
+				c.order.MoveToFront(elem)
+				c.hits++
+				return entry.resp
+			}
+			c.remove(elem)
+		}
+	}
+	c.misses++
+	return nil
+}
+
+// put stores resp under key if it should be cached, evicting the least
+// recently used entries to stay within MaxSize and MaxMemoryBytes.
+func (c *Cache) put(key string, req *types.ChatRequest, resp *types.ChatResponse) {
+	if key == "" || resp == nil {
+		return
+	}
+	if c.config.ShouldCache != nil && !c.config.ShouldCache(req, resp) {
+		return
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	entry := &cacheEntry{key: key, resp: resp, size: int64(len(data))}
+	if c.config.TTL > 0 {
+		entry.expires = time.Now().Add(c.config.TTL)
+	}
+	if c.config.MaxMemoryBytes > 0 && entry.size > c.config.MaxMemoryBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	c.bytes += entry.size
+
+	for c.order.Len() > 0 &&
+		((c.config.MaxSize > 0 && c.order.Len() > c.config.MaxSize) ||
+			(c.config.MaxMemoryBytes > 0 && c.bytes > c.config.MaxMemoryBytes)) {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove deletes an element. The caller must hold c.mu.
+func (c *Cache) remove(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	c.order.Remove(elem)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
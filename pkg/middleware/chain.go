@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// EmbeddingMiddleware wraps embedding handlers. It is implemented by
+// middleware that also applies to interfaces.EmbeddingService.
+type EmbeddingMiddleware interface {
+	// WrapEmbedding wraps an embedding handler with additional behavior.
+	WrapEmbedding(next interfaces.EmbeddingHandler) interfaces.EmbeddingHandler
+}
+
+// Apply wraps service with the given middleware, the first being the
+// outermost. Streaming requests pass through middleware that implements
+// interfaces.StreamingMiddleware and bypass the rest.
+func Apply(service interfaces.ChatService, middleware ...interfaces.Middleware) interfaces.ChatService {
+	handler := interfaces.Handler(service.CreateCompletion)
+	stream := interfaces.StreamingHandler(service.CreateCompletionStream)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i].Wrap(handler)
+		if sm, ok := middleware[i].(interfaces.StreamingMiddleware); ok {
+			stream = sm.WrapStream(stream)
+		}
+	}
+	return &chatService{handler: handler, stream: stream}
+}
+
+// ApplyEmbedding wraps service with the given middleware, the first being
+// the outermost. Middleware that does not implement EmbeddingMiddleware is
+// skipped.
+func ApplyEmbedding(service interfaces.EmbeddingService, middleware ...interfaces.Middleware) interfaces.EmbeddingService {
+	handler := interfaces.EmbeddingHandler(service.CreateEmbedding)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		if em, ok := middleware[i].(EmbeddingMiddleware); ok {
+			handler = em.WrapEmbedding(handler)
+		}
+	}
+	return embeddingService(handler)
+}
+
+// chatService adapts wrapped handlers back to a ChatService.
+type chatService struct {
+	handler interfaces.Handler
+	stream  interfaces.StreamingHandler
+}
+
+func (s *chatService) CreateCompletion(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+	return s.handler(ctx, req)
+}
+
+func (s *chatService) CreateCompletionStream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	return s.stream(ctx, req)
+}
+
+// embeddingService adapts a wrapped handler back to an EmbeddingService.
+type embeddingService interfaces.EmbeddingHandler
+
+func (s embeddingService) CreateEmbedding(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
+	return s(ctx, req)
+}
+
+// observe forwards stream, calling onChunk for each chunk and onClose once
+// the source closes or ctx is done.
+func observe(ctx context.Context, stream <-chan types.StreamChunk, onChunk func(types.StreamChunk), onClose func()) <-chan types.StreamChunk {
+	out := make(chan types.StreamChunk)
+	go func() {
+		defer close(out)
+		defer onClose()
+		for chunk := range stream {
+			if onChunk != nil {
+				onChunk(chunk)
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				go drain(stream)
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// drain discards the remaining chunks of a stream.
+func drain(stream <-chan types.StreamChunk) {
+	for range stream {
+	}
+}
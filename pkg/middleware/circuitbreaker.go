@@ -0,0 +1,254 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Default circuit breaker settings.
+const (
+	// DefaultCircuitMaxFailures is the consecutive failures that open the circuit.
+	DefaultCircuitMaxFailures = 5
+
+	// DefaultCircuitTimeout is how long the circuit stays open.
+	DefaultCircuitTimeout = 30 * time.Second
+
+	// DefaultCircuitHalfOpenRequests is the number of trial requests in half-open state.
+	DefaultCircuitHalfOpenRequests = 1
+)
+
+// ErrCircuitOpen is wrapped by the error returned while the circuit is open.
+var ErrCircuitOpen = errors.New("middleware: circuit breaker is open")
+
+// CircuitBreaker stops sending requests to a failing service.
+//
+// Failures are errors that reflect the health of the service: server,
+// timeout, rate limit and unknown AIErrors, retryable errors, and errors that
+// are not AIErrors at all. Invalid requests do not count as failures. A
+// request canceled by its caller counts as neither a success nor a failure,
+// and a canceled half-open trial is released for another request. While
+// open, requests fail immediately with a retryable server_error wrapping
+// ErrCircuitOpen.
+//
+// OnStateChange is called with the breaker locked and must not call back
+// into it.
+type CircuitBreaker struct {
+	config interfaces.CircuitBreakerConfig
+
+	mu       sync.Mutex
+	state    interfaces.CircuitBreakerState
+	counts   interfaces.CircuitBreakerCounts
+	openedAt time.Time
+	trials   int // requests admitted in the current half-open period
+}
+
+// NewCircuitBreaker creates a circuit breaker. A nil config uses defaults.
+func NewCircuitBreaker(config *interfaces.CircuitBreakerConfig) *CircuitBreaker {
+	cfg := interfaces.CircuitBreakerConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.MaxFailures <= 0 {
+		cfg.MaxFailures = DefaultCircuitMaxFailures
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultCircuitTimeout
+	}
+	if cfg.HalfOpenMaxRequests <= 0 {
+		cfg.HalfOpenMaxRequests = DefaultCircuitHalfOpenRequests
+	}
+	return &CircuitBreaker{config: cfg}
+}
+
+// State returns the current state, moving from open to half-open once the
+// timeout has elapsed.
+func (cb *CircuitBreaker) State() interfaces.CircuitBreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.checkTimeout()
+	return cb.state
+}
+
+// Counts returns the request counts for the current state.
+func (cb *CircuitBreaker) Counts() interfaces.CircuitBreakerCounts {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.counts
+}
+
+// Wrap implements interfaces.Middleware.
+func (cb *CircuitBreaker) Wrap(next interfaces.Handler) interfaces.Handler {
+	return func(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+		if err := cb.Allow(); err != nil {
+			return nil, err
+		}
+		resp, err := next(ctx, req)
+		cb.Record(err)
+		return resp, err
+	}
+}
+
+// WrapStream implements interfaces.StreamingMiddleware. A stream counts as
+// a success when it ends without an error chunk, and as neither a success
+// nor a failure when ctx is done before it ends.
+func (cb *CircuitBreaker) WrapStream(next interfaces.StreamingHandler) interfaces.StreamingHandler {
+	return func(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+		if err := cb.Allow(); err != nil {
+			return nil, err
+		}
+		stream, err := next(ctx, req)
+		if err != nil {
+			cb.Record(err)
+			return nil, err
+		}
+		var streamErr error
+		return observe(ctx, stream, func(chunk types.StreamChunk) {
+			if ec, ok := chunk.(*types.ErrorChunk); ok {
+				streamErr = ec.Err
+			}
+		}, func() {
+			if streamErr == nil && ctx.Err() != nil {
+				// The consumer went away before the stream ended.
+				streamErr = context.Canceled
+			}
+			cb.Record(streamErr)
+		}), nil
+	}
+}
+
+// WrapEmbedding implements EmbeddingMiddleware.
+func (cb *CircuitBreaker) WrapEmbedding(next interfaces.EmbeddingHandler) interfaces.EmbeddingHandler {
+	return func(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
+		if err := cb.Allow(); err != nil {
+			return nil, err
+		}
+		resp, err := next(ctx, req)
+		cb.Record(err)
+		return resp, err
+	}
+}
+
+// Allow reports whether a request may proceed. Every allowed request must
+// be followed by a call to Record.
+func (cb *CircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.checkTimeout()
+
+	switch cb.state {
+	case interfaces.CircuitBreakerOpen:
+		return cb.openError()
+	case interfaces.CircuitBreakerHalfOpen:
+		if cb.trials >= cb.config.HalfOpenMaxRequests {
+			return cb.openError()
+		}
+		cb.trials++
+	}
+	cb.counts.Requests++
+	return nil
+}
+
+// Record records the outcome of an allowed request. A context.Canceled
+// error records no outcome.
+func (cb *CircuitBreaker) Record(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if errors.Is(err, context.Canceled) {
+		cb.release()
+		return
+	}
+
+	// A response that is not a failure, even an error such as an invalid
+	// request, shows the service is reachable.
+	if !IsFailure(err) {
+		cb.counts.TotalSuccesses++
+		cb.counts.ConsecutiveSuccesses++
+		cb.counts.ConsecutiveFailures = 0
+		if cb.state == interfaces.CircuitBreakerHalfOpen &&
+			int(cb.counts.ConsecutiveSuccesses) >= cb.config.HalfOpenMaxRequests {
+			cb.setState(interfaces.CircuitBreakerClosed)
+		}
+		return
+	}
+
+	cb.counts.TotalFailures++
+	cb.counts.ConsecutiveFailures++
+	cb.counts.ConsecutiveSuccesses = 0
+	switch cb.state {
+	case interfaces.CircuitBreakerHalfOpen:
+		cb.setState(interfaces.CircuitBreakerOpen)
+	case interfaces.CircuitBreakerClosed:
+		if cb.shouldTrip() {
+			cb.setState(interfaces.CircuitBreakerOpen)
+		}
+	}
+}
+
+// release gives back the half-open trial of a request that ended without an
+// outcome. The caller must hold cb.mu.
+func (cb *CircuitBreaker) release() {
+	if cb.state == interfaces.CircuitBreakerHalfOpen && cb.trials > 0 {
+		cb.trials--
+	}
+}
+
+// IsFailure reports whether err reflects the health of the service.
+func IsFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var aiErr types.AIError
+	if !errors.As(err, &aiErr) {
+		return true
+	}
+	switch aiErr.Type() {
+	case types.ErrorTypeServer, types.ErrorTypeTimeout, types.ErrorTypeRateLimit, types.ErrorTypeUnknown:
+		return true
+	}
+	return aiErr.Retryable()
+}
+
+func (cb *CircuitBreaker) shouldTrip() bool {
+	if cb.config.ShouldTrip != nil {
+		return cb.config.ShouldTrip(cb.counts)
+	}
+	return int(cb.counts.ConsecutiveFailures) >= cb.config.MaxFailures
+}
+
+// checkTimeout moves an open circuit to half-open after the timeout.
+func (cb *CircuitBreaker) checkTimeout() {
+	if cb.state == interfaces.CircuitBreakerOpen && time.Since(cb.openedAt) >= cb.config.Timeout {
+		cb.setState(interfaces.CircuitBreakerHalfOpen)
+	}
+}
+
+// setState changes state and resets the counts. The caller must hold cb.mu.
+func (cb *CircuitBreaker) setState(state interfaces.CircuitBreakerState) {
+	from := cb.state
+	cb.state = state
+	cb.counts = interfaces.CircuitBreakerCounts{}
+	cb.trials = 0
+	if state == interfaces.CircuitBreakerOpen {
+		cb.openedAt = time.Now()
+	}
+	if cb.config.OnStateChange != nil && from != state {
+		cb.config.OnStateChange(from, state)
+	}
+}
+
+func (cb *CircuitBreaker) openError() error {
+	return &types.ProviderError{
+		ErrorType:   types.ErrorTypeServer,
+		Message:     ErrCircuitOpen.Error(),
+		HTTPStatus:  http.StatusServiceUnavailable,
+		IsRetryable: true,
+		InnerError:  ErrCircuitOpen,
+	}
+}
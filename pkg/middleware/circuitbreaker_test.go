@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+var errUnavailable = &types.ProviderError{ErrorType: types.ErrorTypeServer, Message: "unavailable", HTTPStatus: http.StatusServiceUnavailable, IsRetryable: true}
+
+// endlessStream sends chunks until ctx is done.
+func endlessStream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	ch := make(chan types.StreamChunk)
+	go func() {
+		defer close(ch)
+		for {
+			chunk := &types.ChatStreamChunk{Choices: []*types.StreamChoice{{Delta: &types.MessageDelta{Content: "x"}}}}
+			select {
+			case ch <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// finishedStream sends one complete choice.
+func finishedStream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	ch := make(chan types.StreamChunk, 1)
+	ch <- &types.ChatStreamChunk{Choices: []*types.StreamChoice{{Delta: &types.MessageDelta{Content: "x"}, FinishReason: types.FinishReasonStop}}}
+	close(ch)
+	return ch, nil
+}
+
+// failingStream ends with an error chunk.
+func failingStream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	ch := make(chan types.StreamChunk, 1)
+	ch <- types.NewErrorChunk(errUnavailable)
+	close(ch)
+	return ch, nil
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name string
+		// run sends one request through cb while it is half-open.
+		run       func(cb *CircuitBreaker)
+		wantState interfaces.CircuitBreakerState
+	}{
+		{
+			name: "successful request closes the circuit",
+			run: func(cb *CircuitBreaker) {
+				cb.Wrap(func(context.Context, *types.ChatRequest) (*types.ChatResponse, error) {
+					return &types.ChatResponse{}, nil
+				})(context.Background(), &types.ChatRequest{})
+			},
+			wantState: interfaces.CircuitBreakerClosed,
+		},
+		{
+			name: "failed request reopens the circuit",
+			run: func(cb *CircuitBreaker) {
+				cb.Wrap(func(context.Context, *types.ChatRequest) (*types.ChatResponse, error) {
+					return nil, errUnavailable
+				})(context.Background(), &types.ChatRequest{})
+			},
+			wantState: interfaces.CircuitBreakerOpen,
+		},
+		{
+			name: "canceled request leaves the circuit half-open",
+			run: func(cb *CircuitBreaker) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				cb.Wrap(func(ctx context.Context, _ *types.ChatRequest) (*types.ChatResponse, error) {
+					return nil, ctx.Err()
+				})(ctx, &types.ChatRequest{})
+			},
+			wantState: interfaces.CircuitBreakerHalfOpen,
+		},
+		{
+			name: "finished stream closes the circuit",
+			run: func(cb *CircuitBreaker) {
+				stream, _ := cb.WrapStream(finishedStream)(context.Background(), &types.ChatRequest{})
+				drain(stream)
+			},
+			wantState: interfaces.CircuitBreakerClosed,
+		},
+		{
+			name: "stream error reopens the circuit",
+			run: func(cb *CircuitBreaker) {
+				stream, _ := cb.WrapStream(failingStream)(context.Background(), &types.ChatRequest{})
+				drain(stream)
+			},
+			wantState: interfaces.CircuitBreakerOpen,
+		},
+		{
+			name: "stream abandoned by the consumer leaves the circuit half-open",
+			run: func(cb *CircuitBreaker) {
+				ctx, cancel := context.WithCancel(context.Background())
+				stream, _ := cb.WrapStream(endlessStream)(ctx, &types.ChatRequest{})
+				<-stream
+				cancel()
+				drain(stream)
+			},
+			wantState: interfaces.CircuitBreakerHalfOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := NewCircuitBreaker(&interfaces.CircuitBreakerConfig{MaxFailures: 1, Timeout: 50 * time.Millisecond})
+			if err := cb.Allow(); err != nil {
+				t.Fatalf("Allow() on a new breaker error = %v", err)
+			}
+			cb.Record(errUnavailable)
+			time.Sleep(60 * time.Millisecond)
+			if got := cb.State(); got != interfaces.CircuitBreakerHalfOpen {
+				t.Fatalf("State() after the timeout = %v, want half-open", got)
+			}
+
+			tt.run(cb)
+			if got := cb.State(); got != tt.wantState {
+				t.Errorf("State() = %v, want %v", got, tt.wantState)
+			}
+			if tt.wantState == interfaces.CircuitBreakerHalfOpen {
+				if err := cb.Allow(); err != nil {
+					t.Errorf("Allow() after a canceled trial error = %v, want the trial released", err)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerTrips(t *testing.T) {
+	cb := NewCircuitBreaker(&interfaces.CircuitBreakerConfig{MaxFailures: 3, Timeout: time.Hour})
+	handler := cb.Wrap(func(context.Context, *types.ChatRequest) (*types.ChatResponse, error) {
+		return nil, errUnavailable
+	})
+
+	for i := 0; i < 3; i++ {
+		handler(context.Background(), &types.ChatRequest{})
+	}
+	if got := cb.State(); got != interfaces.CircuitBreakerOpen {
+		t.Fatalf("State() after 3 failures = %v, want open", got)
+	}
+	if _, err := handler(context.Background(), &types.ChatRequest{}); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("request while open error = %v, want ErrCircuitOpen", err)
+	}
+}
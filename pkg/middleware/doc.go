@@ -0,0 +1,19 @@
+// Package middleware implements interfaces.Middleware for the configurations
+// declared in pkg/interfaces: retries, rate limiting, response caching and
+// logging.
+//
+// Every middleware wraps non-streaming handlers (Wrap) and streaming handlers
+// (WrapStream). Retry, RateLimit and Logging also wrap embedding handlers
+// (WrapEmbedding). Apply and ApplyEmbedding wrap a whole service with a stack
+// of middleware; the first middleware is the outermost.
+//
+// Example usage:
+//
+//	service := middleware.Apply(provider.ChatService(),
+//	    middleware.NewLogging(&interfaces.LoggingConfig{LogErrors: true}, log.Default()),
+//	    middleware.NewCache(&interfaces.CacheConfig{TTL: 10 * time.Minute, MaxSize: 1000}),
+//	    middleware.NewRateLimit(&interfaces.RateLimitConfig{RequestsPerSecond: 5, Burst: 10}),
+//	    middleware.NewRetry(&interfaces.RetryConfig{MaxRetries: 3}),
+//	)
+//	resp, err := service.CreateCompletion(ctx, req)
+package middleware
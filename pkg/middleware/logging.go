@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Logging logs requests, responses, errors and token usage as single-line
+// key=value records. Message content is never logged.
+type Logging struct {
+	config interfaces.LoggingConfig
+	logger *log.Logger
+}
+
+// NewLogging creates a logging middleware writing to logger. A nil config
+// logs only errors; a nil logger uses log.Default().
+func NewLogging(config *interfaces.LoggingConfig, logger *log.Logger) *Logging {
+	cfg := interfaces.LoggingConfig{LogErrors: true}
+	if config != nil {
+		cfg = *config
+	}
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Logging{config: cfg, logger: logger}
+}
+
+// Wrap implements interfaces.Middleware.
+func (m *Logging) Wrap(next interfaces.Handler) interfaces.Handler {
+	return func(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+		if m.config.LogRequests {
+			m.log(req.Metadata, "chat request", "model", req.Model, "messages", len(req.Messages), "tools", len(req.Tools))
+		}
+		start := time.Now()
+		resp, err := next(ctx, req)
+		if err != nil {
+			m.logError(req.Metadata, "chat", req.Model, start, err)
+			return resp, err
+		}
+		m.logResponse(req.Metadata, "chat", req.Model, start, resp.Usage,
+			"choices", len(resp.Choices), "finish_reason", resp.GetFirstFinishReason())
+		return resp, nil
+	}
+}
+
+// WrapStream implements interfaces.StreamingMiddleware. The response is
+// logged when the stream closes.
+func (m *Logging) WrapStream(next interfaces.StreamingHandler) interfaces.StreamingHandler {
+	return func(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+		if m.config.LogRequests {
+			m.log(req.Metadata, "chat stream request", "model", req.Model, "messages", len(req.Messages), "tools", len(req.Tools))
+		}
+		start := time.Now()
+		stream, err := next(ctx, req)
+		if err != nil {
+			m.logError(req.Metadata, "chat stream", req.Model, start, err)
+			return stream, err
+		}
+
+		var (
+			chunks    int
+			usage     *types.Usage
+			finish    types.FinishReason
+			streamErr error
+		)
+		return observe(ctx, stream, func(chunk types.StreamChunk) {
+			chunks++
+			if ec, ok := chunk.(*types.ErrorChunk); ok {
+				streamErr = ec.Err
+				return
+			}
+			if uc, ok := chunk.(types.UsageChunk); ok && uc.GetUsage() != nil {
+				usage = uc.GetUsage()
+			}
+			for _, c := range chunk.GetChoices() {
+				if c != nil && c.FinishReason != "" && c.FinishReason != types.FinishReasonNull {
+					finish = c.FinishReason
+				}
+			}
+		}, func() {
+			switch {
+			case streamErr != nil:
+				m.logError(req.Metadata, "chat stream", req.Model, start, streamErr)
+			case ctx.Err() != nil:
+				m.logError(req.Metadata, "chat stream", req.Model, start, ctx.Err())
+			default:
+				m.logResponse(req.Metadata, "chat stream", req.Model, start, usage, "chunks", chunks, "finish_reason", finish)
+			}
+		}), nil
+	}
+}
+
+// WrapEmbedding implements EmbeddingMiddleware.
+func (m *Logging) WrapEmbedding(next interfaces.EmbeddingHandler) interfaces.EmbeddingHandler {
+	return func(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
+		if m.config.LogRequests {
+			m.log(req.Metadata, "embedding request", "model", req.Model, "inputs", len(req.GetInputAsStrings()))
+		}
+		start := time.Now()
+		resp, err := next(ctx, req)
+		if err != nil {
+			m.logError(req.Metadata, "embedding", req.Model, start, err)
+			return resp, err
+		}
+		m.logResponse(req.Metadata, "embedding", req.Model, start, resp.Usage, "embeddings", len(resp.Data))
+		return resp, nil
+	}
+}
+
+// logResponse logs a completed call according to LogResponses and LogTokenUsage.
+func (m *Logging) logResponse(meta *types.RequestMetadata, kind, model string, start time.Time, usage *types.Usage, kv ...interface{}) {
+	if !m.config.LogResponses && !(m.config.LogTokenUsage && usage != nil) {
+		return
+	}
+	fields := []interface{}{"model", model, "duration", time.Since(start).Round(time.Millisecond)}
+	if m.config.LogResponses {
+		fields = append(fields, kv...)
+	}
+	if m.config.LogTokenUsage && usage != nil {
+		fields = append(fields,
+			"prompt_tokens", usage.PromptTokens,
+			"completion_tokens", usage.CompletionTokens,
+			"total_tokens", usage.TotalTokens)
+	}
+	m.log(meta, kind+" response", fields...)
+}
+
+// logError logs a failed call if LogErrors is set.
+func (m *Logging) logError(meta *types.RequestMetadata, kind, model string, start time.Time, err error) {
+	if !m.config.LogErrors {
+		return
+	}
+	fields := []interface{}{"model", model, "duration", time.Since(start).Round(time.Millisecond)}
+	if aiErr, ok := err.(types.AIError); ok {
+		fields = append(fields, "error_type", aiErr.Type(), "status", aiErr.StatusCode())
+	}
+	fields = append(fields, "error", fmt.Sprintf("%q", err.Error()))
+	m.log(meta, kind+" error", fields...)
+}
+
+// log writes msg followed by key=value pairs.
+func (m *Logging) log(meta *types.RequestMetadata, msg string, kv ...interface{}) {
+	var b strings.Builder
+	if m.config.IncludeTimestamps {
+		b.WriteString(time.Now().UTC().Format(time.RFC3339Nano))
+		b.WriteByte(' ')
+	}
+	b.WriteString(msg)
+	if m.config.IncludeRequestID && meta != nil && meta.ID != "" {
+		fmt.Fprintf(&b, " request_id=%s", meta.ID)
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", kv[i], kv[i+1])
+	}
+	m.logger.Print(b.String())
+}
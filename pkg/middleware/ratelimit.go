@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// ErrRateLimited is wrapped by the error returned when a request would wait
+// longer than RateLimitConfig.WaitTimeout.
+var ErrRateLimited = errors.New("middleware: rate limit exceeded")
+
+// RateLimit limits request and token throughput with token buckets.
+//
+// The request rate is the lower of RequestsPerSecond and RequestsPerMinute.
+// TokensPerMinute is enforced after the fact: the usage reported by each
+// response is charged to a token bucket, and new requests wait while it is
+// in deficit. Requests that would wait longer than WaitTimeout fail with a
+// retryable rate_limit_error wrapping ErrRateLimited.
+type RateLimit struct {
+	config   interfaces.RateLimitConfig
+	requests *bucket
+	tokens   *bucket
+}
+
+// NewRateLimit creates a rate limiting middleware. A nil config applies no limits.
+func NewRateLimit(config *interfaces.RateLimitConfig) *RateLimit {
+	cfg := interfaces.RateLimitConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	m := &RateLimit{config: cfg}
+
+	rate := cfg.RequestsPerSecond
+	if perMinute := float64(cfg.RequestsPerMinute) / 60; perMinute > 0 && (rate <= 0 || perMinute < rate) {
+		rate = perMinute
+	}
+	if rate > 0 {
+		burst := float64(cfg.Burst)
+		if burst <= 0 {
+			burst = math.Max(1, math.Ceil(rate))
+		}
+		m.requests = newBucket(rate, burst)
+	}
+	if cfg.TokensPerMinute > 0 {
+		m.tokens = newBucket(float64(cfg.TokensPerMinute)/60, float64(cfg.TokensPerMinute))
+	}
+	return m
+}
+
+// Wrap implements interfaces.Middleware.
+func (m *RateLimit) Wrap(next interfaces.Handler) interfaces.Handler {
+	return func(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+		if err := m.acquire(ctx); err != nil {
+			return nil, err
+		}
+		resp, err := next(ctx, req)
+		if resp != nil {
+			m.charge(resp.Usage)
+		}
+		return resp, err
+	}
+}
+
+// WrapStream implements interfaces.StreamingMiddleware. Token usage is
+// charged when the stream closes, from the last usage it reported.
+func (m *RateLimit) WrapStream(next interfaces.StreamingHandler) interfaces.StreamingHandler {
+	return func(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+		if err := m.acquire(ctx); err != nil {
+			return nil, err
+		}
+		stream, err := next(ctx, req)
+		if err != nil || m.tokens == nil {
+			return stream, err
+		}
+
+		var usage *types.Usage
+		return observe(ctx, stream, func(chunk types.StreamChunk) {
+			if uc, ok := chunk.(types.UsageChunk); ok && uc.GetUsage() != nil {
+				usage = uc.GetUsage()
+			}
+		}, func() {
+			m.charge(usage)
+		}), nil
+	}
+}
+
+// WrapEmbedding implements EmbeddingMiddleware.
+func (m *RateLimit) WrapEmbedding(next interfaces.EmbeddingHandler) interfaces.EmbeddingHandler {
+	return func(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
+		if err := m.acquire(ctx); err != nil {
+			return nil, err
+		}
+		resp, err := next(ctx, req)
+		if resp != nil {
+			m.charge(resp.Usage)
+		}
+		return resp, err
+	}
+}
+
+// acquire waits for capacity in the request and token buckets.
+func (m *RateLimit) acquire(ctx context.Context) error {
+	var wait time.Duration
+	if m.requests != nil {
+		wait = m.requests.reserve(1)
+	}
+	if m.tokens != nil {
+		wait = max(wait, m.tokens.reserve(0))
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	if wait > m.config.WaitTimeout {
+		m.release()
+		return &types.ProviderError{
+			ErrorType:   types.ErrorTypeRateLimit,
+			Message:     ErrRateLimited.Error(),
+			HTTPStatus:  http.StatusTooManyRequests,
+			IsRetryable: true,
+			InnerError:  ErrRateLimited,
+		}
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		m.release()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// release returns a request reservation that will not be used.
+func (m *RateLimit) release() {
+	if m.requests != nil {
+		m.requests.reserve(-1)
+	}
+}
+
+// charge deducts reported token usage from the token bucket.
+func (m *RateLimit) charge(usage *types.Usage) {
+	if m.tokens == nil || usage == nil {
+		return
+	}
+	tokens := usage.TotalTokens
+	if tokens == 0 {
+		tokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	m.tokens.reserve(float64(tokens))
+}
+
+// bucket is a token bucket whose balance may go negative; a negative
+// balance is a debt that later reservations wait out.
+type bucket struct {
+	mu       sync.Mutex
+	rate     float64 // tokens per second
+	capacity float64
+	balance  float64
+	last     time.Time
+}
+
+func newBucket(rate, capacity float64) *bucket {
+	return &bucket{rate: rate, capacity: capacity, balance: capacity, last: time.Now()}
+}
+
+// reserve takes n tokens and returns how long until the balance is no
+// longer negative. A negative n returns tokens.
+func (b *bucket) reserve(n float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.balance = math.Min(b.capacity, b.balance+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.balance -= n
+	if b.balance >= 0 {
+		return 0
+	}
+	return time.Duration(-b.balance / b.rate * float64(time.Second))
+}
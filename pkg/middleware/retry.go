@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// defaultRetryableErrors are retried when RetryConfig.RetryableErrors is nil.
+var defaultRetryableErrors = []types.ErrorType{
+	types.ErrorTypeRateLimit,
+	types.ErrorTypeTimeout,
+	types.ErrorTypeServer,
+}
+
+// Retry retries failed requests with exponential backoff.
+//
+// Streaming requests are retried only while the stream is being established;
+// once CreateCompletionStream has returned a channel, errors are delivered on
+// it and are not retried.
+type Retry struct {
+	config interfaces.RetryConfig
+}
+
+// NewRetry creates a retry middleware. A nil config disables retries.
+func NewRetry(config *interfaces.RetryConfig) *Retry {
+	cfg := interfaces.RetryConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = types.DefaultInitialBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = types.DefaultMaxBackoff
+	}
+	if cfg.BackoffMultiplier <= 0 {
+		cfg.BackoffMultiplier = types.DefaultBackoffMultiplier
+	}
+	if cfg.RetryableErrors == nil {
+		cfg.RetryableErrors = defaultRetryableErrors
+	}
+	return &Retry{config: cfg}
+}
+
+// Wrap implements interfaces.Middleware.
+func (m *Retry) Wrap(next interfaces.Handler) interfaces.Handler {
+	return func(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+		var resp *types.ChatResponse
+		err := m.do(ctx, func() error {
+			var err error
+			resp, err = next(ctx, req)
+			return err
+		})
+		return resp, err
+	}
+}
+
+// WrapStream implements interfaces.StreamingMiddleware.
+func (m *Retry) WrapStream(next interfaces.StreamingHandler) interfaces.StreamingHandler {
+	return func(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+		var stream <-chan types.StreamChunk
+		err := m.do(ctx, func() error {
+			var err error
+			stream, err = next(ctx, req)
+			return err
+		})
+		return stream, err
+	}
+}
+
+// WrapEmbedding implements EmbeddingMiddleware.
+func (m *Retry) WrapEmbedding(next interfaces.EmbeddingHandler) interfaces.EmbeddingHandler {
+	return func(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
+		var resp *types.EmbeddingResponse
+		err := m.do(ctx, func() error {
+			var err error
+			resp, err = next(ctx, req)
+			return err
+		})
+		return resp, err
+	}
+}
+
+// do calls fn until it succeeds, fails with a non-retryable error, the
+// retries are exhausted or ctx is done.
+func (m *Retry) do(ctx context.Context, fn func() error) error {
+	backoff := m.config.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= m.config.MaxRetries || !m.shouldRetry(err) {
+			return err
+		}
+		if m.config.OnRetry != nil {
+			m.config.OnRetry(attempt+1, err)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff = time.Duration(float64(backoff) * m.config.BackoffMultiplier)
+		if backoff > m.config.MaxBackoff {
+			backoff = m.config.MaxBackoff
+		}
+	}
+}
+
+// shouldRetry reports whether err is retryable under the configuration.
+func (m *Retry) shouldRetry(err error) bool {
+	if m.config.ShouldRetry != nil {
+		return m.config.ShouldRetry(err)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var aiErr types.AIError
+	if !errors.As(err, &aiErr) {
+		return false
+	}
+	for _, t := range m.config.RetryableErrors {
+		if aiErr.Type() == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+// Package partialjson parses JSON that is still streaming in.
+//
+// StreamAccumulator concatenates tool call arguments, but the concatenation is
+// not valid JSON until the stream ends. A Parser accepts the fragments as
+// they arrive and, at any point, returns the best-effort value of what has
+// been received: open objects and arrays are closed, a string being written
+// is cut at its last complete character, and keys still waiting for a value
+// are dropped. The value only ever grows as more input arrives, except that
+// the final number or string in it may still be extended.
+//
+// Input that can never become valid JSON is reported as a *SyntaxError as
+// soon as the offending byte arrives, and Validate checks the partial value
+// against a types.JSONSchema so a stream that goes off-schema can be
+// cancelled early.
+//
+// Example usage:
+//
+//	p := partialjson.NewParser()
+//	p.WriteString(`{"city": "San Fra`)
+//	v, _ := p.Value() // map[city:San Fra]
+//	p.WriteString(`ncisco", "days": [1, 2`)
+//	v, _ = p.Value() // map[city:San Francisco days:[1 2]]
+//
+// StreamParser does the same for every tool call in a chat stream:
+//
+//	sp := partialjson.NewStreamParser(nil)
+//	for chunk := range stream {
+//	    for _, u := range sp.Add(chunk) {
+//	        fmt.Printf("%s(%v)\n", u.FunctionName, u.Value)
+//	    }
+//	}
+package partialjson
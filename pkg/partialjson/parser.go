@@ -0,0 +1,576 @@
+package partialjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+	"github.com/zacw/go-ai-types/pkg/validators"
+)
+
+// ErrNoValue is returned when no part of the input can be represented as a value yet.
+var ErrNoValue = errors.New("partialjson: no value yet")
+
+// SyntaxError describes input that can never become valid JSON, no matter
+// what follows it.
+type SyntaxError struct {
+	// Offset is the byte offset of the offending character.
+	Offset int
+
+	// Message describes the error.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("partialjson: %s at offset %d", e.Message, e.Offset)
+}
+
+// scanState is the state of the incremental scanner.
+type scanState int
+
+const (
+	stBegin       scanState = iota // before the top-level value
+	stValue                        // expecting a value after ':' or ','
+	stArrayFirst                   // after '[': a value or ']'
+	stObjectFirst                  // after '{': a key or '}'
+	stKey                          // after ',' in an object: a key
+	stColon                        // after a key: ':'
+	stAfterValue                   // after a value inside a container: ',' or a closer
+	stString                       // inside a string
+	stNumber                       // inside a number
+	stLiteral                      // inside true, false or null
+	stDone                         // the top-level value is complete
+)
+
+// numState tracks progress through the JSON number grammar.
+type numState int
+
+const (
+	numMinus    numState = iota // "-"
+	numZero                     // "0" or "-0"
+	numInt                      // integer digits
+	numDot                      // "."
+	numFrac                     // fraction digits
+	numExp                      // "e" or "E"
+	numExpSign                  // exponent sign
+	numExpDigit                 // exponent digits
+)
+
+// Parser incrementally parses a JSON document that arrives in fragments,
+// such as the Arguments of successive ToolCallDeltas.
+//
+// After each Write, Value returns the best-effort value of the input so far:
+// open objects and arrays are closed, an unterminated string value is
+// included up to its last complete character, and a number is included once
+// it has at least one digit. Object keys without a value, trailing commas and
+// unfinished true/false/null literals are dropped.
+//
+// Scanning is incremental: each Write only examines the new bytes. Value and
+// Repaired are O(n) in the input received so far.
+//
+// A Parser is not safe for concurrent use.
+type Parser struct {
+	buf   strings.Builder
+	err   error
+	state scanState
+	stack []byte // open containers: '{' or '['
+
+	// String state.
+	strKey  bool // the string is an object key
+	strEsc  int  // 0: none, 1: after '\', 2-5: hex digits of \uXXXX read + 2
+	strSafe int  // buffer offset up to which the string content is complete
+
+	// Number and literal state.
+	num numState
+	lit string // remaining bytes of the literal being read
+
+	// Checkpoint: buf[:cpOff] + cpClose is always valid JSON once hasCP is set.
+	hasCP   bool
+	cpOff   int
+	cpClose string
+}
+
+// NewParser creates an empty Parser.
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// Write appends a fragment of the document. It implements io.Writer.
+//
+// Returns a *SyntaxError if the input can no longer become valid JSON. After
+// an error, further writes are ignored and return the same error.
+func (p *Parser) Write(data []byte) (int, error) {
+	if _, err := p.WriteString(string(data)); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// WriteString appends a fragment of the document. See Write.
+func (p *Parser) WriteString(s string) (int, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+	start := p.buf.Len()
+	p.buf.WriteString(s)
+	for i := 0; i < len(s); i++ {
+		if err := p.scan(s[i], start+i); err != nil {
+			p.err = err
+			return 0, err
+		}
+	}
+	return len(s), nil
+}
+
+// Err returns the syntax error encountered, if any.
+func (p *Parser) Err() error {
+	return p.err
+}
+
+// Done reports whether the complete top-level value has been received.
+func (p *Parser) Done() bool {
+	return p.err == nil && p.state == stDone
+}
+
+// Raw returns the input received so far, unmodified.
+func (p *Parser) Raw() string {
+	return p.buf.String()
+}
+
+// Repaired returns the longest usable prefix of the input completed into
+// valid JSON, or "" if there is nothing usable yet.
+//
+// Once Done reports true, Repaired returns the input unmodified.
+func (p *Parser) Repaired() string {
+	buf := p.buf.String()
+	switch {
+	case p.state == stDone:
+		return buf
+	case p.state == stString && !p.strKey:
+		return trimPartialRune(buf[:p.strSafe]) + `"` + closers(p.stack)
+	case p.state == stNumber && p.numComplete():
+		return buf + closers(p.stack)
+	case p.hasCP:
+		return buf[:p.cpOff] + p.cpClose
+	default:
+		return ""
+	}
+}
+
+// Value returns the best-effort value of the input so far, decoded as by
+// json.Unmarshal into an interface{}.
+//
+// Returns ErrNoValue if nothing usable has been received yet.
+func (p *Parser) Value() (interface{}, error) {
+	var v interface{}
+	if err := p.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Decode decodes the best-effort value of the input so far into v, as by
+// json.Unmarshal.
+//
+// Returns ErrNoValue if nothing usable has been received yet.
+func (p *Parser) Decode(v interface{}) error {
+	repaired := p.Repaired()
+	if repaired == "" {
+		return ErrNoValue
+	}
+	return json.Unmarshal([]byte(repaired), v)
+}
+
+// Validate checks the best-effort value against schema.
+//
+// Until Done reports true, the value is validated with
+// validators.ValidatePartialSchemaCompliance, so missing required properties
+// are tolerated and the string or number still being received only needs to
+// be a prefix of an allowed value, but wrong types, disallowed properties and
+// enum mismatches of complete values are reported early. Once done, the value
+// is validated in full.
+//
+// Returns nil if nothing usable has been received yet.
+func (p *Parser) Validate(schema *types.JSONSchema) error {
+	repaired := p.Repaired()
+	if repaired == "" {
+		return nil
+	}
+	if p.Done() {
+		return validators.ValidateJSONSchemaCompliance(schema, []byte(repaired))
+	}
+
+	v, err := decodePartial(repaired, p.state == stNumber, p.state == stString && !p.strKey)
+	if err != nil {
+		return err
+	}
+	return validators.ValidatePartialSchemaCompliance(schema, v)
+}
+
+// decodePartial decodes repaired input with numbers as json.Number. If
+// trailingNumber or trailingString is set, the input ends in a number or
+// string value that may still be extended, which is returned as a
+// validators.PartialNumber or validators.PartialString. All other values
+// are complete.
+func decodePartial(repaired string, trailingNumber, trailingString bool) (interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(repaired))
+	dec.UseNumber()
+	var decode func() (interface{}, error)
+	decode = func() (interface{}, error) {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tok {
+		case json.Delim('{'):
+			obj := make(map[string]interface{})
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				if obj[key.(string)], err = decode(); err != nil {
+					return nil, err
+				}
+			}
+			_, err = dec.Token()
+			return obj, err
+		case json.Delim('['):
+			arr := []interface{}{}
+			for dec.More() {
+				item, err := decode()
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, item)
+			}
+			_, err = dec.Token()
+			return arr, err
+		}
+		// Only closers follow the trailing value.
+		if strings.TrimLeft(repaired[dec.InputOffset():], "]}") == "" {
+			if n, ok := tok.(json.Number); ok && trailingNumber {
+				return validators.PartialNumber(n), nil
+			}
+			if s, ok := tok.(string); ok && trailingString {
+				return validators.PartialString(s), nil
+			}
+		}
+		return tok, nil
+	}
+	return decode()
+}
+
+// Reset discards all input, allowing the Parser to be reused.
+func (p *Parser) Reset() {
+	*p = Parser{}
+}
+
+// Parse returns the best-effort value of a possibly truncated JSON document.
+func Parse(s string) (interface{}, error) {
+	p := NewParser()
+	if _, err := p.WriteString(s); err != nil {
+		return nil, err
+	}
+	return p.Value()
+}
+
+// Repair completes a possibly truncated JSON document into valid JSON.
+// See Parser.Repaired.
+func Repair(s string) (string, error) {
+	p := NewParser()
+	if _, err := p.WriteString(s); err != nil {
+		return "", err
+	}
+	return p.Repaired(), nil
+}
+
+// scan advances the state machine by one byte at offset pos.
+func (p *Parser) scan(c byte, pos int) error {
+	switch p.state {
+	case stString:
+		return p.scanString(c, pos)
+	case stNumber:
+		if p.scanNumber(c) {
+			return nil
+		}
+		if !p.numComplete() {
+			return p.syntaxError(pos, "invalid character %q in number", c)
+		}
+		p.valueDone(pos)
+		// The terminating byte belongs to the enclosing context.
+		return p.scan(c, pos)
+	case stLiteral:
+		if c != p.lit[0] {
+			return p.syntaxError(pos, "invalid character %q in literal", c)
+		}
+		p.lit = p.lit[1:]
+		if p.lit == "" {
+			p.valueDone(pos + 1)
+		}
+		return nil
+	}
+
+	if isSpace(c) {
+		return nil
+	}
+
+	switch p.state {
+	case stBegin, stValue:
+		return p.beginValue(c, pos)
+
+	case stArrayFirst:
+		if c == ']' {
+			return p.closeContainer(c, pos)
+		}
+		return p.beginValue(c, pos)
+
+	case stObjectFirst:
+		if c == '}' {
+			return p.closeContainer(c, pos)
+		}
+		fallthrough
+	case stKey:
+		if c != '"' {
+			return p.syntaxError(pos, "expected object key, found %q", c)
+		}
+		p.beginString(pos, true)
+
+	case stColon:
+		if c != ':' {
+			return p.syntaxError(pos, "expected ':', found %q", c)
+		}
+		p.state = stValue
+
+	case stAfterValue:
+		switch c {
+		case ',':
+			if p.top() == '{' {
+				p.state = stKey
+			} else {
+				p.state = stValue
+			}
+		case '}', ']':
+			return p.closeContainer(c, pos)
+		default:
+			return p.syntaxError(pos, "expected ',' or closing bracket, found %q", c)
+		}
+
+	case stDone:
+		return p.syntaxError(pos, "unexpected %q after top-level value", c)
+	}
+	return nil
+}
+
+// beginValue starts a value with its first byte c.
+func (p *Parser) beginValue(c byte, pos int) error {
+	switch {
+	case c == '{' || c == '[':
+		p.stack = append(p.stack, c)
+		if c == '{' {
+			p.state = stObjectFirst
+		} else {
+			p.state = stArrayFirst
+		}
+		p.checkpoint(pos + 1)
+	case c == '"':
+		p.beginString(pos, false)
+	case c == '-':
+		p.state, p.num = stNumber, numMinus
+	case c == '0':
+		p.state, p.num = stNumber, numZero
+	case c >= '1' && c <= '9':
+		p.state, p.num = stNumber, numInt
+	case c == 't':
+		p.state, p.lit = stLiteral, "rue"
+	case c == 'f':
+		p.state, p.lit = stLiteral, "alse"
+	case c == 'n':
+		p.state, p.lit = stLiteral, "ull"
+	default:
+		return p.syntaxError(pos, "invalid character %q looking for beginning of value", c)
+	}
+	return nil
+}
+
+// beginString starts a string whose opening quote is at pos.
+func (p *Parser) beginString(pos int, key bool) {
+	p.state = stString
+	p.strKey = key
+	p.strEsc = 0
+	p.strSafe = pos + 1
+}
+
+// scanString advances through a string.
+func (p *Parser) scanString(c byte, pos int) error {
+	switch {
+	case p.strEsc == 1:
+		switch c {
+		case 'u':
+			p.strEsc = 2
+			return nil
+		case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			p.strEsc = 0
+		default:
+			return p.syntaxError(pos, "invalid escape character %q", c)
+		}
+	case p.strEsc >= 2:
+		if !isHex(c) {
+			return p.syntaxError(pos, "invalid character %q in \\u escape", c)
+		}
+		if p.strEsc++; p.strEsc < 6 {
+			return nil
+		}
+		p.strEsc = 0
+	case c == '\\':
+		p.strEsc = 1
+		return nil
+	case c == '"':
+		if p.strKey {
+			p.state = stColon
+		} else {
+			p.valueDone(pos + 1)
+		}
+		return nil
+	case c < 0x20:
+		return p.syntaxError(pos, "invalid control character %q in string", c)
+	}
+	p.strSafe = pos + 1
+	return nil
+}
+
+// scanNumber advances through a number. It reports false if c does not
+// continue the number.
+func (p *Parser) scanNumber(c byte) bool {
+	digit := c >= '0' && c <= '9'
+	switch p.num {
+	case numMinus:
+		switch {
+		case c == '0':
+			p.num = numZero
+		case digit:
+			p.num = numInt
+		default:
+			return false
+		}
+	case numZero, numInt:
+		switch {
+		case digit && p.num == numInt:
+			// Still in the integer part.
+		case c == '.':
+			p.num = numDot
+		case c == 'e' || c == 'E':
+			p.num = numExp
+		default:
+			return false
+		}
+	case numDot, numFrac:
+		switch {
+		case digit:
+			p.num = numFrac
+		case (c == 'e' || c == 'E') && p.num == numFrac:
+			p.num = numExp
+		default:
+			return false
+		}
+	case numExp:
+		switch {
+		case c == '+' || c == '-':
+			p.num = numExpSign
+		case digit:
+			p.num = numExpDigit
+		default:
+			return false
+		}
+	case numExpSign, numExpDigit:
+		if !digit {
+			return false
+		}
+		p.num = numExpDigit
+	}
+	return true
+}
+
+// numComplete reports whether the number read so far is a valid JSON number.
+func (p *Parser) numComplete() bool {
+	return p.num == numZero || p.num == numInt || p.num == numFrac || p.num == numExpDigit
+}
+
+// closeContainer closes the innermost container with c.
+func (p *Parser) closeContainer(c byte, pos int) error {
+	open := p.top()
+	if (c == '}' && open != '{') || (c == ']' && open != '[') {
+		return p.syntaxError(pos, "mismatched %q", c)
+	}
+	p.stack = p.stack[:len(p.stack)-1]
+	p.valueDone(pos + 1)
+	return nil
+}
+
+// valueDone records the completion of a value ending at offset end.
+func (p *Parser) valueDone(end int) {
+	if len(p.stack) == 0 {
+		p.state = stDone
+	} else {
+		p.state = stAfterValue
+	}
+	p.checkpoint(end)
+}
+
+// checkpoint records that buf[:off] can be completed with the current closers.
+func (p *Parser) checkpoint(off int) {
+	p.hasCP = true
+	p.cpOff = off
+	p.cpClose = closers(p.stack)
+}
+
+// top returns the innermost open container, or 0.
+func (p *Parser) top() byte {
+	if len(p.stack) == 0 {
+		return 0
+	}
+	return p.stack[len(p.stack)-1]
+}
+
+// syntaxError creates a SyntaxError at pos.
+func (p *Parser) syntaxError(pos int, format string, args ...interface{}) error {
+	return &SyntaxError{Offset: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// closers returns the brackets that close the open containers, innermost first.
+func closers(stack []byte) string {
+	var b strings.Builder
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] == '{' {
+			b.WriteByte('}')
+		} else {
+			b.WriteByte(']')
+		}
+	}
+	return b.String()
+}
+
+// trimPartialRune removes an incomplete UTF-8 sequence from the end of s.
+func trimPartialRune(s string) string {
+	for i := len(s) - 1; i >= 0 && i >= len(s)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(s[i]) {
+			if !utf8.FullRuneInString(s[i:]) {
+				return s[:i]
+			}
+			break
+		}
+	}
+	return s
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
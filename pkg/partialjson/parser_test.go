@@ -0,0 +1,127 @@
+package partialjson
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", input: "", want: ""},
+		{name: "open object", input: "{", want: "{}"},
+		{name: "complete document", input: `{"a": [1, 2]}`, want: `{"a": [1, 2]}`},
+		{name: "partial string value", input: `{"city": "San Fra`, want: `{"city": "San Fra"}`},
+		{name: "partial key is dropped", input: `{"a": 1, "ci`, want: `{"a": 1}`},
+		{name: "key without value is dropped", input: `{"a": 1, "city":`, want: `{"a": 1}`},
+		{name: "trailing comma is dropped", input: `[1, 2,`, want: `[1, 2]`},
+		{name: "number in progress is kept", input: `{"n": 12`, want: `{"n": 12}`},
+		{name: "bare minus is dropped", input: `[1, -`, want: `[1]`},
+		{name: "dangling exponent is dropped", input: `[1, 2e`, want: `[1]`},
+		{name: "unfinished literal is dropped", input: `{"ok": tr`, want: `{}`},
+		{name: "finished literal is kept", input: `{"ok": true`, want: `{"ok": true}`},
+		{name: "nested containers are closed", input: `{"a": [{"b": [1`, want: `{"a": [{"b": [1]}]}`},
+		{name: "escape in progress is cut", input: `["a\`, want: `["a"]`},
+		{name: "unicode escape in progress is cut", input: `["a\u00`, want: `["a"]`},
+		{name: "partial multibyte rune is cut", input: "[\"caf\xc3", want: `["caf"]`},
+		{name: "mismatched closer", input: `{"a": 1]`, wantErr: true},
+		{name: "invalid character", input: `{"a": x`, wantErr: true},
+		{name: "data after value", input: `{} {`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Repair(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Repair(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				var syntaxErr *SyntaxError
+				if !errors.As(err, &syntaxErr) {
+					t.Errorf("Repair(%q) error = %T, want *SyntaxError", tt.input, err)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Repair(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParserIncremental(t *testing.T) {
+	doc := `{"city": "San Francisco", "days": [1, 2, 3], "metric": false}`
+	p := NewParser()
+	for i := 0; i < len(doc); i++ {
+		if _, err := p.WriteString(doc[i : i+1]); err != nil {
+			t.Fatalf("WriteString() at %d error = %v", i, err)
+		}
+		if repaired := p.Repaired(); repaired != "" {
+			if _, err := Parse(repaired); err != nil {
+				t.Fatalf("Repaired() after %q = %q, not valid JSON: %v", doc[:i+1], repaired, err)
+			}
+		}
+	}
+	if !p.Done() {
+		t.Error("Done() = false after the complete document")
+	}
+	if p.Repaired() != doc {
+		t.Errorf("Repaired() = %q, want the input", p.Repaired())
+	}
+}
+
+func TestParserValidate(t *testing.T) {
+	schema := &types.JSONSchema{
+		Type: "object",
+		Properties: map[string]*types.JSONSchema{
+			"n":    {Type: "integer", Enum: []interface{}{10, 20}},
+			"unit": {Type: "string", Enum: []interface{}{"celsius", "fahrenheit"}},
+			"tags": {Type: "array", Items: &types.JSONSchema{Type: "number", Enum: []interface{}{1.5, 2}}},
+		},
+		Required:             []string{"n", "unit"},
+		AdditionalProperties: false,
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "nothing yet", input: ``},
+		{name: "missing required while partial", input: `{"unit": "cel`},
+		{name: "number still streaming may reach enum", input: `{"n": 1`},
+		{name: "decimal still streaming may become an integer", input: `{"n": 1.5`},
+		{name: "number in array still streaming", input: `{"tags": [1`},
+		{name: "terminated number not in enum", input: `{"n": 1,`, wantErr: true},
+		{name: "number closed by whitespace not in enum", input: `{"n": 1 `, wantErr: true},
+		{name: "earlier array item not in enum", input: `{"tags": [3, 1`, wantErr: true},
+		{name: "string prefix not in enum", input: `{"unit": "kel`, wantErr: true},
+		{name: "closed string prefix of enum", input: `{"unit":"c",`, wantErr: true},
+		{name: "closed string prefix of enum before a value", input: `{"unit":"c","n":1`, wantErr: true},
+		{name: "closed string prefix of enum at the end", input: `{"unit":"c"`, wantErr: true},
+		{name: "string key still streaming", input: `{"n": 10, "un`},
+		{name: "wrong type", input: `{"unit": 1`, wantErr: true},
+		{name: "additional property", input: `{"extra": "x`, wantErr: true},
+		{name: "complete and valid", input: `{"n": 10, "unit": "celsius"}`},
+		{name: "complete without required", input: `{"n": 10}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			if _, err := p.WriteString(tt.input); err != nil {
+				t.Fatalf("WriteString() error = %v", err)
+			}
+			err := p.Validate(schema)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() after %q error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package partialjson
+
+import (
+	"sort"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// ContentIndex is the Update.ToolCallIndex used for message content.
+const ContentIndex = -1
+
+// Update reports the new best-effort value of one tool call's arguments, or
+// of a choice's content, after a stream chunk.
+type Update struct {
+	// ChoiceIndex is the index of the choice.
+	ChoiceIndex int
+
+	// ToolCallIndex is the index of the tool call, or ContentIndex for content.
+	ToolCallIndex int
+
+	// ToolCallID is the tool call ID, once received.
+	ToolCallID string
+
+	// FunctionName is the function name, once received.
+	FunctionName string
+
+	// Value is the best-effort value, or nil if nothing usable was received yet.
+	Value interface{}
+
+	// Done reports whether the complete JSON value has been received.
+	Done bool
+
+	// Err is set if the input is not valid JSON. Later fragments for the
+	// same tool call or content are ignored.
+	Err error
+}
+
+// StreamOptions configures a StreamParser.
+type StreamOptions struct {
+	// ParseContent also parses message content deltas as JSON, for streamed
+	// structured output (e.g., json_schema response formats).
+	ParseContent bool
+}
+
+// streamKey identifies a parser within a stream.
+type streamKey struct {
+	choice   int
+	toolCall int
+}
+
+// streamEntry is a parser along with the tool call identity.
+type streamEntry struct {
+	parser *Parser
+	id     string
+	name   string
+}
+
+// StreamParser feeds stream chunks into one Parser per tool call (and
+// optionally per choice's content), reporting partial values as they grow.
+//
+// Example usage:
+//
+//	sp := partialjson.NewStreamParser(nil)
+//	for chunk := range stream {
+//	    for _, u := range sp.Add(chunk) {
+//	        if err := sp.ToolCall(u.ChoiceIndex, u.ToolCallIndex).Validate(schema); err != nil {
+//	            cancel() // the model went off-schema; stop early
+//	        }
+//	        render(u.FunctionName, u.Value)
+//	    }
+//	}
+//
+// A StreamParser is not safe for concurrent use.
+type StreamParser struct {
+	options StreamOptions
+	entries map[streamKey]*streamEntry
+}
+
+// NewStreamParser creates a StreamParser. If options is nil, defaults are used.
+func NewStreamParser(options *StreamOptions) *StreamParser {
+	sp := &StreamParser{entries: make(map[streamKey]*streamEntry)}
+	if options != nil {
+		sp.options = *options
+	}
+	return sp
+}
+
+// Add processes a chunk and returns an Update for every tool call (and
+// content, if enabled) that received new JSON, ordered by choice index and
+// then tool call index, with content first.
+func (sp *StreamParser) Add(chunk types.StreamChunk) []*Update {
+	changed := make(map[streamKey]bool)
+
+	for _, choice := range chunk.GetChoices() {
+		if choice == nil || choice.Delta == nil {
+			continue
+		}
+		delta := choice.Delta
+
+		if sp.options.ParseContent && delta.Content != "" {
+			key := streamKey{choice.Index, ContentIndex}
+			sp.write(key, delta.Content)
+			changed[key] = true
+		}
+
+		for _, tc := range delta.ToolCalls {
+			if tc == nil {
+				continue
+			}
+			key := streamKey{choice.Index, tc.Index}
+			entry := sp.entry(key)
+			if tc.ID != "" {
+				entry.id = tc.ID
+			}
+			if tc.Function != nil {
+				if tc.Function.Name != "" {
+					entry.name = tc.Function.Name
+				}
+				if tc.Function.Arguments != "" {
+					sp.write(key, tc.Function.Arguments)
+					changed[key] = true
+				}
+			}
+		}
+	}
+
+	keys := make([]streamKey, 0, len(changed))
+	for key := range changed {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].choice != keys[j].choice {
+			return keys[i].choice < keys[j].choice
+		}
+		return keys[i].toolCall < keys[j].toolCall
+	})
+
+	updates := make([]*Update, 0, len(keys))
+	for _, key := range keys {
+		updates = append(updates, sp.update(key))
+	}
+	return updates
+}
+
+// ToolCall returns the parser for a tool call's arguments, or nil if no
+// fragment of that tool call has been seen.
+func (sp *StreamParser) ToolCall(choiceIndex, toolCallIndex int) *Parser {
+	if entry, ok := sp.entries[streamKey{choiceIndex, toolCallIndex}]; ok {
+		return entry.parser
+	}
+	return nil
+}
+
+// Content returns the parser for a choice's content, or nil if content
+// parsing is disabled or no content has been seen.
+func (sp *StreamParser) Content(choiceIndex int) *Parser {
+	return sp.ToolCall(choiceIndex, ContentIndex)
+}
+
+// entry returns the entry for key, creating it if needed.
+func (sp *StreamParser) entry(key streamKey) *streamEntry {
+	entry, ok := sp.entries[key]
+	if !ok {
+		entry = &streamEntry{parser: NewParser()}
+		sp.entries[key] = entry
+	}
+	return entry
+}
+
+// write feeds a fragment to the parser for key. Syntax errors are kept on
+// the parser and reported through Update.Err.
+func (sp *StreamParser) write(key streamKey, fragment string) {
+	_, _ = sp.entry(key).parser.WriteString(fragment)
+}
+
+// update builds the Update for key.
+func (sp *StreamParser) update(key streamKey) *Update {
+	entry := sp.entries[key]
+	u := &Update{
+		ChoiceIndex:   key.choice,
+		ToolCallIndex: key.toolCall,
+		ToolCallID:    entry.id,
+		FunctionName:  entry.name,
+		Done:          entry.parser.Done(),
+		Err:           entry.parser.Err(),
+	}
+	if u.Err == nil {
+		if v, err := entry.parser.Value(); err == nil {
+			u.Value = v
+		}
+	}
+	return u
+}
@@ -0,0 +1,99 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/zacw/go-ai-types/pkg/converters"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// transcriptionService implements interfaces.TranscriptionService.
+type transcriptionService struct {
+	client *client
+}
+
+// CreateTranscription implements interfaces.TranscriptionService.
+func (s *transcriptionService) CreateTranscription(ctx context.Context, req *types.TranscriptionRequest) (*types.TranscriptionResponse, error) {
+	form, err := converters.ToOpenAITranscriptionForm(req)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := s.client.send(ctx, s.client.http, http.MethodPost, "/audio/transcriptions", form)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp *types.TranscriptionResponse
+	if req.ResponseFormat.IsJSON() {
+		var out converters.OpenAITranscriptionResponse
+		if err := s.client.decode(httpResp, &out); err != nil {
+			return nil, err
+		}
+		resp = converters.FromOpenAITranscriptionResponse(&out)
+	} else {
+		text, err := io.ReadAll(httpResp.Body)
+		if err != nil {
+			return nil, s.client.transportError(err)
+		}
+		resp = &types.TranscriptionResponse{Text: string(text)}
+	}
+	resp.Metadata = &types.ResponseMetadata{
+		Model:    req.Model,
+		Provider: s.client.config.Name,
+	}
+	return resp, nil
+}
+
+// speechService implements interfaces.SpeechService.
+type speechService struct {
+	client *client
+}
+
+// CreateSpeech implements interfaces.SpeechService.
+func (s *speechService) CreateSpeech(ctx context.Context, req *types.SpeechRequest) (*types.SpeechResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	httpResp, err := s.client.send(ctx, s.client.http, http.MethodPost, "/audio/speech", converters.ToOpenAISpeechRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	audio, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, s.client.transportError(err)
+	}
+	format := req.ResponseFormat
+	if format == "" {
+		format = types.AudioFormatMP3
+	}
+	return &types.SpeechResponse{
+		Audio:       audio,
+		Format:      format,
+		ContentType: httpResp.Header.Get("Content-Type"),
+		Metadata: &types.ResponseMetadata{
+			Model:    req.Model,
+			Provider: s.client.config.Name,
+		},
+	}, nil
+}
+
+// CreateSpeechStream implements interfaces.SpeechService. The audio is
+// streamed as the response body arrives.
+func (s *speechService) CreateSpeechStream(ctx context.Context, req *types.SpeechRequest) (io.ReadCloser, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	httpResp, err := s.client.send(ctx, s.client.stream, http.MethodPost, "/audio/speech", converters.ToOpenAISpeechRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	return httpResp.Body, nil
+}
@@ -0,0 +1,137 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/zacw/go-ai-types/pkg/converters"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// maxEventBytes is the largest server-sent event line accepted.
+const maxEventBytes = 4 << 20
+
+// chatService implements interfaces.ChatService.
+type chatService struct {
+	client *client
+}
+
+// CreateCompletion implements interfaces.ChatService.
+func (s *chatService) CreateCompletion(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+	wire, err := converters.ToOpenAIRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	wire.Stream = false
+	wire.StreamOptions = nil
+
+	var out converters.OpenAIChatResponse
+	if err := s.client.do(ctx, http.MethodPost, "/chat/completions", wire, &out); err != nil {
+		return nil, err
+	}
+	resp, err := converters.FromOpenAIResponse(&out)
+	if err != nil {
+		return nil, err
+	}
+	resp.Metadata = &types.ResponseMetadata{
+		ID:                resp.ID,
+		Created:           resp.Created,
+		Model:             resp.Model,
+		Provider:          s.client.config.Name,
+		SystemFingerprint: resp.SystemFingerprint,
+	}
+	return resp, nil
+}
+
+// CreateCompletionStream implements interfaces.ChatService.
+func (s *chatService) CreateCompletionStream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	wire, err := converters.ToOpenAIRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	wire.Stream = true
+	wire.StreamOptions = &converters.OpenAIStreamOptions{IncludeUsage: true}
+
+	resp, err := s.client.send(ctx, s.client.stream, http.MethodPost, "/chat/completions", wire)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan types.StreamChunk, types.DefaultStreamBufferSize)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+		s.readEvents(ctx, resp.Body, out)
+	}()
+	return out, nil
+}
+
+// readEvents decodes server-sent events from body until [DONE], an error
+// event, a read error or ctx is done.
+func (s *chatService) readEvents(ctx context.Context, body io.Reader, out chan<- types.StreamChunk) {
+	send := func(chunk types.StreamChunk) bool {
+		select {
+		case out <- chunk:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventBytes)
+	var eventID string
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		switch {
+		case bytes.HasPrefix(line, []byte("id:")):
+			eventID = string(bytes.TrimSpace(line[3:]))
+			continue
+		case !bytes.HasPrefix(line, []byte("data:")):
+			continue
+		}
+
+		data := bytes.TrimSpace(line[5:])
+		if string(data) == "[DONE]" {
+			return
+		}
+
+		var errBody converters.OpenAIErrorResponse
+		if json.Unmarshal(data, &errBody) == nil && errBody.Error != nil {
+			providerErr := converters.FromOpenAIError(http.StatusInternalServerError, data)
+			providerErr.HTTPStatus = 0
+			providerErr.ProviderName = s.client.config.Name
+			send(types.NewErrorChunk(providerErr))
+			return
+		}
+
+		var wire converters.OpenAIChatChunk
+		if err := json.Unmarshal(data, &wire); err != nil {
+			send(types.NewErrorChunk(&types.ProviderError{
+				ErrorType:    types.ErrorTypeServer,
+				Message:      "invalid stream event: " + err.Error(),
+				ProviderName: s.client.config.Name,
+				InnerError:   err,
+			}))
+			return
+		}
+		chunk := converters.FromOpenAIChunk(&wire)
+		chunk.EventID = eventID
+		if !send(chunk) {
+			return
+		}
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+	err := scanner.Err()
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	send(types.NewErrorChunk(s.client.transportError(err)))
+}
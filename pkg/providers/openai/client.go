@@ -0,0 +1,120 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/zacw/go-ai-types/pkg/converters"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// maxErrorBodyBytes limits how much of an error response is read.
+const maxErrorBodyBytes = 1 << 20
+
+// client sends HTTP requests to the API.
+type client struct {
+	config *Config
+	http   *http.Client
+	stream *http.Client
+}
+
+// do sends a request with an optional body and decodes a JSON response into out.
+func (c *client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	resp, err := c.send(ctx, c.http, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return c.decode(resp, out)
+}
+
+// decode decodes a JSON response body into out.
+func (c *client) decode(resp *http.Response, out interface{}) error {
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return &types.ProviderError{
+			ErrorType:    types.ErrorTypeServer,
+			Message:      "invalid response body: " + err.Error(),
+			HTTPStatus:   resp.StatusCode,
+			ProviderName: c.config.Name,
+			InnerError:   err,
+		}
+	}
+	return nil
+}
+
+// send sends a request and returns the response if its status is 2xx.
+// Other statuses are converted to a *types.ProviderError. A
+// *converters.Form body is sent as multipart/form-data; any other body is
+// sent as JSON.
+func (c *client) send(ctx context.Context, hc *http.Client, method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	var contentType string
+	switch b := body.(type) {
+	case nil:
+	case *converters.Form:
+		data, ct, err := b.Encode()
+		if err != nil {
+			return nil, fmt.Errorf("openai: encode request: %w", err)
+		}
+		reader, contentType = bytes.NewReader(data), ct
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("openai: encode request: %w", err)
+		}
+		reader, contentType = bytes.NewReader(data), "application/json"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.config.BaseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("openai: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.config.UserAgent)
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+	if c.config.Organization != "" {
+		req.Header.Set("OpenAI-Organization", c.config.Organization)
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, c.transportError(err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		providerErr := converters.FromOpenAIError(resp.StatusCode, data)
+		providerErr.ProviderName = c.config.Name
+		return nil, providerErr
+	}
+	return resp, nil
+}
+
+// transportError wraps a network failure as a retryable ProviderError.
+func (c *client) transportError(err error) *types.ProviderError {
+	errType := types.ErrorTypeServer
+	var netErr interface{ Timeout() bool }
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		errType = types.ErrorTypeTimeout
+	}
+	return &types.ProviderError{
+		ErrorType:    errType,
+		Message:      err.Error(),
+		ProviderName: c.config.Name,
+		IsRetryable:  true,
+		InnerError:   err,
+	}
+}
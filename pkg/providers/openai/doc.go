@@ -0,0 +1,23 @@
+// Package openai implements interfaces.Provider for the OpenAI API and any
+// server that speaks the same protocol (Azure OpenAI deployments behind a
+// compatible proxy, vLLM, Ollama, LM Studio, the gateway in cmd/gateway).
+//
+// Requests and responses are translated with pkg/converters. HTTP errors are
+// returned as *types.ProviderError, with rate limit, server and network
+// errors marked retryable. A stream that ends without OpenAI's [DONE] event
+// reports io.ErrUnexpectedEOF in a *types.ErrorChunk, so streams.Reconnect
+// can resume it.
+//
+// Example usage:
+//
+//	provider, err := openai.NewProvider(&openai.Config{
+//	    ProviderConfig: interfaces.ProviderConfig{
+//	        APIKey: os.Getenv("OPENAI_API_KEY"),
+//	    },
+//	    Models: []string{"gpt-4o", "text-embedding-3-small"},
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//	resp, err := provider.ChatService().CreateCompletion(ctx, req)
+package openai
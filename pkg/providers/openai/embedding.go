@@ -0,0 +1,28 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/zacw/go-ai-types/pkg/converters"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// embeddingService implements interfaces.EmbeddingService.
+type embeddingService struct {
+	client *client
+}
+
+// CreateEmbedding implements interfaces.EmbeddingService.
+func (s *embeddingService) CreateEmbedding(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
+	var out converters.OpenAIEmbeddingResponse
+	if err := s.client.do(ctx, http.MethodPost, "/embeddings", converters.ToOpenAIEmbeddingRequest(req), &out); err != nil {
+		return nil, err
+	}
+	resp := converters.FromOpenAIEmbeddingResponse(&out)
+	resp.Metadata = &types.ResponseMetadata{
+		Model:    resp.Model,
+		Provider: s.client.config.Name,
+	}
+	return resp, nil
+}
@@ -0,0 +1,46 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/zacw/go-ai-types/pkg/converters"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// imageService implements interfaces.ImageService.
+type imageService struct {
+	client *client
+}
+
+// CreateImage implements interfaces.ImageService.
+func (s *imageService) CreateImage(ctx context.Context, req *types.ImageGenerationRequest) (*types.ImageGenerationResponse, error) {
+	var out converters.OpenAIImageResponse
+	if err := s.client.do(ctx, http.MethodPost, "/images/generations", converters.ToOpenAIImageRequest(req), &out); err != nil {
+		return nil, err
+	}
+	return s.response(&out, req.Model), nil
+}
+
+// EditImage implements interfaces.ImageService.
+func (s *imageService) EditImage(ctx context.Context, req *types.ImageGenerationRequest) (*types.ImageGenerationResponse, error) {
+	form, err := converters.ToOpenAIImageEditForm(req)
+	if err != nil {
+		return nil, err
+	}
+	var out converters.OpenAIImageResponse
+	if err := s.client.do(ctx, http.MethodPost, "/images/edits", form, &out); err != nil {
+		return nil, err
+	}
+	return s.response(&out, req.Model), nil
+}
+
+func (s *imageService) response(out *converters.OpenAIImageResponse, model string) *types.ImageGenerationResponse {
+	resp := converters.FromOpenAIImageResponse(out)
+	resp.Metadata = &types.ResponseMetadata{
+		Created:  resp.Created,
+		Model:    model,
+		Provider: s.client.config.Name,
+	}
+	return resp
+}
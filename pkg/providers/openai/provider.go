@@ -0,0 +1,170 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zacw/go-ai-types/internal/utils"
+	"github.com/zacw/go-ai-types/pkg/converters"
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// DefaultBaseURL is the OpenAI API base URL.
+const DefaultBaseURL = "https://api.openai.com/v1"
+
+// Config configures a Provider.
+type Config struct {
+	interfaces.ProviderConfig
+
+	// Name is reported by Provider.Name and in ResponseMetadata.Provider.
+	// Default is types.ProviderOpenAI.
+	Name types.Provider
+
+	// Models lists the model IDs returned by Provider.Models.
+	// ListModels always queries the API.
+	Models []string
+
+	// HTTPClient sends the requests. Default is a client with a timeout of
+	// ProviderConfig.Timeout seconds, or types.DefaultTimeout. Streaming
+	// requests are bounded by their context only.
+	HTTPClient *http.Client
+}
+
+// Provider is an OpenAI-compatible provider.
+type Provider struct {
+	config Config
+	client *client
+}
+
+// NewProvider creates a provider. A nil config uses the OpenAI API with no
+// API key, which is only useful for local servers.
+func NewProvider(config *Config) (*Provider, error) {
+	cfg := Config{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Name == "" {
+		cfg.Name = types.ProviderOpenAI
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = types.DefaultUserAgent
+	}
+
+	timeout := types.DefaultTimeout
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	streamClient := cfg.HTTPClient
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: timeout}
+		streamClient = &http.Client{}
+	}
+
+	return &Provider{
+		config: cfg,
+		client: &client{config: &cfg, http: cfg.HTTPClient, stream: streamClient},
+	}, nil
+}
+
+// Factory creates a Provider from a generic ProviderConfig. The optional
+// Custom keys "name" (string) and "models" (array of strings) set
+// Config.Name and Config.Models.
+func Factory(config *interfaces.ProviderConfig) (interfaces.Provider, error) {
+	cfg := &Config{}
+	if config != nil {
+		cfg.ProviderConfig = *config
+		if name, ok := config.Custom["name"].(string); ok {
+			cfg.Name = types.Provider(name)
+		}
+		cfg.Models = utils.StringSlice(config.Custom["models"])
+	}
+	return NewProvider(cfg)
+}
+
+// Name implements interfaces.Provider.
+func (p *Provider) Name() types.Provider {
+	return p.config.Name
+}
+
+// Capabilities implements interfaces.Provider.
+func (p *Provider) Capabilities() []types.ModelCapability {
+	return []types.ModelCapability{
+		types.CapabilityChat,
+		types.CapabilityStreaming,
+		types.CapabilityFunctionCalling,
+		types.CapabilityToolCalling,
+		types.CapabilityVision,
+		types.CapabilityJSONMode,
+		types.CapabilityEmbedding,
+		types.CapabilityImageGeneration,
+		types.CapabilityAudio,
+	}
+}
+
+// Models implements interfaces.Provider.
+func (p *Provider) Models() []string {
+	return p.config.Models
+}
+
+// ChatService implements interfaces.Provider.
+func (p *Provider) ChatService() interfaces.ChatService {
+	return &chatService{client: p.client}
+}
+
+// EmbeddingService implements interfaces.Provider.
+func (p *Provider) EmbeddingService() interfaces.EmbeddingService {
+	return &embeddingService{client: p.client}
+}
+
+// ImageService implements interfaces.ImageProvider.
+func (p *Provider) ImageService() interfaces.ImageService {
+	return &imageService{client: p.client}
+}
+
+// TranscriptionService implements interfaces.AudioProvider.
+func (p *Provider) TranscriptionService() interfaces.TranscriptionService {
+	return &transcriptionService{client: p.client}
+}
+
+// SpeechService implements interfaces.AudioProvider.
+func (p *Provider) SpeechService() interfaces.SpeechService {
+	return &speechService{client: p.client}
+}
+
+// ListModels implements interfaces.ModelLister.
+func (p *Provider) ListModels(ctx context.Context) ([]*types.ModelInfo, error) {
+	var list converters.OpenAIModelList
+	if err := p.client.do(ctx, http.MethodGet, "/models", nil, &list); err != nil {
+		return nil, err
+	}
+	models := make([]*types.ModelInfo, 0, len(list.Data))
+	for _, m := range list.Data {
+		if m != nil {
+			models = append(models, converters.FromOpenAIModel(m, p.config.Name))
+		}
+	}
+	return models, nil
+}
+
+// GetModel implements interfaces.ModelLister.
+func (p *Provider) GetModel(ctx context.Context, modelID string) (*types.ModelInfo, error) {
+	var m converters.OpenAIModel
+	if err := p.client.do(ctx, http.MethodGet, "/models/"+modelID, nil, &m); err != nil {
+		return nil, err
+	}
+	return converters.FromOpenAIModel(&m, p.config.Name), nil
+}
+
+// Health implements interfaces.HealthChecker by listing models.
+func (p *Provider) Health(ctx context.Context) error {
+	var list json.RawMessage
+	return p.client.do(ctx, http.MethodGet, "/models", nil, &list)
+}
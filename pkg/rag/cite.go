@@ -0,0 +1,102 @@
+package rag
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// marker matches citation markers such as "[1]" and "[1, 3]".
+var marker = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// Marker is one citation marker in an answer. A marker listing several
+// numbers, such as "[1, 2]", yields one Marker per number with the same
+// offsets.
+type Marker struct {
+	// Start and End are the byte offsets of the marker in the answer text.
+	Start int `json:"start"`
+	End   int `json:"end"`
+
+	// Number is the cited source number.
+	Number int `json:"number"`
+
+	// Citation is the cited source, or nil if Number does not match any
+	// source in the prompt.
+	Citation *Citation `json:"citation,omitempty"`
+}
+
+// Answer is a response text with its citation markers resolved.
+type Answer struct {
+	// Text is the response text.
+	Text string `json:"text"`
+
+	// Markers lists every citation marker in Text, in order.
+	Markers []Marker `json:"markers,omitempty"`
+
+	// Citations lists the cited sources once each, in order of first
+	// citation.
+	Citations []Citation `json:"citations,omitempty"`
+
+	// Unknown lists marker numbers that match no source, once each.
+	Unknown []int `json:"unknown,omitempty"`
+}
+
+// Cite resolves the citation markers in the first choice of resp against
+// citations, as returned by BuildRequest. The cited sources are also
+// recorded in the response message's MessageMetadata.Custom under
+// MetadataKeyCitations. A response without a message yields an empty
+// Answer.
+func Cite(resp *types.ChatResponse, citations []Citation) *Answer {
+	if resp == nil || len(resp.Choices) == 0 || resp.Choices[0].Message == nil {
+		return &Answer{}
+	}
+	msg := resp.Choices[0].Message
+	var text string
+	if msg.Content != nil {
+		text = msg.Content.String()
+	}
+	answer := ResolveCitations(text, citations)
+
+	if msg.Metadata == nil {
+		msg.Metadata = &types.MessageMetadata{}
+	}
+	if msg.Metadata.Custom == nil {
+		msg.Metadata.Custom = make(map[string]interface{})
+	}
+	msg.Metadata.Custom[MetadataKeyCitations] = answer.Citations
+	return answer
+}
+
+// ResolveCitations finds the citation markers in text and maps them to
+// citations by number.
+func ResolveCitations(text string, citations []Citation) *Answer {
+	byNumber := make(map[int]*Citation, len(citations))
+	for i := range citations {
+		byNumber[citations[i].Number] = &citations[i]
+	}
+
+	answer := &Answer{Text: text}
+	cited := make(map[int]bool)
+	for _, m := range marker.FindAllStringSubmatchIndex(text, -1) {
+		for _, field := range strings.Split(text[m[2]:m[3]], ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				continue
+			}
+			c := byNumber[n]
+			answer.Markers = append(answer.Markers, Marker{Start: m[0], End: m[1], Number: n, Citation: c})
+			if cited[n] {
+				continue
+			}
+			cited[n] = true
+			if c != nil {
+				answer.Citations = append(answer.Citations, *c)
+			} else {
+				answer.Unknown = append(answer.Unknown, n)
+			}
+		}
+	}
+	return answer
+}
@@ -0,0 +1,136 @@
+package rag
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+func TestResolveCitations(t *testing.T) {
+	citations := []Citation{{Number: 1, ID: "a"}, {Number: 2, ID: "b"}, {Number: 3, ID: "c"}}
+
+	tests := []struct {
+		name          string
+		text          string
+		wantMarkers   string // number@start-end, with * for unknown numbers
+		wantCitations string
+		wantUnknown   string
+	}{
+		{
+			name:          "single markers",
+			text:          "A [1] and B [2].",
+			wantMarkers:   "[1@2-5 2@12-15]",
+			wantCitations: "[a b]",
+			wantUnknown:   "[]",
+		},
+		{
+			name:          "list with spaces shares offsets",
+			text:          "See [1, 3].",
+			wantMarkers:   "[1@4-10 3@4-10]",
+			wantCitations: "[a c]",
+			wantUnknown:   "[]",
+		},
+		{
+			name:          "list without spaces",
+			text:          "[3,2]",
+			wantMarkers:   "[3@0-5 2@0-5]",
+			wantCitations: "[c b]",
+			wantUnknown:   "[]",
+		},
+		{
+			name:          "repeated citations are listed once",
+			text:          "[2] then [1] then [2, 1]",
+			wantMarkers:   "[2@0-3 1@9-12 2@18-24 1@18-24]",
+			wantCitations: "[b a]",
+			wantUnknown:   "[]",
+		},
+		{
+			name:          "unknown numbers",
+			text:          "[1, 7] and [7] and [0]",
+			wantMarkers:   "[1@0-6 7*@0-6 7*@11-14 0*@19-22]",
+			wantCitations: "[a]",
+			wantUnknown:   "[7 0]",
+		},
+		{
+			name:          "non-markers are ignored",
+			text:          "a[x] [1,] [] [ 1] [1 2]",
+			wantMarkers:   "[]",
+			wantCitations: "[]",
+			wantUnknown:   "[]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer := ResolveCitations(tt.text, citations)
+			if answer.Text != tt.text {
+				t.Errorf("Text = %q, want %q", answer.Text, tt.text)
+			}
+
+			markers := []string{}
+			for _, m := range answer.Markers {
+				unknown := ""
+				if m.Citation == nil {
+					unknown = "*"
+				} else if m.Citation.Number != m.Number {
+					t.Errorf("marker %d resolved to citation %d", m.Number, m.Citation.Number)
+				}
+				markers = append(markers, fmt.Sprintf("%d%s@%d-%d", m.Number, unknown, m.Start, m.End))
+			}
+			if got := fmt.Sprint(markers); got != tt.wantMarkers {
+				t.Errorf("Markers = %s, want %s", got, tt.wantMarkers)
+			}
+
+			ids := []string{}
+			for _, c := range answer.Citations {
+				ids = append(ids, c.ID)
+			}
+			if got := fmt.Sprint(ids); got != tt.wantCitations {
+				t.Errorf("Citations = %s, want %s", got, tt.wantCitations)
+			}
+			if got := fmt.Sprint(append([]int{}, answer.Unknown...)); got != tt.wantUnknown {
+				t.Errorf("Unknown = %s, want %s", got, tt.wantUnknown)
+			}
+		})
+	}
+}
+
+func TestCite(t *testing.T) {
+	citations := []Citation{{Number: 1, ID: "a"}, {Number: 2, ID: "b"}}
+
+	tests := []struct {
+		name string
+		resp *types.ChatResponse
+		want string
+	}{
+		{name: "nil response", want: "[]"},
+		{name: "no choices", resp: &types.ChatResponse{}, want: "[]"},
+		{name: "no content", resp: &types.ChatResponse{Choices: []*types.Choice{{Message: &types.Message{Role: types.RoleAssistant}}}}, want: "[]"},
+		{
+			name: "cited sources are recorded on the message",
+			resp: &types.ChatResponse{Choices: []*types.Choice{{Message: &types.Message{Role: types.RoleAssistant, Content: types.NewTextContent("B [2].")}}}},
+			want: "[b]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer := Cite(tt.resp, citations)
+			ids := []string{}
+			for _, c := range answer.Citations {
+				ids = append(ids, c.ID)
+			}
+			if got := fmt.Sprint(ids); got != tt.want {
+				t.Errorf("Citations = %s, want %s", got, tt.want)
+			}
+			if tt.resp == nil || len(tt.resp.Choices) == 0 {
+				return
+			}
+			recorded, ok := tt.resp.Choices[0].Message.Metadata.Custom[MetadataKeyCitations].([]Citation)
+			if !ok || len(recorded) != len(answer.Citations) {
+				t.Errorf("recorded citations = %v, want %v", recorded, answer.Citations)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+// Package rag provides a retrieval-augmented generation pipeline over an
+// interfaces.EmbeddingService and an index.Index.
+//
+// A Pipeline embeds a query, retrieves the most similar chunks from the
+// index and appends a user message to a ChatRequest holding instructions,
+// the chunks as numbered sources and the query. Sources are added in rank
+// order only while the prompt fits the configured types.TokenBudget. The
+// sources included are recorded as Citations in the message's
+// MessageMetadata.Custom under MetadataKeyCitations.
+//
+// Cite and ResolveCitations map markers such as "[2]" in the model's answer
+// back to the cited chunks, reporting marker offsets and any numbers that
+// match no source.
+//
+// Chunk text is read from index entry metadata under MetadataKeyText.
+// AddChunks embeds textsplit chunks and stores them that way.
+//
+// Example usage:
+//
+//	pipeline := rag.NewPipeline(embeddingService, idx, &rag.Config{
+//	    EmbeddingModel: "text-embedding-3-small",
+//	    TopK:           8,
+//	    Budget:         types.NewTokenBudget(8000, 1000),
+//	})
+//
+//	chunks := textsplit.NewMarkdownSplitter(nil).Split(document)
+//	if err := pipeline.AddChunks(ctx, "handbook", chunks); err != nil {
+//	    return err
+//	}
+//
+//	req := &types.ChatRequest{Model: "gpt-4o"}
+//	resp, answer, err := pipeline.Query(ctx, chatService, req, "How do I reset my password?")
+//	if err != nil {
+//	    return err
+//	}
+//	for _, c := range answer.Citations {
+//	    log.Printf("[%d] %s (%v)", c.Number, c.ID, c.Metadata[textsplit.MetadataKeyHeadings])
+//	}
+package rag
@@ -0,0 +1,272 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zacw/go-ai-types/pkg/index"
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/textsplit"
+	"github.com/zacw/go-ai-types/pkg/types"
+	"github.com/zacw/go-ai-types/pkg/vector"
+)
+
+// Default pipeline settings.
+const (
+	// DefaultTopK is the number of chunks retrieved per query.
+	DefaultTopK = 5
+
+	// DefaultInstructions precede the retrieved sources in the prompt.
+	DefaultInstructions = "Answer the question using only the sources below. " +
+		"Cite the sources you use with their bracketed number, for example [1]. " +
+		"If the sources do not contain the answer, say so."
+)
+
+// Metadata keys used by the pipeline.
+const (
+	// MetadataKeyText is the index entry metadata key holding the chunk
+	// text (value: string).
+	MetadataKeyText = "text"
+
+	// MetadataKeyDocument is the index entry metadata key holding the ID of
+	// the document a chunk came from (value: string). Set by AddChunks.
+	MetadataKeyDocument = "document_id"
+
+	// MetadataKeyCitations is the MessageMetadata.Custom key holding the
+	// citations of a message (value: []Citation). On the prompt message it
+	// lists every source included; on a response message, set by Cite, the
+	// sources the answer cited.
+	MetadataKeyCitations = "rag_citations"
+)
+
+// ErrNoEmbedding is returned when the embedding service returns no
+// embedding for the query.
+var ErrNoEmbedding = errors.New("rag: no embedding returned for query")
+
+// Citation is a retrieved chunk included in a prompt.
+type Citation struct {
+	// Number is the marker number, so the chunk is cited as "[Number]".
+	Number int `json:"number"`
+
+	// ID is the index entry ID of the chunk.
+	ID string `json:"id"`
+
+	// Score is the similarity of the chunk to the query.
+	Score float64 `json:"score"`
+
+	// Text is the chunk text.
+	Text string `json:"text"`
+
+	// Metadata is the index entry metadata of the chunk.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Config configures a Pipeline.
+type Config struct {
+	// EmbeddingModel is the model used to embed queries and chunks. It must
+	// match the model the index was built with.
+	EmbeddingModel string
+
+	// Dimensions, if positive, requests embeddings of this size.
+	Dimensions int
+
+	// TopK is the number of chunks retrieved per query.
+	// Default is DefaultTopK.
+	TopK int
+
+	// Search configures the index search, for example with a metadata
+	// filter.
+	Search *index.SearchOptions
+
+	// Budget limits the prompt size. Sources are added in rank order while
+	// the request, including the existing messages, fits within
+	// Budget.AvailableForPrompt(); sources that do not fit are skipped. The
+	// budget is not modified. If nil, every retrieved source is included.
+	Budget *types.TokenBudget
+
+	// TokenCounter counts prompt tokens for Budget.
+	// Default is types.NewHeuristicTokenCounter().
+	TokenCounter types.TokenCounter
+
+	// Instructions precede the sources in the prompt.
+	// Default is DefaultInstructions.
+	Instructions string
+
+	// TextKey is the index entry metadata key holding the chunk text.
+	// Default is MetadataKeyText.
+	TextKey string
+}
+
+// Pipeline retrieves chunks relevant to a query from an index.Index and
+// builds chat requests grounded on them.
+type Pipeline struct {
+	embeddings interfaces.EmbeddingService
+	index      *index.Index
+	config     Config
+}
+
+// NewPipeline creates a pipeline over idx, embedding with embeddings. Nil
+// config uses defaults.
+func NewPipeline(embeddings interfaces.EmbeddingService, idx *index.Index, config *Config) *Pipeline {
+	cfg := Config{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.TopK <= 0 {
+		cfg.TopK = DefaultTopK
+	}
+	if cfg.TokenCounter == nil {
+		cfg.TokenCounter = types.NewHeuristicTokenCounter()
+	}
+	if cfg.Instructions == "" {
+		cfg.Instructions = DefaultInstructions
+	}
+	if cfg.TextKey == "" {
+		cfg.TextKey = MetadataKeyText
+	}
+	return &Pipeline{embeddings: embeddings, index: idx, config: cfg}
+}
+
+// AddChunks embeds chunks of the document docID and adds them to the index
+// with IDs "<docID>#<chunk index>". Each entry's metadata is the chunk
+// metadata plus the chunk text and docID.
+func (p *Pipeline) AddChunks(ctx context.Context, docID string, chunks []textsplit.Chunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	resp, err := p.embeddings.CreateEmbedding(ctx, p.embeddingRequest(textsplit.Texts(chunks)))
+	if err != nil {
+		return err
+	}
+	ids := make([]string, len(chunks))
+	metadata := make([]map[string]interface{}, len(chunks))
+	for i, c := range chunks {
+		ids[i] = fmt.Sprintf("%s#%d", docID, c.Index)
+		md := make(map[string]interface{}, len(c.Metadata)+2)
+		for k, v := range c.Metadata {
+			md[k] = v
+		}
+		md[p.config.TextKey] = c.Text
+		md[MetadataKeyDocument] = docID
+		metadata[i] = md
+	}
+	return p.index.AddEmbeddings(resp, ids, metadata)
+}
+
+// Retrieve embeds query and returns the TopK most similar chunks, best
+// first.
+func (p *Pipeline) Retrieve(ctx context.Context, query string) ([]index.Result, error) {
+	resp, err := p.embeddings.CreateEmbedding(ctx, p.embeddingRequest(query))
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := vector.Float32s(resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 {
+		return nil, ErrNoEmbedding
+	}
+	return p.index.Search(vectors[0], p.config.TopK, p.config.Search)
+}
+
+func (p *Pipeline) embeddingRequest(input interface{}) *types.EmbeddingRequest {
+	req := &types.EmbeddingRequest{Model: p.config.EmbeddingModel, Input: input}
+	if p.config.Dimensions > 0 {
+		req.WithDimensions(p.config.Dimensions)
+	}
+	return req
+}
+
+// BuildRequest retrieves sources for query and returns a copy of req with
+// a user message appended that holds the instructions, the numbered
+// sources and the query. The sources included are returned and recorded in
+// the message's MessageMetadata.Custom under MetadataKeyCitations. req is
+// not modified.
+func (p *Pipeline) BuildRequest(ctx context.Context, req *types.ChatRequest, query string) (*types.ChatRequest, []Citation, error) {
+	results, err := p.Retrieve(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	msg, citations := p.pack(req.Messages, results, query)
+
+	out := *req
+	out.Messages = append(append(make([]*types.Message, 0, len(req.Messages)+1), req.Messages...), msg)
+	return &out, citations, nil
+}
+
+// Query builds a request with BuildRequest, sends it to chat and resolves
+// the citations in the response with Cite.
+func (p *Pipeline) Query(ctx context.Context, chat interfaces.ChatService, req *types.ChatRequest, query string) (*types.ChatResponse, *Answer, error) {
+	built, citations, err := p.BuildRequest(ctx, req, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := chat.CreateCompletion(ctx, built)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, Cite(resp, citations), nil
+}
+
+// pack builds the prompt message from results, adding sources in rank
+// order while the prompt fits the budget. Each candidate is measured by
+// counting the whole prompt it would produce, so separators and the
+// counter's rounding are accounted for exactly.
+func (p *Pipeline) pack(history []*types.Message, results []index.Result, query string) (*types.Message, []Citation) {
+	limit := -1
+	historyTokens := 0
+	if p.config.Budget != nil {
+		limit = p.config.Budget.AvailableForPrompt()
+		historyTokens = p.config.TokenCounter.CountMessagesTokens(history)
+	}
+
+	var citations []Citation
+	var sources []string
+	for _, r := range results {
+		text, _ := r.Metadata[p.config.TextKey].(string)
+		if text == "" {
+			continue
+		}
+		candidate := append(sources[:len(sources):len(sources)], fmt.Sprintf("[%d] %s", len(citations)+1, text))
+		if limit >= 0 {
+			msg := userMessage(prompt(p.config.Instructions, candidate, query))
+			if historyTokens+p.config.TokenCounter.CountMessagesTokens([]*types.Message{msg}) > limit {
+				continue
+			}
+		}
+		sources = candidate
+		citations = append(citations, Citation{
+			Number:   len(citations) + 1,
+			ID:       r.ID,
+			Score:    r.Score,
+			Text:     text,
+			Metadata: r.Metadata,
+		})
+	}
+
+	msg := userMessage(prompt(p.config.Instructions, sources, query))
+	msg.Metadata = &types.MessageMetadata{
+		Custom: map[string]interface{}{MetadataKeyCitations: citations},
+	}
+	return msg, citations
+}
+
+func prompt(instructions string, sources []string, query string) string {
+	var b strings.Builder
+	b.WriteString(instructions)
+	b.WriteString("\n\nSources:\n\n")
+	if len(sources) == 0 {
+		b.WriteString("(none)")
+	}
+	b.WriteString(strings.Join(sources, "\n\n"))
+	b.WriteString("\n\nQuestion: ")
+	b.WriteString(query)
+	return b.String()
+}
+
+func userMessage(text string) *types.Message {
+	return &types.Message{Role: types.RoleUser, Content: types.NewTextContent(text)}
+}
@@ -0,0 +1,169 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/index"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// queryEmbedder embeds every input as the vector (1, 0).
+type queryEmbedder struct{}
+
+func (queryEmbedder) CreateEmbedding(_ context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
+	resp := &types.EmbeddingResponse{Model: req.Model}
+	for i := range req.GetInputAsStrings() {
+		resp.Data = append(resp.Data, &types.Embedding{Index: i, Embedding: []float32{1, 0}})
+	}
+	return resp, nil
+}
+
+// rankedIndex returns an index whose entries rank in the order of texts
+// for the query vector (1, 0).
+func rankedIndex(t *testing.T, texts ...string) *index.Index {
+	t.Helper()
+	ix := index.New(nil)
+	for i, text := range texts {
+		entry := index.Entry{
+			ID:       fmt.Sprintf("doc#%d", i),
+			Vector:   []float32{1, float32(i)},
+			Metadata: map[string]interface{}{MetadataKeyText: text},
+		}
+		if err := ix.Add(entry); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	return ix
+}
+
+// promptTokens counts the tokens of history plus a prompt holding sources.
+func promptTokens(history []*types.Message, sources ...string) int {
+	counter := types.NewHeuristicTokenCounter()
+	msg := userMessage(prompt(DefaultInstructions, sources, "q"))
+	return counter.CountMessagesTokens(history) + counter.CountMessagesTokens([]*types.Message{msg})
+}
+
+func TestBuildRequestPacking(t *testing.T) {
+	long := strings.Repeat("long source text ", 40)
+	history := []*types.Message{{Role: types.RoleSystem, Content: types.NewTextContent("You are helpful.")}}
+
+	tests := []struct {
+		name    string
+		texts   []string
+		budget  *types.TokenBudget
+		want    string // citation number:ID pairs
+		wantErr bool
+	}{
+		{
+			name:  "no budget includes every source",
+			texts: []string{"alpha", "beta", "gamma"},
+			want:  "[1:doc#0 2:doc#1 3:doc#2]",
+		},
+		{
+			name:   "exact fit includes the source",
+			texts:  []string{"alpha", "beta", "gamma"},
+			budget: types.NewTokenBudget(promptTokens(history, "[1] alpha", "[2] beta"), 0),
+			want:   "[1:doc#0 2:doc#1]",
+		},
+		{
+			name:   "one token short leaves the source out",
+			texts:  []string{"alpha", "beta", "gamma"},
+			budget: types.NewTokenBudget(promptTokens(history, "[1] alpha", "[2] beta")-1, 0),
+			want:   "[1:doc#0]",
+		},
+		{
+			name:   "skipped source does not take a number",
+			texts:  []string{"alpha", long, "gamma"},
+			budget: types.NewTokenBudget(promptTokens(history, "[1] alpha", "[2] gamma"), 0),
+			want:   "[1:doc#0 2:doc#2]",
+		},
+		{
+			name:  "entries without text are skipped",
+			texts: []string{"", "beta"},
+			want:  "[1:doc#1]",
+		},
+		{
+			name:   "reserved output counts against the budget",
+			texts:  []string{"alpha"},
+			budget: types.NewTokenBudget(promptTokens(history, "[1] alpha")+99, 100),
+			want:   "[]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPipeline(queryEmbedder{}, rankedIndex(t, tt.texts...), &Config{Budget: tt.budget})
+			req := &types.ChatRequest{Model: "m", Messages: history}
+			built, citations, err := p.BuildRequest(context.Background(), req, "q")
+			if err != nil {
+				t.Fatalf("BuildRequest() error = %v", err)
+			}
+
+			var got []string
+			var sources []string
+			for _, c := range citations {
+				got = append(got, fmt.Sprintf("%d:%s", c.Number, c.ID))
+				sources = append(sources, fmt.Sprintf("[%d] %s", c.Number, c.Text))
+			}
+			if fmt.Sprint(got) != tt.want {
+				t.Errorf("citations = %v, want %s", got, tt.want)
+			}
+
+			if len(req.Messages) != 1 || len(built.Messages) != 2 {
+				t.Fatalf("messages = %d in req and %d built, want 1 and 2", len(req.Messages), len(built.Messages))
+			}
+			msg := built.Messages[1]
+			if want := prompt(DefaultInstructions, sources, "q"); msg.Content.String() != want {
+				t.Errorf("prompt =\n%s\nwant\n%s", msg.Content.String(), want)
+			}
+			if recorded := msg.Metadata.Custom[MetadataKeyCitations].([]Citation); len(recorded) != len(citations) {
+				t.Errorf("recorded citations = %v, want %v", recorded, citations)
+			}
+			if tt.budget != nil {
+				if used := promptTokens(history, sources...); used > tt.budget.AvailableForPrompt() {
+					t.Errorf("prompt uses %d tokens, budget allows %d", used, tt.budget.AvailableForPrompt())
+				}
+				if tt.budget.Used != 0 {
+					t.Errorf("budget Used = %d, want it unmodified", tt.budget.Used)
+				}
+			}
+		})
+	}
+}
+
+// answerService answers every request with a fixed text.
+type answerService struct {
+	text string
+	req  *types.ChatRequest
+}
+
+func (s *answerService) CreateCompletion(_ context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+	s.req = req
+	return &types.ChatResponse{Choices: []*types.Choice{{Message: &types.Message{Role: types.RoleAssistant, Content: types.NewTextContent(s.text)}}}}, nil
+}
+
+func (s *answerService) CreateCompletionStream(context.Context, *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func TestQuery(t *testing.T) {
+	chat := &answerService{text: "Alpha [1], gamma [3, 1]."}
+	p := NewPipeline(queryEmbedder{}, rankedIndex(t, "alpha", "beta"), &Config{TopK: 2})
+
+	resp, answer, err := p.Query(context.Background(), chat, &types.ChatRequest{Model: "m"}, "q")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(chat.req.Messages) != 1 {
+		t.Errorf("sent %d messages, want the prompt only", len(chat.req.Messages))
+	}
+	if len(answer.Citations) != 1 || answer.Citations[0].ID != "doc#0" || fmt.Sprint(answer.Unknown) != "[3]" {
+		t.Errorf("answer = %+v, want doc#0 cited and 3 unknown", answer)
+	}
+	if cited := resp.Choices[0].Message.Metadata.Custom[MetadataKeyCitations].([]Citation); len(cited) != 1 {
+		t.Errorf("response citations = %v, want doc#0", cited)
+	}
+}
@@ -0,0 +1,514 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Default balancer settings.
+const (
+	// DefaultHealthCheckInterval is how often members are health checked.
+	DefaultHealthCheckInterval = 30 * time.Second
+
+	// DefaultHealthCheckTimeout bounds a single health check.
+	DefaultHealthCheckTimeout = 5 * time.Second
+
+	// DefaultErrorPenalty is the latency recorded for a failed request.
+	DefaultErrorPenalty = 30 * time.Second
+)
+
+// ErrNoHealthyMembers is wrapped by the error returned when every member
+// has been ejected.
+var ErrNoHealthyMembers = errors.New("routing: no healthy members")
+
+// Strategy selects the member that serves a request.
+type Strategy int
+
+const (
+	// StrategyWeightedRoundRobin spreads requests in proportion to Member.Weight.
+	StrategyWeightedRoundRobin Strategy = iota
+
+	// StrategyLeastInFlight picks the member with the fewest in-flight
+	// requests relative to its weight.
+	StrategyLeastInFlight
+
+	// StrategyLatency picks the member with the lowest average latency,
+	// scaled by its in-flight requests and weight. A member without latency
+	// samples is picked first so it gets measured, one request at a time.
+	// Failed requests are recorded as BalancerConfig.ErrorPenalty.
+	StrategyLatency
+)
+
+// String returns the strategy name.
+func (s Strategy) String() string {
+	switch s {
+	case StrategyWeightedRoundRobin:
+		return "weighted_round_robin"
+	case StrategyLeastInFlight:
+		return "least_in_flight"
+	case StrategyLatency:
+		return "latency"
+	default:
+		return "unknown"
+	}
+}
+
+// Member is one deployment or API key behind a Balancer.
+type Member struct {
+	// Provider serves the requests. If it implements interfaces.HealthChecker,
+	// the member is ejected while its health checks fail.
+	Provider interfaces.Provider
+
+	// Model replaces ChatRequest.Model. If empty, the request's model is kept.
+	Model string
+
+	// Weight is the member's share of traffic.
+	// Default is 1.
+	Weight int
+
+	// Name labels the member in metrics and latency tracking. Members
+	// sharing a provider name should set distinct names.
+	// Default is Provider.Name().
+	Name types.Provider
+}
+
+// BalancerConfig configures a Balancer.
+type BalancerConfig struct {
+	// Strategy selects members.
+	// Default is StrategyWeightedRoundRobin.
+	Strategy Strategy
+
+	// Latency supplies the completion averages used by StrategyLatency. The
+	// balancer records every completion into it, labelled by Member.Name and
+	// model.
+	// Default is a new tracker with DefaultEWMAAlpha.
+	Latency *LatencyTracker
+
+	// StreamLatency supplies the averages used by StrategyLatency for
+	// streams, where the time to the first chunk is recorded.
+	// Default is a new tracker with DefaultEWMAAlpha.
+	StreamLatency *LatencyTracker
+
+	// ErrorPenalty is recorded as the latency of a failed request, or the
+	// request's own latency if longer, so StrategyLatency steers away from
+	// failing members. Requests canceled by the caller are not recorded.
+	// Default is DefaultErrorPenalty.
+	ErrorPenalty time.Duration
+
+	// Metrics, if set, receives the balancer's request, response, error and
+	// token usage records. A stream's response is recorded when it ends.
+	Metrics interfaces.MetricsCollector
+
+	// HealthCheckInterval is how often members implementing
+	// interfaces.HealthChecker are checked.
+	// Default is DefaultHealthCheckInterval; negative disables checks.
+	HealthCheckInterval time.Duration
+
+	// HealthCheckTimeout bounds each health check.
+	// Default is DefaultHealthCheckTimeout.
+	HealthCheckTimeout time.Duration
+
+	// OnHealthChange, if set, is called when a member is ejected or readmitted.
+	OnHealthChange func(member Member, healthy bool, err error)
+}
+
+// Balancer is a ChatService that spreads requests over members serving
+// the same model. Health checks run in the background until Close.
+type Balancer struct {
+	config  BalancerConfig
+	members []*member
+
+	mu sync.Mutex // guards member selection state
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// member is a Member with its balancing state.
+type member struct {
+	Member
+	service interfaces.ChatService
+	checker interfaces.HealthChecker
+
+	// Guarded by Balancer.mu.
+	inFlight      int
+	currentWeight int
+	healthy       bool
+}
+
+// NewBalancer creates a balancer over members and starts health checking
+// those that implement interfaces.HealthChecker. A nil config uses defaults.
+func NewBalancer(members []Member, config *BalancerConfig) *Balancer {
+	cfg := BalancerConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Latency == nil {
+		cfg.Latency = NewLatencyTracker(DefaultEWMAAlpha)
+	}
+	if cfg.StreamLatency == nil {
+		cfg.StreamLatency = NewLatencyTracker(DefaultEWMAAlpha)
+	}
+	if cfg.ErrorPenalty <= 0 {
+		cfg.ErrorPenalty = DefaultErrorPenalty
+	}
+	if cfg.HealthCheckInterval == 0 {
+		cfg.HealthCheckInterval = DefaultHealthCheckInterval
+	}
+	if cfg.HealthCheckTimeout <= 0 {
+		cfg.HealthCheckTimeout = DefaultHealthCheckTimeout
+	}
+
+	b := &Balancer{
+		config: cfg,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	checked := false
+	for _, m := range members {
+		service := m.Provider.ChatService()
+		if service == nil {
+			continue
+		}
+		if m.Weight <= 0 {
+			m.Weight = 1
+		}
+		if m.Name == "" {
+			m.Name = m.Provider.Name()
+		}
+		mem := &member{Member: m, service: service, healthy: true}
+		if hc, ok := m.Provider.(interfaces.HealthChecker); ok {
+			mem.checker = hc
+			checked = true
+		}
+		b.members = append(b.members, mem)
+	}
+
+	if checked && cfg.HealthCheckInterval > 0 {
+		go b.healthLoop()
+	} else {
+		close(b.done)
+	}
+	return b
+}
+
+// Close stops health checking.
+func (b *Balancer) Close() error {
+	b.once.Do(func() { close(b.stop) })
+	<-b.done
+	return nil
+}
+
+// CheckHealth runs one round of health checks, ejecting members whose
+// check fails and readmitting members whose check passes.
+func (b *Balancer) CheckHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, m := range b.members {
+		if m.checker == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(m *member) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, b.config.HealthCheckTimeout)
+			err := m.checker.Health(checkCtx)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+
+			b.mu.Lock()
+			changed := m.healthy != (err == nil)
+			m.healthy = err == nil
+			b.mu.Unlock()
+			if changed && b.config.OnHealthChange != nil {
+				b.config.OnHealthChange(m.Member, err == nil, err)
+			}
+		}(m)
+	}
+	wg.Wait()
+}
+
+// Healthy returns the members currently accepting traffic.
+func (b *Balancer) Healthy() []Member {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var healthy []Member
+	for _, m := range b.members {
+		if m.healthy {
+			healthy = append(healthy, m.Member)
+		}
+	}
+	return healthy
+}
+
+// CreateCompletion implements interfaces.ChatService. The response's
+// Metadata.Provider names the member's provider.
+func (b *Balancer) CreateCompletion(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+	m, err := b.acquire(req.Model, b.config.Latency)
+	if err != nil {
+		return nil, err
+	}
+	defer b.release(m)
+
+	routed := m.request(req)
+	b.recordRequest(m, routed.Model)
+	start := time.Now()
+	resp, err := m.service.CreateCompletion(ctx, routed)
+	if err != nil {
+		b.recordError(ctx, b.config.Latency, m, routed.Model, time.Since(start), err)
+		return nil, err
+	}
+	b.config.Latency.RecordResponse(m.Name, routed.Model, time.Since(start), 0)
+	b.recordResponse(m, routed.Model, time.Since(start), resp.Usage)
+
+	if resp.Metadata == nil {
+		resp.Metadata = &types.ResponseMetadata{ID: resp.ID, Created: resp.Created, Model: resp.Model}
+	}
+	resp.Metadata.Provider = m.Provider.Name()
+	return resp, nil
+}
+
+// CreateCompletionStream implements interfaces.ChatService. The member
+// counts as in flight until the stream closes, and the time to the first
+// chunk is recorded in BalancerConfig.StreamLatency.
+func (b *Balancer) CreateCompletionStream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	latency := b.config.StreamLatency
+	m, err := b.acquire(req.Model, latency)
+	if err != nil {
+		return nil, err
+	}
+
+	routed := m.request(req)
+	b.recordRequest(m, routed.Model)
+	start := time.Now()
+	stream, err := m.service.CreateCompletionStream(ctx, routed)
+	if err != nil {
+		b.release(m)
+		b.recordError(ctx, latency, m, routed.Model, time.Since(start), err)
+		return nil, err
+	}
+
+	out := make(chan types.StreamChunk)
+	go func() {
+		defer close(out)
+		defer b.release(m)
+		first := true
+		var usage *types.Usage
+		var streamErr error
+		for chunk := range stream {
+			if ec, ok := chunk.(*types.ErrorChunk); ok {
+				streamErr = ec.Err
+			} else {
+				if first {
+					latency.RecordResponse(m.Name, routed.Model, time.Since(start), 0)
+				}
+				if uc, ok := chunk.(types.UsageChunk); ok && uc.GetUsage() != nil {
+					usage = uc.GetUsage()
+				}
+			}
+			first = false
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				go drain(stream)
+				return
+			}
+		}
+		if streamErr != nil {
+			b.recordError(ctx, latency, m, routed.Model, time.Since(start), streamErr)
+		} else {
+			b.recordResponse(m, routed.Model, time.Since(start), usage)
+		}
+	}()
+	return out, nil
+}
+
+// acquire selects a healthy member for a request for model and marks it in
+// flight. StrategyLatency compares the averages in latency.
+func (b *Balancer) acquire(model string, latency *LatencyTracker) (*member, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var chosen *member
+	switch b.config.Strategy {
+	case StrategyLeastInFlight:
+		chosen = b.pickLeastInFlight()
+	case StrategyLatency:
+		chosen = b.pickLatency(model, latency)
+	default:
+		chosen = b.pickWeighted()
+	}
+	if chosen == nil {
+		return nil, &types.ProviderError{
+			ErrorType:   types.ErrorTypeServer,
+			Message:     ErrNoHealthyMembers.Error(),
+			HTTPStatus:  http.StatusServiceUnavailable,
+			IsRetryable: true,
+			InnerError:  ErrNoHealthyMembers,
+		}
+	}
+	chosen.inFlight++
+	return chosen, nil
+}
+
+// release marks a member's request as finished.
+func (b *Balancer) release(m *member) {
+	b.mu.Lock()
+	m.inFlight--
+	b.mu.Unlock()
+}
+
+// pickWeighted implements smooth weighted round-robin: each healthy member
+// gains its weight, the richest is chosen and pays the total weight.
+func (b *Balancer) pickWeighted() *member {
+	var chosen *member
+	total := 0
+	for _, m := range b.members {
+		if !m.healthy {
+			continue
+		}
+		m.currentWeight += m.Weight
+		total += m.Weight
+		if chosen == nil || m.currentWeight > chosen.currentWeight {
+			chosen = m
+		}
+	}
+	if chosen != nil {
+		chosen.currentWeight -= total
+	}
+	return chosen
+}
+
+// pickLeastInFlight picks the lowest in-flight to weight ratio, breaking
+// ties by weighted round-robin order.
+func (b *Balancer) pickLeastInFlight() *member {
+	return b.pickMin(func(m *member) float64 {
+		return float64(m.inFlight) / float64(m.Weight)
+	})
+}
+
+// pickLatency picks the lowest latency, scaled by load and weight. A member
+// without samples is preferred while it has no request in flight, and is
+// otherwise only picked if no member has samples, so a member that keeps
+// failing without a sample is not flooded before its first result.
+func (b *Balancer) pickLatency(model string, tracker *LatencyTracker) *member {
+	return b.pickMin(func(m *member) float64 {
+		latency, ok := tracker.Latency(m.Name, m.model(model))
+		if !ok {
+			if m.inFlight == 0 {
+				return math.Inf(-1)
+			}
+			return math.Inf(1)
+		}
+		return float64(latency) * float64(m.inFlight+1) / float64(m.Weight)
+	})
+}
+
+// pickMin picks the healthy member with the lowest score. Ties go to the
+// member with the highest smooth round-robin weight, which also advances.
+func (b *Balancer) pickMin(score func(*member) float64) *member {
+	var chosen *member
+	best := math.Inf(1)
+	total := 0
+	for _, m := range b.members {
+		if !m.healthy {
+			continue
+		}
+		m.currentWeight += m.Weight
+		total += m.Weight
+		s := score(m)
+		if chosen == nil || s < best || (s == best && m.currentWeight > chosen.currentWeight) {
+			chosen, best = m, s
+		}
+	}
+	if chosen != nil {
+		chosen.currentWeight -= total
+	}
+	return chosen
+}
+
+// healthLoop runs CheckHealth every HealthCheckInterval until Close.
+func (b *Balancer) healthLoop() {
+	defer close(b.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-b.stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(b.config.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		b.CheckHealth(ctx)
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// model returns the model the member serves for a request for model.
+func (m *member) model(model string) string {
+	if m.Model == "" {
+		return model
+	}
+	return m.Model
+}
+
+// request returns req with the member's model.
+func (m *member) request(req *types.ChatRequest) *types.ChatRequest {
+	if m.model(req.Model) == req.Model {
+		return req
+	}
+	routed := *req
+	routed.Model = m.Model
+	return &routed
+}
+
+// recordRequest and recordResponse forward to BalancerConfig.Metrics.
+func (b *Balancer) recordRequest(m *member, model string) {
+	if b.config.Metrics != nil {
+		b.config.Metrics.RecordRequest(m.Name, model)
+	}
+}
+
+func (b *Balancer) recordResponse(m *member, model string, d time.Duration, usage *types.Usage) {
+	if b.config.Metrics == nil {
+		return
+	}
+	tokens := 0
+	if usage != nil {
+		tokens = usage.TotalTokens
+		b.config.Metrics.RecordTokenUsage(m.Name, model, usage.PromptTokens, usage.CompletionTokens)
+	}
+	b.config.Metrics.RecordResponse(m.Name, model, d, tokens)
+}
+
+// recordError forwards a failure to BalancerConfig.Metrics and records the
+// error penalty in latency, unless the caller canceled ctx.
+func (b *Balancer) recordError(ctx context.Context, latency *LatencyTracker, m *member, model string, d time.Duration, err error) {
+	if ctx.Err() == nil {
+		latency.RecordResponse(m.Name, model, max(d, b.config.ErrorPenalty), 0)
+	}
+	if b.config.Metrics == nil {
+		return
+	}
+	errType := types.ErrorTypeUnknown
+	var aiErr types.AIError
+	if errors.As(err, &aiErr) {
+		errType = aiErr.Type()
+	}
+	b.config.Metrics.RecordError(m.Name, model, errType)
+}
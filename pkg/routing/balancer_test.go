@@ -0,0 +1,268 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// fakeProvider is a Provider whose ChatService calls complete and stream.
+type fakeProvider struct {
+	name     types.Provider
+	complete func(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error)
+	stream   func(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error)
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *fakeProvider) Name() types.Provider                          { return p.name }
+func (p *fakeProvider) Capabilities() []types.ModelCapability         { return nil }
+func (p *fakeProvider) Models() []string                              { return nil }
+func (p *fakeProvider) ChatService() interfaces.ChatService           { return p }
+func (p *fakeProvider) EmbeddingService() interfaces.EmbeddingService { return nil }
+
+func (p *fakeProvider) CreateCompletion(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	return p.complete(ctx, req)
+}
+
+func (p *fakeProvider) CreateCompletionStream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	return p.stream(ctx, req)
+}
+
+func (p *fakeProvider) callCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+// respondAfter returns a completion func that answers after d, or fails
+// with ctx's error if it is canceled first.
+func respondAfter(d time.Duration) func(context.Context, *types.ChatRequest) (*types.ChatResponse, error) {
+	return func(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+		select {
+		case <-time.After(d):
+			return &types.ChatResponse{Model: req.Model, Usage: &types.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// failWith returns a completion func that fails at once with err.
+func failWith(err error) func(context.Context, *types.ChatRequest) (*types.ChatResponse, error) {
+	return func(context.Context, *types.ChatRequest) (*types.ChatResponse, error) {
+		return nil, err
+	}
+}
+
+// streamOf returns a stream func that sends chunks.
+func streamOf(chunks ...types.StreamChunk) func(context.Context, *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	return func(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+		ch := make(chan types.StreamChunk, len(chunks))
+		for _, c := range chunks {
+			ch <- c
+		}
+		close(ch)
+		return ch, nil
+	}
+}
+
+// recordingMetrics counts the responses and errors recorded per provider.
+type recordingMetrics struct {
+	mu        sync.Mutex
+	responses map[types.Provider]int
+	tokens    map[types.Provider]int
+	errors    map[types.Provider]int
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{
+		responses: make(map[types.Provider]int),
+		tokens:    make(map[types.Provider]int),
+		errors:    make(map[types.Provider]int),
+	}
+}
+
+func (m *recordingMetrics) RecordRequest(types.Provider, string) {}
+func (m *recordingMetrics) RecordResponse(p types.Provider, _ string, _ time.Duration, tokens int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.responses[p]++
+	m.tokens[p] += tokens
+}
+func (m *recordingMetrics) RecordError(p types.Provider, _ string, _ types.ErrorType) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors[p]++
+}
+func (m *recordingMetrics) RecordTokenUsage(types.Provider, string, int, int) {}
+func (m *recordingMetrics) RecordCacheHit(types.Provider, string)             {}
+func (m *recordingMetrics) RecordCacheMiss(types.Provider, string)            {}
+func (m *recordingMetrics) RecordRetry(types.Provider, string, int)           {}
+
+var errBadKey = &types.ProviderError{ErrorType: types.ErrorTypeAuthentication, Message: "invalid api key", HTTPStatus: http.StatusUnauthorized}
+
+func TestBalancerSelection(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy Strategy
+		members  []Member
+		requests int
+		want     map[types.Provider]int
+	}{
+		{
+			name:     "weighted round robin follows weights",
+			strategy: StrategyWeightedRoundRobin,
+			members: []Member{
+				{Provider: &fakeProvider{name: "a", complete: respondAfter(0)}, Weight: 3},
+				{Provider: &fakeProvider{name: "b", complete: respondAfter(0)}},
+			},
+			requests: 8,
+			want:     map[types.Provider]int{"a": 6, "b": 2},
+		},
+		{
+			name:     "least in flight alternates sequential requests",
+			strategy: StrategyLeastInFlight,
+			members: []Member{
+				{Provider: &fakeProvider{name: "a", complete: respondAfter(0)}},
+				{Provider: &fakeProvider{name: "b", complete: respondAfter(0)}},
+			},
+			requests: 6,
+			want:     map[types.Provider]int{"a": 3, "b": 3},
+		},
+		{
+			name:     "latency prefers the faster member",
+			strategy: StrategyLatency,
+			members: []Member{
+				{Provider: &fakeProvider{name: "slow", complete: respondAfter(20 * time.Millisecond)}},
+				{Provider: &fakeProvider{name: "fast", complete: respondAfter(0)}},
+			},
+			requests: 10,
+			want:     map[types.Provider]int{"slow": 1, "fast": 9},
+		},
+		{
+			name:     "latency stops sending to an always failing member",
+			strategy: StrategyLatency,
+			members: []Member{
+				{Provider: &fakeProvider{name: "broken", complete: failWith(errBadKey)}},
+				{Provider: &fakeProvider{name: "good", complete: respondAfter(time.Millisecond)}},
+			},
+			requests: 100,
+			want:     map[types.Provider]int{"broken": 1, "good": 99},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBalancer(tt.members, &BalancerConfig{Strategy: tt.strategy})
+			defer b.Close()
+
+			for i := 0; i < tt.requests; i++ {
+				b.CreateCompletion(context.Background(), &types.ChatRequest{Model: "m"})
+			}
+			for _, m := range tt.members {
+				got := m.Provider.(*fakeProvider).callCount()
+				if got != tt.want[m.Provider.Name()] {
+					t.Errorf("member %s got %d requests, want %d", m.Provider.Name(), got, tt.want[m.Provider.Name()])
+				}
+			}
+		})
+	}
+}
+
+func TestBalancerProbesUntriedMemberOnce(t *testing.T) {
+	release := make(chan struct{})
+	untried := &fakeProvider{name: "untried", complete: func(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+		<-release
+		return nil, errBadKey
+	}}
+	known := &fakeProvider{name: "known", complete: respondAfter(0)}
+
+	latency := NewLatencyTracker(DefaultEWMAAlpha)
+	latency.RecordResponse("known", "m", 10*time.Millisecond, 0)
+	b := NewBalancer([]Member{{Provider: untried}, {Provider: known}}, &BalancerConfig{
+		Strategy: StrategyLatency,
+		Latency:  latency,
+	})
+	defer b.Close()
+
+	// The probe blocks, so later requests must go to the measured member.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		b.CreateCompletion(context.Background(), &types.ChatRequest{Model: "m"})
+	}()
+	for untried.callCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := b.CreateCompletion(context.Background(), &types.ChatRequest{Model: "m"}); err != nil {
+			t.Fatalf("CreateCompletion() error = %v", err)
+		}
+	}
+	close(release)
+	wg.Wait()
+
+	if got := untried.callCount(); got != 1 {
+		t.Errorf("untried member got %d requests, want 1", got)
+	}
+	if got, ok := latency.Latency("untried", "m"); !ok || got < DefaultErrorPenalty {
+		t.Errorf("untried member latency = %v, %v, want at least the error penalty", got, ok)
+	}
+}
+
+func TestBalancerStreamLatency(t *testing.T) {
+	chunk := &types.ChatStreamChunk{Choices: []*types.StreamChoice{{Delta: &types.MessageDelta{Content: "hi"}}}}
+	final := &types.ChatStreamChunk{Usage: &types.Usage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7}}
+	provider := &fakeProvider{name: "a", complete: respondAfter(0), stream: streamOf(chunk, final)}
+
+	metrics := newRecordingMetrics()
+	b := NewBalancer([]Member{{Provider: provider}}, &BalancerConfig{Strategy: StrategyLatency, Metrics: metrics})
+	defer b.Close()
+
+	stream, err := b.CreateCompletionStream(context.Background(), &types.ChatRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("CreateCompletionStream() error = %v", err)
+	}
+	for range stream {
+	}
+
+	if _, ok := b.config.StreamLatency.Latency("a", "m"); !ok {
+		t.Error("time to first chunk not recorded in StreamLatency")
+	}
+	if _, ok := b.config.Latency.Latency("a", "m"); ok {
+		t.Error("time to first chunk recorded as completion latency")
+	}
+	if metrics.responses["a"] != 1 || metrics.tokens["a"] != 7 {
+		t.Errorf("metrics got %d responses with %d tokens, want 1 with 7", metrics.responses["a"], metrics.tokens["a"])
+	}
+}
+
+func TestBalancerCanceledRequestNotPenalized(t *testing.T) {
+	provider := &fakeProvider{name: "a", complete: respondAfter(time.Hour)}
+	b := NewBalancer([]Member{{Provider: provider}}, &BalancerConfig{Strategy: StrategyLatency})
+	defer b.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := b.CreateCompletion(ctx, &types.ChatRequest{Model: "m"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CreateCompletion() error = %v, want deadline exceeded", err)
+	}
+	if _, ok := b.config.Latency.Latency("a", "m"); ok {
+		t.Error("canceled request recorded as latency")
+	}
+}
@@ -0,0 +1,71 @@
+package routing
+
+import (
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// RequiredCapabilities returns the model capabilities a request depends on:
+// vision for image content, audio for audio content, tool_calling when Tools
+// is set, function_calling when Functions is set, and json_mode for JSON
+// response formats. Streaming is not included; the Router adds it for
+// streaming calls.
+func RequiredCapabilities(req *types.ChatRequest) []types.ModelCapability {
+	var caps []types.ModelCapability
+	add := func(c types.ModelCapability) {
+		for _, existing := range caps {
+			if existing == c {
+				return
+			}
+		}
+		caps = append(caps, c)
+	}
+
+	for _, msg := range req.Messages {
+		if msg == nil {
+			continue
+		}
+		switch c := msg.Content.(type) {
+		case *types.ImageContent:
+			add(types.CapabilityVision)
+		case *types.AudioContent:
+			add(types.CapabilityAudio)
+		case *types.MultiContent:
+			for _, part := range c.Parts {
+				switch part.Type {
+				case types.ContentTypeImage, types.ContentTypeImageURL:
+					add(types.CapabilityVision)
+				case types.ContentTypeAudio:
+					add(types.CapabilityAudio)
+				}
+			}
+		}
+	}
+
+	if len(req.Tools) > 0 {
+		add(types.CapabilityToolCalling)
+	}
+	if len(req.Functions) > 0 {
+		add(types.CapabilityFunctionCalling)
+	}
+	if rf := req.ResponseFormat; rf != nil && (rf.Type == "json_object" || rf.Type == "json_schema") {
+		add(types.CapabilityJSONMode)
+	}
+	return caps
+}
+
+// missingCapability returns the first required capability not in supported.
+func missingCapability(required, supported []types.ModelCapability) (types.ModelCapability, bool) {
+	for _, r := range required {
+		found := false
+		for _, s := range supported {
+			if s == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return r, true
+		}
+	}
+	return "", false
+}
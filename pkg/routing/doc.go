@@ -0,0 +1,46 @@
+// Package routing provides ChatServices that spread requests across several
+// providers.
+//
+// Router tries an ordered list of provider/model targets and falls over to
+// the next one on retryable errors or an open circuit breaker, skipping
+// targets whose capabilities do not cover the request.
+//
+// Balancer spreads requests over members serving the same model, by
+// weighted round-robin, fewest in-flight requests, or lowest average
+// latency as measured by a LatencyTracker. Members implementing
+// interfaces.HealthChecker are ejected while their health checks fail.
+//
+// Hedger cuts tail latency by sending a second request when the first is
+// slower than a percentile of recent latencies, returning whichever
+// succeeds first. Requests opt in by setting MetadataKeyHedge in
+// RequestMetadata.Custom.
+//
+// Example usage:
+//
+//	router := routing.NewRouter([]routing.Target{
+//	    {Provider: openaiProvider, Model: "gpt-4o"},
+//	    {Provider: azureProvider, Model: "gpt-4o"},
+//	    {Provider: localProvider, Model: "llama3.1", Capabilities: []types.ModelCapability{
+//	        types.CapabilityChat, types.CapabilityStreaming,
+//	    }},
+//	}, &routing.RouterConfig{
+//	    CircuitBreaker: &interfaces.CircuitBreakerConfig{MaxFailures: 3, Timeout: time.Minute},
+//	})
+//
+//	resp, err := router.CreateCompletion(ctx, req)
+//	if err == nil {
+//	    log.Printf("served by %s", resp.Metadata.Provider)
+//	}
+//
+//	balancer := routing.NewBalancer([]routing.Member{
+//	    {Provider: eastProvider, Name: "openai-east", Weight: 2},
+//	    {Provider: westProvider, Name: "openai-west"},
+//	}, &routing.BalancerConfig{Strategy: routing.StrategyLatency})
+//	defer balancer.Close()
+//
+//	hedger := routing.NewHedger([]routing.Target{
+//	    {Provider: openaiProvider, Model: "gpt-4o-mini"},
+//	    {Provider: azureProvider, Model: "gpt-4o-mini"},
+//	}, &routing.HedgeConfig{Percentile: 0.9, OnUsage: costs.Record})
+//	req.Metadata = &types.RequestMetadata{Custom: map[string]interface{}{routing.MetadataKeyHedge: true}}
+package routing
@@ -0,0 +1,518 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// MetadataKeyHedge opts a request into hedging when set to true in
+// RequestMetadata.Custom. Requests without it are sent once.
+const MetadataKeyHedge = "hedge"
+
+// Default hedging settings.
+const (
+	// DefaultHedgePercentile is the latency percentile after which a
+	// request is hedged.
+	DefaultHedgePercentile = 0.95
+
+	// DefaultHedgeDelay is the hedge delay used until enough latencies have
+	// been observed.
+	DefaultHedgeDelay = time.Second
+
+	// DefaultHedgeMinSamples is the number of latencies needed before the
+	// percentile is used.
+	DefaultHedgeMinSamples = 20
+
+	// DefaultHedgeWindow is the number of recent latencies kept.
+	DefaultHedgeWindow = 200
+)
+
+// HedgeConfig configures a Hedger.
+type HedgeConfig struct {
+	// Percentile of recent latencies after which the hedge is sent, in (0, 1).
+	// Default is DefaultHedgePercentile.
+	Percentile float64
+
+	// Delay is the hedge delay until MinSamples latencies have been observed.
+	// Default is DefaultHedgeDelay.
+	Delay time.Duration
+
+	// MinDelay and MaxDelay clamp the percentile delay. Zero means no bound.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+
+	// MinSamples is the number of latencies needed before Percentile is used.
+	// Default is DefaultHedgeMinSamples.
+	MinSamples int
+
+	// Window is the number of recent latencies the percentile is taken over.
+	// Only primary requests are sampled; a primary canceled because its
+	// hedge won is sampled at the time it was canceled. Completions and time
+	// to first stream chunk are tracked separately.
+	// Default is DefaultHedgeWindow.
+	Window int
+
+	// Metrics, if set, receives request, response, error and token usage
+	// records for every request sent, including hedges that lost.
+	Metrics interfaces.MetricsCollector
+
+	// OnUsage, if set, is called with the usage of every request sent,
+	// winners and losers alike, for cost tracking. A loser canceled before
+	// reporting usage is charged its prompt tokens as estimated by
+	// TokenCounter, with estimated set.
+	OnUsage func(target Target, usage *types.Usage, estimated bool)
+
+	// TokenCounter estimates the prompt tokens of canceled requests.
+	// If nil, canceled requests report no usage.
+	TokenCounter types.TokenCounter
+
+	// OnHedge, if set, is called when a hedge request is sent.
+	OnHedge func(target Target, delay time.Duration)
+}
+
+// Hedger is a ChatService that reduces tail latency by sending a second
+// request when the first has not responded within a percentile of recent
+// latencies. The first success is returned and the other request is
+// canceled through its context.
+//
+// Hedging doubles the cost of slow requests, so it only applies to requests
+// that set MetadataKeyHedge; others go to the primary target alone. For
+// streams, the first chunk counts as the response.
+type Hedger struct {
+	primary *routeTarget
+	hedge   *routeTarget
+	config  HedgeConfig
+
+	completion *latencyWindow
+	stream     *latencyWindow
+}
+
+// NewHedger creates a hedger. Requests go to targets[0]; hedges go to
+// targets[1], or to targets[0] again if there is only one target. A nil
+// config uses defaults.
+func NewHedger(targets []Target, config *HedgeConfig) *Hedger {
+	cfg := HedgeConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Percentile <= 0 || cfg.Percentile >= 1 {
+		cfg.Percentile = DefaultHedgePercentile
+	}
+	if cfg.Delay <= 0 {
+		cfg.Delay = DefaultHedgeDelay
+	}
+	if cfg.MinSamples <= 0 {
+		cfg.MinSamples = DefaultHedgeMinSamples
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultHedgeWindow
+	}
+
+	h := &Hedger{
+		config:     cfg,
+		completion: newLatencyWindow(cfg.Window),
+		stream:     newLatencyWindow(cfg.Window),
+	}
+	var eligible []*routeTarget
+	for _, t := range targets {
+		if service := t.Provider.ChatService(); service != nil {
+			eligible = append(eligible, &routeTarget{Target: t, service: service})
+		}
+	}
+	switch len(eligible) {
+	case 0:
+	case 1:
+		h.primary, h.hedge = eligible[0], eligible[0]
+	default:
+		h.primary, h.hedge = eligible[0], eligible[1]
+	}
+	return h
+}
+
+// Hedged reports whether req opts into hedging through MetadataKeyHedge.
+func Hedged(req *types.ChatRequest) bool {
+	if req.Metadata == nil {
+		return false
+	}
+	v, _ := req.Metadata.Custom[MetadataKeyHedge].(bool)
+	return v
+}
+
+// Delay returns the current hedge delay for completions, or for streams if
+// stream is set.
+func (h *Hedger) Delay(stream bool) time.Duration {
+	w := h.completion
+	if stream {
+		w = h.stream
+	}
+	d, ok := w.percentile(h.config.Percentile, h.config.MinSamples)
+	if !ok {
+		return h.config.Delay
+	}
+	if h.config.MinDelay > 0 && d < h.config.MinDelay {
+		d = h.config.MinDelay
+	}
+	if h.config.MaxDelay > 0 && d > h.config.MaxDelay {
+		d = h.config.MaxDelay
+	}
+	return d
+}
+
+// hedgeResult is the outcome of one completion request.
+type hedgeResult struct {
+	target *routeTarget
+	resp   *types.ChatResponse
+	err    error
+}
+
+// CreateCompletion implements interfaces.ChatService. The response's
+// Metadata.Provider names the provider that served it.
+func (h *Hedger) CreateCompletion(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+	if h.primary == nil {
+		return nil, ErrNoTargets
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult, 2)
+	send := func(t *routeTarget, primary bool) {
+		go func() {
+			routed := t.request(req)
+			h.recordRequest(t, routed.Model)
+			start := time.Now()
+			resp, err := t.service.CreateCompletion(ctx, routed)
+			elapsed := time.Since(start)
+			if primary && (err == nil || lost(ctx, parent)) {
+				h.completion.add(elapsed)
+			}
+			if err == nil {
+				h.recordResponse(t, routed.Model, elapsed, resp.Usage)
+			}
+			results <- hedgeResult{target: t, resp: resp, err: err}
+		}()
+	}
+
+	send(h.primary, true)
+	pending := 1
+	var timer <-chan time.Time
+	delay := h.Delay(false)
+	if Hedged(req) {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	var attempts []Attempt
+	for pending > 0 {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				cancel()
+				h.settle(req, r, true)
+				if pending > 0 {
+					go func() { h.settle(req, <-results, false) }()
+				}
+				if r.resp.Metadata == nil {
+					r.resp.Metadata = &types.ResponseMetadata{ID: r.resp.ID, Created: r.resp.Created, Model: r.resp.Model}
+				}
+				r.resp.Metadata.Provider = r.target.Provider.Name()
+				return r.resp, nil
+			}
+			h.settle(req, r, false)
+			attempts = append(attempts, Attempt{Target: r.target.Target, Err: r.err})
+			// A fast retryable failure sends the hedge at once rather than
+			// waiting out the delay.
+			if timer != nil && ctx.Err() == nil && retryable(r.err) {
+				timer = nil
+				h.sendHedge(send, 0)
+				pending++
+			}
+		case <-timer:
+			timer = nil
+			h.sendHedge(send, delay)
+			pending++
+		case <-ctx.Done():
+			for ; pending > 0; pending-- {
+				go func() { h.settle(req, <-results, false) }()
+			}
+			return nil, ctx.Err()
+		}
+	}
+
+	if len(attempts) == 1 {
+		return nil, attempts[0].Err
+	}
+	return nil, &FallbackError{Attempts: attempts}
+}
+
+// hedgeStream is the outcome of one stream request up to its first chunk.
+type hedgeStream struct {
+	target *routeTarget
+	model  string
+	stream <-chan types.StreamChunk
+	first  types.StreamChunk
+	ok     bool
+	ctx    context.Context
+	cancel context.CancelFunc
+	start  time.Time
+	err    error
+}
+
+// CreateCompletionStream implements interfaces.ChatService. A stream whose
+// first chunk is an error counts as a failure.
+func (h *Hedger) CreateCompletionStream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	if h.primary == nil {
+		return nil, ErrNoTargets
+	}
+
+	results := make(chan *hedgeStream, 2)
+	var sent []*hedgeStream
+	send := func(t *routeTarget, primary bool) {
+		r := &hedgeStream{target: t, model: t.request(req).Model, start: time.Now()}
+		r.ctx, r.cancel = context.WithCancel(ctx)
+		sent = append(sent, r)
+		go func() {
+			h.recordRequest(t, r.model)
+			r.stream, r.err = t.service.CreateCompletionStream(r.ctx, t.request(req))
+			if r.err == nil {
+				select {
+				case r.first, r.ok = <-r.stream:
+					if ec, isErr := r.first.(*types.ErrorChunk); r.ok && isErr {
+						r.err = ec.Err
+					}
+				case <-r.ctx.Done():
+					r.err = r.ctx.Err()
+				}
+			}
+			if primary && (r.err == nil || lost(r.ctx, ctx)) {
+				h.stream.add(time.Since(r.start))
+			}
+			if r.err != nil {
+				r.cancel()
+				if r.stream != nil {
+					go drain(r.stream)
+				}
+			}
+			results <- r
+		}()
+	}
+
+	send(h.primary, true)
+	pending := 1
+	var timer <-chan time.Time
+	delay := h.Delay(true)
+	if Hedged(req) {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	// discard cancels and settles a stream that lost.
+	discard := func(r *hedgeStream) {
+		r.cancel()
+		if r.err == nil {
+			go drain(r.stream)
+			r.err = context.Canceled
+		}
+		h.settle(req, hedgeResult{target: r.target, err: r.err}, false)
+	}
+
+	var attempts []Attempt
+	for pending > 0 {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				// Cancel the other request now rather than when it responds.
+				for _, other := range sent {
+					if other != r {
+						other.cancel()
+					}
+				}
+				if pending > 0 {
+					go func() { discard(<-results) }()
+				}
+				return h.forward(req, r), nil
+			}
+			h.settle(req, hedgeResult{target: r.target, err: r.err}, false)
+			attempts = append(attempts, Attempt{Target: r.target.Target, Err: r.err})
+			if timer != nil && ctx.Err() == nil && retryable(r.err) {
+				timer = nil
+				h.sendHedge(send, 0)
+				pending++
+			}
+		case <-timer:
+			timer = nil
+			h.sendHedge(send, delay)
+			pending++
+		case <-ctx.Done():
+			for ; pending > 0; pending-- {
+				go func() { discard(<-results) }()
+			}
+			return nil, ctx.Err()
+		}
+	}
+
+	if len(attempts) == 1 {
+		return nil, attempts[0].Err
+	}
+	return nil, &FallbackError{Attempts: attempts}
+}
+
+// forward returns the winning stream, recording its usage when it ends.
+func (h *Hedger) forward(req *types.ChatRequest, r *hedgeStream) <-chan types.StreamChunk {
+	out := make(chan types.StreamChunk)
+	go func() {
+		defer close(out)
+		defer r.cancel()
+
+		var usage *types.Usage
+		var streamErr error
+		defer func() {
+			if streamErr != nil {
+				h.recordError(r.target, r.model, streamErr)
+			}
+			h.settle(req, hedgeResult{target: r.target, resp: &types.ChatResponse{Usage: usage}, err: streamErr}, true)
+		}()
+
+		chunk, ok := r.first, r.ok
+		for ok {
+			if ec, isErr := chunk.(*types.ErrorChunk); isErr {
+				streamErr = ec.Err
+			} else if uc, isUsage := chunk.(types.UsageChunk); isUsage && uc.GetUsage() != nil {
+				usage = uc.GetUsage()
+			}
+			select {
+			case out <- chunk:
+			case <-r.ctx.Done():
+				streamErr = r.ctx.Err()
+				go drain(r.stream)
+				return
+			}
+			chunk, ok = <-r.stream
+		}
+		if usage != nil {
+			h.recordResponse(r.target, r.model, time.Since(r.start), usage)
+		}
+	}()
+	return out
+}
+
+// sendHedge sends the hedge request.
+func (h *Hedger) sendHedge(send func(t *routeTarget, primary bool), delay time.Duration) {
+	if h.config.OnHedge != nil {
+		h.config.OnHedge(h.hedge.Target, delay)
+	}
+	send(h.hedge, false)
+}
+
+// lost reports whether a request's ctx was canceled by the hedger rather
+// than through parent, the caller's context.
+func lost(ctx, parent context.Context) bool {
+	return ctx.Err() != nil && parent.Err() == nil
+}
+
+// settle reports the usage of a finished request to OnUsage. Requests that
+// failed without usage are charged their estimated prompt tokens, since
+// providers may bill for work done before cancellation.
+func (h *Hedger) settle(req *types.ChatRequest, r hedgeResult, won bool) {
+	if r.err != nil && !won && !errors.Is(r.err, context.Canceled) {
+		h.recordError(r.target, r.target.request(req).Model, r.err)
+	}
+	if h.config.OnUsage == nil {
+		return
+	}
+	if r.resp != nil && r.resp.Usage != nil {
+		h.config.OnUsage(r.target.Target, r.resp.Usage, false)
+		return
+	}
+	if r.err != nil && errors.Is(r.err, context.Canceled) && h.config.TokenCounter != nil {
+		estimate := h.config.TokenCounter.EstimateRequestTokens(r.target.request(req))
+		if estimate != nil {
+			h.config.OnUsage(r.target.Target, &types.Usage{
+				PromptTokens: estimate.PromptTokens,
+				TotalTokens:  estimate.PromptTokens,
+			}, true)
+		}
+	}
+}
+
+func (h *Hedger) recordRequest(t *routeTarget, model string) {
+	if h.config.Metrics != nil {
+		h.config.Metrics.RecordRequest(t.Provider.Name(), model)
+	}
+}
+
+func (h *Hedger) recordResponse(t *routeTarget, model string, d time.Duration, usage *types.Usage) {
+	if h.config.Metrics == nil {
+		return
+	}
+	tokens := 0
+	if usage != nil {
+		tokens = usage.TotalTokens
+		h.config.Metrics.RecordTokenUsage(t.Provider.Name(), model, usage.PromptTokens, usage.CompletionTokens)
+	}
+	h.config.Metrics.RecordResponse(t.Provider.Name(), model, d, tokens)
+}
+
+func (h *Hedger) recordError(t *routeTarget, model string, err error) {
+	if h.config.Metrics == nil {
+		return
+	}
+	errType := types.ErrorTypeUnknown
+	var aiErr types.AIError
+	if errors.As(err, &aiErr) {
+		errType = aiErr.Type()
+	}
+	h.config.Metrics.RecordError(t.Provider.Name(), model, errType)
+}
+
+// latencyWindow keeps the most recent latencies in a ring buffer.
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+func newLatencyWindow(size int) *latencyWindow {
+	return &latencyWindow{samples: make([]time.Duration, size)}
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.samples[w.next] = d
+	w.next++
+	if w.next == len(w.samples) {
+		w.next = 0
+		w.full = true
+	}
+}
+
+// percentile returns the p-th percentile, and false with fewer than minSamples samples.
+func (w *latencyWindow) percentile(p float64, minSamples int) (time.Duration, bool) {
+	w.mu.Lock()
+	n := w.next
+	if w.full {
+		n = len(w.samples)
+	}
+	if n == 0 || n < minSamples {
+		w.mu.Unlock()
+		return 0, false
+	}
+	sorted := append([]time.Duration(nil), w.samples[:n]...)
+	w.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[int(p*float64(n-1))], true
+}
@@ -0,0 +1,163 @@
+package routing
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+func hedgedRequest() *types.ChatRequest {
+	return &types.ChatRequest{
+		Model:    "m",
+		Metadata: &types.RequestMetadata{Custom: map[string]interface{}{MetadataKeyHedge: true}},
+	}
+}
+
+func TestHedgerSelection(t *testing.T) {
+	unavailable := &types.ProviderError{ErrorType: types.ErrorTypeServer, Message: "unavailable", HTTPStatus: http.StatusServiceUnavailable, IsRetryable: true}
+
+	tests := []struct {
+		name         string
+		primary      func(context.Context, *types.ChatRequest) (*types.ChatResponse, error)
+		hedge        func(context.Context, *types.ChatRequest) (*types.ChatResponse, error)
+		req          *types.ChatRequest
+		wantProvider types.Provider
+		wantHedges   int32
+		wantErr      bool
+	}{
+		{
+			name:         "fast primary is not hedged",
+			primary:      respondAfter(0),
+			hedge:        respondAfter(0),
+			req:          hedgedRequest(),
+			wantProvider: "primary",
+		},
+		{
+			name:         "slow primary loses to the hedge",
+			primary:      respondAfter(time.Second),
+			hedge:        respondAfter(0),
+			req:          hedgedRequest(),
+			wantProvider: "hedge",
+			wantHedges:   1,
+		},
+		{
+			name:         "requests without the hedge key are not hedged",
+			primary:      respondAfter(50 * time.Millisecond),
+			hedge:        respondAfter(0),
+			req:          &types.ChatRequest{Model: "m"},
+			wantProvider: "primary",
+		},
+		{
+			name:         "retryable failure hedges at once",
+			primary:      failWith(unavailable),
+			hedge:        respondAfter(0),
+			req:          hedgedRequest(),
+			wantProvider: "hedge",
+			wantHedges:   1,
+		},
+		{
+			name:       "both failing returns an error",
+			primary:    failWith(unavailable),
+			hedge:      failWith(unavailable),
+			req:        hedgedRequest(),
+			wantHedges: 1,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hedges atomic.Int32
+			h := NewHedger([]Target{
+				{Provider: &fakeProvider{name: "primary", complete: tt.primary}},
+				{Provider: &fakeProvider{name: "hedge", complete: tt.hedge}},
+			}, &HedgeConfig{
+				Delay:   10 * time.Millisecond,
+				OnHedge: func(Target, time.Duration) { hedges.Add(1) },
+			})
+
+			resp, err := h.CreateCompletion(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateCompletion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && resp.Metadata.Provider != tt.wantProvider {
+				t.Errorf("served by %s, want %s", resp.Metadata.Provider, tt.wantProvider)
+			}
+			if got := hedges.Load(); got != tt.wantHedges {
+				t.Errorf("sent %d hedges, want %d", got, tt.wantHedges)
+			}
+		})
+	}
+}
+
+// TestHedgerDelayKeepsLosingPrimaries checks that a primary canceled because
+// its hedge won still counts towards the delay, so winning hedges do not
+// drag the percentile down.
+func TestHedgerDelayKeepsLosingPrimaries(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream bool
+	}{
+		{name: "completion"},
+		{name: "stream", stream: true},
+	}
+
+	chunk := &types.ChatStreamChunk{Choices: []*types.StreamChoice{{Delta: &types.MessageDelta{Content: "hi"}}}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slowStream := func(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+				ch := make(chan types.StreamChunk)
+				go func() {
+					defer close(ch)
+					select {
+					case <-time.After(time.Second):
+						ch <- chunk
+					case <-ctx.Done():
+					}
+				}()
+				return ch, nil
+			}
+			h := NewHedger([]Target{
+				{Provider: &fakeProvider{name: "primary", complete: respondAfter(time.Second), stream: slowStream}},
+				{Provider: &fakeProvider{name: "hedge", complete: respondAfter(0), stream: streamOf(chunk)}},
+			}, &HedgeConfig{Delay: 20 * time.Millisecond, MinSamples: 1})
+
+			for i := 0; i < 5; i++ {
+				if tt.stream {
+					stream, err := h.CreateCompletionStream(context.Background(), hedgedRequest())
+					if err != nil {
+						t.Fatalf("CreateCompletionStream() error = %v", err)
+					}
+					for range stream {
+					}
+				} else if _, err := h.CreateCompletion(context.Background(), hedgedRequest()); err != nil {
+					t.Fatalf("CreateCompletion() error = %v", err)
+				}
+				// The losing primary is sampled once it returns.
+				w := h.completion
+				if tt.stream {
+					w = h.stream
+				}
+				for deadline := time.Now().Add(time.Second); windowLen(w) <= i && time.Now().Before(deadline); {
+					time.Sleep(time.Millisecond)
+				}
+			}
+			if got := h.Delay(tt.stream); got < 20*time.Millisecond {
+				t.Errorf("Delay() = %v, want at least the 20ms the primaries ran", got)
+			}
+		})
+	}
+}
+
+func windowLen(w *latencyWindow) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.full {
+		return len(w.samples)
+	}
+	return w.next
+}
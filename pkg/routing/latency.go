@@ -0,0 +1,88 @@
+package routing
+
+import (
+	"sync"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// DefaultEWMAAlpha is the weight of the newest sample in a LatencyTracker.
+const DefaultEWMAAlpha = 0.3
+
+// LatencyTracker is an interfaces.MetricsCollector that keeps an
+// exponentially weighted moving average of response latency per provider
+// and model. Only RecordResponse is used; the other methods are no-ops, so a
+// tracker can be added wherever a MetricsCollector is accepted and shared
+// with a Balancer.
+type LatencyTracker struct {
+	alpha float64
+
+	mu    sync.RWMutex
+	stats map[latencyKey]*latencyStat
+}
+
+type latencyKey struct {
+	provider types.Provider
+	model    string
+}
+
+type latencyStat struct {
+	ewma    float64 // nanoseconds
+	samples int
+}
+
+// NewLatencyTracker creates a tracker. An alpha outside (0, 1] uses
+// DefaultEWMAAlpha.
+func NewLatencyTracker(alpha float64) *LatencyTracker {
+	if alpha <= 0 || alpha > 1 {
+		alpha = DefaultEWMAAlpha
+	}
+	return &LatencyTracker{alpha: alpha, stats: make(map[latencyKey]*latencyStat)}
+}
+
+// Latency returns the average latency for a provider and model, and false
+// if no response has been recorded.
+func (t *LatencyTracker) Latency(provider types.Provider, model string) (time.Duration, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	s, ok := t.stats[latencyKey{provider, model}]
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(s.ewma), true
+}
+
+// RecordResponse implements interfaces.MetricsCollector.
+func (t *LatencyTracker) RecordResponse(provider types.Provider, model string, duration time.Duration, tokens int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := latencyKey{provider, model}
+	s, ok := t.stats[key]
+	if !ok {
+		t.stats[key] = &latencyStat{ewma: float64(duration), samples: 1}
+		return
+	}
+	s.ewma = t.alpha*float64(duration) + (1-t.alpha)*s.ewma
+	s.samples++
+}
+
+// RecordRequest implements interfaces.MetricsCollector.
+func (t *LatencyTracker) RecordRequest(provider types.Provider, model string) {}
+
+// RecordError implements interfaces.MetricsCollector.
+func (t *LatencyTracker) RecordError(provider types.Provider, model string, errorType types.ErrorType) {
+}
+
+// RecordTokenUsage implements interfaces.MetricsCollector.
+func (t *LatencyTracker) RecordTokenUsage(provider types.Provider, model string, promptTokens, completionTokens int) {
+}
+
+// RecordCacheHit implements interfaces.MetricsCollector.
+func (t *LatencyTracker) RecordCacheHit(provider types.Provider, model string) {}
+
+// RecordCacheMiss implements interfaces.MetricsCollector.
+func (t *LatencyTracker) RecordCacheMiss(provider types.Provider, model string) {}
+
+// RecordRetry implements interfaces.MetricsCollector.
+func (t *LatencyTracker) RecordRetry(provider types.Provider, model string, attempt int) {}
@@ -0,0 +1,311 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/middleware"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+var (
+	// ErrNoTargets is returned by a Router with no targets.
+	ErrNoTargets = errors.New("routing: no targets configured")
+
+	// ErrNoCapableTarget is wrapped by the error returned when every target
+	// was skipped for lacking a required capability.
+	ErrNoCapableTarget = errors.New("routing: no target supports the request")
+)
+
+// Target is a provider and model a Router can send requests to.
+type Target struct {
+	// Provider serves the requests and is recorded in ResponseMetadata.Provider.
+	Provider interfaces.Provider
+
+	// Model replaces ChatRequest.Model. If empty, the request's model is kept.
+	Model string
+
+	// Capabilities are the capabilities of Model on Provider.
+	// If nil, Provider.Capabilities() is used.
+	Capabilities []types.ModelCapability
+}
+
+// String returns "provider/model".
+func (t Target) String() string {
+	if t.Model == "" {
+		return string(t.Provider.Name())
+	}
+	return string(t.Provider.Name()) + "/" + t.Model
+}
+
+// RouterConfig configures a Router.
+type RouterConfig struct {
+	// CircuitBreaker, if set, gives each target its own circuit breaker with
+	// this configuration. Targets whose circuit is open are skipped.
+	CircuitBreaker *interfaces.CircuitBreakerConfig
+
+	// ShouldFallback decides whether an error moves on to the next target.
+	// Default falls over on retryable AIErrors, which include errors from
+	// an open circuit.
+	ShouldFallback func(err error) bool
+
+	// OnFallback, if set, is called when a target fails and the next one is tried.
+	OnFallback func(target Target, err error)
+
+	// OnServe, if set, is called with the target that served a request,
+	// before the response or stream is returned. Stream chunks do not carry
+	// ResponseMetadata, so this is the only way to learn which provider
+	// served a stream.
+	OnServe func(target Target)
+}
+
+// Attempt records what happened to one target.
+type Attempt struct {
+	// Target is the target tried or skipped.
+	Target Target
+
+	// Skipped is set when the target was not called because it lacked a
+	// required capability.
+	Skipped bool
+
+	// Err is the error the target returned, or why it was skipped.
+	Err error
+}
+
+// FallbackError is returned when no target served the request.
+type FallbackError struct {
+	// Attempts lists every target in order.
+	Attempts []Attempt
+}
+
+// Error implements the error interface.
+func (e *FallbackError) Error() string {
+	parts := make([]string, 0, len(e.Attempts))
+	for _, a := range e.Attempts {
+		parts = append(parts, fmt.Sprintf("%s: %v", a.Target, a.Err))
+	}
+	return "routing: no target served the request: " + strings.Join(parts, "; ")
+}
+
+// Unwrap returns every attempt's error, so errors.Is and errors.As match
+// any of them.
+func (e *FallbackError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Attempts))
+	for _, a := range e.Attempts {
+		errs = append(errs, a.Err)
+	}
+	return errs
+}
+
+// Router is a ChatService that tries an ordered list of targets, falling
+// over to the next target when one fails with a retryable error or has an
+// open circuit. Targets lacking a capability the request needs (see
+// RequiredCapabilities) are skipped.
+//
+// Streaming requests fall over while the stream is being established and
+// when the first chunk is an error; once a chunk has been delivered, errors
+// are passed through. The chunks do not say which target served them; use
+// RouterConfig.OnServe to find out.
+type Router struct {
+	targets []*routeTarget
+	config  RouterConfig
+}
+
+// routeTarget is a Target with its circuit-breaker-wrapped service.
+type routeTarget struct {
+	Target
+	service interfaces.ChatService
+}
+
+// NewRouter creates a router over targets, tried in order. A nil config
+// uses defaults.
+func NewRouter(targets []Target, config *RouterConfig) *Router {
+	cfg := RouterConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.ShouldFallback == nil {
+		cfg.ShouldFallback = retryable
+	}
+
+	r := &Router{config: cfg}
+	for _, t := range targets {
+		service := t.Provider.ChatService()
+		if service == nil {
+			continue
+		}
+		if cfg.CircuitBreaker != nil {
+			service = middleware.Apply(service, middleware.NewCircuitBreaker(cfg.CircuitBreaker))
+		}
+		r.targets = append(r.targets, &routeTarget{Target: t, service: service})
+	}
+	return r
+}
+
+// CreateCompletion implements interfaces.ChatService. The response's
+// Metadata.Provider names the provider that served it.
+func (r *Router) CreateCompletion(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+	var resp *types.ChatResponse
+	target, err := r.try(ctx, RequiredCapabilities(req), func(t *routeTarget) error {
+		var err error
+		resp, err = t.service.CreateCompletion(ctx, t.request(req))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.served(target)
+	if resp.Metadata == nil {
+		resp.Metadata = &types.ResponseMetadata{ID: resp.ID, Created: resp.Created, Model: resp.Model}
+	}
+	resp.Metadata.Provider = target.Provider.Name()
+	return resp, nil
+}
+
+// CreateCompletionStream implements interfaces.ChatService. The serving
+// target is reported to RouterConfig.OnServe.
+func (r *Router) CreateCompletionStream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	required := append(RequiredCapabilities(req), types.CapabilityStreaming)
+
+	var stream <-chan types.StreamChunk
+	target, err := r.try(ctx, required, func(t *routeTarget) error {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		s, err := t.service.CreateCompletionStream(attemptCtx, t.request(req))
+		if err != nil {
+			cancel()
+			return err
+		}
+
+		var first types.StreamChunk
+		var ok bool
+		select {
+		case first, ok = <-s:
+		case <-ctx.Done():
+			cancel()
+			go drain(s)
+			return ctx.Err()
+		}
+		if ec, isErr := first.(*types.ErrorChunk); ok && isErr && r.config.ShouldFallback(ec.Err) {
+			cancel()
+			go drain(s)
+			return ec.Err
+		}
+		stream = prepend(attemptCtx, cancel, first, ok, s)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.served(target)
+	return stream, nil
+}
+
+// served reports the target that served a request to OnServe.
+func (r *Router) served(t *routeTarget) {
+	if r.config.OnServe != nil {
+		r.config.OnServe(t.Target)
+	}
+}
+
+// try calls fn for each eligible target until one succeeds or fails with an
+// error that should not fall over.
+func (r *Router) try(ctx context.Context, required []types.ModelCapability, fn func(*routeTarget) error) (*routeTarget, error) {
+	if len(r.targets) == 0 {
+		return nil, ErrNoTargets
+	}
+
+	attempts := make([]Attempt, 0, len(r.targets))
+	called := false
+	for i, t := range r.targets {
+		supported := t.Capabilities
+		if supported == nil {
+			supported = t.Provider.Capabilities()
+		}
+		if c, missing := missingCapability(required, supported); missing {
+			attempts = append(attempts, Attempt{
+				Target:  t.Target,
+				Skipped: true,
+				Err:     fmt.Errorf("%w: %s lacks %s", ErrNoCapableTarget, t.Target, c),
+			})
+			continue
+		}
+
+		called = true
+		err := fn(t)
+		if err == nil {
+			return t, nil
+		}
+		attempts = append(attempts, Attempt{Target: t.Target, Err: err})
+		if ctx.Err() != nil || !r.config.ShouldFallback(err) {
+			return nil, err
+		}
+		if r.config.OnFallback != nil && i < len(r.targets)-1 {
+			r.config.OnFallback(t.Target, err)
+		}
+	}
+
+	if !called {
+		fallbackErr := &FallbackError{Attempts: attempts}
+		return nil, &types.ProviderError{
+			ErrorType:  types.ErrorTypeInvalidRequest,
+			Message:    fallbackErr.Error(),
+			HTTPStatus: http.StatusBadRequest,
+			InnerError: fallbackErr,
+		}
+	}
+	return nil, &FallbackError{Attempts: attempts}
+}
+
+// request returns req with the target's model.
+func (t *routeTarget) request(req *types.ChatRequest) *types.ChatRequest {
+	if t.Model == "" || t.Model == req.Model {
+		return req
+	}
+	routed := *req
+	routed.Model = t.Model
+	return &routed
+}
+
+// retryable reports whether err is a retryable AIError.
+func retryable(err error) bool {
+	var aiErr types.AIError
+	return errors.As(err, &aiErr) && aiErr.Retryable()
+}
+
+// prepend returns a stream yielding first (if ok) followed by the rest of
+// src. cancel is called when the stream ends.
+func prepend(ctx context.Context, cancel context.CancelFunc, first types.StreamChunk, ok bool, src <-chan types.StreamChunk) <-chan types.StreamChunk {
+	out := make(chan types.StreamChunk)
+	go func() {
+		defer close(out)
+		defer cancel()
+		if !ok {
+			return
+		}
+		select {
+		case out <- first:
+		case <-ctx.Done():
+			go drain(src)
+			return
+		}
+		for chunk := range src {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				go drain(src)
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// drain discards the remaining chunks of a stream.
+func drain(stream <-chan types.StreamChunk) {
+	for range stream {
+	}
+}
@@ -0,0 +1,120 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// failStream returns a stream func that fails at once with err.
+func failStream(err error) func(context.Context, *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	return func(context.Context, *types.ChatRequest) (<-chan types.StreamChunk, error) {
+		return nil, err
+	}
+}
+
+func TestRouterStreamOnServe(t *testing.T) {
+	unavailable := &types.ProviderError{ErrorType: types.ErrorTypeServer, Message: "unavailable", HTTPStatus: http.StatusServiceUnavailable, IsRetryable: true}
+	invalid := &types.ProviderError{ErrorType: types.ErrorTypeInvalidRequest, Message: "bad", HTTPStatus: http.StatusBadRequest}
+	content := &types.ChatStreamChunk{Choices: []*types.StreamChoice{{Delta: &types.MessageDelta{Content: "hi"}}}}
+
+	tests := []struct {
+		name      string
+		primary   func(context.Context, *types.ChatRequest) (<-chan types.StreamChunk, error)
+		secondary func(context.Context, *types.ChatRequest) (<-chan types.StreamChunk, error)
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "primary serves",
+			primary:   streamOf(content),
+			secondary: streamOf(content),
+			want:      "primary/a",
+		},
+		{
+			name:      "failure to open falls over",
+			primary:   failStream(unavailable),
+			secondary: streamOf(content),
+			want:      "secondary/b",
+		},
+		{
+			name:      "error as the first chunk falls over",
+			primary:   streamOf(types.NewErrorChunk(unavailable)),
+			secondary: streamOf(content),
+			want:      "secondary/b",
+		},
+		{
+			name:      "non-retryable error is not served",
+			primary:   failStream(invalid),
+			secondary: streamOf(content),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var served []string
+			streaming := []types.ModelCapability{types.CapabilityStreaming}
+			r := NewRouter([]Target{
+				{Provider: &fakeProvider{name: "primary", stream: tt.primary}, Model: "a", Capabilities: streaming},
+				{Provider: &fakeProvider{name: "secondary", stream: tt.secondary}, Model: "b", Capabilities: streaming},
+			}, &RouterConfig{OnServe: func(target Target) { served = append(served, target.String()) }})
+
+			stream, err := r.CreateCompletionStream(context.Background(), &types.ChatRequest{Model: "m"})
+			if tt.wantErr {
+				if err == nil || len(served) != 0 {
+					t.Errorf("CreateCompletionStream() error = %v, served = %v, want an error and no OnServe call", err, served)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateCompletionStream() error = %v", err)
+			}
+			if len(served) != 1 || served[0] != tt.want {
+				t.Errorf("OnServe targets = %v, want [%s]", served, tt.want)
+			}
+
+			var got []string
+			for chunk := range stream {
+				if ec, ok := chunk.(*types.ErrorChunk); ok {
+					t.Fatalf("stream error = %v", ec.Err)
+				}
+				got = append(got, chunk.(*types.ChatStreamChunk).Choices[0].Delta.Content)
+			}
+			if len(got) != 1 || got[0] != "hi" {
+				t.Errorf("stream = %v, want [hi]", got)
+			}
+		})
+	}
+}
+
+func TestRouterCompletionOnServe(t *testing.T) {
+	unavailable := &types.ProviderError{ErrorType: types.ErrorTypeServer, Message: "unavailable", HTTPStatus: http.StatusServiceUnavailable, IsRetryable: true}
+
+	var served []Target
+	var fellBack []error
+	r := NewRouter([]Target{
+		{Provider: &fakeProvider{name: "primary", complete: failWith(unavailable)}},
+		{Provider: &fakeProvider{name: "secondary", complete: respondAfter(0)}},
+	}, &RouterConfig{
+		OnServe:    func(target Target) { served = append(served, target) },
+		OnFallback: func(_ Target, err error) { fellBack = append(fellBack, err) },
+	})
+
+	resp, err := r.CreateCompletion(context.Background(), &types.ChatRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("CreateCompletion() error = %v", err)
+	}
+	if resp.Metadata == nil || resp.Metadata.Provider != "secondary" {
+		t.Errorf("Metadata = %+v, want provider secondary", resp.Metadata)
+	}
+	if len(served) != 1 || served[0].Provider.Name() != "secondary" {
+		t.Errorf("OnServe targets = %v, want secondary", served)
+	}
+	if len(fellBack) != 1 || !errors.Is(fellBack[0], unavailable) {
+		t.Errorf("OnFallback errors = %v, want [%v]", fellBack, unavailable)
+	}
+}
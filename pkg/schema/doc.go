@@ -0,0 +1,33 @@
+// Package schema generates types.JSONSchema values from Go types.
+//
+// Schemas are derived by reflection, following encoding/json conventions so
+// the schema matches what json.Marshal produces for the same type:
+//
+//   - Field names come from `json` tags; fields tagged "-" and unexported
+//     fields are skipped, and embedded structs are flattened.
+//   - Fields without "omitempty" are required.
+//   - Pointers are nullable (anyOf with {"type": "null"}).
+//   - time.Time is a string with format "date-time".
+//   - Slices and arrays are arrays; maps with string keys are objects with
+//     additionalProperties.
+//   - Recursive types are emitted once under $defs and referenced with $ref.
+//
+// Field descriptions and enums are read from the `description` and `enum`
+// struct tags. Enum values are comma-separated and converted to the field's type:
+//
+//	type SearchArgs struct {
+//	    Query string   `json:"query" description:"Full-text search query"`
+//	    Sort  string   `json:"sort,omitempty" enum:"relevance,date"`
+//	    Limit int      `json:"limit,omitempty" description:"Maximum results"`
+//	    Tags  []string `json:"tags,omitempty"`
+//	}
+//
+//	params, err := schema.For[SearchArgs](nil)
+//
+// Strict mode produces schemas compatible with OpenAI structured outputs:
+// every property is required, optional (omitempty) fields become nullable
+// instead, and every object sets additionalProperties to false. Maps cannot
+// be expressed in strict mode and produce an error.
+//
+//	strictSchema, err := schema.For[SearchArgs](&schema.Options{Strict: true})
+package schema
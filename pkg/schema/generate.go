@@ -0,0 +1,509 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Struct tags read by the generator.
+const (
+	// TagDescription is the struct tag holding a field description.
+	TagDescription = "description"
+
+	// TagEnum is the struct tag holding comma-separated allowed values.
+	TagEnum = "enum"
+)
+
+// ErrUnsupportedType is returned when a Go type cannot be expressed as a JSON
+// Schema (e.g., channels and functions), or cannot be expressed in strict mode
+// (e.g., maps and interface values).
+var ErrUnsupportedType = errors.New("schema: unsupported type")
+
+// Options configures schema generation.
+type Options struct {
+	// Strict produces schemas compatible with OpenAI structured outputs:
+	// all properties are required, optional fields are nullable, and
+	// additionalProperties is false on every object.
+	Strict bool
+}
+
+// For generates a schema for the type T.
+//
+// Example:
+//
+//	params, err := schema.For[WeatherArgs](nil)
+//	def := types.NewFunctionDefinition("get_weather", "Get the weather", params)
+func For[T any](opts *Options) (*types.JSONSchema, error) {
+	return FromType(reflect.TypeOf((*T)(nil)).Elem(), opts)
+}
+
+// MustFor is like For but panics on error.
+// It is intended for initializing package-level schema variables.
+func MustFor[T any](opts *Options) *types.JSONSchema {
+	s, err := For[T](opts)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Generate generates a schema for the dynamic type of v.
+func Generate(v interface{}, opts *Options) (*types.JSONSchema, error) {
+	if v == nil {
+		return nil, fmt.Errorf("%w: nil value", ErrUnsupportedType)
+	}
+	return FromType(reflect.TypeOf(v), opts)
+}
+
+// FromType generates a schema for the given reflect.Type.
+//
+// A pointer at the root is dereferenced rather than made nullable. In strict
+// mode, the root type must be a struct.
+func FromType(t reflect.Type, opts *Options) (*types.JSONSchema, error) {
+	if t == nil {
+		return nil, fmt.Errorf("%w: nil type", ErrUnsupportedType)
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	g := &generator{
+		visiting:  make(map[reflect.Type]bool),
+		recursive: make(map[reflect.Type]bool),
+		names:     make(map[reflect.Type]string),
+		defs:      make(map[string]*types.JSONSchema),
+		root:      t,
+	}
+	if opts != nil {
+		g.opts = *opts
+	}
+
+	if g.opts.Strict && t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: strict mode requires a struct at the root, got %s", ErrUnsupportedType, t)
+	}
+
+	s, err := g.schemaFor(t)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.defs) > 0 {
+		s.Defs = g.defs
+	}
+	return s, nil
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	byteSliceType  = reflect.TypeOf([]byte{})
+)
+
+// generator holds the state of a single schema generation.
+type generator struct {
+	opts Options
+
+	// visiting tracks struct types currently being generated, to detect recursion.
+	visiting map[reflect.Type]bool
+
+	// recursive marks struct types that reference themselves and must be
+	// emitted under $defs.
+	recursive map[reflect.Type]bool
+
+	names map[reflect.Type]string
+	defs  map[string]*types.JSONSchema
+	root  reflect.Type
+}
+
+// schemaFor returns the schema for t.
+func (g *generator) schemaFor(t reflect.Type) (*types.JSONSchema, error) {
+	switch t {
+	case timeType:
+		return &types.JSONSchema{Type: "string", Format: "date-time"}, nil
+	case rawMessageType:
+		if g.opts.Strict {
+			return nil, fmt.Errorf("%w: json.RawMessage in strict mode", ErrUnsupportedType)
+		}
+		return &types.JSONSchema{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &types.JSONSchema{Type: "boolean"}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &types.JSONSchema{Type: "integer"}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return &types.JSONSchema{Type: "number"}, nil
+
+	case reflect.String:
+		return &types.JSONSchema{Type: "string"}, nil
+
+	case reflect.Interface:
+		if g.opts.Strict {
+			return nil, fmt.Errorf("%w: interface type %s in strict mode", ErrUnsupportedType, t)
+		}
+		return &types.JSONSchema{}, nil
+
+	case reflect.Ptr:
+		elem, err := g.schemaFor(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return nullable(elem), nil
+
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 && t.ConvertibleTo(byteSliceType) {
+			// encoding/json encodes []byte as a base64 string.
+			return &types.JSONSchema{Type: "string"}, nil
+		}
+		items, err := g.schemaFor(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &types.JSONSchema{Type: "array", Items: items}, nil
+
+	case reflect.Map:
+		if g.opts.Strict {
+			return nil, fmt.Errorf("%w: map type %s in strict mode", ErrUnsupportedType, t)
+		}
+		switch t.Key().Kind() {
+		case reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return nil, fmt.Errorf("%w: map key type %s", ErrUnsupportedType, t.Key())
+		}
+		values, err := g.schemaFor(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &types.JSONSchema{Type: "object", AdditionalProperties: values}, nil
+
+	case reflect.Struct:
+		return g.structSchema(t)
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+	}
+}
+
+// structSchema returns the schema for a struct type, handling recursion.
+func (g *generator) structSchema(t reflect.Type) (*types.JSONSchema, error) {
+	if g.visiting[t] {
+		if t == g.root {
+			return &types.JSONSchema{Ref: "#"}, nil
+		}
+		g.recursive[t] = true
+		return &types.JSONSchema{Ref: "#/$defs/" + g.name(t)}, nil
+	}
+
+	g.visiting[t] = true
+	defer delete(g.visiting, t)
+
+	s := &types.JSONSchema{
+		Type:       "object",
+		Properties: make(map[string]*types.JSONSchema),
+	}
+	if g.opts.Strict {
+		s.AdditionalProperties = false
+	}
+
+	for _, f := range structFields(t) {
+		prop, err := g.fieldSchema(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t, f.field.Name, err)
+		}
+		s.Properties[f.name] = prop
+		if g.opts.Strict || !f.optional {
+			s.Required = append(s.Required, f.name)
+		}
+	}
+
+	if g.recursive[t] && t != g.root {
+		name := g.name(t)
+		g.defs[name] = s
+		return &types.JSONSchema{Ref: "#/$defs/" + name}, nil
+	}
+	return s, nil
+}
+
+// fieldSchema returns the schema for a struct field, applying tags and
+// strict-mode nullability.
+func (g *generator) fieldSchema(f field) (*types.JSONSchema, error) {
+	t := f.field.Type
+
+	var s *types.JSONSchema
+	if f.asString {
+		s = &types.JSONSchema{Type: "string"}
+		if t.Kind() == reflect.Ptr {
+			s = nullable(s)
+		}
+	} else {
+		var err error
+		if s, err = g.schemaFor(t); err != nil {
+			return nil, err
+		}
+	}
+
+	if enum := f.field.Tag.Get(TagEnum); enum != "" {
+		values, err := parseEnum(enum, t)
+		if err != nil {
+			return nil, err
+		}
+		target := s
+		if len(target.AnyOf) > 0 {
+			target = target.AnyOf[0]
+		}
+		if target.Type == "array" && target.Items != nil {
+			target = target.Items
+		}
+		target.Enum = values
+	}
+
+	if g.opts.Strict && f.optional && !isNullable(s) {
+		s = nullable(s)
+	}
+
+	if desc := f.field.Tag.Get(TagDescription); desc != "" {
+		if s.Ref != "" {
+			// Keywords alongside $ref are ignored by some validators; wrap instead.
+			s = &types.JSONSchema{AnyOf: []*types.JSONSchema{s}}
+		}
+		s.Description = desc
+	}
+	return s, nil
+}
+
+// name returns a stable, unique $defs name for t.
+func (g *generator) name(t reflect.Type) string {
+	if n, ok := g.names[t]; ok {
+		return n
+	}
+
+	base := t.Name()
+	if base == "" {
+		base = "Anonymous"
+	}
+	base = strings.Map(func(r rune) rune {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, base)
+
+	n := base
+	for i := 2; g.nameTaken(n); i++ {
+		n = base + "_" + strconv.Itoa(i)
+	}
+	g.names[t] = n
+	return n
+}
+
+// nameTaken reports whether a $defs name is already assigned.
+func (g *generator) nameTaken(n string) bool {
+	for _, existing := range g.names {
+		if existing == n {
+			return true
+		}
+	}
+	return false
+}
+
+// nullable wraps s so that null is also accepted.
+func nullable(s *types.JSONSchema) *types.JSONSchema {
+	if isNullable(s) {
+		return s
+	}
+	return &types.JSONSchema{AnyOf: []*types.JSONSchema{s, {Type: "null"}}}
+}
+
+// isNullable reports whether s already accepts null.
+func isNullable(s *types.JSONSchema) bool {
+	for _, alt := range s.AnyOf {
+		if alt.Type == "null" {
+			return true
+		}
+	}
+	return s.Type == "null"
+}
+
+// parseEnum converts a comma-separated enum tag into values of the field's kind.
+func parseEnum(tag string, t reflect.Type) ([]interface{}, error) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+
+	parts := strings.Split(tag, ",")
+	values := make([]interface{}, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		switch t.Kind() {
+		case reflect.String:
+			values = append(values, p)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseInt(p, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("schema: invalid integer enum value %q", p)
+			}
+			values = append(values, n)
+		case reflect.Float32, reflect.Float64:
+			n, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return nil, fmt.Errorf("schema: invalid number enum value %q", p)
+			}
+			values = append(values, n)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(p)
+			if err != nil {
+				return nil, fmt.Errorf("schema: invalid boolean enum value %q", p)
+			}
+			values = append(values, b)
+		default:
+			return nil, fmt.Errorf("%w: enum tag on %s", ErrUnsupportedType, t)
+		}
+	}
+	return values, nil
+}
+
+// field is a JSON-visible struct field.
+type field struct {
+	field    reflect.StructField
+	name     string
+	optional bool
+	asString bool
+	tagged   bool
+	depth    int
+}
+
+// structFields returns the JSON-visible fields of t in declaration order,
+// flattening embedded structs and resolving name conflicts like encoding/json:
+// the shallowest field wins, and among fields at the same depth a tagged field
+// wins; otherwise all conflicting fields are dropped.
+func structFields(t reflect.Type) []field {
+	var all []field
+	collectFields(t, 0, map[reflect.Type]bool{}, &all)
+
+	byName := make(map[string][]int)
+	for i, f := range all {
+		byName[f.name] = append(byName[f.name], i)
+	}
+
+	result := make([]field, 0, len(all))
+	for i, f := range all {
+		idx := byName[f.name]
+		if len(idx) == 1 {
+			result = append(result, f)
+			continue
+		}
+		if dominant(all, idx) == i {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// dominant returns the index of the winning field among candidates, or -1.
+func dominant(all []field, candidates []int) int {
+	minDepth := all[candidates[0]].depth
+	for _, i := range candidates {
+		if all[i].depth < minDepth {
+			minDepth = all[i].depth
+		}
+	}
+
+	winner, tagged, count := -1, 0, 0
+	for _, i := range candidates {
+		if all[i].depth != minDepth {
+			continue
+		}
+		count++
+		if all[i].tagged {
+			tagged++
+			winner = i
+		}
+	}
+	if count == 1 {
+		for _, i := range candidates {
+			if all[i].depth == minDepth {
+				return i
+			}
+		}
+	}
+	if tagged == 1 {
+		return winner
+	}
+	return -1
+}
+
+// collectFields appends the JSON-visible fields of t to out.
+func collectFields(t reflect.Type, depth int, seen map[reflect.Type]bool, out *[]field) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if sf.Anonymous {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if name == "" && ft.Kind() == reflect.Struct {
+				collectFields(ft, depth+1, seen, out)
+				continue
+			}
+			if !sf.IsExported() && ft.Kind() != reflect.Struct {
+				continue
+			}
+		} else if !sf.IsExported() {
+			continue
+		}
+
+		f := field{field: sf, name: name, tagged: name != "", depth: depth}
+		if f.name == "" {
+			f.name = sf.Name
+		}
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "omitempty", "omitzero":
+				f.optional = true
+			case "string":
+				f.asString = isScalar(sf.Type)
+			}
+		}
+		*out = append(*out, f)
+	}
+}
+
+// isScalar reports whether t (or *t) is a kind affected by the ",string" option.
+func isScalar(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,209 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type searchArgs struct {
+	Query string   `json:"query" description:"Full-text search query"`
+	Sort  string   `json:"sort,omitempty" enum:"relevance,date"`
+	Limit int      `json:"limit,omitempty"`
+	Tags  []string `json:"tags,omitempty" enum:"a,b"`
+}
+
+type base struct {
+	ID      string `json:"id"`
+	Shadow  string `json:"shadow"`
+	ignored string
+}
+
+type withEmbedded struct {
+	base
+	Shadow  int       `json:"shadow"`
+	Created time.Time `json:"created"`
+	Skip    string    `json:"-"`
+	Count   int64     `json:"count,string"`
+	Data    []byte    `json:"data"`
+}
+
+type node struct {
+	Value    int     `json:"value"`
+	Children []*node `json:"children,omitempty"`
+}
+
+type tree struct {
+	Root *leaf `json:"root" description:"Top of the tree"`
+}
+
+type leaf struct {
+	Next *leaf `json:"next"`
+}
+
+type withMap struct {
+	Labels map[string]int `json:"labels"`
+}
+
+type withChan struct {
+	C chan int `json:"c"`
+}
+
+type badEnum struct {
+	N int `json:"n" enum:"one"`
+}
+
+// canonical re-encodes JSON so key order and spacing do not matter.
+func canonical(t *testing.T, data string) string {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatalf("json.Unmarshal(%s) error = %v", data, err)
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
+
+func TestFromType(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  reflect.Type
+		opts *Options
+		want string
+	}{
+		{
+			name: "tags, enums and required fields",
+			typ:  reflect.TypeOf(searchArgs{}),
+			want: `{"type":"object","properties":{
+				"query":{"type":"string","description":"Full-text search query"},
+				"sort":{"type":"string","enum":["relevance","date"]},
+				"limit":{"type":"integer"},
+				"tags":{"type":"array","items":{"type":"string","enum":["a","b"]}}},
+				"required":["query"]}`,
+		},
+		{
+			name: "strict mode makes optional fields nullable",
+			typ:  reflect.TypeOf(searchArgs{}),
+			opts: &Options{Strict: true},
+			want: `{"type":"object","additionalProperties":false,"properties":{
+				"query":{"type":"string","description":"Full-text search query"},
+				"sort":{"anyOf":[{"type":"string","enum":["relevance","date"]},{"type":"null"}]},
+				"limit":{"anyOf":[{"type":"integer"},{"type":"null"}]},
+				"tags":{"anyOf":[{"type":"array","items":{"type":"string","enum":["a","b"]}},{"type":"null"}]}},
+				"required":["query","sort","limit","tags"]}`,
+		},
+		{
+			name: "embedded structs, shadowing, time, string option and bytes",
+			typ:  reflect.TypeOf(&withEmbedded{}),
+			want: `{"type":"object","properties":{
+				"id":{"type":"string"},
+				"shadow":{"type":"integer"},
+				"created":{"type":"string","format":"date-time"},
+				"count":{"type":"string"},
+				"data":{"type":"string"}},
+				"required":["id","shadow","created","count","data"]}`,
+		},
+		{
+			name: "recursive root uses #",
+			typ:  reflect.TypeOf(node{}),
+			want: `{"type":"object","properties":{
+				"value":{"type":"integer"},
+				"children":{"type":"array","items":{"anyOf":[{"$ref":"#"},{"type":"null"}]}}},
+				"required":["value"]}`,
+		},
+		{
+			name: "recursive nested type goes to $defs",
+			typ:  reflect.TypeOf(tree{}),
+			want: `{"type":"object","properties":{
+				"root":{"description":"Top of the tree","anyOf":[{"$ref":"#/$defs/leaf"},{"type":"null"}]}},
+				"required":["root"],
+				"$defs":{"leaf":{"type":"object","properties":{
+					"next":{"anyOf":[{"$ref":"#/$defs/leaf"},{"type":"null"}]}},"required":["next"]}}}`,
+		},
+		{
+			name: "map",
+			typ:  reflect.TypeOf(withMap{}),
+			want: `{"type":"object","properties":{
+				"labels":{"type":"object","additionalProperties":{"type":"integer"}}},
+				"required":["labels"]}`,
+		},
+		{
+			name: "non-struct root",
+			typ:  reflect.TypeOf([]float64{}),
+			want: `{"type":"array","items":{"type":"number"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := FromType(tt.typ, tt.opts)
+			if err != nil {
+				t.Fatalf("FromType() error = %v", err)
+			}
+			data, err := json.Marshal(s)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if got, want := canonical(t, string(data)), canonical(t, tt.want); got != want {
+				t.Errorf("FromType() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestFromTypeErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		typ         reflect.Type
+		opts        *Options
+		unsupported bool
+	}{
+		{name: "nil type", unsupported: true},
+		{name: "channel field", typ: reflect.TypeOf(withChan{}), unsupported: true},
+		{name: "function", typ: reflect.TypeOf(func() {}), unsupported: true},
+		{name: "map key", typ: reflect.TypeOf(map[bool]string{}), unsupported: true},
+		{name: "map in strict mode", typ: reflect.TypeOf(withMap{}), opts: &Options{Strict: true}, unsupported: true},
+		{name: "non-struct root in strict mode", typ: reflect.TypeOf(""), opts: &Options{Strict: true}, unsupported: true},
+		{name: "interface in strict mode", typ: reflect.TypeOf(struct {
+			V interface{} `json:"v"`
+		}{}), opts: &Options{Strict: true}, unsupported: true},
+		{name: "invalid enum value", typ: reflect.TypeOf(badEnum{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromType(tt.typ, tt.opts)
+			if err == nil {
+				t.Fatal("FromType() error = nil, want an error")
+			}
+			if errors.Is(err, ErrUnsupportedType) != tt.unsupported {
+				t.Errorf("FromType() error = %v, want ErrUnsupportedType %v", err, tt.unsupported)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	fromValue, err := Generate(&searchArgs{}, nil)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	fromType := MustFor[searchArgs](nil)
+	a, _ := json.Marshal(fromValue)
+	b, _ := json.Marshal(fromType)
+	if string(a) != string(b) {
+		t.Errorf("Generate() = %s, For() = %s, want the same schema", a, b)
+	}
+
+	if _, err := Generate(nil, nil); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Generate(nil) error = %v, want ErrUnsupportedType", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustFor() of an unsupported type did not panic")
+		}
+	}()
+	MustFor[withChan](nil)
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/converters"
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Default handler settings.
+const (
+	// DefaultHeartbeatInterval is how often a comment is sent on an idle stream.
+	DefaultHeartbeatInterval = 15 * time.Second
+
+	// DefaultMaxBodyBytes is the largest accepted request body.
+	DefaultMaxBodyBytes = 10 << 20
+)
+
+// ErrStreamingUnsupported is reported when the ResponseWriter cannot flush.
+var ErrStreamingUnsupported = errors.New("server: response writer does not support flushing")
+
+// HandlerConfig configures a ChatHandler.
+type HandlerConfig struct {
+	// HeartbeatInterval is how often an SSE comment is written while waiting
+	// for the next chunk, keeping proxies from closing idle connections.
+	// Default is DefaultHeartbeatInterval; negative disables heartbeats.
+	HeartbeatInterval time.Duration
+
+	// MaxBodyBytes limits the request body size.
+	// Default is DefaultMaxBodyBytes.
+	MaxBodyBytes int64
+
+	// OnError, if set, is called with every error returned to a client,
+	// including errors delivered in-band on a stream.
+	OnError func(r *http.Request, err error)
+}
+
+// ChatHandler serves OpenAI-compatible chat completions from a ChatService.
+type ChatHandler struct {
+	service interfaces.ChatService
+	config  HandlerConfig
+}
+
+// NewChatHandler creates a handler serving service. A nil config uses defaults.
+func NewChatHandler(service interfaces.ChatService, config *HandlerConfig) *ChatHandler {
+	cfg := HandlerConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.HeartbeatInterval == 0 {
+		cfg.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	return &ChatHandler{service: service, config: cfg}
+}
+
+// ServeHTTP handles a POST of an OpenAI chat completion request.
+func (h *ChatHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		h.writeError(w, r, methodNotAllowed(r))
+		return
+	}
+
+	var wire converters.OpenAIChatRequest
+	if err := DecodeJSON(w, r, h.config.MaxBodyBytes, &wire); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	req, err := converters.FromOpenAIRequest(&wire)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err := validateChatRequest(h.service, req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	if req.Stream {
+		includeUsage := wire.StreamOptions != nil && wire.StreamOptions.IncludeUsage
+		h.serveStream(w, r, req, includeUsage)
+		return
+	}
+
+	resp, err := h.service.CreateCompletion(r.Context(), req)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	body, err := converters.ToOpenAIResponse(resp)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if body.Model == "" {
+		body.Model = req.Model
+	}
+	WriteJSON(w, http.StatusOK, body)
+}
+
+// serveStream writes the completion stream as server-sent events.
+//
+// Headers are committed on the first chunk or heartbeat, so an error before
+// then is still returned with a proper status code.
+func (h *ChatHandler) serveStream(w http.ResponseWriter, r *http.Request, req *types.ChatRequest, includeUsage bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.writeError(w, r, ErrStreamingUnsupported)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	stream, err := h.service.CreateCompletionStream(ctx, req)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	// Release the producer if we stop reading early.
+	defer func() { go drain(stream) }()
+
+	sse := &sseWriter{w: w, flusher: flusher}
+	created := time.Now().Unix()
+
+	var heartbeat <-chan time.Time
+	if h.config.HeartbeatInterval > 0 {
+		ticker := time.NewTicker(h.config.HeartbeatInterval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-heartbeat:
+			if sse.comment("ping") != nil {
+				return
+			}
+
+		case chunk, ok := <-stream:
+			if !ok {
+				sse.done()
+				return
+			}
+			if ec, isErr := chunk.(*types.ErrorChunk); isErr {
+				h.streamError(w, r, sse, ec.Err)
+				return
+			}
+
+			wire := converters.ToOpenAIChunk(chunk)
+			if wire == nil {
+				continue
+			}
+			if !includeUsage {
+				wire.Usage = nil
+				if len(wire.Choices) == 0 {
+					continue
+				}
+			}
+			if wire.Model == "" {
+				wire.Model = req.Model
+			}
+			if wire.Created == 0 {
+				wire.Created = created
+			}
+			if sse.data(wire) != nil {
+				return
+			}
+		}
+	}
+}
+
+// streamError reports an error that ended a stream, as a normal error response
+// if nothing has been written yet and as an in-band event otherwise.
+func (h *ChatHandler) streamError(w http.ResponseWriter, r *http.Request, sse *sseWriter, err error) {
+	if !sse.started {
+		h.writeError(w, r, err)
+		return
+	}
+	if h.config.OnError != nil {
+		h.config.OnError(r, err)
+	}
+	_, body := converters.ToOpenAIError(err)
+	_ = sse.data(body)
+}
+
+// writeError writes err as an OpenAI error response.
+func (h *ChatHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	if h.config.OnError != nil {
+		h.config.OnError(r, err)
+	}
+	WriteError(w, err)
+}
+
+// validateChatRequest checks the fields every request needs and runs the
+// service's own validation when it offers one.
+func validateChatRequest(service interfaces.ChatService, req *types.ChatRequest) error {
+	if req.Model == "" {
+		return types.NewValidationError("model", "model is required")
+	}
+	if len(req.Messages) == 0 {
+		return types.NewValidationError("messages", "at least one message is required")
+	}
+	if v, ok := service.(interfaces.ChatServiceWithValidation); ok {
+		return v.ValidateRequest(req)
+	}
+	return nil
+}
+
+// DecodeJSON decodes a JSON request body of at most maxBytes into v.
+// Decoding failures are returned as ValidationErrors.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, maxBytes int64, v interface{}) error {
+	body := http.MaxBytesReader(w, r.Body, maxBytes)
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			return &types.ProviderError{
+				ErrorType:  types.ErrorTypeInvalidRequest,
+				Message:    "request body too large",
+				HTTPStatus: http.StatusRequestEntityTooLarge,
+			}
+		case errors.Is(err, io.EOF):
+			return types.NewValidationError("body", "request body is empty")
+		default:
+			return types.NewValidationError("body", "invalid JSON: "+err.Error())
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes v as a JSON response with the given status.
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// WriteError writes err as an OpenAI error response, using the status
+// chosen by converters.ToOpenAIError.
+func WriteError(w http.ResponseWriter, err error) {
+	status, body := converters.ToOpenAIError(err)
+	WriteJSON(w, status, body)
+}
+
+// drain discards the remaining chunks of a stream.
+func drain(stream <-chan types.StreamChunk) {
+	for range stream {
+	}
+}
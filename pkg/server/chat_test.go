@@ -0,0 +1,349 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/converters"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// chatService answers with a fixed response or streams fixed chunks. With
+// hold set, the stream stays open after the chunks until ctx is done.
+type chatService struct {
+	resp   *types.ChatResponse
+	err    error
+	chunks []types.StreamChunk
+	hold   bool
+
+	mu       sync.Mutex
+	req      *types.ChatRequest
+	canceled chan struct{}
+}
+
+func (s *chatService) CreateCompletion(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+	s.mu.Lock()
+	s.req = req
+	s.mu.Unlock()
+	return s.resp, s.err
+}
+
+func (s *chatService) CreateCompletionStream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	s.mu.Lock()
+	s.req = req
+	s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	ch := make(chan types.StreamChunk)
+	go func() {
+		defer close(ch)
+		for _, c := range s.chunks {
+			select {
+			case ch <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if s.hold {
+			<-ctx.Done()
+			close(s.canceled)
+		}
+	}()
+	return ch, nil
+}
+
+func contentChunk(text string) *types.ChatStreamChunk {
+	return &types.ChatStreamChunk{ID: "c1", Choices: []*types.StreamChoice{{Delta: &types.MessageDelta{Content: text}}}}
+}
+
+const chatBody = `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`
+
+const streamBody = `{"model":"gpt-4o","stream":true,"messages":[{"role":"user","content":"hi"}]}`
+
+// events reads a server-sent event stream and returns the data payloads.
+func events(t *testing.T, body io.Reader) []string {
+	t.Helper()
+	var data []string
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		if payload, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			data = append(data, payload)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("reading stream: %v", err)
+	}
+	return data
+}
+
+// errorBody decodes an OpenAI error payload.
+func errorBody(t *testing.T, data string) *converters.OpenAIError {
+	t.Helper()
+	var wire converters.OpenAIErrorResponse
+	if err := json.Unmarshal([]byte(data), &wire); err != nil || wire.Error == nil {
+		t.Fatalf("error body %s: %v", data, err)
+	}
+	return wire.Error
+}
+
+func TestChatHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		svc        *chatService
+		wantStatus int
+		check      func(t *testing.T, body string)
+	}{
+		{
+			name: "completion round trip",
+			body: chatBody,
+			svc: &chatService{resp: &types.ChatResponse{ID: "chatcmpl-1", Choices: []*types.Choice{{
+				Message:      &types.Message{Role: types.RoleAssistant, Content: types.NewTextContent("hello")},
+				FinishReason: types.FinishReasonStop,
+			}}, Usage: &types.Usage{PromptTokens: 1, CompletionTokens: 1, TotalTokens: 2}}},
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body string) {
+				var wire converters.OpenAIChatResponse
+				if err := json.Unmarshal([]byte(body), &wire); err != nil {
+					t.Fatalf("json.Unmarshal() error = %v", err)
+				}
+				resp, err := converters.FromOpenAIResponse(&wire)
+				if err != nil {
+					t.Fatalf("FromOpenAIResponse() error = %v", err)
+				}
+				if resp.Model != "gpt-4o" || resp.GetFirstContent() != "hello" || resp.Usage.TotalTokens != 2 {
+					t.Errorf("response = %s", body)
+				}
+			},
+		},
+		{
+			name:       "wrong method",
+			method:     http.MethodGet,
+			svc:        &chatService{},
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "malformed JSON",
+			body:       `{"model":`,
+			svc:        &chatService{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing model",
+			body:       `{"messages":[{"role":"user","content":"hi"}]}`,
+			svc:        &chatService{},
+			wantStatus: http.StatusBadRequest,
+			check: func(t *testing.T, body string) {
+				if e := errorBody(t, body); e.Param != "model" {
+					t.Errorf("error = %+v, want param model", e)
+				}
+			},
+		},
+		{
+			name:       "service error",
+			body:       chatBody,
+			svc:        &chatService{err: &types.ProviderError{ErrorType: types.ErrorTypeRateLimit, Message: "slow down"}},
+			wantStatus: http.StatusTooManyRequests,
+			check: func(t *testing.T, body string) {
+				if e := errorBody(t, body); e.Message != "slow down" || e.Type != string(types.ErrorTypeRateLimit) {
+					t.Errorf("error = %+v", e)
+				}
+			},
+		},
+		{
+			name:       "error before the first chunk keeps its status",
+			body:       streamBody,
+			svc:        &chatService{chunks: []types.StreamChunk{types.NewErrorChunk(types.NewValidationError("messages", "too long"))}},
+			wantStatus: http.StatusBadRequest,
+			check: func(t *testing.T, body string) {
+				if e := errorBody(t, body); e.Param != "messages" {
+					t.Errorf("error = %+v, want param messages", e)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				reported []error
+			)
+			srv := httptest.NewServer(NewChatHandler(tt.svc, &HandlerConfig{
+				OnError: func(r *http.Request, err error) {
+					mu.Lock()
+					defer mu.Unlock()
+					reported = append(reported, err)
+				},
+			}))
+			defer srv.Close()
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req, _ := http.NewRequest(method, srv.URL, strings.NewReader(tt.body))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request error = %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if (tt.wantStatus != http.StatusOK) != (len(reported) == 1) {
+				t.Errorf("OnError called %d times for status %d", len(reported), tt.wantStatus)
+			}
+			if tt.check != nil {
+				tt.check(t, string(body))
+			}
+		})
+	}
+}
+
+func TestChatHandlerStream(t *testing.T) {
+	usage := &types.ChatStreamChunk{ID: "c1", Choices: []*types.StreamChoice{}, Usage: &types.Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3}}
+	tests := []struct {
+		name   string
+		body   string
+		chunks []types.StreamChunk
+		want   []string
+	}{
+		{
+			name:   "chunks end with DONE",
+			body:   streamBody,
+			chunks: []types.StreamChunk{contentChunk("he"), contentChunk("llo"), usage},
+			want:   []string{"he", "llo", "[DONE]"},
+		},
+		{
+			name:   "usage only when requested",
+			body:   `{"model":"gpt-4o","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"hi"}]}`,
+			chunks: []types.StreamChunk{contentChunk("hi"), usage},
+			want:   []string{"hi", "usage:3", "[DONE]"},
+		},
+		{
+			name:   "error after the first chunk is sent in band",
+			body:   streamBody,
+			chunks: []types.StreamChunk{contentChunk("he"), types.NewErrorChunk(errors.New("upstream reset"))},
+			want:   []string{"he", "error:upstream reset"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				reported []error
+			)
+			srv := httptest.NewServer(NewChatHandler(&chatService{chunks: tt.chunks}, &HandlerConfig{
+				HeartbeatInterval: -1,
+				OnError: func(r *http.Request, err error) {
+					mu.Lock()
+					defer mu.Unlock()
+					reported = append(reported, err)
+				},
+			}))
+			defer srv.Close()
+
+			resp, err := http.Post(srv.URL, "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("request error = %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+				t.Fatalf("status = %d, Content-Type = %q, want an event stream", resp.StatusCode, resp.Header.Get("Content-Type"))
+			}
+
+			var got []string
+			for _, data := range events(t, resp.Body) {
+				if data == "[DONE]" {
+					got = append(got, data)
+					continue
+				}
+				if strings.Contains(data, `"error"`) {
+					got = append(got, "error:"+errorBody(t, data).Message)
+					continue
+				}
+				var wire converters.OpenAIChatChunk
+				if err := json.Unmarshal([]byte(data), &wire); err != nil {
+					t.Fatalf("json.Unmarshal(%s) error = %v", data, err)
+				}
+				if wire.Model != "gpt-4o" || wire.Created == 0 || wire.Object != converters.OpenAIObjectChatCompletionChunk {
+					t.Errorf("chunk header = %s, want model, created and object filled in", data)
+				}
+				chunk := converters.FromOpenAIChunk(&wire)
+				switch {
+				case chunk.Usage != nil:
+					got = append(got, "usage:"+strconv.Itoa(chunk.Usage.TotalTokens))
+				case len(chunk.Choices) > 0:
+					got = append(got, chunk.Choices[0].Delta.Content)
+				}
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("events = %q, want %q", got, tt.want)
+			}
+			wantReported := 0
+			if strings.HasPrefix(tt.want[len(tt.want)-1], "error:") {
+				wantReported = 1
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if len(reported) != wantReported {
+				t.Errorf("OnError called %d times, want %d", len(reported), wantReported)
+			}
+		})
+	}
+}
+
+func TestChatHandlerStreamClientDisconnect(t *testing.T) {
+	svc := &chatService{chunks: []types.StreamChunk{contentChunk("he")}, hold: true, canceled: make(chan struct{})}
+	srv := httptest.NewServer(NewChatHandler(svc, &HandlerConfig{HeartbeatInterval: time.Millisecond}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, strings.NewReader(streamBody))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Wait for the first chunk; heartbeats may come before it.
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading stream: %v", err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			break
+		}
+	}
+	cancel()
+
+	select {
+	case <-svc.canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the service's context was not canceled after the client went away")
+	}
+}
@@ -0,0 +1,19 @@
+// Package server exposes the services in pkg/interfaces over HTTP using
+// provider-compatible wire formats.
+//
+// ChatHandler serves the OpenAI Chat Completions API on top of any
+// interfaces.ChatService, so existing OpenAI clients and SDKs can talk to a
+// service backed by any provider. Non-streaming requests receive a JSON
+// response; streaming requests receive server-sent events in OpenAI's
+// chat.completion.chunk format, flushed per chunk, with heartbeat comments
+// while the upstream is idle. Errors that occur after the stream has started
+// are delivered in-band as an OpenAI error event.
+//
+// Example usage:
+//
+//	handler := server.NewChatHandler(provider.ChatService(), &server.HandlerConfig{
+//	    HeartbeatInterval: 10 * time.Second,
+//	})
+//	http.Handle("/v1/chat/completions", handler)
+//	log.Fatal(http.ListenAndServe(":8080", nil))
+package server
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/zacw/go-ai-types/pkg/converters"
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// EmbeddingHandler serves OpenAI-compatible embeddings from an EmbeddingService.
+type EmbeddingHandler struct {
+	service interfaces.EmbeddingService
+	config  HandlerConfig
+}
+
+// NewEmbeddingHandler creates a handler serving service. A nil config uses
+// defaults; HeartbeatInterval does not apply.
+func NewEmbeddingHandler(service interfaces.EmbeddingService, config *HandlerConfig) *EmbeddingHandler {
+	cfg := HandlerConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	return &EmbeddingHandler{service: service, config: cfg}
+}
+
+// ServeHTTP handles a POST of an OpenAI embeddings request.
+func (h *EmbeddingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		h.writeError(w, r, methodNotAllowed(r))
+		return
+	}
+
+	var wire converters.OpenAIEmbeddingRequest
+	if err := DecodeJSON(w, r, h.config.MaxBodyBytes, &wire); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	req, err := converters.FromOpenAIEmbeddingRequest(&wire)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if req.Model == "" {
+		h.writeError(w, r, types.NewValidationError("model", "model is required"))
+		return
+	}
+	if v, ok := h.service.(interfaces.EmbeddingServiceWithValidation); ok {
+		if err := v.ValidateRequest(req); err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+	}
+
+	resp, err := h.service.CreateEmbedding(r.Context(), req)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	body := converters.ToOpenAIEmbeddingResponse(resp)
+	if body.Model == "" {
+		body.Model = req.Model
+	}
+	WriteJSON(w, http.StatusOK, body)
+}
+
+func (h *EmbeddingHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	if h.config.OnError != nil {
+		h.config.OnError(r, err)
+	}
+	WriteError(w, err)
+}
+
+// methodNotAllowed returns the error for a request with an unsupported method.
+func methodNotAllowed(r *http.Request) error {
+	return &types.ProviderError{
+		ErrorType:  types.ErrorTypeInvalidRequest,
+		Message:    "method " + r.Method + " not allowed",
+		HTTPStatus: http.StatusMethodNotAllowed,
+	}
+}
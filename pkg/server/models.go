@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/zacw/go-ai-types/pkg/converters"
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// ModelsHandler serves the OpenAI list models API.
+//
+// GET on the handler's path lists every model; GET on path/{model} returns
+// a single model or a 404 error.
+type ModelsHandler struct {
+	provider interfaces.Provider
+	config   HandlerConfig
+}
+
+// NewModelsHandler creates a handler listing the models of provider.
+//
+// If the provider implements interfaces.ModelLister its ListModels result is
+// served; otherwise Provider.Models is used. A nil config uses defaults.
+func NewModelsHandler(provider interfaces.Provider, config *HandlerConfig) *ModelsHandler {
+	cfg := HandlerConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	return &ModelsHandler{provider: provider, config: cfg}
+}
+
+// ServeHTTP handles GET /models and GET /models/{model}.
+func (h *ModelsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		h.writeError(w, r, methodNotAllowed(r))
+		return
+	}
+
+	models, err := listModels(r.Context(), h.provider)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	id := r.PathValue("model")
+	if id == "" {
+		WriteJSON(w, http.StatusOK, converters.ToOpenAIModelList(models))
+		return
+	}
+	for _, m := range models {
+		if m.ID == id {
+			WriteJSON(w, http.StatusOK, converters.ToOpenAIModel(m))
+			return
+		}
+	}
+	h.writeError(w, r, &types.ProviderError{
+		ErrorType:  types.ErrorTypeNotFound,
+		Message:    "the model '" + id + "' does not exist",
+		ErrorCode:  "model_not_found",
+		Param:      "model",
+		HTTPStatus: http.StatusNotFound,
+	})
+}
+
+func (h *ModelsHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	if h.config.OnError != nil {
+		h.config.OnError(r, err)
+	}
+	WriteError(w, err)
+}
+
+// listModels returns the provider's models, preferring ModelLister.
+func listModels(ctx context.Context, provider interfaces.Provider) ([]*types.ModelInfo, error) {
+	if lister, ok := provider.(interfaces.ModelLister); ok {
+		return lister.ListModels(ctx)
+	}
+	ids := provider.Models()
+	models := make([]*types.ModelInfo, 0, len(ids))
+	for _, id := range ids {
+		models = append(models, &types.ModelInfo{
+			ID:           id,
+			Provider:     provider.Name(),
+			Capabilities: provider.Capabilities(),
+		})
+	}
+	return models, nil
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// sseWriter writes server-sent events, committing the response headers on
+// the first write and flushing after every event.
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	started bool
+}
+
+// start writes the event-stream headers once.
+func (s *sseWriter) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	h := s.w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	s.w.WriteHeader(http.StatusOK)
+}
+
+// data writes v as the JSON payload of a data event.
+func (s *sseWriter) data(v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.write("data: %s\n\n", payload)
+}
+
+// comment writes an SSE comment, which clients ignore.
+func (s *sseWriter) comment(text string) error {
+	return s.write(": %s\n\n", text)
+}
+
+// done writes the terminating [DONE] event.
+func (s *sseWriter) done() {
+	_ = s.write("data: [DONE]\n\n")
+}
+
+func (s *sseWriter) write(format string, args ...interface{}) error {
+	s.start()
+	if _, err := fmt.Fprintf(s.w, format, args...); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
@@ -0,0 +1,28 @@
+// Package streams provides utilities for the <-chan types.StreamChunk
+// returned by ChatService.CreateCompletionStream.
+//
+// A stream channel can only be consumed once. Tee splits it between several
+// consumers with a configurable backpressure policy, Replay records it so
+// subscribers that arrive late still receive every chunk, and FanIn merges
+// streams from parallel requests into one stream with distinct choice indexes.
+// Reconnect implements the reconnection settings of types.StreamConfig,
+// resuming an interrupted stream without repeating delivered output.
+//
+// Chunks are shared, not copied, between consumers and must be treated as
+// read-only.
+//
+// Example usage:
+//
+//	stream, err := chatService.CreateCompletionStream(ctx, req)
+//	if err != nil {
+//	    return err
+//	}
+//
+//	outs := streams.Tee(ctx, stream, 3, &streams.TeeConfig{
+//	    Policy:     streams.PolicyBuffer,
+//	    BufferSize: streamConfig.BufferSize,
+//	})
+//	go writeSSE(w, outs[0])       // the HTTP client
+//	go persistTranscript(outs[1]) // storage
+//	go collectMetrics(outs[2])    // metrics
+package streams
@@ -0,0 +1,173 @@
+package streams
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// FanIn merges streams into a single stream, as if they were the choices of
+// one request with N > 1.
+//
+// Choice j of stream i is renumbered to j*len(streams)+i, so when each
+// stream has a single choice, stream i becomes choice i. Chunks are copied
+// before renumbering; chunk types other than *types.ChatStreamChunk are
+// forwarded unchanged.
+//
+// Usage is removed from input *types.ChatStreamChunks, and chunks that only
+// carried usage are dropped. Once every input is closed, a final chunk
+// reports the sum of the inputs' usage, each merged as by
+// StreamAccumulator.MergeUsage, so accumulating the output counts every
+// request rather than the largest.
+//
+// The output is closed once every input is closed or ctx is cancelled. On
+// cancellation, the inputs are drained in the background and no usage is
+// reported.
+func FanIn(ctx context.Context, streams ...<-chan types.StreamChunk) <-chan types.StreamChunk {
+	return fanIn(ctx, streams, nil)
+}
+
+// fanIn implements FanIn, calling onClose (if non-nil) after the output is closed.
+func fanIn(ctx context.Context, streams []<-chan types.StreamChunk, onClose func()) <-chan types.StreamChunk {
+	out := make(chan types.StreamChunk, types.DefaultStreamBufferSize)
+	n := len(streams)
+
+	// usage[i] merges the usage reported by stream i; first is the first
+	// chat chunk, whose ID and model label the usage chunk.
+	usage := make([]types.StreamAccumulator, n)
+	var first sync.Once
+	var id, model string
+
+	var wg sync.WaitGroup
+	for i, src := range streams {
+		wg.Add(1)
+		go func(i int, src <-chan types.StreamChunk) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					go drain(src)
+					return
+				case chunk, ok := <-src:
+					if !ok {
+						return
+					}
+					if uc, ok := chunk.(types.UsageChunk); ok {
+						usage[i].MergeUsage(uc.GetUsage())
+					}
+					if c, ok := chunk.(*types.ChatStreamChunk); ok {
+						first.Do(func() { id, model = c.ID, c.Model })
+						if c.Usage != nil && len(c.Choices) == 0 {
+							continue
+						}
+					}
+					select {
+					case out <- renumber(chunk, i, n):
+					case <-ctx.Done():
+						go drain(src)
+						return
+					}
+				}
+			}
+		}(i, src)
+	}
+
+	go func() {
+		wg.Wait()
+		if total := sumUsage(usage); total != nil && ctx.Err() == nil {
+			select {
+			case out <- &types.ChatStreamChunk{ID: id, Object: "chat.completion.chunk", Model: model, Choices: []*types.StreamChoice{}, Usage: total}:
+			case <-ctx.Done():
+			}
+		}
+		close(out)
+		if onClose != nil {
+			onClose()
+		}
+	}()
+	return out
+}
+
+// sumUsage returns the sum of the accumulated usage, or nil if no stream
+// reported usage.
+func sumUsage(accs []types.StreamAccumulator) *types.Usage {
+	var total *types.Usage
+	for i := range accs {
+		if accs[i].Usage == nil {
+			continue
+		}
+		if total == nil {
+			total = &types.Usage{}
+		}
+		total.Add(accs[i].Usage)
+	}
+	return total
+}
+
+// Parallel sends n copies of req concurrently, each asking for a single
+// choice, and merges the responses with FanIn so that request i produces
+// choice i. This emulates N > 1 for providers that do not support it.
+//
+// If any request fails to start, the streams already started are cancelled
+// and the error is returned.
+func Parallel(ctx context.Context, service interfaces.ChatService, req *types.ChatRequest, n int) (<-chan types.StreamChunk, error) {
+	if n <= 0 {
+		return nil, types.NewValidationError("n", "must be positive")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	srcs := make([]<-chan types.StreamChunk, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reqCopy := *req
+			reqCopy.N = 1
+			reqCopy.Stream = true
+			srcs[i], errs[i] = service.CreateCompletionStream(ctx, &reqCopy)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			cancel()
+			for _, src := range srcs {
+				if src != nil {
+					go drain(src)
+				}
+			}
+			return nil, fmt.Errorf("streams: request %d: %w", i, err)
+		}
+	}
+
+	return fanIn(ctx, srcs, cancel), nil
+}
+
+// renumber returns a copy of chunk with choice indexes remapped for stream i
+// of n and usage removed.
+func renumber(chunk types.StreamChunk, i, n int) types.StreamChunk {
+	c, ok := chunk.(*types.ChatStreamChunk)
+	if !ok {
+		return chunk
+	}
+
+	cp := *c
+	cp.Usage = nil
+	cp.Choices = make([]*types.StreamChoice, len(c.Choices))
+	for j, choice := range c.Choices {
+		if choice == nil {
+			continue
+		}
+		choiceCopy := *choice
+		choiceCopy.Index = choice.Index*n + i
+		cp.Choices[j] = &choiceCopy
+	}
+	return &cp
+}
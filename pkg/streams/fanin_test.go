@@ -0,0 +1,130 @@
+package streams
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// chanOf returns a closed channel holding chunks.
+func chanOf(chunks ...types.StreamChunk) <-chan types.StreamChunk {
+	ch := make(chan types.StreamChunk, len(chunks))
+	for _, c := range chunks {
+		ch <- c
+	}
+	close(ch)
+	return ch
+}
+
+// textChunk returns a chunk with content for choice index, finished if reason is set.
+func textChunk(index int, content string, reason types.FinishReason) *types.ChatStreamChunk {
+	return &types.ChatStreamChunk{
+		ID:      "chatcmpl-1",
+		Model:   "m",
+		Choices: []*types.StreamChoice{{Index: index, Delta: &types.MessageDelta{Content: content}, FinishReason: reason}},
+	}
+}
+
+func usageChunk(prompt, completion int) *types.ChatStreamChunk {
+	return &types.ChatStreamChunk{
+		ID:      "chatcmpl-1",
+		Model:   "m",
+		Choices: []*types.StreamChoice{},
+		Usage:   &types.Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion},
+	}
+}
+
+func TestFanIn(t *testing.T) {
+	tests := []struct {
+		name        string
+		streams     [][]types.StreamChunk
+		wantContent map[int]string
+		wantUsage   *types.Usage
+	}{
+		{
+			name: "single choice streams become choices",
+			streams: [][]types.StreamChunk{
+				{textChunk(0, "a", ""), textChunk(0, "b", types.FinishReasonStop)},
+				{textChunk(0, "c", types.FinishReasonStop)},
+			},
+			wantContent: map[int]string{0: "ab", 1: "c"},
+		},
+		{
+			name: "multi choice streams are interleaved",
+			streams: [][]types.StreamChunk{
+				{textChunk(0, "a0", ""), textChunk(1, "a1", "")},
+				{textChunk(0, "b0", ""), textChunk(1, "b1", "")},
+			},
+			wantContent: map[int]string{0: "a0", 1: "b0", 2: "a1", 3: "b1"},
+		},
+		{
+			name: "usage is summed across streams",
+			streams: [][]types.StreamChunk{
+				{textChunk(0, "a", types.FinishReasonStop), usageChunk(10, 5)},
+				{textChunk(0, "b", types.FinishReasonStop), usageChunk(10, 5)},
+			},
+			wantContent: map[int]string{0: "a", 1: "b"},
+			wantUsage:   &types.Usage{PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30},
+		},
+		{
+			name: "running totals within a stream are not double counted",
+			streams: [][]types.StreamChunk{
+				{usageChunk(10, 0), textChunk(0, "a", types.FinishReasonStop), usageChunk(10, 5)},
+				{textChunk(0, "b", types.FinishReasonStop), usageChunk(7, 3)},
+			},
+			wantContent: map[int]string{0: "a", 1: "b"},
+			wantUsage:   &types.Usage{PromptTokens: 17, CompletionTokens: 8, TotalTokens: 25},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srcs := make([]<-chan types.StreamChunk, len(tt.streams))
+			for i, chunks := range tt.streams {
+				srcs[i] = chanOf(chunks...)
+			}
+
+			acc := &types.StreamAccumulator{}
+			usageChunks := 0
+			for chunk := range FanIn(context.Background(), srcs...) {
+				if c, ok := chunk.(*types.ChatStreamChunk); ok && c.Usage != nil {
+					usageChunks++
+				}
+				acc.Add(chunk)
+			}
+
+			for index, want := range tt.wantContent {
+				choice, ok := acc.Choices[index]
+				if !ok || choice.Content != want {
+					t.Errorf("choice %d content = %v, want %q", index, choice, want)
+				}
+			}
+			if len(acc.Choices) != len(tt.wantContent) {
+				t.Errorf("got %d choices, want %d", len(acc.Choices), len(tt.wantContent))
+			}
+			if tt.wantUsage == nil {
+				if acc.Usage != nil {
+					t.Errorf("usage = %+v, want none", acc.Usage)
+				}
+				return
+			}
+			if usageChunks != 1 {
+				t.Errorf("got %d usage chunks, want 1", usageChunks)
+			}
+			if acc.Usage == nil || *acc.Usage != *tt.wantUsage {
+				t.Errorf("usage = %+v, want %+v", acc.Usage, tt.wantUsage)
+			}
+		})
+	}
+}
+
+func TestFanInCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := make(chan types.StreamChunk)
+	out := FanIn(ctx, src)
+	cancel()
+	for range out {
+	}
+	close(src)
+}
@@ -0,0 +1,705 @@
+package streams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Defaults for reconnection, matching the documented types.StreamConfig defaults.
+const (
+	DefaultMaxReconnectAttempts = 3
+	DefaultReconnectBackoff     = 1 * time.Second
+
+	// DefaultOverlapWindow is the number of bytes of continuation buffered
+	// after a prefill resume to detect text the model repeated.
+	DefaultOverlapWindow = 64
+
+	// DefaultMinOverlap is the shortest repeated text that is removed.
+	DefaultMinOverlap = 4
+)
+
+// ErrResumeNotPossible is matched (with errors.Is) by every *ResumeError.
+var ErrResumeNotPossible = errors.New("streams: stream cannot be resumed")
+
+// errClosedUnfinished is the cause of a stream that closed cleanly before
+// every choice had a finish reason.
+var errClosedUnfinished = fmt.Errorf("streams: stream closed before every choice finished: %w", io.ErrUnexpectedEOF)
+
+// ResumeError reports that an interrupted stream could not be continued
+// without corrupting the output already delivered.
+type ResumeError struct {
+	// Reason explains why resuming is not possible.
+	Reason string
+
+	// Err is the error that interrupted the stream.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ResumeError) Error() string {
+	if e.Err == nil {
+		return "streams: cannot resume stream: " + e.Reason
+	}
+	return fmt.Sprintf("streams: cannot resume stream: %s (interrupted by: %v)", e.Reason, e.Err)
+}
+
+// Unwrap returns the error that interrupted the stream.
+func (e *ResumeError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrResumeNotPossible.
+func (e *ResumeError) Is(target error) bool {
+	return target == ErrResumeNotPossible
+}
+
+// ReconnectError reports that the stream was still failing after the maximum
+// number of reconnection attempts.
+type ReconnectError struct {
+	// Attempts is the number of reconnection attempts made.
+	Attempts int
+
+	// Err is the last error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ReconnectError) Error() string {
+	return fmt.Sprintf("streams: stream failed after %d reconnect attempts: %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the last error.
+func (e *ReconnectError) Unwrap() error {
+	return e.Err
+}
+
+// ReconnectConfig configures Reconnect.
+type ReconnectConfig struct {
+	// Stream provides EnableReconnect, MaxReconnectAttempts and
+	// ReconnectBackoff. If nil or EnableReconnect is false, Reconnect returns
+	// the underlying stream unchanged.
+	Stream *types.StreamConfig
+
+	// Prefill indicates that the provider continues a trailing assistant
+	// message (e.g., Anthropic). The stream is then resumed by re-sending the
+	// request with the text delivered so far as an assistant prefill.
+	Prefill bool
+
+	// Restart allows resuming by re-sending the original request and
+	// discarding the part of the new stream that was already delivered. The
+	// new output must repeat the delivered output exactly, so this is only
+	// useful for deterministic requests (e.g., cached or seeded with
+	// temperature 0); otherwise the stream fails with a *ResumeError.
+	//
+	// Restart also allows resuming a stream that closed cleanly without a
+	// finish reason.
+	Restart bool
+
+	// OverlapWindow is the number of bytes buffered after a prefill resume to
+	// detect repeated text. Defaults to DefaultOverlapWindow.
+	OverlapWindow int
+
+	// MinOverlap is the shortest repeated text that is removed after a
+	// prefill resume. Defaults to DefaultMinOverlap.
+	MinOverlap int
+
+	// OnReconnect is called before each reconnection attempt.
+	OnReconnect func(attempt int, err error)
+}
+
+// Reconnect starts a stream for req that transparently reconnects when it is
+// interrupted mid-stream.
+//
+// A stream is interrupted when it ends with a *types.ErrorChunk carrying a
+// retryable error (a retryable types.AIError, a network error or
+// io.ErrUnexpectedEOF). Other errors are forwarded as-is. A stream that
+// closes cleanly before every choice has a finish reason is only resumed if
+// config.Restart is set, since some providers omit finish reasons and
+// re-sending the request would multiply its cost; otherwise it ends with a
+// *ResumeError.
+//
+// The stream is resumed, in order of preference:
+//   - from the last event ID, if chunks carry one (types.EventIDChunk) and
+//     the service implements interfaces.ChatServiceWithResume;
+//   - with the delivered text as an assistant prefill, if config.Prefill is set
+//     and the response is a single choice of plain text;
+//   - by re-sending the request, if config.Restart is set.
+//
+// If none applies, or the resumed output contradicts what was delivered, the
+// stream ends with a *types.ErrorChunk wrapping a *ResumeError. When the
+// attempts are exhausted it ends with one wrapping a *ReconnectError.
+//
+// The error from opening the initial stream is returned directly.
+func Reconnect(ctx context.Context, service interfaces.ChatService, req *types.ChatRequest, config *ReconnectConfig) (<-chan types.StreamChunk, error) {
+	cfg := ReconnectConfig{}
+	if config != nil {
+		cfg = *config
+	}
+
+	src, err := service.CreateCompletionStream(ctx, req)
+	if err != nil || cfg.Stream == nil || !cfg.Stream.EnableReconnect {
+		return src, err
+	}
+
+	if cfg.OverlapWindow <= 0 {
+		cfg.OverlapWindow = DefaultOverlapWindow
+	}
+	if cfg.MinOverlap <= 0 {
+		cfg.MinOverlap = DefaultMinOverlap
+	}
+
+	r := &reconnector{
+		service:     service,
+		req:         req,
+		config:      cfg,
+		maxAttempts: cfg.Stream.MaxReconnectAttempts,
+		backoff:     cfg.Stream.ReconnectBackoff,
+		choices:     make(map[int]*choiceState),
+		out:         make(chan types.StreamChunk, streamBufferSize(cfg.Stream)),
+	}
+	if r.maxAttempts <= 0 {
+		r.maxAttempts = DefaultMaxReconnectAttempts
+	}
+	if r.backoff <= 0 {
+		r.backoff = DefaultReconnectBackoff
+	}
+
+	go r.run(ctx, src)
+	return r.out, nil
+}
+
+// resumeMode is how the current attempt relates to the delivered output.
+type resumeMode int
+
+const (
+	modeContinue resumeMode = iota // the stream continues exactly after the delivered output
+	modePrefill                    // the stream continues a prefill and may repeat some text
+	modeRestart                    // the stream starts over and must repeat the delivered output
+)
+
+// choiceState tracks what has been delivered for one choice.
+type choiceState struct {
+	role     bool
+	content  delivered
+	refusal  delivered
+	function delivered
+	tools    map[int]*toolState
+	finished bool
+
+	// Prefill resync: continuation text held back until overlap is resolved.
+	resync   bool
+	pending  string
+	trimLead bool
+}
+
+// toolState tracks what has been delivered for one tool call.
+type toolState struct {
+	id   string
+	name string
+	args delivered
+}
+
+// delivered is text sent to the consumer, and how much of it the current
+// attempt has reproduced so far.
+type delivered struct {
+	text    string
+	matched int
+}
+
+// accept merges incoming text from the current attempt, returning the part
+// that has not been delivered yet. It reports false if incoming contradicts
+// the delivered text.
+func (d *delivered) accept(incoming string) (string, bool) {
+	if d.matched < len(d.text) {
+		rest := d.text[d.matched:]
+		if len(incoming) <= len(rest) {
+			if rest[:len(incoming)] != incoming {
+				return "", false
+			}
+			d.matched += len(incoming)
+			return "", true
+		}
+		if incoming[:len(rest)] != rest {
+			return "", false
+		}
+		incoming = incoming[len(rest):]
+	}
+	d.text += incoming
+	d.matched = len(d.text)
+	return incoming, true
+}
+
+// complete reports whether the current attempt has reproduced all delivered text.
+func (d *delivered) complete() bool {
+	return d.matched >= len(d.text)
+}
+
+// reconnector runs a reconnecting stream.
+type reconnector struct {
+	service     interfaces.ChatService
+	req         *types.ChatRequest
+	config      ReconnectConfig
+	maxAttempts int
+	backoff     time.Duration
+
+	out         chan types.StreamChunk
+	choices     map[int]*choiceState
+	lastEventID string
+	mode        resumeMode
+}
+
+// run pumps src into the output, reconnecting as needed.
+func (r *reconnector) run(ctx context.Context, src <-chan types.StreamChunk) {
+	defer close(r.out)
+
+	for attempt := 1; ; attempt++ {
+		cause, ok := r.pump(ctx, src)
+		if ok || cause == nil {
+			return
+		}
+		if cause == errClosedUnfinished && !r.config.Restart {
+			r.fail(ctx, &ResumeError{Reason: "stream closed without a finish reason and restart is not enabled", Err: cause})
+			return
+		}
+		if attempt > r.maxAttempts {
+			r.fail(ctx, &ReconnectError{Attempts: r.maxAttempts, Err: cause})
+			return
+		}
+
+		if r.config.OnReconnect != nil {
+			r.config.OnReconnect(attempt, cause)
+		}
+		select {
+		case <-time.After(r.backoff):
+		case <-ctx.Done():
+			return
+		}
+
+		var err error
+		src, err = r.resume(ctx, cause)
+		for err != nil {
+			var resumeErr *ResumeError
+			if errors.As(err, &resumeErr) || !isInterruption(err) {
+				r.fail(ctx, err)
+				return
+			}
+			// Opening the stream failed transiently; count it as an attempt.
+			if attempt++; attempt > r.maxAttempts {
+				r.fail(ctx, &ReconnectError{Attempts: r.maxAttempts, Err: err})
+				return
+			}
+			if r.config.OnReconnect != nil {
+				r.config.OnReconnect(attempt, err)
+			}
+			select {
+			case <-time.After(r.backoff):
+			case <-ctx.Done():
+				return
+			}
+			src, err = r.resume(ctx, cause)
+		}
+	}
+}
+
+// pump forwards src until it ends. It reports ok when the stream completed
+// or a non-retryable error was forwarded (cause nil), and otherwise the
+// interruption cause. A nil cause with ok false means the run must stop.
+func (r *reconnector) pump(ctx context.Context, src <-chan types.StreamChunk) (cause error, ok bool) {
+	for {
+		select {
+		case <-ctx.Done():
+			go drain(src)
+			return nil, false
+
+		case chunk, open := <-src:
+			if !open {
+				if r.finished() {
+					return nil, true
+				}
+				return errClosedUnfinished, false
+			}
+
+			if ec, isErr := chunk.(*types.ErrorChunk); isErr {
+				go drain(src)
+				if isInterruption(ec.Err) {
+					return ec.Err, false
+				}
+				r.send(ctx, chunk)
+				return nil, true
+			}
+
+			if c, hasID := chunk.(types.EventIDChunk); hasID && c.GetEventID() != "" {
+				r.lastEventID = c.GetEventID()
+			}
+
+			filtered, err := r.filter(chunk)
+			if err != nil {
+				go drain(src)
+				r.fail(ctx, err)
+				return nil, false
+			}
+			if !r.send(ctx, filtered) {
+				go drain(src)
+				return nil, false
+			}
+		}
+	}
+}
+
+// resume opens a new stream that continues the delivered output.
+func (r *reconnector) resume(ctx context.Context, cause error) (<-chan types.StreamChunk, error) {
+	if !r.deliveredAny() {
+		r.setMode(modeContinue)
+		return r.service.CreateCompletionStream(ctx, r.req)
+	}
+
+	if resumer, ok := r.service.(interfaces.ChatServiceWithResume); ok && r.lastEventID != "" {
+		r.setMode(modeContinue)
+		return resumer.ResumeCompletionStream(ctx, r.req, r.lastEventID)
+	}
+
+	if r.config.Prefill {
+		prefillReq, reason := r.prefillRequest()
+		if reason == "" {
+			r.setMode(modePrefill)
+			return r.service.CreateCompletionStream(ctx, prefillReq)
+		}
+		if !r.config.Restart {
+			return nil, &ResumeError{Reason: reason, Err: cause}
+		}
+	}
+
+	if r.config.Restart {
+		r.setMode(modeRestart)
+		return r.service.CreateCompletionStream(ctx, r.req)
+	}
+
+	return nil, &ResumeError{
+		Reason: "no event ID to resume from, and neither prefill nor restart is enabled",
+		Err:    cause,
+	}
+}
+
+// prefillRequest builds the request that continues the delivered text, or
+// returns the reason it cannot.
+func (r *reconnector) prefillRequest() (*types.ChatRequest, string) {
+	if len(r.choices) != 1 {
+		return nil, "prefill cannot resume multiple choices"
+	}
+	state, ok := r.choices[0]
+	if !ok {
+		return nil, "prefill can only resume choice 0"
+	}
+	if len(state.tools) > 0 || state.function.text != "" || state.refusal.text != "" {
+		return nil, "prefill can only resume plain text output"
+	}
+
+	// Providers reject prefills ending in whitespace; the model re-emits it.
+	prefill := strings.TrimRight(state.content.text, " \t\r\n")
+	state.trimLead = len(prefill) < len(state.content.text)
+
+	reqCopy := *r.req
+	reqCopy.Messages = append(append([]*types.Message(nil), r.req.Messages...), &types.Message{
+		Role:    types.RoleAssistant,
+		Content: types.NewTextContent(prefill),
+	})
+	return &reqCopy, ""
+}
+
+// setMode prepares the delivered state for a new attempt.
+func (r *reconnector) setMode(mode resumeMode) {
+	r.mode = mode
+	for _, state := range r.choices {
+		reset := func(d *delivered) {
+			if mode == modeRestart {
+				d.matched = 0
+			} else {
+				d.matched = len(d.text)
+			}
+		}
+		reset(&state.content)
+		reset(&state.refusal)
+		reset(&state.function)
+		for _, tool := range state.tools {
+			reset(&tool.args)
+		}
+		state.resync = mode == modePrefill
+		state.pending = ""
+		if mode != modePrefill {
+			state.trimLead = false
+		}
+	}
+}
+
+// filter rewrites a chunk from the current attempt so that only output not
+// yet delivered is forwarded.
+func (r *reconnector) filter(chunk types.StreamChunk) (types.StreamChunk, error) {
+	c, ok := chunk.(*types.ChatStreamChunk)
+	if !ok {
+		// Unknown chunk types cannot be rewritten; record finishes and forward.
+		for _, choice := range chunk.GetChoices() {
+			if choice != nil {
+				r.state(choice.Index).finished = choice.FinishReason != "" && choice.FinishReason != types.FinishReasonNull
+			}
+		}
+		return chunk, nil
+	}
+
+	cp := *c
+	cp.Choices = make([]*types.StreamChoice, 0, len(c.Choices))
+	for _, choice := range c.Choices {
+		if choice == nil {
+			continue
+		}
+		filtered, err := r.filterChoice(choice)
+		if err != nil {
+			return nil, err
+		}
+		cp.Choices = append(cp.Choices, filtered)
+	}
+
+	return &cp, nil
+}
+
+// filterChoice rewrites a single streamed choice.
+func (r *reconnector) filterChoice(choice *types.StreamChoice) (*types.StreamChoice, error) {
+	state := r.state(choice.Index)
+	out := *choice
+	finishing := choice.FinishReason != "" && choice.FinishReason != types.FinishReasonNull
+	diverged := func(what string) error {
+		return &ResumeError{Reason: fmt.Sprintf("resumed %s for choice %d differs from the output already delivered", what, choice.Index)}
+	}
+
+	if d := choice.Delta; d != nil {
+		delta := *d
+
+		if delta.Role != "" {
+			if state.role {
+				delta.Role = ""
+			}
+			state.role = true
+		}
+
+		var ok bool
+		if delta.Refusal, ok = state.refusal.accept(delta.Refusal); !ok {
+			return nil, diverged("refusal")
+		}
+
+		if fc := d.FunctionCall; fc != nil {
+			fcCopy := *fc
+			if fcCopy.Arguments, ok = state.function.accept(fc.Arguments); !ok {
+				return nil, diverged("function call")
+			}
+			delta.FunctionCall = &fcCopy
+		}
+
+		if len(d.ToolCalls) > 0 {
+			delta.ToolCalls = make([]*types.ToolCallDelta, 0, len(d.ToolCalls))
+			for _, tc := range d.ToolCalls {
+				if tc == nil {
+					continue
+				}
+				filtered, ok := state.filterToolCall(tc)
+				if !ok {
+					return nil, diverged("tool call")
+				}
+				delta.ToolCalls = append(delta.ToolCalls, filtered)
+			}
+		}
+		out.Delta = &delta
+	}
+
+	// Content is filtered even without a delta so that text held back after a
+	// prefill resume is flushed by the finishing chunk.
+	var incoming string
+	if out.Delta != nil {
+		incoming = out.Delta.Content
+	}
+	content, err := r.filterContent(state, incoming, finishing)
+	if err != nil {
+		return nil, diverged("content")
+	}
+	if out.Delta != nil {
+		out.Delta.Content = content
+	} else if content != "" {
+		out.Delta = &types.MessageDelta{Content: content}
+	}
+
+	if finishing {
+		if r.mode == modeRestart && !state.replayed() {
+			return nil, &ResumeError{Reason: fmt.Sprintf("restarted choice %d finished before reproducing the output already delivered", choice.Index)}
+		}
+		state.finished = true
+	}
+	return &out, nil
+}
+
+// filterContent handles a content delta, resolving repeated text after a
+// prefill resume.
+func (r *reconnector) filterContent(state *choiceState, incoming string, finishing bool) (string, error) {
+	if !state.resync {
+		content, ok := state.content.accept(incoming)
+		if !ok {
+			return "", ErrResumeNotPossible
+		}
+		return content, nil
+	}
+
+	state.pending += incoming
+	if len(state.pending) < r.config.OverlapWindow && !finishing {
+		return "", nil
+	}
+
+	pending := state.pending
+	if state.trimLead {
+		pending = strings.TrimLeft(pending, " \t\r\n")
+	}
+	pending = pending[overlap(state.content.text, pending, r.config.MinOverlap):]
+
+	state.resync = false
+	state.pending = ""
+	content, _ := state.content.accept(pending)
+	return content, nil
+}
+
+// filterToolCall rewrites a tool call delta. Tool call IDs and names from a
+// restarted stream replace nothing: the delivered ones are kept.
+func (s *choiceState) filterToolCall(tc *types.ToolCallDelta) (*types.ToolCallDelta, bool) {
+	tool, exists := s.tools[tc.Index]
+	if !exists {
+		tool = &toolState{}
+		s.tools[tc.Index] = tool
+	}
+
+	out := *tc
+	if tc.ID != "" {
+		if tool.id != "" {
+			out.ID = ""
+		}
+		tool.id = tc.ID
+	}
+
+	if fn := tc.Function; fn != nil {
+		fnCopy := *fn
+		if fn.Name != "" {
+			if tool.name != "" && tool.name != fn.Name {
+				return nil, false
+			}
+			if tool.name != "" {
+				fnCopy.Name = ""
+			}
+			tool.name = fn.Name
+		}
+		var ok bool
+		if fnCopy.Arguments, ok = tool.args.accept(fn.Arguments); !ok {
+			return nil, false
+		}
+		out.Function = &fnCopy
+	}
+	return &out, true
+}
+
+// replayed reports whether a restarted attempt has reproduced everything
+// delivered for the choice.
+func (s *choiceState) replayed() bool {
+	if !s.content.complete() || !s.refusal.complete() || !s.function.complete() {
+		return false
+	}
+	for _, tool := range s.tools {
+		if !tool.args.complete() {
+			return false
+		}
+	}
+	return true
+}
+
+// state returns the state for a choice, creating it if needed.
+func (r *reconnector) state(index int) *choiceState {
+	state, ok := r.choices[index]
+	if !ok {
+		state = &choiceState{tools: make(map[int]*toolState)}
+		r.choices[index] = state
+	}
+	return state
+}
+
+// finished reports whether at least one choice was seen and all have finished.
+func (r *reconnector) finished() bool {
+	if len(r.choices) == 0 {
+		return false
+	}
+	for _, state := range r.choices {
+		if !state.finished {
+			return false
+		}
+	}
+	return true
+}
+
+// deliveredAny reports whether any output has been delivered.
+func (r *reconnector) deliveredAny() bool {
+	for _, state := range r.choices {
+		if state.content.text != "" || state.refusal.text != "" || state.function.text != "" || len(state.tools) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// send forwards a chunk. It reports false if ctx was cancelled.
+func (r *reconnector) send(ctx context.Context, chunk types.StreamChunk) bool {
+	select {
+	case r.out <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// fail ends the stream with an error chunk.
+func (r *reconnector) fail(ctx context.Context, err error) {
+	r.send(ctx, types.NewErrorChunk(err))
+}
+
+// overlap returns the length of the longest prefix of next, at least
+// minLen bytes long, that delivered ends with. It returns 0 if there is none.
+func overlap(delivered, next string, minLen int) int {
+	for k := min(len(delivered), len(next)); k >= minLen && k > 0; k-- {
+		if strings.HasSuffix(delivered, next[:k]) {
+			return k
+		}
+	}
+	return 0
+}
+
+// isInterruption reports whether err is a transient failure worth reconnecting for.
+func isInterruption(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var aiErr types.AIError
+	if errors.As(err, &aiErr) {
+		return aiErr.Retryable()
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// streamBufferSize returns the configured output buffer size.
+func streamBufferSize(config *types.StreamConfig) int {
+	if config.BufferSize > 0 {
+		return config.BufferSize
+	}
+	return types.DefaultStreamBufferSize
+}
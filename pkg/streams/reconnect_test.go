@@ -0,0 +1,287 @@
+package streams
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// scriptedService returns the next scripted stream on each call.
+type scriptedService struct {
+	mu       sync.Mutex
+	attempts [][]types.StreamChunk
+	requests []*types.ChatRequest
+	resumeID []string
+}
+
+func (s *scriptedService) CreateCompletion(context.Context, *types.ChatRequest) (*types.ChatResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *scriptedService) CreateCompletionStream(ctx context.Context, req *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	if len(s.attempts) == 0 {
+		return nil, errors.New("no more scripted streams")
+	}
+	chunks := s.attempts[0]
+	s.attempts = s.attempts[1:]
+	return chanOf(chunks...), nil
+}
+
+func (s *scriptedService) calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+// resumableService is a scriptedService that resumes from event IDs.
+type resumableService struct {
+	scriptedService
+}
+
+func (s *resumableService) ResumeCompletionStream(ctx context.Context, req *types.ChatRequest, lastEventID string) (<-chan types.StreamChunk, error) {
+	s.mu.Lock()
+	s.resumeID = append(s.resumeID, lastEventID)
+	s.mu.Unlock()
+	return s.CreateCompletionStream(ctx, req)
+}
+
+func withEventID(c *types.ChatStreamChunk, id string) *types.ChatStreamChunk {
+	c.EventID = id
+	return c
+}
+
+var errDropped = types.NewErrorChunk(io.ErrUnexpectedEOF)
+
+func TestReconnect(t *testing.T) {
+	tests := []struct {
+		name        string
+		attempts    [][]types.StreamChunk
+		resumable   bool
+		config      ReconnectConfig
+		maxAttempts int
+		wantContent string
+		wantCalls   int
+		wantErr     error
+		check       func(t *testing.T, s *scriptedService)
+	}{
+		{
+			name:        "complete stream is forwarded",
+			attempts:    [][]types.StreamChunk{{textChunk(0, "Hello", types.FinishReasonStop)}},
+			wantContent: "Hello",
+			wantCalls:   1,
+		},
+		{
+			name: "dropped stream with nothing delivered is re-sent",
+			attempts: [][]types.StreamChunk{
+				{errDropped},
+				{textChunk(0, "Hello", types.FinishReasonStop)},
+			},
+			wantContent: "Hello",
+			wantCalls:   2,
+		},
+		{
+			name: "clean close without finish reason is not re-sent",
+			attempts: [][]types.StreamChunk{
+				{},
+				{textChunk(0, "Hello", types.FinishReasonStop)},
+			},
+			wantCalls: 1,
+			wantErr:   ErrResumeNotPossible,
+		},
+		{
+			name: "clean close after output is not resumed",
+			attempts: [][]types.StreamChunk{
+				{textChunk(0, "Hel", "")},
+				{textChunk(0, "lo", types.FinishReasonStop)},
+			},
+			config:      ReconnectConfig{Prefill: true},
+			wantContent: "Hel",
+			wantCalls:   1,
+			wantErr:     ErrResumeNotPossible,
+		},
+		{
+			name: "clean close is re-sent with restart",
+			attempts: [][]types.StreamChunk{
+				{},
+				{textChunk(0, "Hello", types.FinishReasonStop)},
+			},
+			config:      ReconnectConfig{Restart: true},
+			wantContent: "Hello",
+			wantCalls:   2,
+		},
+		{
+			name: "resumes from the last event ID",
+			attempts: [][]types.StreamChunk{
+				{withEventID(textChunk(0, "Hel", ""), "7"), errDropped},
+				{textChunk(0, "lo", types.FinishReasonStop)},
+			},
+			resumable:   true,
+			wantContent: "Hello",
+			wantCalls:   2,
+			check: func(t *testing.T, s *scriptedService) {
+				if len(s.resumeID) != 1 || s.resumeID[0] != "7" {
+					t.Errorf("resumed from %v, want [7]", s.resumeID)
+				}
+			},
+		},
+		{
+			name: "prefill removes repeated text",
+			attempts: [][]types.StreamChunk{
+				{textChunk(0, "Hello world", ""), errDropped},
+				{textChunk(0, "world, again", ""), textChunk(0, "!", types.FinishReasonStop)},
+			},
+			config:      ReconnectConfig{Prefill: true},
+			wantContent: "Hello world, again!",
+			wantCalls:   2,
+			check: func(t *testing.T, s *scriptedService) {
+				msgs := s.requests[1].Messages
+				last := msgs[len(msgs)-1]
+				if last.Role != types.RoleAssistant || last.Content.String() != "Hello world" {
+					t.Errorf("prefill message = %s %q, want assistant %q", last.Role, last.Content.String(), "Hello world")
+				}
+			},
+		},
+		{
+			name: "prefill keeps overlap shorter than MinOverlap",
+			attempts: [][]types.StreamChunk{
+				{textChunk(0, "Hello wor", ""), errDropped},
+				{textChunk(0, "world", types.FinishReasonStop)},
+			},
+			config:      ReconnectConfig{Prefill: true},
+			wantContent: "Hello worworld",
+			wantCalls:   2,
+		},
+		{
+			name: "prefill trims whitespace the model repeats",
+			attempts: [][]types.StreamChunk{
+				{textChunk(0, "Hello ", ""), errDropped},
+				{textChunk(0, " world", types.FinishReasonStop)},
+			},
+			config:      ReconnectConfig{Prefill: true},
+			wantContent: "Hello world",
+			wantCalls:   2,
+		},
+		{
+			name: "restart skips the replayed output",
+			attempts: [][]types.StreamChunk{
+				{textChunk(0, "Hello", ""), errDropped},
+				{textChunk(0, "Hel", ""), textChunk(0, "lo world", types.FinishReasonStop)},
+			},
+			config:      ReconnectConfig{Restart: true},
+			wantContent: "Hello world",
+			wantCalls:   2,
+		},
+		{
+			name: "restart that diverges fails",
+			attempts: [][]types.StreamChunk{
+				{textChunk(0, "Hello", ""), errDropped},
+				{textChunk(0, "Howdy", types.FinishReasonStop)},
+			},
+			config:      ReconnectConfig{Restart: true},
+			wantContent: "Hello",
+			wantCalls:   2,
+			wantErr:     ErrResumeNotPossible,
+		},
+		{
+			name: "delivered output without a resume mode fails",
+			attempts: [][]types.StreamChunk{
+				{textChunk(0, "Hello", ""), errDropped},
+			},
+			wantContent: "Hello",
+			wantCalls:   1,
+			wantErr:     ErrResumeNotPossible,
+		},
+		{
+			name: "non-retryable error is forwarded",
+			attempts: [][]types.StreamChunk{
+				{types.NewErrorChunk(&types.ProviderError{ErrorType: types.ErrorTypeInvalidRequest, Message: "bad request"})},
+			},
+			wantCalls: 1,
+			wantErr:   &types.ProviderError{},
+		},
+		{
+			name: "attempts are exhausted",
+			attempts: [][]types.StreamChunk{
+				{errDropped}, {errDropped}, {errDropped}, {errDropped},
+			},
+			maxAttempts: 2,
+			wantCalls:   3,
+			wantErr:     &ReconnectError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := &resumableService{scriptedService{attempts: tt.attempts}}
+			config := tt.config
+			config.Stream = &types.StreamConfig{
+				EnableReconnect:      true,
+				MaxReconnectAttempts: tt.maxAttempts,
+				ReconnectBackoff:     time.Millisecond,
+			}
+
+			var stream <-chan types.StreamChunk
+			var err error
+			req := &types.ChatRequest{Model: "m", Messages: []*types.Message{{Role: types.RoleUser, Content: types.NewTextContent("Hi")}}}
+			if tt.resumable {
+				stream, err = Reconnect(context.Background(), rs, req, &config)
+			} else {
+				stream, err = Reconnect(context.Background(), &rs.scriptedService, req, &config)
+			}
+			if err != nil {
+				t.Fatalf("Reconnect() error = %v", err)
+			}
+
+			var content strings.Builder
+			var streamErr error
+			for chunk := range stream {
+				if ec, ok := chunk.(*types.ErrorChunk); ok {
+					streamErr = ec.Err
+					continue
+				}
+				for _, choice := range chunk.GetChoices() {
+					if choice.Delta != nil {
+						content.WriteString(choice.Delta.Content)
+					}
+				}
+			}
+
+			if content.String() != tt.wantContent {
+				t.Errorf("content = %q, want %q", content.String(), tt.wantContent)
+			}
+			if got := rs.calls(); got != tt.wantCalls {
+				t.Errorf("made %d requests, want %d", got, tt.wantCalls)
+			}
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if streamErr != nil {
+					t.Errorf("stream error = %v, want none", streamErr)
+				}
+			case *types.ProviderError:
+				if !errors.As(streamErr, &want) {
+					t.Errorf("stream error = %v, want a *types.ProviderError", streamErr)
+				}
+			case *ReconnectError:
+				if !errors.As(streamErr, &want) || want.Attempts != tt.maxAttempts {
+					t.Errorf("stream error = %v, want a *ReconnectError after %d attempts", streamErr, tt.maxAttempts)
+				}
+			default:
+				if !errors.Is(streamErr, tt.wantErr) {
+					t.Errorf("stream error = %v, want %v", streamErr, tt.wantErr)
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, &rs.scriptedService)
+			}
+		})
+	}
+}
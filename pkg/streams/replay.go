@@ -0,0 +1,120 @@
+package streams
+
+import (
+	"context"
+	"sync"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Replay records a stream so that any number of subscribers, including ones
+// that subscribe after chunks were sent or after the stream ended, receive
+// the complete stream from the first chunk.
+//
+// The source is read as fast as it produces; subscribers never slow it
+// down. Every chunk is retained for the lifetime of the Replay.
+//
+// A Replay is safe for concurrent use.
+type Replay struct {
+	mu     sync.Mutex
+	chunks []types.StreamChunk
+	closed bool
+	notify chan struct{} // closed and replaced whenever chunks or closed change
+	done   chan struct{}
+}
+
+// NewReplay starts recording src. Recording stops when src is closed or ctx
+// is cancelled; on cancellation, src is drained in the background.
+func NewReplay(ctx context.Context, src <-chan types.StreamChunk) *Replay {
+	r := &Replay{
+		notify: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go r.record(ctx, src)
+	return r
+}
+
+// record reads src into the history.
+func (r *Replay) record(ctx context.Context, src <-chan types.StreamChunk) {
+	defer r.finish()
+	for {
+		select {
+		case <-ctx.Done():
+			go drain(src)
+			return
+		case chunk, ok := <-src:
+			if !ok {
+				return
+			}
+			r.mu.Lock()
+			r.chunks = append(r.chunks, chunk)
+			r.broadcast()
+			r.mu.Unlock()
+		}
+	}
+}
+
+// finish marks the recording complete.
+func (r *Replay) finish() {
+	r.mu.Lock()
+	r.closed = true
+	r.broadcast()
+	r.mu.Unlock()
+	close(r.done)
+}
+
+// broadcast wakes all waiting subscribers. r.mu must be held.
+func (r *Replay) broadcast() {
+	close(r.notify)
+	r.notify = make(chan struct{})
+}
+
+// Subscribe returns a stream that delivers every recorded chunk from the
+// beginning, followed by new chunks as they arrive. The returned channel is
+// closed after the last chunk once recording has finished, or when ctx is
+// cancelled.
+func (r *Replay) Subscribe(ctx context.Context) <-chan types.StreamChunk {
+	out := make(chan types.StreamChunk)
+	go func() {
+		defer close(out)
+		for next := 0; ; {
+			r.mu.Lock()
+			if next < len(r.chunks) {
+				chunk := r.chunks[next]
+				r.mu.Unlock()
+				select {
+				case out <- chunk:
+					next++
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+			if r.closed {
+				r.mu.Unlock()
+				return
+			}
+			wait := r.notify
+			r.mu.Unlock()
+
+			select {
+			case <-wait:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Chunks returns a snapshot of the chunks recorded so far.
+func (r *Replay) Chunks() []types.StreamChunk {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]types.StreamChunk(nil), r.chunks...)
+}
+
+// Done returns a channel that is closed when recording has finished.
+func (r *Replay) Done() <-chan struct{} {
+	return r.done
+}
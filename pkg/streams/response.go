@@ -0,0 +1,87 @@
+package streams
+
+import (
+	"context"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// FromResponse returns a stream that replays a complete response: one chunk
+// per choice carrying its whole message and finish reason, followed by a
+// usage chunk when the response has usage. It lets a cached or otherwise
+// non-streamed response be served to a streaming consumer.
+func FromResponse(ctx context.Context, resp *types.ChatResponse) <-chan types.StreamChunk {
+	chunks := ResponseChunks(resp)
+	out := make(chan types.StreamChunk, len(chunks))
+	go func() {
+		defer close(out)
+		for _, chunk := range chunks {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// ResponseChunks converts a complete response to the chunks FromResponse sends.
+func ResponseChunks(resp *types.ChatResponse) []types.StreamChunk {
+	chunks := make([]types.StreamChunk, 0, len(resp.Choices)+1)
+	for _, choice := range resp.Choices {
+		if choice == nil {
+			continue
+		}
+		sc := &types.StreamChoice{
+			Index:        choice.Index,
+			FinishReason: choice.FinishReason,
+			LogProbs:     choice.LogProbs,
+			Delta:        &types.MessageDelta{Role: types.RoleAssistant},
+		}
+		if msg := choice.Message; msg != nil {
+			if msg.Role != "" {
+				sc.Delta.Role = msg.Role
+			}
+			if msg.Content != nil {
+				sc.Delta.Content = msg.Content.String()
+			}
+			sc.Delta.Refusal = msg.Refusal
+			for i, tc := range msg.ToolCalls {
+				sc.Delta.ToolCalls = append(sc.Delta.ToolCalls, &types.ToolCallDelta{
+					Index: i,
+					ID:    tc.ID,
+					Type:  tc.Type,
+					Function: &types.FunctionCallDelta{
+						Name:      tc.Function.Name,
+						Arguments: tc.Function.Arguments,
+					},
+				})
+			}
+			if fc := msg.FunctionCall; fc != nil {
+				sc.Delta.FunctionCall = &types.FunctionCallDelta{Name: fc.Name, Arguments: fc.Arguments}
+			}
+		}
+		chunks = append(chunks, &types.ChatStreamChunk{
+			ID:                resp.ID,
+			Object:            "chat.completion.chunk",
+			Created:           resp.Created,
+			Model:             resp.Model,
+			SystemFingerprint: resp.SystemFingerprint,
+			Choices:           []*types.StreamChoice{sc},
+		})
+	}
+	if resp.Usage != nil {
+		usage := *resp.Usage
+		chunks = append(chunks, &types.ChatStreamChunk{
+			ID:                resp.ID,
+			Object:            "chat.completion.chunk",
+			Created:           resp.Created,
+			Model:             resp.Model,
+			SystemFingerprint: resp.SystemFingerprint,
+			Choices:           []*types.StreamChoice{},
+			Usage:             &usage,
+		})
+	}
+	return chunks
+}
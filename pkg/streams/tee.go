@@ -0,0 +1,137 @@
+package streams
+
+import (
+	"context"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Policy determines what Tee does when a consumer is not keeping up.
+type Policy int
+
+const (
+	// PolicyBlock delivers every chunk to every consumer over unbuffered
+	// channels. The source is read only as fast as the slowest consumer.
+	PolicyBlock Policy = iota
+
+	// PolicyBuffer gives each consumer a buffer of BufferSize chunks. When a
+	// consumer's buffer is full, Tee waits for it, as with PolicyBlock.
+	PolicyBuffer
+
+	// PolicyDrop gives each consumer a buffer of BufferSize chunks and drops
+	// chunks for a consumer whose buffer is full, so a slow consumer never
+	// delays the others. Suitable for consumers that tolerate gaps, such as
+	// metrics collection.
+	PolicyDrop
+)
+
+// String returns the string representation of the Policy.
+func (p Policy) String() string {
+	switch p {
+	case PolicyBlock:
+		return "block"
+	case PolicyBuffer:
+		return "buffer"
+	case PolicyDrop:
+		return "drop"
+	default:
+		return "unknown"
+	}
+}
+
+// TeeConfig configures Tee.
+type TeeConfig struct {
+	// Policy is the backpressure policy. Default is PolicyBlock.
+	Policy Policy
+
+	// BufferSize is the per-consumer buffer size for PolicyBuffer and
+	// PolicyDrop, typically types.StreamConfig.BufferSize.
+	// Defaults to types.DefaultStreamBufferSize if zero.
+	BufferSize int
+
+	// OnDrop is called when PolicyDrop discards a chunk for a consumer.
+	OnDrop func(consumer int, chunk types.StreamChunk)
+}
+
+// Tee splits src into n streams that each receive every chunk, subject to
+// the backpressure policy. If config is nil, PolicyBlock is used.
+//
+// All outputs are closed when src is closed or ctx is cancelled. On
+// cancellation, src is drained in the background so its producer is not
+// left blocked.
+func Tee(ctx context.Context, src <-chan types.StreamChunk, n int, config *TeeConfig) []<-chan types.StreamChunk {
+	cfg := TeeConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = types.DefaultStreamBufferSize
+	}
+
+	size := cfg.BufferSize
+	if cfg.Policy == PolicyBlock {
+		size = 0
+	}
+
+	outs := make([]chan types.StreamChunk, n)
+	result := make([]<-chan types.StreamChunk, n)
+	for i := range outs {
+		outs[i] = make(chan types.StreamChunk, size)
+		result[i] = outs[i]
+	}
+
+	go func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				go drain(src)
+				return
+			case chunk, ok := <-src:
+				if !ok {
+					return
+				}
+				if !cfg.send(ctx, outs, chunk) {
+					go drain(src)
+					return
+				}
+			}
+		}
+	}()
+
+	return result
+}
+
+// send delivers chunk to every output. It reports false if ctx was cancelled.
+func (c *TeeConfig) send(ctx context.Context, outs []chan types.StreamChunk, chunk types.StreamChunk) bool {
+	for i, out := range outs {
+		if c.Policy == PolicyDrop {
+			select {
+			case out <- chunk:
+			default:
+				if c.OnDrop != nil {
+					c.OnDrop(i, chunk)
+				}
+			}
+			continue
+		}
+
+		select {
+		case out <- chunk:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// drain discards the remaining chunks of src until it is closed.
+func drain(src <-chan types.StreamChunk) {
+	for range src {
+	}
+}
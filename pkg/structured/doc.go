@@ -0,0 +1,30 @@
+// Package structured provides typed structured output on top of any
+// interfaces.ChatService.
+//
+// Generate derives a strict JSON schema from a Go type, sends it as the
+// request's ResponseFormat, validates the reply against the schema and decodes
+// it into the type. Refusals and truncated replies are reported as typed
+// errors (*RefusalError and *TruncatedError); replies that do not match the
+// schema are reported as *OutputError.
+//
+// Example usage:
+//
+//	type Sentiment struct {
+//	    Label      string  `json:"label" enum:"positive,neutral,negative"`
+//	    Confidence float64 `json:"confidence" description:"Between 0 and 1"`
+//	}
+//
+//	req := types.NewChatRequest("gpt-4o", []*types.Message{
+//	    {Role: types.RoleUser, Content: types.NewTextContent("Classify: I love it!")},
+//	})
+//
+//	sentiment, result, err := structured.Generate[Sentiment](ctx, chatService, req)
+//	var refusal *structured.RefusalError
+//	switch {
+//	case errors.As(err, &refusal):
+//	    log.Printf("model refused: %s", refusal.Refusal)
+//	case err != nil:
+//	    return err
+//	}
+//	log.Printf("%s (%.2f), %d tokens", sentiment.Label, sentiment.Confidence, result.Response.Usage.TotalTokens)
+package structured
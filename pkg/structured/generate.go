@@ -0,0 +1,264 @@
+package structured
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/zacw/go-ai-types/pkg/interfaces"
+	"github.com/zacw/go-ai-types/pkg/schema"
+	"github.com/zacw/go-ai-types/pkg/types"
+	"github.com/zacw/go-ai-types/pkg/validators"
+)
+
+// DefaultSchemaName is the schema name used when the target type is unnamed.
+const DefaultSchemaName = "response"
+
+// ErrNoChoices is returned when the response contains no choices.
+var ErrNoChoices = errors.New("structured: response contains no choices")
+
+// RefusalError is returned when the model declines to produce structured output.
+type RefusalError struct {
+	// Refusal is the model's refusal message.
+	Refusal string
+
+	// Response is the full response.
+	Response *types.ChatResponse
+}
+
+// Error implements the error interface.
+func (e *RefusalError) Error() string {
+	return "structured: model refused: " + e.Refusal
+}
+
+// TruncatedError is returned when the model stopped because it reached the
+// token limit (FinishReasonLength), leaving the output incomplete.
+type TruncatedError struct {
+	// Content is the incomplete output.
+	Content string
+
+	// Response is the full response.
+	Response *types.ChatResponse
+}
+
+// Error implements the error interface.
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("structured: output truncated after %d bytes (finish_reason=%s)", len(e.Content), types.FinishReasonLength)
+}
+
+// OutputError is returned when the model's output is not valid JSON, does not
+// conform to the schema, or cannot be decoded into the target type.
+type OutputError struct {
+	// Content is the output that failed.
+	Content string
+
+	// Err is the underlying error. Schema violations are *types.ValidationError.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *OutputError) Error() string {
+	return "structured: invalid output: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *OutputError) Unwrap() error {
+	return e.Err
+}
+
+// Options configures structured output generation.
+type Options struct {
+	// Name is the schema name sent to the provider.
+	// Defaults to the Go type name, or DefaultSchemaName for unnamed types.
+	Name string
+
+	// Description describes the expected output to the model.
+	Description string
+
+	// NonStrict generates a non-strict schema (optional fields may be omitted,
+	// maps are allowed) and disables provider-side strict mode.
+	NonStrict bool
+
+	// SkipValidation skips schema validation of the reply before decoding.
+	SkipValidation bool
+}
+
+// Result contains the details of a structured output call.
+type Result struct {
+	// Response is the raw chat response.
+	Response *types.ChatResponse
+
+	// Content is the JSON output that was decoded.
+	Content string
+
+	// Schema is the schema the output was requested and validated against.
+	Schema *types.JSONSchema
+}
+
+// Usage returns the token usage of the call, or nil if unavailable.
+func (r *Result) Usage() *types.Usage {
+	if r == nil || r.Response == nil {
+		return nil
+	}
+	return r.Response.Usage
+}
+
+// Generate requests structured output of type T.
+//
+// The request is copied; the caller's request is not modified.
+// See GenerateWithOptions for details.
+func Generate[T any](ctx context.Context, service interfaces.ChatService, req *types.ChatRequest) (T, *Result, error) {
+	return GenerateWithOptions[T](ctx, service, req, nil)
+}
+
+// GenerateWithOptions requests structured output of type T.
+//
+// The schema is derived from T with the schema package and sent as a
+// json_schema ResponseFormat. The reply is validated against the schema and
+// decoded into T.
+//
+// A non-nil Result is returned whenever the service returned a response,
+// including when decoding fails, so the raw output can be inspected.
+//
+// Returns:
+// - *types.ValidationError if service or req is nil
+// - *RefusalError if the model refused
+// - *TruncatedError if the output was cut off by the token limit
+// - *OutputError if the output is invalid or does not match the schema
+// - the service error if the request failed
+func GenerateWithOptions[T any](ctx context.Context, service interfaces.ChatService, req *types.ChatRequest, opts *Options) (T, *Result, error) {
+	var zero T
+	if service == nil {
+		return zero, nil, types.NewValidationError("service", "chat service is required")
+	}
+	if req == nil {
+		return zero, nil, types.NewValidationError("req", "chat request is required")
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	format, s, err := ResponseFormat[T](opts)
+	if err != nil {
+		return zero, nil, err
+	}
+
+	reqCopy := *req
+	reqCopy.ResponseFormat = format
+
+	resp, err := service.CreateCompletion(ctx, &reqCopy)
+	if err != nil {
+		return zero, nil, err
+	}
+
+	result := &Result{Response: resp, Schema: s}
+	validateWith := s
+	if opts.SkipValidation {
+		validateWith = nil
+	}
+	value, content, err := decode[T](resp, validateWith)
+	result.Content = content
+	return value, result, err
+}
+
+// ResponseFormat builds the json_schema ResponseFormat for T and returns it
+// along with the generated schema.
+func ResponseFormat[T any](opts *Options) (*types.ResponseFormat, *types.JSONSchema, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	s, err := schema.For[T](&schema.Options{Strict: !opts.NonStrict})
+	if err != nil {
+		return nil, nil, fmt.Errorf("structured: derive schema: %w", err)
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = schemaName(reflect.TypeOf((*T)(nil)).Elem())
+	}
+
+	return types.NewJSONSchemaResponseFormat(&types.JSONSchemaFormat{
+		Name:        name,
+		Description: opts.Description,
+		Schema:      s,
+		Strict:      !opts.NonStrict,
+	}), s, nil
+}
+
+// Decode extracts structured output of type T from a response, for example
+// one assembled from a stream with types.StreamAccumulator.
+//
+// If s is non-nil, the output is validated against it before decoding.
+// Returns the same typed errors as GenerateWithOptions.
+func Decode[T any](resp *types.ChatResponse, s *types.JSONSchema) (T, error) {
+	value, _, err := decode[T](resp, s)
+	return value, err
+}
+
+// decode checks the response for refusal and truncation, then validates and
+// decodes the first choice's content. It returns the content it decoded.
+func decode[T any](resp *types.ChatResponse, s *types.JSONSchema) (T, string, error) {
+	var zero T
+	if resp == nil || len(resp.Choices) == 0 {
+		return zero, "", ErrNoChoices
+	}
+
+	if refusal := resp.GetRefusal(); refusal != "" {
+		return zero, "", &RefusalError{Refusal: refusal, Response: resp}
+	}
+
+	content := stripCodeFence(resp.GetFirstContent())
+	if resp.GetFirstFinishReason() == types.FinishReasonLength {
+		return zero, content, &TruncatedError{Content: content, Response: resp}
+	}
+
+	if s != nil {
+		if err := validators.ValidateJSONSchemaCompliance(s, []byte(content)); err != nil {
+			return zero, content, &OutputError{Content: content, Err: err}
+		}
+	}
+
+	var value T
+	if err := json.Unmarshal([]byte(content), &value); err != nil {
+		return zero, content, &OutputError{Content: content, Err: err}
+	}
+	return value, content, nil
+}
+
+// stripCodeFence removes a surrounding Markdown code fence, which some models
+// add even in JSON mode.
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") || !strings.HasSuffix(s, "```") || len(s) < 6 {
+		return s
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "```"), "```")
+	if nl := strings.IndexByte(s, '\n'); nl >= 0 && !strings.ContainsAny(s[:nl], "{[\"") {
+		s = s[nl+1:]
+	}
+	return strings.TrimSpace(s)
+}
+
+// schemaName derives a provider-safe schema name ([a-zA-Z0-9_-]{1,64}) from t.
+func schemaName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, t.Name())
+	if name == "" {
+		return DefaultSchemaName
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
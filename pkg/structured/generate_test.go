@@ -0,0 +1,181 @@
+package structured
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+type weather struct {
+	City string  `json:"city"`
+	Temp float64 `json:"temp"`
+	Unit string  `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+}
+
+// replyService answers every request with a fixed message and finish reason.
+type replyService struct {
+	msg    *types.Message
+	reason types.FinishReason
+	err    error
+	req    *types.ChatRequest
+}
+
+func (s *replyService) CreateCompletion(ctx context.Context, req *types.ChatRequest) (*types.ChatResponse, error) {
+	s.req = req
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &types.ChatResponse{
+		Choices: []*types.Choice{{Message: s.msg, FinishReason: s.reason}},
+		Usage:   &types.Usage{TotalTokens: 7},
+	}, nil
+}
+
+func (s *replyService) CreateCompletionStream(context.Context, *types.ChatRequest) (<-chan types.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func reply(content string) *types.Message {
+	return &types.Message{Role: types.RoleAssistant, Content: types.NewTextContent(content)}
+}
+
+func TestGenerate(t *testing.T) {
+	errDown := errors.New("down")
+	tests := []struct {
+		name    string
+		svc     *replyService
+		want    weather
+		wantErr func(t *testing.T, err error)
+	}{
+		{
+			name: "valid output",
+			svc:  &replyService{msg: reply(`{"city":"Paris","temp":21,"unit":"celsius"}`), reason: types.FinishReasonStop},
+			want: weather{City: "Paris", Temp: 21, Unit: "celsius"},
+		},
+		{
+			name: "code fence is stripped",
+			svc:  &replyService{msg: reply("```json\n{\"city\":\"Oslo\",\"temp\":-3,\"unit\":null}\n```"), reason: types.FinishReasonStop},
+			want: weather{City: "Oslo", Temp: -3},
+		},
+		{
+			name: "refusal",
+			svc:  &replyService{msg: &types.Message{Role: types.RoleAssistant, Refusal: "I can't help with that."}, reason: types.FinishReasonStop},
+			wantErr: func(t *testing.T, err error) {
+				var refusal *RefusalError
+				if !errors.As(err, &refusal) || refusal.Refusal != "I can't help with that." || refusal.Response == nil {
+					t.Errorf("error = %v, want a *RefusalError with the refusal", err)
+				}
+			},
+		},
+		{
+			name: "truncated",
+			svc:  &replyService{msg: reply(`{"city":"Par`), reason: types.FinishReasonLength},
+			wantErr: func(t *testing.T, err error) {
+				var truncated *TruncatedError
+				if !errors.As(err, &truncated) || truncated.Content != `{"city":"Par` {
+					t.Errorf("error = %v, want a *TruncatedError with the partial content", err)
+				}
+			},
+		},
+		{
+			name: "schema mismatch",
+			svc:  &replyService{msg: reply(`{"city":"Paris","temp":21,"unit":"kelvin"}`), reason: types.FinishReasonStop},
+			wantErr: func(t *testing.T, err error) {
+				var outputErr *OutputError
+				var validationErr *types.ValidationError
+				if !errors.As(err, &outputErr) || !errors.As(err, &validationErr) {
+					t.Errorf("error = %v, want an *OutputError wrapping a *types.ValidationError", err)
+				}
+			},
+		},
+		{
+			name: "missing required field",
+			svc:  &replyService{msg: reply(`{"city":"Paris","unit":null}`), reason: types.FinishReasonStop},
+			wantErr: func(t *testing.T, err error) {
+				var outputErr *OutputError
+				if !errors.As(err, &outputErr) {
+					t.Errorf("error = %v, want an *OutputError", err)
+				}
+			},
+		},
+		{
+			name: "invalid JSON",
+			svc:  &replyService{msg: reply(`not json`), reason: types.FinishReasonStop},
+			wantErr: func(t *testing.T, err error) {
+				var outputErr *OutputError
+				if !errors.As(err, &outputErr) || outputErr.Content != "not json" {
+					t.Errorf("error = %v, want an *OutputError with the content", err)
+				}
+			},
+		},
+		{
+			name: "service error",
+			svc:  &replyService{err: errDown},
+			wantErr: func(t *testing.T, err error) {
+				if !errors.Is(err, errDown) {
+					t.Errorf("error = %v, want %v", err, errDown)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := types.NewChatRequest("gpt-4", []*types.Message{{Role: types.RoleUser, Content: types.NewTextContent("weather?")}})
+			got, result, err := Generate[weather](context.Background(), tt.svc, req)
+
+			if req.ResponseFormat != nil {
+				t.Error("Generate() modified the caller's request")
+			}
+			if format, ok := tt.svc.req.ResponseFormat.JSONSchema.(*types.JSONSchemaFormat); !ok || format.Name != "weather" || !format.Strict {
+				t.Errorf("sent ResponseFormat = %+v, want the strict weather schema", tt.svc.req.ResponseFormat)
+			}
+			if tt.wantErr != nil {
+				tt.wantErr(t, err)
+				if tt.svc.err == nil && result == nil {
+					t.Error("Generate() result = nil, want the raw response")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Generate() = %+v, want %+v", got, tt.want)
+			}
+			if result.Usage().TotalTokens != 7 {
+				t.Errorf("Result.Usage() = %+v", result.Usage())
+			}
+		})
+	}
+}
+
+func TestGenerateInvalidArguments(t *testing.T) {
+	req := types.NewChatRequest("gpt-4", nil)
+	tests := []struct {
+		name  string
+		svc   *replyService
+		req   *types.ChatRequest
+		field string
+	}{
+		{name: "nil service", req: req, field: "service"},
+		{name: "nil request", svc: &replyService{}, field: "req"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.svc == nil {
+				_, _, err = Generate[weather](context.Background(), nil, tt.req)
+			} else {
+				_, _, err = Generate[weather](context.Background(), tt.svc, tt.req)
+			}
+			var validationErr *types.ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != tt.field {
+				t.Errorf("Generate() error = %v, want a *types.ValidationError for %s", err, tt.field)
+			}
+		})
+	}
+}
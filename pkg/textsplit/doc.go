@@ -0,0 +1,42 @@
+// Package textsplit splits documents into chunks that fit embedding model
+// input limits.
+//
+// Chunk sizes are measured in tokens with a types.TokenCounter, so the same
+// budget used for requests applies to chunks. Every splitter breaks text at
+// the most natural boundary that makes it fit, then packs the pieces back
+// together into chunks of up to Config.ChunkSize tokens, optionally
+// repeating up to Config.ChunkOverlap tokens between consecutive chunks.
+//
+//   - RecursiveSplitter breaks text by paragraphs, lines, sentences, words
+//     and finally characters, or by any list of Separators.
+//   - NewSentenceSplitter returns a RecursiveSplitter that packs whole
+//     sentences.
+//   - MarkdownSplitter keeps sections under different headings apart,
+//     keeps fenced code blocks whole where possible, and records the
+//     heading path of every chunk.
+//
+// Each Chunk records its byte offsets in the source, both as fields and in
+// its Metadata (MetadataKeyStart, MetadataKeyEnd), so search results can be
+// traced back to the exact passage.
+//
+// Example usage:
+//
+//	splitter := textsplit.NewMarkdownSplitter(&textsplit.Config{
+//	    ChunkSize:    400,
+//	    ChunkOverlap: 50,
+//	})
+//	chunks := splitter.Split(document)
+//
+//	req := types.NewEmbeddingRequestFromStrings("text-embedding-3-small", textsplit.Texts(chunks))
+//	resp, err := embeddingService.CreateEmbedding(ctx, req)
+//	if err != nil {
+//	    return err
+//	}
+//	ids := make([]string, len(chunks))
+//	metadata := make([]map[string]interface{}, len(chunks))
+//	for i, c := range chunks {
+//	    ids[i] = fmt.Sprintf("%s#%d", docID, c.Index)
+//	    metadata[i] = c.Metadata
+//	}
+//	err = idx.AddEmbeddings(resp, ids, metadata)
+package textsplit
@@ -0,0 +1,162 @@
+package textsplit
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	headingLine = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	fenceLine   = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*([^`\\s]*)")
+)
+
+// CodeSeparators break fenced code blocks by blank lines, then lines.
+var CodeSeparators = []Separator{Paragraphs, Lines}
+
+// MarkdownSplitter splits Markdown into sections at ATX headings ("#" to
+// "######"), so no chunk spans two sections. Sections larger than
+// ChunkSize are split like RecursiveSplitter, except that fenced code
+// blocks are kept whole when they fit in a chunk and otherwise split by
+// CodeSeparators. Headings inside code blocks are ignored.
+//
+// Every chunk under a heading has the heading path in its metadata under
+// MetadataKeyHeadings, and chunks inside a code block have its language
+// under MetadataKeyCodeLanguage. Overlap does not cross sections.
+type MarkdownSplitter struct {
+	config Config
+}
+
+// NewMarkdownSplitter creates a Markdown splitter. Nil config uses
+// defaults; config.Separators applies to prose outside code blocks.
+func NewMarkdownSplitter(config *Config) *MarkdownSplitter {
+	cfg := Config{}
+	if config != nil {
+		cfg = *config
+	}
+	return &MarkdownSplitter{config: cfg.withDefaults(DefaultSeparators)}
+}
+
+// section is a heading and the text up to the next heading.
+type section struct {
+	span
+	headings []string
+	blocks   []block
+}
+
+// block is prose or a fenced code block within a section.
+type block struct {
+	span
+	code     bool
+	language string
+}
+
+// Split implements Splitter.
+func (s *MarkdownSplitter) Split(text string) []Chunk {
+	p := packer{config: s.config, text: text}
+	for _, sec := range parseMarkdown(text) {
+		var base map[string]interface{}
+		if len(sec.headings) > 0 {
+			base = map[string]interface{}{MetadataKeyHeadings: sec.headings}
+		}
+		first := len(p.chunks)
+
+		if p.count(sec.span) <= s.config.ChunkSize {
+			p.pack([]span{sec.span}, base)
+		} else {
+			var pieces []span
+			for _, b := range sec.blocks {
+				if b.code {
+					pieces = append(pieces, p.pieces(b.span, CodeSeparators)...)
+				} else {
+					pieces = append(pieces, p.pieces(b.span, s.config.Separators)...)
+				}
+			}
+			p.pack(pieces, base)
+		}
+
+		for i := first; i < len(p.chunks); i++ {
+			c := &p.chunks[i]
+			for _, b := range sec.blocks {
+				if b.code && b.language != "" && c.Start >= b.start && c.End <= b.end {
+					c.Metadata[MetadataKeyCodeLanguage] = b.language
+				}
+			}
+		}
+	}
+	return p.chunks
+}
+
+// parseMarkdown divides text into sections at headings and each section
+// into prose and fenced code blocks.
+func parseMarkdown(text string) []section {
+	var sections []section
+	cur := section{}
+	blockStart := 0
+	var stack []string // heading text by level - 1
+	var fence string   // closing fence of the open code block
+	var language string
+
+	closeBlock := func(end int, code bool) {
+		if end > blockStart {
+			cur.blocks = append(cur.blocks, block{span: span{blockStart, end}, code: code, language: language})
+		}
+		blockStart = end
+	}
+
+	for offset := 0; offset < len(text); {
+		end := strings.IndexByte(text[offset:], '\n')
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += offset + 1
+		}
+		line := strings.TrimRight(text[offset:end], "\r\n")
+
+		switch {
+		case fence != "":
+			if m := fenceLine.FindStringSubmatch(line); m != nil && m[1][0] == fence[0] && len(m[1]) >= len(fence) && m[2] == "" {
+				closeBlock(end, true)
+				fence, language = "", ""
+			}
+		case fenceLine.MatchString(line):
+			m := fenceLine.FindStringSubmatch(line)
+			closeBlock(offset, false)
+			fence, language = m[1], m[2]
+		case headingLine.MatchString(line):
+			m := headingLine.FindStringSubmatch(line)
+			closeBlock(offset, false)
+			cur.end = offset
+			if cur.end > cur.start {
+				sections = append(sections, cur)
+			}
+			level := len(m[1])
+			if len(stack) >= level {
+				stack = stack[:level-1]
+			}
+			for len(stack) < level-1 {
+				stack = append(stack, "")
+			}
+			stack = append(stack, strings.TrimSpace(m[2]))
+			cur = section{span: span{start: offset}, headings: headingPath(stack)}
+		}
+		offset = end
+	}
+
+	closeBlock(len(text), fence != "")
+	cur.end = len(text)
+	if cur.end > cur.start {
+		sections = append(sections, cur)
+	}
+	return sections
+}
+
+// headingPath returns a copy of stack without skipped levels.
+func headingPath(stack []string) []string {
+	path := make([]string, 0, len(stack))
+	for _, h := range stack {
+		if h != "" {
+			path = append(path, h)
+		}
+	}
+	return path
+}
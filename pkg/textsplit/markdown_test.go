@@ -0,0 +1,91 @@
+package textsplit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const markdownDoc = "Intro text before any heading.\n" +
+	"\n" +
+	"# Guide\n" +
+	"\n" +
+	"Some prose under the guide.\n" +
+	"\n" +
+	"## Install\n" +
+	"\n" +
+	"Run the installer.\n" +
+	"\n" +
+	"```go\n" +
+	"# not a heading\n" +
+	"func main() {\n" +
+	"\tfmt.Println(\"hi\")\n" +
+	"}\n" +
+	"```\n" +
+	"\n" +
+	"#### Skipped level\n" +
+	"\n" +
+	"Deep text.\n" +
+	"\n" +
+	"# Reference\n" +
+	"\n" +
+	"Last section.\n"
+
+func TestMarkdownSplitter(t *testing.T) {
+	chunks := NewMarkdownSplitter(&Config{ChunkSize: 1000}).Split(markdownDoc)
+
+	want := []struct {
+		prefix   string
+		headings []string
+	}{
+		{prefix: "Intro text"},
+		{prefix: "# Guide", headings: []string{"Guide"}},
+		{prefix: "## Install", headings: []string{"Guide", "Install"}},
+		{prefix: "#### Skipped level", headings: []string{"Guide", "Install", "Skipped level"}},
+		{prefix: "# Reference", headings: []string{"Reference"}},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("Split() returned %d chunks, want one per section (%d): %q", len(chunks), len(want), Texts(chunks))
+	}
+	for i, w := range want {
+		c := chunks[i]
+		if !strings.HasPrefix(c.Text, w.prefix) {
+			t.Errorf("chunk %d = %q, want prefix %q", i, c.Text, w.prefix)
+		}
+		headings, _ := c.Metadata[MetadataKeyHeadings].([]string)
+		if fmt.Sprint(headings) != fmt.Sprint(w.headings) {
+			t.Errorf("chunk %d headings = %q, want %q", i, headings, w.headings)
+		}
+	}
+	if !strings.Contains(chunks[2].Text, "# not a heading") {
+		t.Errorf("heading inside a code block split the section: %q", chunks[2].Text)
+	}
+}
+
+func TestMarkdownSplitterCodeBlocks(t *testing.T) {
+	chunks := NewMarkdownSplitter(&Config{ChunkSize: 20}).Split(markdownDoc)
+
+	var code *Chunk
+	for i := range chunks {
+		if strings.HasPrefix(chunks[i].Text, "```go") {
+			code = &chunks[i]
+		}
+	}
+	if code == nil {
+		t.Fatalf("no chunk starts with the code block: %q", Texts(chunks))
+	}
+	if !strings.HasSuffix(code.Text, "```") {
+		t.Errorf("code block that fits in a chunk was split: %q", code.Text)
+	}
+	if code.Metadata[MetadataKeyCodeLanguage] != "go" {
+		t.Errorf("code chunk language = %v, want go", code.Metadata[MetadataKeyCodeLanguage])
+	}
+	for _, c := range chunks {
+		if c.Start != code.Start && c.Metadata[MetadataKeyCodeLanguage] != nil {
+			t.Errorf("prose chunk %q has a code language", c.Text)
+		}
+		if strings.Contains(c.Text, "# Guide") && strings.Contains(c.Text, "# Reference") {
+			t.Errorf("chunk %q spans two sections", c.Text)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package textsplit
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Default splitting settings.
+const (
+	// DefaultChunkSize is the maximum number of tokens per chunk.
+	DefaultChunkSize = 512
+)
+
+// Metadata keys set on every Chunk.
+const (
+	// MetadataKeyStart is the byte offset of the chunk in the source text
+	// (value: int).
+	MetadataKeyStart = "start_offset"
+
+	// MetadataKeyEnd is the byte offset just past the chunk in the source
+	// text (value: int).
+	MetadataKeyEnd = "end_offset"
+
+	// MetadataKeyHeadings is the Markdown heading path of the section the
+	// chunk belongs to, outermost first (value: []string). Set by
+	// MarkdownSplitter when the chunk is under a heading.
+	MetadataKeyHeadings = "headings"
+
+	// MetadataKeyCodeLanguage is the info string of the fenced code block
+	// containing the chunk (value: string). Set by MarkdownSplitter when the
+	// chunk lies entirely within a code block that names a language.
+	MetadataKeyCodeLanguage = "code_language"
+)
+
+// Chunk is a piece of a source text.
+type Chunk struct {
+	// Text is the chunk text, trimmed of surrounding whitespace. It always
+	// equals source[Start:End].
+	Text string `json:"text"`
+
+	// Index is the position of the chunk among the chunks of its source.
+	Index int `json:"index"`
+
+	// Start and End are the byte offsets of Text in the source.
+	Start int `json:"start"`
+	End   int `json:"end"`
+
+	// Tokens is the size of Text as counted by the splitter's TokenCounter.
+	Tokens int `json:"tokens"`
+
+	// Metadata holds the offsets under MetadataKeyStart and MetadataKeyEnd,
+	// plus any keys added by the splitter, so it can be stored directly as
+	// index entry metadata.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Splitter splits text into chunks.
+type Splitter interface {
+	// Split returns the chunks of text in order. Whitespace-only text
+	// yields no chunks.
+	Split(text string) []Chunk
+}
+
+// Texts returns the text of each chunk, ready for an embedding request.
+func Texts(chunks []Chunk) []string {
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.Text
+	}
+	return texts
+}
+
+// Config configures a splitter.
+type Config struct {
+	// ChunkSize is the maximum number of tokens per chunk. A single
+	// character larger than ChunkSize is still emitted as its own chunk.
+	// Default is DefaultChunkSize.
+	ChunkSize int
+
+	// ChunkOverlap is the maximum number of tokens repeated from the end of
+	// a chunk at the start of the next. Overlap is made of whole pieces, so
+	// it never cuts through a separator. It should be well below ChunkSize.
+	// Default is 0.
+	ChunkOverlap int
+
+	// TokenCounter measures chunk sizes.
+	// Default is types.NewHeuristicTokenCounter().
+	TokenCounter types.TokenCounter
+
+	// Separators are tried in order to break text that does not fit in a
+	// chunk. Text that no separator can break small enough is split between
+	// characters.
+	// Default is DefaultSeparators for RecursiveSplitter and
+	// SentenceSeparators for a sentence splitter.
+	Separators []Separator
+}
+
+func (c Config) withDefaults(separators []Separator) Config {
+	if c.ChunkSize <= 0 {
+		c.ChunkSize = DefaultChunkSize
+	}
+	if c.ChunkOverlap < 0 {
+		c.ChunkOverlap = 0
+	}
+	if c.TokenCounter == nil {
+		c.TokenCounter = types.NewHeuristicTokenCounter()
+	}
+	if c.Separators == nil {
+		c.Separators = separators
+	}
+	return c
+}
+
+// Separator returns the offsets in text just past each separator, in
+// increasing order. Text is broken at those offsets, so separators stay
+// attached to the end of the preceding piece.
+type Separator func(text string) []int
+
+// Literal breaks text after each occurrence of sep.
+func Literal(sep string) Separator {
+	return func(text string) []int {
+		if sep == "" {
+			return nil
+		}
+		var cuts []int
+		for i := 0; ; {
+			j := strings.Index(text[i:], sep)
+			if j < 0 {
+				return cuts
+			}
+			i += j + len(sep)
+			cuts = append(cuts, i)
+		}
+	}
+}
+
+// Regexp breaks text after each non-empty match of re.
+func Regexp(re *regexp.Regexp) Separator {
+	return func(text string) []int {
+		var cuts []int
+		for _, m := range re.FindAllStringIndex(text, -1) {
+			if m[1] > m[0] {
+				cuts = append(cuts, m[1])
+			}
+		}
+		return cuts
+	}
+}
+
+var sentenceEnd = regexp.MustCompile(`(?:[.!?]+["'”’)\]]*\s+|[。！？]+\s*|\n\s*\n\s*)`)
+
+// Predefined separators.
+var (
+	// Paragraphs breaks text after blank lines.
+	Paragraphs = Literal("\n\n")
+
+	// Lines breaks text after newlines.
+	Lines = Literal("\n")
+
+	// Sentences breaks text after sentence-ending punctuation followed by
+	// whitespace, after CJK sentence punctuation and after blank lines.
+	Sentences = Regexp(sentenceEnd)
+
+	// Words breaks text after spaces.
+	Words = Literal(" ")
+)
+
+// DefaultSeparators break text by paragraphs, then lines, sentences and
+// words.
+var DefaultSeparators = []Separator{Paragraphs, Lines, Sentences, Words}
+
+// SentenceSeparators break text by sentences, then words.
+var SentenceSeparators = []Separator{Sentences, Words}
+
+// RecursiveSplitter splits text with the first separator that breaks it,
+// recursing with the remaining separators into pieces that are still too
+// large, then packs the pieces into chunks of up to ChunkSize tokens.
+type RecursiveSplitter struct {
+	config Config
+}
+
+// NewRecursiveSplitter creates a splitter. Nil config uses defaults.
+func NewRecursiveSplitter(config *Config) *RecursiveSplitter {
+	cfg := Config{}
+	if config != nil {
+		cfg = *config
+	}
+	return &RecursiveSplitter{config: cfg.withDefaults(DefaultSeparators)}
+}
+
+// NewSentenceSplitter creates a splitter that packs whole sentences into
+// chunks, breaking a sentence only when it alone exceeds ChunkSize. Nil
+// config uses defaults; config.Separators replaces SentenceSeparators if
+// set.
+func NewSentenceSplitter(config *Config) *RecursiveSplitter {
+	cfg := Config{}
+	if config != nil {
+		cfg = *config
+	}
+	return &RecursiveSplitter{config: cfg.withDefaults(SentenceSeparators)}
+}
+
+// Split implements Splitter.
+func (s *RecursiveSplitter) Split(text string) []Chunk {
+	p := packer{config: s.config, text: text}
+	pieces := p.pieces(span{0, len(text)}, s.config.Separators)
+	p.pack(pieces, nil)
+	return p.chunks
+}
+
+// span is a byte range of the source text, [start, end).
+type span struct {
+	start, end int
+}
+
+// packer splits one source text and collects its chunks.
+type packer struct {
+	config Config
+	text   string
+	chunks []Chunk
+}
+
+func (p *packer) count(sp span) int {
+	return p.config.TokenCounter.CountTokens(p.text[sp.start:sp.end])
+}
+
+// pieces breaks sp into pieces of at most ChunkSize tokens, trying seps in
+// order and falling back to characters.
+func (p *packer) pieces(sp span, seps []Separator) []span {
+	if sp.end <= sp.start {
+		return nil
+	}
+	if p.count(sp) <= p.config.ChunkSize {
+		return []span{sp}
+	}
+	for i, sep := range seps {
+		cuts := sep(p.text[sp.start:sp.end])
+		if len(cuts) == 0 || (len(cuts) == 1 && cuts[0] >= sp.end-sp.start) {
+			continue
+		}
+		var out []span
+		prev := sp.start
+		for _, c := range append(cuts, sp.end-sp.start) {
+			end := sp.start + c
+			if end <= prev || end > sp.end {
+				continue
+			}
+			out = append(out, p.pieces(span{prev, end}, seps[i+1:])...)
+			prev = end
+		}
+		return out
+	}
+	return p.characters(sp)
+}
+
+// characters breaks sp between characters into the longest pieces of at
+// most ChunkSize tokens.
+func (p *packer) characters(sp span) []span {
+	var bounds []int
+	for i := sp.start; i < sp.end; {
+		_, size := utf8.DecodeRuneInString(p.text[i:sp.end])
+		i += size
+		bounds = append(bounds, i)
+	}
+	var out []span
+	start := sp.start
+	for len(bounds) > 0 {
+		// The first bound is always taken so every piece has a character.
+		n := sort.Search(len(bounds), func(i int) bool {
+			return i > 0 && p.count(span{start, bounds[i]}) > p.config.ChunkSize
+		})
+		out = append(out, span{start, bounds[n-1]})
+		start = bounds[n-1]
+		bounds = bounds[n:]
+	}
+	return out
+}
+
+// pack greedily combines consecutive pieces into chunks of at most
+// ChunkSize tokens, starting each chunk with up to ChunkOverlap tokens of
+// the previous one. Each chunk's metadata starts as a copy of base.
+func (p *packer) pack(pieces []span, base map[string]interface{}) {
+	var cur []span
+	for _, piece := range pieces {
+		if len(cur) > 0 && p.count(span{cur[0].start, piece.end}) > p.config.ChunkSize {
+			p.emit(span{cur[0].start, cur[len(cur)-1].end}, base)
+			cur = p.overlap(cur)
+			for len(cur) > 0 && p.count(span{cur[0].start, piece.end}) > p.config.ChunkSize {
+				cur = cur[1:]
+			}
+		}
+		cur = append(cur, piece)
+	}
+	if len(cur) > 0 {
+		p.emit(span{cur[0].start, cur[len(cur)-1].end}, base)
+	}
+}
+
+// overlap returns the longest proper suffix of pieces within ChunkOverlap
+// tokens.
+func (p *packer) overlap(pieces []span) []span {
+	if p.config.ChunkOverlap <= 0 {
+		return nil
+	}
+	last := pieces[len(pieces)-1].end
+	k := len(pieces)
+	for k > 1 && p.count(span{pieces[k-1].start, last}) <= p.config.ChunkOverlap {
+		k--
+	}
+	return pieces[k:]
+}
+
+// emit trims sp and appends it as a chunk unless it is blank.
+func (p *packer) emit(sp span, base map[string]interface{}) {
+	s := p.text[sp.start:sp.end]
+	trimmed := strings.TrimLeftFunc(s, unicode.IsSpace)
+	sp.start += len(s) - len(trimmed)
+	trimmed = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+	sp.end = sp.start + len(trimmed)
+	if sp.end <= sp.start {
+		return
+	}
+
+	metadata := make(map[string]interface{}, len(base)+2)
+	for k, v := range base {
+		metadata[k] = v
+	}
+	metadata[MetadataKeyStart] = sp.start
+	metadata[MetadataKeyEnd] = sp.end
+	p.chunks = append(p.chunks, Chunk{
+		Text:     trimmed,
+		Index:    len(p.chunks),
+		Start:    sp.start,
+		End:      sp.end,
+		Tokens:   p.count(sp),
+		Metadata: metadata,
+	})
+}
@@ -0,0 +1,162 @@
+package textsplit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+const prose = `Embeddings turn text into vectors. Similar texts get similar vectors, so a nearest neighbor search finds related passages.
+
+Documents are usually too long to embed whole. They are split into chunks first, and each chunk is embedded on its own! Does the split matter? It does: a chunk that cuts a sentence in half loses meaning.
+A single newline keeps lines in the same paragraph.
+
+The last paragraph has a very long word: ` + "supercalifragilisticexpialidocioussupercalifragilisticexpialidocioussupercalifragilisticexpialidocious" + `. 日本語の文も分割できます。次の文です。最後の文です。`
+
+// wordCounter counts whitespace-separated words as tokens.
+type wordCounter struct {
+	types.TokenCounter
+}
+
+func (wordCounter) CountTokens(text string) int {
+	return len(strings.Fields(text))
+}
+
+// checkChunks verifies the invariants every splitter guarantees.
+func checkChunks(t *testing.T, text string, chunks []Chunk, cfg Config) {
+	t.Helper()
+	counter := types.NewHeuristicTokenCounter()
+	covered := make([]bool, len(text))
+	for i, c := range chunks {
+		if c.Index != i {
+			t.Errorf("chunk %d has Index %d", i, c.Index)
+		}
+		if c.Start < 0 || c.End > len(text) || c.Start >= c.End {
+			t.Fatalf("chunk %d has offsets [%d, %d) in a text of %d bytes", i, c.Start, c.End, len(text))
+		}
+		if c.Text != text[c.Start:c.End] {
+			t.Errorf("chunk %d text %q != source[%d:%d] %q", i, c.Text, c.Start, c.End, text[c.Start:c.End])
+		}
+		if c.Metadata[MetadataKeyStart] != c.Start || c.Metadata[MetadataKeyEnd] != c.End {
+			t.Errorf("chunk %d metadata offsets %v, %v, want %d, %d", i, c.Metadata[MetadataKeyStart], c.Metadata[MetadataKeyEnd], c.Start, c.End)
+		}
+		if !utf8.ValidString(c.Text) {
+			t.Errorf("chunk %d cuts a character: %q", i, c.Text)
+		}
+		if strings.TrimSpace(c.Text) != c.Text || c.Text == "" {
+			t.Errorf("chunk %d is not trimmed: %q", i, c.Text)
+		}
+		if c.Tokens != counter.CountTokens(c.Text) {
+			t.Errorf("chunk %d Tokens = %d, want %d", i, c.Tokens, counter.CountTokens(c.Text))
+		}
+		if c.Tokens > cfg.ChunkSize && utf8.RuneCountInString(c.Text) > 1 {
+			t.Errorf("chunk %d has %d tokens, more than ChunkSize %d: %q", i, c.Tokens, cfg.ChunkSize, c.Text)
+		}
+		if i > 0 {
+			prev := chunks[i-1]
+			if c.Start <= prev.Start || c.End <= prev.End {
+				t.Errorf("chunk %d [%d, %d) does not advance past chunk %d [%d, %d)", i, c.Start, c.End, i-1, prev.Start, prev.End)
+			}
+			if c.Start < prev.End {
+				shared := text[c.Start:prev.End]
+				if cfg.ChunkOverlap == 0 {
+					t.Errorf("chunks %d and %d overlap by %q without ChunkOverlap", i-1, i, shared)
+				} else if n := counter.CountTokens(shared); n > cfg.ChunkOverlap {
+					t.Errorf("chunks %d and %d overlap by %d tokens, more than ChunkOverlap %d", i-1, i, n, cfg.ChunkOverlap)
+				}
+			}
+		}
+		for j := c.Start; j < c.End; j++ {
+			covered[j] = true
+		}
+	}
+	for j, r := range text {
+		if !covered[j] && !unicode.IsSpace(r) {
+			t.Fatalf("byte %d (%q) of the source is in no chunk", j, r)
+		}
+	}
+}
+
+func TestSplitInvariants(t *testing.T) {
+	splitters := map[string]func(*Config) Splitter{
+		"recursive": func(c *Config) Splitter { return NewRecursiveSplitter(c) },
+		"sentence":  func(c *Config) Splitter { return NewSentenceSplitter(c) },
+		"markdown":  func(c *Config) Splitter { return NewMarkdownSplitter(c) },
+	}
+	configs := []Config{
+		{ChunkSize: 1000},
+		{ChunkSize: 40},
+		{ChunkSize: 40, ChunkOverlap: 10},
+		{ChunkSize: 12, ChunkOverlap: 4},
+		{ChunkSize: 3},
+		{ChunkSize: 1},
+	}
+
+	for name, newSplitter := range splitters {
+		for _, cfg := range configs {
+			t.Run(fmt.Sprintf("%s/size=%d/overlap=%d", name, cfg.ChunkSize, cfg.ChunkOverlap), func(t *testing.T) {
+				c := cfg
+				for _, text := range []string{prose, markdownDoc} {
+					chunks := newSplitter(&c).Split(text)
+					if len(chunks) == 0 {
+						t.Fatal("Split() returned no chunks")
+					}
+					checkChunks(t, text, chunks, cfg.withDefaults(nil))
+				}
+			})
+		}
+	}
+}
+
+func TestRecursiveSplitter(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		text   string
+		want   []string
+	}{
+		{
+			name:   "fits in one chunk",
+			config: Config{ChunkSize: 100},
+			text:   "  One short text.\n\n",
+			want:   []string{"One short text."},
+		},
+		{
+			name:   "blank text",
+			config: Config{ChunkSize: 100},
+			text:   " \n\t ",
+			want:   nil,
+		},
+		{
+			name:   "paragraphs are kept whole",
+			config: Config{ChunkSize: 8},
+			text:   "First paragraph is here.\n\nSecond paragraph is here.",
+			want:   []string{"First paragraph is here.", "Second paragraph is here."},
+		},
+		{
+			name:   "sentences are packed together",
+			config: Config{ChunkSize: 8, Separators: SentenceSeparators},
+			text:   "One. Two. Three. Four is a much longer sentence.",
+			want:   []string{"One. Two. Three.", "Four is a much longer sentence."},
+		},
+		{
+			name:   "overlap repeats whole pieces",
+			config: Config{ChunkSize: 4, ChunkOverlap: 2, TokenCounter: wordCounter{}, Separators: []Separator{Words}},
+			text:   "a b c d e f",
+			want:   []string{"a b c d", "c d e f"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := NewRecursiveSplitter(&tt.config).Split(tt.text)
+			if got := Texts(chunks); fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+				t.Errorf("Split() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+// Package tools provides a registry that executes model tool calls.
+//
+// A Registry pairs Go functions with their types.ToolDefinition. It exports
+// the definitions for ChatRequest.Tools, executes the ToolCalls returned by a
+// model (in parallel, with a configurable limit), and converts each result or
+// error into a RoleTool message carrying the matching ToolCallID.
+//
+// Example usage:
+//
+//	type WeatherArgs struct {
+//	    City string `json:"city"`
+//	}
+//
+//	registry := tools.NewRegistry(nil)
+//	registry.MustRegister(
+//	    tools.NewDefinition("get_weather", "Get the current weather for a city",
+//	        types.NewObjectSchema("", map[string]*types.JSONSchema{
+//	            "city": types.NewStringSchema("City name"),
+//	        }, []string{"city"})),
+//	    tools.Typed(func(ctx context.Context, args WeatherArgs) (string, error) {
+//	        return lookupWeather(ctx, args.City)
+//	    }),
+//	)
+//
+//	req.Tools = registry.Tools()
+//	resp, err := chatService.CreateCompletion(ctx, req)
+//	if err != nil {
+//	    return err
+//	}
+//
+//	if resp.HasToolCalls() {
+//	    req.AddMessage(resp.GetFirstMessage())
+//	    for _, msg := range registry.Dispatch(ctx, resp.GetToolCalls()) {
+//	        req.AddMessage(msg)
+//	    }
+//	}
+package tools
@@ -0,0 +1,357 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// ErrToolNotFound is returned when a tool call names a tool that is not registered.
+var ErrToolNotFound = errors.New("tools: tool not found")
+
+// ErrToolExists is returned when registering a tool whose name is already taken.
+var ErrToolExists = errors.New("tools: tool already registered")
+
+// Func executes a tool call.
+//
+// arguments is the raw JSON argument string produced by the model. The returned
+// string is sent back to the model as the content of the RoleTool message.
+type Func func(ctx context.Context, arguments string) (string, error)
+
+// Typed adapts a strongly-typed function into a Func.
+//
+// The model's arguments are decoded into A using FunctionCall.ParseArguments
+// semantics. The result is sent to the model as-is if R is a string, and as
+// JSON otherwise.
+//
+// Example:
+//
+//	fn := tools.Typed(func(ctx context.Context, args SearchArgs) ([]SearchHit, error) {
+//	    return index.Search(ctx, args.Query, args.Limit)
+//	})
+func Typed[A any, R any](fn func(ctx context.Context, args A) (R, error)) Func {
+	return func(ctx context.Context, arguments string) (string, error) {
+		var args A
+		if arguments != "" {
+			call := types.FunctionCall{Arguments: arguments}
+			if err := call.ParseArguments(&args); err != nil {
+				return "", &ArgumentError{Err: err}
+			}
+		}
+
+		result, err := fn(ctx, args)
+		if err != nil {
+			return "", err
+		}
+		if s, ok := any(result).(string); ok {
+			return s, nil
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return "", fmt.Errorf("tools: encode result: %w", err)
+		}
+		return string(data), nil
+	}
+}
+
+// ArgumentError indicates that the model produced arguments that could not
+// be decoded for the tool.
+type ArgumentError struct {
+	// Err is the underlying decoding error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ArgumentError) Error() string {
+	return "invalid tool arguments: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *ArgumentError) Unwrap() error {
+	return e.Err
+}
+
+// PanicError is returned when a tool function panics.
+type PanicError struct {
+	// Tool is the name of the tool that panicked.
+	Tool string
+
+	// Value is the value passed to panic.
+	Value interface{}
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("tool %s panicked: %v", e.Tool, e.Value)
+}
+
+// NewDefinition creates a function ToolDefinition.
+func NewDefinition(name, description string, parameters interface{}) *types.ToolDefinition {
+	return &types.ToolDefinition{
+		Type:     types.ToolTypeFunction,
+		Function: *types.NewFunctionDefinition(name, description, parameters),
+	}
+}
+
+// Tool is a registered tool.
+type Tool struct {
+	// Definition is the definition sent to the model.
+	Definition *types.ToolDefinition
+
+	// Func executes calls to the tool.
+	Func Func
+}
+
+// Name returns the tool's function name.
+func (t *Tool) Name() string {
+	return t.Definition.Function.Name
+}
+
+// Result is the outcome of executing a single tool call.
+type Result struct {
+	// Call is the tool call that was executed.
+	Call *types.ToolCall
+
+	// Output is the tool output. Empty if Err is set.
+	Output string
+
+	// Err is the error returned by the tool, if any.
+	Err error
+
+	// Duration is how long the tool took to execute.
+	Duration time.Duration
+}
+
+// RegistryConfig configures a Registry.
+type RegistryConfig struct {
+	// MaxParallel is the maximum number of tool calls executed concurrently
+	// by ExecuteAll. Defaults to runtime.GOMAXPROCS(0) if zero.
+	// Set to 1 to execute calls sequentially.
+	MaxParallel int
+
+	// Timeout is the maximum duration of a single tool call.
+	// If zero, only the caller's context bounds execution.
+	Timeout time.Duration
+
+	// FormatError converts a tool error into the content sent to the model.
+	// If nil, errors are sent as a JSON object: {"error": "<message>"}.
+	FormatError func(call *types.ToolCall, err error) string
+}
+
+// Registry maps tool names to their definitions and implementations.
+//
+// A Registry is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	tools  map[string]*Tool
+	order  []string
+	config RegistryConfig
+}
+
+// NewRegistry creates an empty Registry. If config is nil, defaults are used.
+func NewRegistry(config *RegistryConfig) *Registry {
+	r := &Registry{tools: make(map[string]*Tool)}
+	if config != nil {
+		r.config = *config
+	}
+	if r.config.MaxParallel <= 0 {
+		r.config.MaxParallel = runtime.GOMAXPROCS(0)
+	}
+	if r.config.FormatError == nil {
+		r.config.FormatError = defaultFormatError
+	}
+	return r
+}
+
+// Register adds a tool to the registry.
+//
+// Returns a ValidationError if the definition has no function name or fn is
+// nil, and ErrToolExists if a tool with the same name is already registered.
+func (r *Registry) Register(def *types.ToolDefinition, fn Func) error {
+	if def == nil || def.Function.Name == "" {
+		return types.NewValidationError("Function.Name", "tool name is required")
+	}
+	if fn == nil {
+		return types.NewValidationError("Func", "tool function is required")
+	}
+	if def.Type == "" {
+		def.Type = types.ToolTypeFunction
+	}
+
+	name := def.Function.Name
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tools[name]; exists {
+		return fmt.Errorf("%w: %s", ErrToolExists, name)
+	}
+	r.tools[name] = &Tool{Definition: def, Func: fn}
+	r.order = append(r.order, name)
+	return nil
+}
+
+// MustRegister is like Register but panics on error.
+// It is intended for registering tools during program initialization.
+func (r *Registry) MustRegister(def *types.ToolDefinition, fn Func) {
+	if err := r.Register(def, fn); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister removes a tool from the registry. It is a no-op if the tool does not exist.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tools[name]; !exists {
+		return
+	}
+	delete(r.tools, name)
+	for i, n := range r.order {
+		if n == name {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Get returns the tool with the given name.
+func (r *Registry) Get(name string) (*Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tools[name]
+	return t, ok
+}
+
+// Len returns the number of registered tools.
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.tools)
+}
+
+// Tools returns the definitions of all registered tools in registration order,
+// suitable for ChatRequest.Tools.
+func (r *Registry) Tools() []*types.ToolDefinition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	defs := make([]*types.ToolDefinition, 0, len(r.order))
+	for _, name := range r.order {
+		defs = append(defs, r.tools[name].Definition)
+	}
+	return defs
+}
+
+// Execute runs a single tool call. It never panics; failures are reported
+// in Result.Err.
+func (r *Registry) Execute(ctx context.Context, call *types.ToolCall) *Result {
+	result := &Result{Call: call}
+	if call == nil {
+		result.Err = types.NewValidationError("call", "tool call is nil")
+		return result
+	}
+
+	tool, ok := r.Get(call.Function.Name)
+	if !ok {
+		result.Err = fmt.Errorf("%w: %s", ErrToolNotFound, call.Function.Name)
+		return result
+	}
+
+	if r.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.config.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	result.Output, result.Err = invoke(ctx, tool, call.Function.Arguments)
+	result.Duration = time.Since(start)
+	return result
+}
+
+// ExecuteAll runs the tool calls concurrently, at most MaxParallel at a time,
+// and returns their results in the same order as calls.
+//
+// If ctx is cancelled, calls that have not started are not executed and
+// report the context error.
+func (r *Registry) ExecuteAll(ctx context.Context, calls []*types.ToolCall) []*Result {
+	results := make([]*Result, len(calls))
+	sem := make(chan struct{}, r.config.MaxParallel)
+
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i] = &Result{Call: call, Err: ctx.Err()}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, call *types.ToolCall) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = r.Execute(ctx, call)
+		}(i, call)
+	}
+	wg.Wait()
+	return results
+}
+
+// Dispatch executes the tool calls with ExecuteAll and returns the resulting
+// RoleTool messages in call order, ready to append to the conversation.
+func (r *Registry) Dispatch(ctx context.Context, calls []*types.ToolCall) []*types.Message {
+	return r.Messages(r.ExecuteAll(ctx, calls))
+}
+
+// Message converts a result into a RoleTool message with the matching ToolCallID.
+// Errors are rendered with the registry's FormatError function.
+func (r *Registry) Message(result *Result) *types.Message {
+	content := result.Output
+	if result.Err != nil {
+		content = r.config.FormatError(result.Call, result.Err)
+	}
+
+	msg := &types.Message{
+		Role:    types.RoleTool,
+		Content: types.NewTextContent(content),
+	}
+	if result.Call != nil {
+		msg.ToolCallID = result.Call.ID
+		msg.Name = result.Call.Function.Name
+	}
+	return msg
+}
+
+// Messages converts results into RoleTool messages, preserving order.
+func (r *Registry) Messages(results []*Result) []*types.Message {
+	messages := make([]*types.Message, 0, len(results))
+	for _, result := range results {
+		messages = append(messages, r.Message(result))
+	}
+	return messages
+}
+
+// invoke calls the tool function, converting panics into errors.
+func invoke(ctx context.Context, tool *Tool, arguments string) (output string, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			output, err = "", &PanicError{Tool: tool.Name(), Value: v}
+		}
+	}()
+	return tool.Func(ctx, arguments)
+}
+
+// defaultFormatError renders an error as a JSON object for the model.
+func defaultFormatError(_ *types.ToolCall, err error) string {
+	data, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return string(data)
+}
@@ -0,0 +1,236 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+type addArgs struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+type sum struct {
+	Sum int `json:"sum"`
+}
+
+var errBroken = errors.New("broken")
+
+// newTestRegistry returns a registry with add, echo, fail, panic and slow tools.
+func newTestRegistry(config *RegistryConfig) *Registry {
+	r := NewRegistry(config)
+	r.MustRegister(NewDefinition("add", "Add two numbers", nil), Typed(func(_ context.Context, args addArgs) (sum, error) {
+		return sum{Sum: args.A + args.B}, nil
+	}))
+	r.MustRegister(NewDefinition("echo", "Echo the arguments", nil), func(_ context.Context, arguments string) (string, error) {
+		return arguments, nil
+	})
+	r.MustRegister(NewDefinition("fail", "Always fails", nil), func(context.Context, string) (string, error) {
+		return "", errBroken
+	})
+	r.MustRegister(NewDefinition("panic", "Always panics", nil), func(context.Context, string) (string, error) {
+		panic("boom")
+	})
+	r.MustRegister(NewDefinition("slow", "Waits for its context", nil), func(ctx context.Context, _ string) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	return r
+}
+
+func call(id, name, arguments string) *types.ToolCall {
+	return &types.ToolCall{ID: id, Type: types.ToolTypeFunction, Function: types.FunctionCall{Name: name, Arguments: arguments}}
+}
+
+func TestRegister(t *testing.T) {
+	r := newTestRegistry(nil)
+	fn := func(context.Context, string) (string, error) { return "", nil }
+
+	tests := []struct {
+		name    string
+		def     *types.ToolDefinition
+		fn      Func
+		wantErr bool
+	}{
+		{name: "new tool", def: &types.ToolDefinition{Function: types.FunctionDefinition{Name: "new"}}, fn: fn},
+		{name: "duplicate name", def: NewDefinition("add", "", nil), fn: fn, wantErr: true},
+		{name: "nil definition", fn: fn, wantErr: true},
+		{name: "missing name", def: NewDefinition("", "", nil), fn: fn, wantErr: true},
+		{name: "nil function", def: NewDefinition("other", "", nil), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.Register(tt.def, tt.fn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Register() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.def.Type != types.ToolTypeFunction {
+				t.Errorf("Register() left Type = %q, want function", tt.def.Type)
+			}
+		})
+	}
+
+	if err := r.Register(NewDefinition("add", "", nil), fn); !errors.Is(err, ErrToolExists) {
+		t.Errorf("Register() of a duplicate error = %v, want ErrToolExists", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustRegister() of a duplicate did not panic")
+		}
+	}()
+	r.MustRegister(NewDefinition("add", "", nil), fn)
+}
+
+func TestToolsOrder(t *testing.T) {
+	r := newTestRegistry(nil)
+	r.Unregister("echo")
+	r.Unregister("missing")
+
+	var names []string
+	for _, def := range r.Tools() {
+		names = append(names, def.Function.Name)
+	}
+	if got := strings.Join(names, ","); got != "add,fail,panic,slow" {
+		t.Errorf("Tools() = %s, want registration order without echo", got)
+	}
+	if r.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", r.Len())
+	}
+	if _, ok := r.Get("echo"); ok {
+		t.Error("Get() found an unregistered tool")
+	}
+}
+
+func TestExecute(t *testing.T) {
+	r := newTestRegistry(&RegistryConfig{Timeout: 20 * time.Millisecond})
+
+	tests := []struct {
+		name       string
+		call       *types.ToolCall
+		wantOutput string
+		// wantErr checks the error; nil means no error is expected.
+		wantErr func(error) bool
+	}{
+		{name: "typed result is JSON", call: call("1", "add", `{"a":2,"b":3}`), wantOutput: `{"sum":5}`},
+		{name: "typed empty arguments", call: call("1", "add", ""), wantOutput: `{"sum":0}`},
+		{name: "string result is sent as-is", call: call("1", "echo", "plain"), wantOutput: "plain"},
+		{name: "unknown tool", call: call("1", "missing", "{}"), wantErr: func(err error) bool { return errors.Is(err, ErrToolNotFound) }},
+		{name: "nil call", wantErr: func(err error) bool {
+			var v *types.ValidationError
+			return errors.As(err, &v)
+		}},
+		{name: "invalid arguments", call: call("1", "add", `{"a":"x"}`), wantErr: func(err error) bool {
+			var argErr *ArgumentError
+			return errors.As(err, &argErr)
+		}},
+		{name: "tool error", call: call("1", "fail", ""), wantErr: func(err error) bool { return errors.Is(err, errBroken) }},
+		{name: "panic", call: call("1", "panic", ""), wantErr: func(err error) bool {
+			var p *PanicError
+			return errors.As(err, &p) && p.Tool == "panic" && p.Value == "boom"
+		}},
+		{name: "timeout", call: call("1", "slow", ""), wantErr: func(err error) bool { return errors.Is(err, context.DeadlineExceeded) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := r.Execute(context.Background(), tt.call)
+			if result.Call != tt.call {
+				t.Error("Result.Call is not the executed call")
+			}
+			if tt.wantErr != nil {
+				if result.Err == nil || !tt.wantErr(result.Err) {
+					t.Errorf("Execute() error = %v", result.Err)
+				}
+				if result.Output != "" {
+					t.Errorf("Execute() output = %q with an error, want empty", result.Output)
+				}
+				return
+			}
+			if result.Err != nil {
+				t.Fatalf("Execute() error = %v", result.Err)
+			}
+			if result.Output != tt.wantOutput {
+				t.Errorf("Execute() output = %q, want %q", result.Output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestExecuteAll(t *testing.T) {
+	var running, peak int32
+	r := NewRegistry(&RegistryConfig{MaxParallel: 2})
+	r.MustRegister(NewDefinition("work", "", nil), func(_ context.Context, arguments string) (string, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return arguments, nil
+	})
+
+	calls := []*types.ToolCall{call("a", "work", "1"), call("b", "work", "2"), call("c", "work", "3"), call("d", "work", "4"), call("e", "work", "5")}
+	results := r.ExecuteAll(context.Background(), calls)
+	for i, result := range results {
+		if result.Call != calls[i] || result.Output != calls[i].Function.Arguments {
+			t.Errorf("result %d = %q for call %s, want call order", i, result.Output, result.Call.ID)
+		}
+	}
+	if peak > 2 {
+		t.Errorf("ExecuteAll() ran %d calls at once, want at most MaxParallel 2", peak)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	slow := []*types.ToolCall{call("a", "slow", ""), call("b", "slow", ""), call("c", "slow", "")}
+	for i, result := range newTestRegistry(&RegistryConfig{MaxParallel: 1}).ExecuteAll(ctx, slow) {
+		if result.Call != slow[i] || !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("result %d with a canceled context error = %v, want context.Canceled", i, result.Err)
+		}
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *RegistryConfig
+		call   *types.ToolCall
+		want   string
+	}{
+		{name: "output", call: call("c1", "echo", "hi"), want: "hi"},
+		{name: "default error format", call: call("c2", "fail", ""), want: `{"error":"broken"}`},
+		{
+			name: "custom error format",
+			config: &RegistryConfig{FormatError: func(call *types.ToolCall, err error) string {
+				return call.Function.Name + ": " + err.Error()
+			}},
+			call: call("c3", "fail", ""),
+			want: "fail: broken",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := newTestRegistry(tt.config).Dispatch(context.Background(), []*types.ToolCall{tt.call})
+			if len(messages) != 1 {
+				t.Fatalf("Dispatch() returned %d messages, want 1", len(messages))
+			}
+			msg := messages[0]
+			if msg.Role != types.RoleTool || msg.ToolCallID != tt.call.ID || msg.Name != tt.call.Function.Name {
+				t.Errorf("Dispatch() message = role %q, tool call %q, name %q", msg.Role, msg.ToolCallID, msg.Name)
+			}
+			if got := msg.Content.String(); got != tt.want {
+				t.Errorf("Dispatch() content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package types
+
+import (
+	"time"
+)
+
+// TranscriptionFormat is the response format of a transcription.
+type TranscriptionFormat string
+
+const (
+	// TranscriptionFormatJSON returns the text as JSON.
+	TranscriptionFormatJSON TranscriptionFormat = "json"
+
+	// TranscriptionFormatText returns plain text.
+	TranscriptionFormatText TranscriptionFormat = "text"
+
+	// TranscriptionFormatSRT returns SubRip subtitles.
+	TranscriptionFormatSRT TranscriptionFormat = "srt"
+
+	// TranscriptionFormatVTT returns WebVTT subtitles.
+	TranscriptionFormatVTT TranscriptionFormat = "vtt"
+
+	// TranscriptionFormatVerboseJSON returns JSON with the language,
+	// duration, segments and, if requested, word timestamps.
+	TranscriptionFormatVerboseJSON TranscriptionFormat = "verbose_json"
+)
+
+// String returns the string representation of the TranscriptionFormat.
+func (f TranscriptionFormat) String() string {
+	return string(f)
+}
+
+// IsValid checks if the transcription format is valid.
+func (f TranscriptionFormat) IsValid() bool {
+	switch f {
+	case TranscriptionFormatJSON, TranscriptionFormatText, TranscriptionFormatSRT,
+		TranscriptionFormatVTT, TranscriptionFormatVerboseJSON:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsJSON reports whether the format is returned as JSON. The empty format
+// is the provider default, JSON.
+func (f TranscriptionFormat) IsJSON() bool {
+	return f == "" || f == TranscriptionFormatJSON || f == TranscriptionFormatVerboseJSON
+}
+
+// TimestampGranularity selects the timestamps returned with a verbose
+// transcription.
+type TimestampGranularity string
+
+const (
+	// TimestampGranularitySegment returns segment timestamps.
+	TimestampGranularitySegment TimestampGranularity = "segment"
+
+	// TimestampGranularityWord returns word timestamps.
+	TimestampGranularityWord TimestampGranularity = "word"
+)
+
+// AudioFormat is the encoding of generated speech.
+type AudioFormat string
+
+const (
+	// AudioFormatMP3 is MP3 audio.
+	AudioFormatMP3 AudioFormat = "mp3"
+
+	// AudioFormatOpus is Opus audio in an Ogg container.
+	AudioFormatOpus AudioFormat = "opus"
+
+	// AudioFormatAAC is AAC audio.
+	AudioFormatAAC AudioFormat = "aac"
+
+	// AudioFormatFLAC is lossless FLAC audio.
+	AudioFormatFLAC AudioFormat = "flac"
+
+	// AudioFormatWAV is uncompressed WAV audio.
+	AudioFormatWAV AudioFormat = "wav"
+
+	// AudioFormatPCM is raw 24kHz 16-bit signed little-endian samples.
+	AudioFormatPCM AudioFormat = "pcm"
+)
+
+// String returns the string representation of the AudioFormat.
+func (f AudioFormat) String() string {
+	return string(f)
+}
+
+// IsValid checks if the audio format is valid.
+func (f AudioFormat) IsValid() bool {
+	return f.MimeType() != ""
+}
+
+// MimeType returns the MIME type of the format, or "" if it is unknown.
+func (f AudioFormat) MimeType() string {
+	switch f {
+	case AudioFormatMP3:
+		return "audio/mpeg"
+	case AudioFormatOpus:
+		return "audio/ogg"
+	case AudioFormatAAC:
+		return "audio/aac"
+	case AudioFormatFLAC:
+		return "audio/flac"
+	case AudioFormatWAV:
+		return "audio/wav"
+	case AudioFormatPCM:
+		return "audio/pcm"
+	default:
+		return ""
+	}
+}
+
+// TranscriptionRequest represents a request to transcribe audio to text.
+type TranscriptionRequest struct {
+	// Model is the ID of the model to use.
+	Model string `json:"model"`
+
+	// File is the audio to transcribe. Providers infer the audio format
+	// from its name.
+	File *File `json:"file"`
+
+	// Language is the ISO-639-1 code of the spoken language. Setting it
+	// improves accuracy and latency.
+	Language string `json:"language,omitempty"`
+
+	// Prompt guides the style of the transcription or continues a previous
+	// segment. It should be in the spoken language.
+	Prompt string `json:"prompt,omitempty"`
+
+	// ResponseFormat is the format of the transcription.
+	// Default is TranscriptionFormatJSON.
+	ResponseFormat TranscriptionFormat `json:"response_format,omitempty"`
+
+	// Temperature is the sampling temperature (0.0 to 1.0).
+	Temperature *float64 `json:"temperature,omitempty"`
+
+	// TimestampGranularities selects segment and word timestamps. It
+	// requires TranscriptionFormatVerboseJSON.
+	TimestampGranularities []TimestampGranularity `json:"timestamp_granularities,omitempty"`
+
+	// Metadata contains additional request metadata.
+	Metadata *RequestMetadata `json:"metadata,omitempty"`
+}
+
+// TranscriptionResponse represents a transcription. For the text and
+// subtitle formats only Text is set, holding the response body.
+type TranscriptionResponse struct {
+	// Text is the transcribed text.
+	Text string `json:"text"`
+
+	// Language is the detected or requested language.
+	Language string `json:"language,omitempty"`
+
+	// Duration is the length of the audio in seconds.
+	Duration float64 `json:"duration,omitempty"`
+
+	// Segments holds the transcription split into timed segments.
+	Segments []*TranscriptionSegment `json:"segments,omitempty"`
+
+	// Words holds word timestamps, if requested.
+	Words []*TranscriptionWord `json:"words,omitempty"`
+
+	// Usage contains token usage information, if the model reports it.
+	Usage *Usage `json:"usage,omitempty"`
+
+	// Metadata contains additional response metadata.
+	Metadata *ResponseMetadata `json:"metadata,omitempty"`
+}
+
+// TranscriptionSegment is a timed segment of a transcription.
+type TranscriptionSegment struct {
+	// ID is the segment number.
+	ID int `json:"id"`
+
+	// Start and End are the segment times in seconds.
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+
+	// Text is the text of the segment.
+	Text string `json:"text"`
+
+	// AvgLogProb is the average log probability of the segment's tokens.
+	// Values below -1 suggest a poor transcription.
+	AvgLogProb float64 `json:"avg_logprob,omitempty"`
+
+	// NoSpeechProb is the probability that the segment has no speech.
+	NoSpeechProb float64 `json:"no_speech_prob,omitempty"`
+}
+
+// TranscriptionWord is a word with its timestamps.
+type TranscriptionWord struct {
+	// Word is the text of the word.
+	Word string `json:"word"`
+
+	// Start and End are the word times in seconds.
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// StartTime returns Start as a time.Duration.
+func (s *TranscriptionSegment) StartTime() time.Duration {
+	return seconds(s.Start)
+}
+
+// EndTime returns End as a time.Duration.
+func (s *TranscriptionSegment) EndTime() time.Duration {
+	return seconds(s.End)
+}
+
+// StartTime returns Start as a time.Duration.
+func (w *TranscriptionWord) StartTime() time.Duration {
+	return seconds(w.Start)
+}
+
+// EndTime returns End as a time.Duration.
+func (w *TranscriptionWord) EndTime() time.Duration {
+	return seconds(w.End)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// SpeechRequest represents a request to synthesize speech from text.
+type SpeechRequest struct {
+	// Model is the ID of the model to use.
+	Model string `json:"model"`
+
+	// Input is the text to speak.
+	Input string `json:"input"`
+
+	// Voice is the voice to use, such as "alloy".
+	Voice string `json:"voice"`
+
+	// ResponseFormat is the encoding of the audio.
+	// Default is AudioFormatMP3.
+	ResponseFormat AudioFormat `json:"response_format,omitempty"`
+
+	// Speed is the playback speed (0.25 to 4.0). Zero uses the provider
+	// default of 1.0.
+	Speed float64 `json:"speed,omitempty"`
+
+	// Instructions control the tone and delivery of the voice, if the model
+	// supports them.
+	Instructions string `json:"instructions,omitempty"`
+
+	// Metadata contains additional request metadata.
+	Metadata *RequestMetadata `json:"metadata,omitempty"`
+}
+
+// SpeechResponse represents synthesized speech.
+type SpeechResponse struct {
+	// Audio is the encoded audio.
+	Audio []byte `json:"audio"`
+
+	// Format is the requested encoding of Audio.
+	Format AudioFormat `json:"format"`
+
+	// ContentType is the MIME type reported by the provider.
+	ContentType string `json:"content_type,omitempty"`
+
+	// Metadata contains additional response metadata.
+	Metadata *ResponseMetadata `json:"metadata,omitempty"`
+}
+
+// NewTranscriptionRequest creates a new TranscriptionRequest.
+func NewTranscriptionRequest(model string, file *File) *TranscriptionRequest {
+	return &TranscriptionRequest{
+		Model: model,
+		File:  file,
+	}
+}
+
+// WithLanguage sets the spoken language.
+func (r *TranscriptionRequest) WithLanguage(language string) *TranscriptionRequest {
+	r.Language = language
+	return r
+}
+
+// WithTimestamps requests verbose JSON with the given timestamp
+// granularities.
+func (r *TranscriptionRequest) WithTimestamps(granularities ...TimestampGranularity) *TranscriptionRequest {
+	r.ResponseFormat = TranscriptionFormatVerboseJSON
+	r.TimestampGranularities = granularities
+	return r
+}
+
+// Validate checks that the request has audio, a valid format and
+// temperature, and that timestamps are only requested with verbose JSON.
+func (r *TranscriptionRequest) Validate() error {
+	if r.File == nil || len(r.File.Data) == 0 {
+		return NewValidationError("file", "audio file is required")
+	}
+	if r.ResponseFormat != "" && !r.ResponseFormat.IsValid() {
+		return &ValidationError{Field: "response_format", Message: "unknown transcription format", Value: r.ResponseFormat}
+	}
+	if r.Temperature != nil && (*r.Temperature < 0 || *r.Temperature > 1) {
+		return &ValidationError{Field: "temperature", Message: "must be between 0 and 1", Value: *r.Temperature}
+	}
+	for _, g := range r.TimestampGranularities {
+		if g != TimestampGranularitySegment && g != TimestampGranularityWord {
+			return &ValidationError{Field: "timestamp_granularities", Message: "must be \"segment\" or \"word\"", Value: g}
+		}
+	}
+	if len(r.TimestampGranularities) > 0 && r.ResponseFormat != TranscriptionFormatVerboseJSON {
+		return NewValidationError("timestamp_granularities", "requires response_format \"verbose_json\"")
+	}
+	return nil
+}
+
+// NewSpeechRequest creates a new SpeechRequest.
+func NewSpeechRequest(model, input, voice string) *SpeechRequest {
+	return &SpeechRequest{
+		Model: model,
+		Input: input,
+		Voice: voice,
+	}
+}
+
+// WithFormat sets the audio encoding.
+func (r *SpeechRequest) WithFormat(format AudioFormat) *SpeechRequest {
+	r.ResponseFormat = format
+	return r
+}
+
+// WithSpeed sets the playback speed.
+func (r *SpeechRequest) WithSpeed(speed float64) *SpeechRequest {
+	r.Speed = speed
+	return r
+}
+
+// Validate checks that the request has input and a voice and that the
+// format and speed are valid.
+func (r *SpeechRequest) Validate() error {
+	if r.Input == "" {
+		return NewValidationError("input", "input is required")
+	}
+	if r.Voice == "" {
+		return NewValidationError("voice", "voice is required")
+	}
+	if r.ResponseFormat != "" && !r.ResponseFormat.IsValid() {
+		return &ValidationError{Field: "response_format", Message: "unknown audio format", Value: r.ResponseFormat}
+	}
+	if r.Speed != 0 && (r.Speed < 0.25 || r.Speed > 4) {
+		return &ValidationError{Field: "speed", Message: "must be between 0.25 and 4.0", Value: r.Speed}
+	}
+	return nil
+}
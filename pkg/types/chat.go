@@ -120,6 +120,9 @@ type Choice struct {
 type LogProbability struct {
 	// Content contains log probabilities for each token in the content.
 	Content []*TokenLogProb `json:"content,omitempty"`
+
+	// Refusal contains log probabilities for each token in the refusal.
+	Refusal []*TokenLogProb `json:"refusal,omitempty"`
 }
 
 // TokenLogProb contains log probability information for a single token.
@@ -165,6 +168,23 @@ func (r *ChatResponse) GetFirstContent() string {
 	return ""
 }
 
+// GetRefusal returns the refusal message from the first choice, or an empty
+// string if the model did not refuse.
+func (r *ChatResponse) GetRefusal() string {
+	if msg := r.GetFirstMessage(); msg != nil {
+		return msg.Refusal
+	}
+	return ""
+}
+
+// GetFirstFinishReason returns the finish reason of the first choice.
+func (r *ChatResponse) GetFirstFinishReason() FinishReason {
+	if len(r.Choices) > 0 {
+		return r.Choices[0].FinishReason
+	}
+	return ""
+}
+
 // HasToolCalls returns true if the first choice contains tool calls.
 func (r *ChatResponse) HasToolCalls() bool {
 	if msg := r.GetFirstMessage(); msg != nil {
@@ -150,6 +150,9 @@ const (
 
 	// CapabilityJSONMode indicates the model supports JSON output mode.
 	CapabilityJSONMode ModelCapability = "json_mode"
+
+	// CapabilityRerank indicates the model supports document reranking.
+	CapabilityRerank ModelCapability = "rerank"
 )
 
 // String returns the string representation of the ModelCapability.
@@ -1,5 +1,38 @@
 package types
 
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Embedding encoding formats.
+const (
+	// EmbeddingFormatFloat is an array of floating-point numbers.
+	EmbeddingFormatFloat = "float"
+
+	// EmbeddingFormatBase64 is a base64 string of little-endian float32
+	// values, as returned by OpenAI for encoding_format "base64".
+	EmbeddingFormatBase64 = "base64"
+
+	// EmbeddingFormatInt8 is a vector of signed 8-bit quantized values.
+	EmbeddingFormatInt8 = "int8"
+
+	// EmbeddingFormatUint8 is a vector of unsigned 8-bit quantized values.
+	EmbeddingFormatUint8 = "uint8"
+
+	// EmbeddingFormatBinary is a bit-packed vector of signs, eight
+	// dimensions per byte with the first dimension in the high bit. Values
+	// are reported as signed bytes.
+	EmbeddingFormatBinary = "binary"
+
+	// EmbeddingFormatUbinary is EmbeddingFormatBinary with values reported
+	// as unsigned bytes.
+	EmbeddingFormatUbinary = "ubinary"
+)
+
 // EmbeddingRequest represents a request to generate embeddings.
 type EmbeddingRequest struct {
 	// Model is the ID of the model to use for embeddings.
@@ -10,7 +43,7 @@ type EmbeddingRequest struct {
 	Input interface{} `json:"input"`
 
 	// EncodingFormat specifies the format for the embeddings.
-	// Supported values: "float", "base64".
+	// Supported values depend on the provider; see the EmbeddingFormat constants.
 	EncodingFormat string `json:"encoding_format,omitempty"`
 
 	// Dimensions is the number of dimensions for the embedding (if supported).
@@ -51,40 +84,382 @@ type Embedding struct {
 	Index int `json:"index"`
 
 	// Embedding is the embedding vector.
-	// Usually a slice of float64, but can be base64 encoded.
+	// Usually a slice of float64, but can be base64 encoded, a []float32, or
+	// a slice of quantized integers as described by EncodingFormat.
 	Embedding interface{} `json:"embedding"`
 
 	// Dimensions is the number of dimensions in the embedding.
 	Dimensions int `json:"dimensions,omitempty"`
+
+	// EncodingFormat is the format of Embedding, one of the EmbeddingFormat
+	// constants. Empty means EmbeddingFormatFloat for numbers and
+	// EmbeddingFormatBase64 for strings.
+	EncodingFormat string `json:"encoding_format,omitempty"`
 }
 
 // AsFloatVector returns the embedding as a float64 slice.
-// Returns nil if the embedding is not in float format.
+// Returns nil if the embedding cannot be decoded; use Float64 for the error.
 func (e *Embedding) AsFloatVector() []float64 {
+	v, err := e.Float64()
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// AsBase64 returns the embedding as a base64 string. A base64 embedding is
+// returned as is; a float embedding is encoded as little-endian float32
+// values. Returns empty string if the embedding cannot be encoded.
+func (e *Embedding) AsBase64() string {
+	if s, ok := e.Embedding.(string); ok {
+		return s
+	}
+	if e.isQuantized() {
+		return ""
+	}
+	v, err := e.Float32()
+	if err != nil {
+		return ""
+	}
+	return EncodeFloat32Base64(v)
+}
+
+// Float32 returns the embedding as float32 values, decoding base64 and
+// converting quantized formats. Binary embeddings are unpacked to -1 and 1
+// per dimension. Returns a ValidationError if the embedding cannot be
+// decoded or its length does not match Dimensions.
+func (e *Embedding) Float32() ([]float32, error) {
+	var out []float32
+	switch v := e.Embedding.(type) {
+	case []float32:
+		out = v
+	case string:
+		switch e.EncodingFormat {
+		case "", EmbeddingFormatBase64, EmbeddingFormatFloat:
+			var err error
+			if out, err = DecodeFloat32Base64(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if out != nil {
+		if err := e.checkDimensions(len(out)); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+
+	v, err := e.Float64()
+	if err != nil {
+		return nil, err
+	}
+	out = make([]float32, len(v))
+	for i, f := range v {
+		out[i] = float32(f)
+	}
+	return out, nil
+}
+
+// Float64 returns the embedding as float64 values. See Float32.
+func (e *Embedding) Float64() ([]float64, error) {
+	var out []float64
 	switch v := e.Embedding.(type) {
 	case []float64:
-		return v
+		out = v
+	case []float32:
+		out = make([]float64, len(v))
+		for i, f := range v {
+			out[i] = float64(f)
+		}
+	case []int8:
+		out = make([]float64, len(v))
+		for i, n := range v {
+			out[i] = float64(n)
+		}
+	case []byte:
+		if e.isBinary() {
+			return bitsToFloat64(v, e.Dimensions)
+		}
+		out = make([]float64, len(v))
+		for i, n := range v {
+			out[i] = float64(n)
+		}
 	case []interface{}:
-		// Convert []interface{} to []float64
-		result := make([]float64, len(v))
+		if e.isBinary() {
+			b, err := e.Bits()
+			if err != nil {
+				return nil, err
+			}
+			return bitsToFloat64(b, e.Dimensions)
+		}
+		out = make([]float64, len(v))
 		for i, val := range v {
-			if f, ok := val.(float64); ok {
-				result[i] = f
+			f, ok := number(val)
+			if !ok {
+				return nil, &ValidationError{Field: "embedding", Message: fmt.Sprintf("element %d is %T, not a number", i, val), Value: val}
 			}
+			out[i] = f
+		}
+	case string:
+		raw, err := decodeEmbeddingBase64(v)
+		if err != nil {
+			return nil, err
+		}
+		switch e.EncodingFormat {
+		case "", EmbeddingFormatBase64, EmbeddingFormatFloat:
+			f32, err := float32sFromBytes(raw)
+			if err != nil {
+				return nil, err
+			}
+			out = make([]float64, len(f32))
+			for i, f := range f32 {
+				out[i] = float64(f)
+			}
+		case EmbeddingFormatInt8:
+			out = make([]float64, len(raw))
+			for i, b := range raw {
+				out[i] = float64(int8(b))
+			}
+		case EmbeddingFormatUint8:
+			out = make([]float64, len(raw))
+			for i, b := range raw {
+				out[i] = float64(b)
+			}
+		case EmbeddingFormatBinary, EmbeddingFormatUbinary:
+			return bitsToFloat64(raw, e.Dimensions)
+		default:
+			return nil, unknownEmbeddingFormat(e.EncodingFormat)
 		}
-		return result
 	default:
-		return nil
+		return nil, &ValidationError{Field: "embedding", Message: fmt.Sprintf("unsupported embedding type %T", e.Embedding)}
 	}
+	if err := e.checkDimensions(len(out)); err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-// AsBase64 returns the embedding as a base64 encoded string.
-// Returns empty string if the embedding is not in base64 format.
-func (e *Embedding) AsBase64() string {
-	if s, ok := e.Embedding.(string); ok {
-		return s
+// Int8 returns an EmbeddingFormatInt8 embedding, given as integers or as
+// base64 bytes.
+func (e *Embedding) Int8() ([]int8, error) {
+	if v, ok := e.Embedding.([]int8); ok {
+		if err := e.checkDimensions(len(v)); err != nil {
+			return nil, err
+		}
+		return v, nil
 	}
-	return ""
+	raw, err := e.quantized(math.MinInt8, math.MaxInt8)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]int8, len(raw))
+	for i, n := range raw {
+		out[i] = int8(n)
+	}
+	if err := e.checkDimensions(len(out)); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Uint8 returns an EmbeddingFormatUint8 embedding, given as integers or as
+// base64 bytes.
+func (e *Embedding) Uint8() ([]uint8, error) {
+	if v, ok := e.Embedding.([]byte); ok && !e.isBinary() {
+		if err := e.checkDimensions(len(v)); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	raw, err := e.quantized(0, math.MaxUint8)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]uint8, len(raw))
+	for i, n := range raw {
+		out[i] = uint8(n)
+	}
+	if err := e.checkDimensions(len(out)); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Bits returns a binary or ubinary embedding as packed bytes, eight
+// dimensions per byte with the first dimension in the high bit. Dimensions,
+// if set, counts bits and must fit the packed length.
+func (e *Embedding) Bits() ([]byte, error) {
+	var out []byte
+	if v, ok := e.Embedding.([]byte); ok {
+		out = v
+	} else {
+		raw, err := e.quantized(math.MinInt8, math.MaxUint8)
+		if err != nil {
+			return nil, err
+		}
+		out = make([]byte, len(raw))
+		for i, n := range raw {
+			out[i] = byte(n)
+		}
+	}
+	if e.Dimensions > 0 && (e.Dimensions > len(out)*8 || e.Dimensions <= (len(out)-1)*8) {
+		return nil, &ValidationError{
+			Field:   "dimensions",
+			Message: fmt.Sprintf("%d packed bytes cannot hold %d dimensions", len(out), e.Dimensions),
+			Value:   e.Dimensions,
+		}
+	}
+	return out, nil
+}
+
+// quantized returns integer embedding values within [lo, hi], from JSON
+// numbers or base64 bytes.
+func (e *Embedding) quantized(lo, hi float64) ([]int, error) {
+	switch v := e.Embedding.(type) {
+	case string:
+		raw, err := decodeEmbeddingBase64(v)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]int, len(raw))
+		for i, b := range raw {
+			if lo < 0 && e.EncodingFormat != EmbeddingFormatUbinary {
+				out[i] = int(int8(b))
+			} else {
+				out[i] = int(b)
+			}
+		}
+		return out, nil
+	case []int:
+		out := make([]int, len(v))
+		for i, n := range v {
+			if float64(n) < lo || float64(n) > hi {
+				return nil, outOfRange(i, n)
+			}
+			out[i] = n
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]int, len(v))
+		for i, val := range v {
+			f, ok := number(val)
+			if !ok || f != math.Trunc(f) || f < lo || f > hi {
+				return nil, outOfRange(i, val)
+			}
+			out[i] = int(f)
+		}
+		return out, nil
+	default:
+		return nil, &ValidationError{Field: "embedding", Message: fmt.Sprintf("unsupported quantized embedding type %T", e.Embedding)}
+	}
+}
+
+// checkDimensions returns a ValidationError if Dimensions is set and n differs.
+func (e *Embedding) checkDimensions(n int) error {
+	if e.Dimensions > 0 && n != e.Dimensions {
+		return &ValidationError{
+			Field:   "dimensions",
+			Message: fmt.Sprintf("embedding has %d values, expected %d", n, e.Dimensions),
+			Value:   n,
+		}
+	}
+	return nil
+}
+
+func (e *Embedding) isBinary() bool {
+	return e.EncodingFormat == EmbeddingFormatBinary || e.EncodingFormat == EmbeddingFormatUbinary
+}
+
+func (e *Embedding) isQuantized() bool {
+	return e.isBinary() || e.EncodingFormat == EmbeddingFormatInt8 || e.EncodingFormat == EmbeddingFormatUint8
+}
+
+// EncodeFloat32Base64 encodes v as base64 little-endian float32 values, the
+// OpenAI "base64" embedding format.
+func EncodeFloat32Base64(v []float32) string {
+	raw := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(raw[4*i:], math.Float32bits(f))
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// DecodeFloat32Base64 decodes base64 little-endian float32 values.
+func DecodeFloat32Base64(s string) ([]float32, error) {
+	raw, err := decodeEmbeddingBase64(s)
+	if err != nil {
+		return nil, err
+	}
+	return float32sFromBytes(raw)
+}
+
+func decodeEmbeddingBase64(s string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, &ValidationError{Field: "embedding", Message: "invalid base64: " + err.Error()}
+	}
+	return raw, nil
+}
+
+func float32sFromBytes(raw []byte) ([]float32, error) {
+	if len(raw)%4 != 0 {
+		return nil, &ValidationError{Field: "embedding", Message: fmt.Sprintf("%d bytes is not a whole number of float32 values", len(raw))}
+	}
+	out := make([]float32, len(raw)/4)
+	for i := range out {
+		out[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[4*i:]))
+	}
+	return out, nil
+}
+
+// bitsToFloat64 unpacks packed sign bits to 1 (set) and -1 (clear). If
+// dimensions is zero, every bit is unpacked.
+func bitsToFloat64(packed []byte, dimensions int) ([]float64, error) {
+	n := len(packed) * 8
+	if dimensions > 0 {
+		if dimensions > n || dimensions <= n-8 {
+			return nil, &ValidationError{
+				Field:   "dimensions",
+				Message: fmt.Sprintf("%d packed bytes cannot hold %d dimensions", len(packed), dimensions),
+				Value:   dimensions,
+			}
+		}
+		n = dimensions
+	}
+	out := make([]float64, n)
+	for i := range out {
+		if packed[i/8]&(0x80>>(i%8)) != 0 {
+			out[i] = 1
+		} else {
+			out[i] = -1
+		}
+	}
+	return out, nil
+}
+
+// number converts a decoded JSON number to float64.
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func outOfRange(i int, v interface{}) error {
+	return &ValidationError{Field: "embedding", Message: fmt.Sprintf("element %d is not a valid quantized value", i), Value: v}
+}
+
+func unknownEmbeddingFormat(format string) error {
+	return &ValidationError{Field: "encoding_format", Message: "unknown embedding format " + format, Value: format}
 }
 
 // Helper functions for creating requests
@@ -177,13 +552,34 @@ func (r *EmbeddingResponse) GetFirstVector() []float64 {
 	return nil
 }
 
-// GetAllVectors returns all embeddings as float vectors.
+// GetAllVectors returns all embeddings as float vectors, with vectors[i]
+// holding r.Data[i]. An embedding that is nil or cannot be decoded is a nil
+// entry, so the result always has len(r.Data) entries; use Vectors to get
+// the decoding error instead.
 func (r *EmbeddingResponse) GetAllVectors() [][]float64 {
-	vectors := make([][]float64, 0, len(r.Data))
-	for _, emb := range r.Data {
-		if vec := emb.AsFloatVector(); vec != nil {
-			vectors = append(vectors, vec)
+	vectors := make([][]float64, len(r.Data))
+	for i, emb := range r.Data {
+		if emb != nil {
+			vectors[i] = emb.AsFloatVector()
 		}
 	}
 	return vectors
 }
+
+// Vectors returns all embeddings as float vectors, with vectors[i] holding
+// r.Data[i]. Returns a ValidationError for the first embedding that is nil
+// or cannot be decoded.
+func (r *EmbeddingResponse) Vectors() ([][]float64, error) {
+	vectors := make([][]float64, len(r.Data))
+	for i, emb := range r.Data {
+		if emb == nil {
+			return nil, NewValidationError(fmt.Sprintf("data[%d]", i), "embedding is nil")
+		}
+		vec, err := emb.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("data[%d]: %w", i, err)
+		}
+		vectors[i] = vec
+	}
+	return vectors, nil
+}
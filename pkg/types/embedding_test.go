@@ -0,0 +1,203 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// decodeEmbedding unmarshals an embedding object as a provider response
+// would deliver it.
+func decodeEmbedding(t *testing.T, data string) *Embedding {
+	t.Helper()
+	var e Embedding
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		t.Fatalf("json.Unmarshal(%s) error = %v", data, err)
+	}
+	return &e
+}
+
+func b64(raw ...byte) string {
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+func TestEmbeddingFloat64(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    []float64
+		wantErr bool
+	}{
+		{name: "float array", json: `{"embedding": [0.5, -1, 2]}`, want: []float64{0.5, -1, 2}},
+		{name: "base64 without format", json: fmt.Sprintf(`{"embedding": %q}`, EncodeFloat32Base64([]float32{0.5, -1, 2})), want: []float64{0.5, -1, 2}},
+		{name: "base64 format", json: fmt.Sprintf(`{"embedding": %q, "encoding_format": "base64"}`, EncodeFloat32Base64([]float32{0.25})), want: []float64{0.25}},
+		{name: "dimensions match", json: `{"embedding": [1, 2], "dimensions": 2}`, want: []float64{1, 2}},
+		{name: "int8 array", json: `{"embedding": [-128, 0, 127], "encoding_format": "int8"}`, want: []float64{-128, 0, 127}},
+		{name: "int8 base64", json: fmt.Sprintf(`{"embedding": %q, "encoding_format": "int8"}`, b64(0x80, 0x00, 0x7f)), want: []float64{-128, 0, 127}},
+		{name: "uint8 base64", json: fmt.Sprintf(`{"embedding": %q, "encoding_format": "uint8"}`, b64(0xff, 0x01)), want: []float64{255, 1}},
+		{name: "binary array", json: `{"embedding": [-96], "encoding_format": "binary", "dimensions": 4}`, want: []float64{1, -1, 1, -1}},
+		{name: "ubinary array", json: `{"embedding": [160, 255], "encoding_format": "ubinary", "dimensions": 10}`, want: []float64{1, -1, 1, -1, -1, -1, -1, -1, 1, 1}},
+		{name: "binary base64 without dimensions", json: fmt.Sprintf(`{"embedding": %q, "encoding_format": "binary"}`, b64(0x81)), want: []float64{1, -1, -1, -1, -1, -1, -1, 1}},
+
+		{name: "dimensions mismatch", json: `{"embedding": [1, 2, 3], "dimensions": 2}`, wantErr: true},
+		{name: "base64 dimensions mismatch", json: fmt.Sprintf(`{"embedding": %q, "dimensions": 3}`, EncodeFloat32Base64([]float32{1, 2})), wantErr: true},
+		{name: "binary dimensions too large", json: `{"embedding": [1], "encoding_format": "binary", "dimensions": 9}`, wantErr: true},
+		{name: "binary dimensions leave a byte unused", json: `{"embedding": [1, 1], "encoding_format": "binary", "dimensions": 8}`, wantErr: true},
+		{name: "invalid base64", json: `{"embedding": "not base64!"}`, wantErr: true},
+		{name: "partial float32", json: fmt.Sprintf(`{"embedding": %q}`, b64(1, 2, 3)), wantErr: true},
+		{name: "unknown format", json: fmt.Sprintf(`{"embedding": %q, "encoding_format": "float16"}`, b64(1, 2)), wantErr: true},
+		{name: "non-number element", json: `{"embedding": [1, "2"]}`, wantErr: true},
+		{name: "binary value out of range", json: `{"embedding": [256], "encoding_format": "ubinary"}`, wantErr: true},
+		{name: "missing embedding", json: `{}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := decodeEmbedding(t, tt.json)
+			got, err := e.Float64()
+			if tt.wantErr {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Errorf("Float64() error = %v, want a *ValidationError", err)
+				}
+				if e.AsFloatVector() != nil {
+					t.Error("AsFloatVector() is not nil for an invalid embedding")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Float64() error = %v", err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Float64() = %v, want %v", got, tt.want)
+			}
+
+			f32, err := e.Float32()
+			if err != nil {
+				t.Fatalf("Float32() error = %v", err)
+			}
+			if len(f32) != len(tt.want) {
+				t.Errorf("Float32() has %d values, want %d", len(f32), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestEmbeddingQuantized(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		decode  func(e *Embedding) (interface{}, error)
+		want    string
+		wantErr bool
+	}{
+		{name: "int8 array", json: `{"embedding": [-128, 5, 127]}`, decode: int8s, want: "[-128 5 127]"},
+		{name: "int8 base64", json: fmt.Sprintf(`{"embedding": %q}`, b64(0xff, 0x05)), decode: int8s, want: "[-1 5]"},
+		{name: "int8 too large", json: `{"embedding": [128]}`, decode: int8s, wantErr: true},
+		{name: "int8 fraction", json: `{"embedding": [1.5]}`, decode: int8s, wantErr: true},
+		{name: "int8 dimensions mismatch", json: `{"embedding": [1, 2], "dimensions": 3}`, decode: int8s, wantErr: true},
+		{name: "uint8 array", json: `{"embedding": [0, 255]}`, decode: uint8s, want: "[0 255]"},
+		{name: "uint8 base64", json: fmt.Sprintf(`{"embedding": %q}`, b64(0xff, 0x05)), decode: uint8s, want: "[255 5]"},
+		{name: "uint8 negative", json: `{"embedding": [-1]}`, decode: uint8s, wantErr: true},
+		{name: "binary signed bytes", json: `{"embedding": [-1, 127], "encoding_format": "binary"}`, decode: bits, want: "[255 127]"},
+		{name: "ubinary base64", json: fmt.Sprintf(`{"embedding": %q, "encoding_format": "ubinary", "dimensions": 12}`, b64(0xf0, 0x0f)), decode: bits, want: "[240 15]"},
+		{name: "binary dimensions too large", json: `{"embedding": [1], "encoding_format": "binary", "dimensions": 9}`, decode: bits, wantErr: true},
+		{name: "float array is not quantized", json: `{"embedding": [0.5]}`, decode: int8s, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.decode(decodeEmbedding(t, tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && fmt.Sprint(got) != tt.want {
+				t.Errorf("got %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func int8s(e *Embedding) (interface{}, error)  { return e.Int8() }
+func uint8s(e *Embedding) (interface{}, error) { return e.Uint8() }
+func bits(e *Embedding) (interface{}, error)   { return e.Bits() }
+
+func TestEmbeddingBase64RoundTrip(t *testing.T) {
+	want := []float32{0.1, -0.2, 3.5e-8, 1e10}
+	e := &Embedding{Embedding: want}
+
+	encoded := e.AsBase64()
+	if encoded == "" {
+		t.Fatal("AsBase64() is empty for a []float32 embedding")
+	}
+	data, err := json.Marshal(&Embedding{Embedding: encoded, Dimensions: len(want)})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	got, err := decodeEmbedding(t, string(data)).Float32()
+	if err != nil {
+		t.Fatalf("Float32() error = %v", err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Float32() after a round trip = %v, want %v", got, want)
+	}
+
+	if s := (&Embedding{Embedding: []interface{}{1.0}, EncodingFormat: EmbeddingFormatInt8}).AsBase64(); s != "" {
+		t.Errorf("AsBase64() of a quantized embedding = %q, want empty", s)
+	}
+}
+
+func TestEmbeddingResponseVectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []*Embedding
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "all decodable",
+			data: []*Embedding{{Embedding: []float64{1, 2}}, {Embedding: EncodeFloat32Base64([]float32{3})}},
+			want: "[[1 2] [3]]",
+		},
+		{
+			name:    "undecodable entry keeps its place",
+			data:    []*Embedding{{Embedding: []float64{1}}, {Embedding: "not base64!"}, {Embedding: []float64{3}}},
+			want:    "[[1] [] [3]]",
+			wantErr: true,
+		},
+		{
+			name:    "nil entry keeps its place",
+			data:    []*Embedding{nil, {Embedding: []float64{2}}},
+			want:    "[[] [2]]",
+			wantErr: true,
+		},
+		{
+			name: "empty",
+			want: "[]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &EmbeddingResponse{Data: tt.data}
+			got := r.GetAllVectors()
+			if len(got) != len(tt.data) || fmt.Sprint(got) != tt.want {
+				t.Errorf("GetAllVectors() = %v, want %s", got, tt.want)
+			}
+
+			vectors, err := r.Vectors()
+			if tt.wantErr {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) || vectors != nil {
+					t.Errorf("Vectors() = %v, %v, want a *ValidationError", vectors, err)
+				}
+				return
+			}
+			if err != nil || fmt.Sprint(vectors) != tt.want {
+				t.Errorf("Vectors() = %v, %v, want %s", vectors, err, tt.want)
+			}
+		})
+	}
+}
@@ -143,11 +143,38 @@ func NewJSONSchemaResponseFormat(schema interface{}) *ResponseFormat {
 	}
 }
 
+// JSONSchemaFormat is the structured-output schema wrapper used as
+// ResponseFormat.JSONSchema by OpenAI-compatible providers.
+type JSONSchemaFormat struct {
+	// Name identifies the schema. Must match [a-zA-Z0-9_-]{1,64}.
+	Name string `json:"name"`
+
+	// Description explains what the response represents.
+	Description string `json:"description,omitempty"`
+
+	// Schema is the JSON schema the response must follow.
+	Schema *JSONSchema `json:"schema"`
+
+	// Strict enables strict schema adherence (if supported by provider).
+	Strict bool `json:"strict,omitempty"`
+}
+
+// NewStructuredResponseFormat creates a json_schema response format with a
+// named schema.
+func NewStructuredResponseFormat(name string, schema *JSONSchema, strict bool) *ResponseFormat {
+	return NewJSONSchemaResponseFormat(&JSONSchemaFormat{
+		Name:   name,
+		Schema: schema,
+		Strict: strict,
+	})
+}
+
 // JSONSchema represents a JSON Schema for function parameters.
 // This is a flexible structure that can represent any JSON Schema.
 type JSONSchema struct {
 	// Type is the JSON type (e.g., "object", "string", "number").
-	Type string `json:"type"`
+	// Empty for schemas that only use AnyOf or Ref, or that accept any value.
+	Type string `json:"type,omitempty"`
 
 	// Description describes the schema.
 	Description string `json:"description,omitempty"`
@@ -166,6 +193,19 @@ type JSONSchema struct {
 
 	// AdditionalProperties controls whether additional properties are allowed.
 	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+
+	// Format is a semantic format hint for string types (e.g., "date-time").
+	Format string `json:"format,omitempty"`
+
+	// AnyOf lists alternative schemas, one of which the value must match.
+	// Commonly used to express nullable values: [{...}, {"type": "null"}].
+	AnyOf []*JSONSchema `json:"anyOf,omitempty"`
+
+	// Ref references another schema, typically one in Defs (e.g., "#/$defs/Node").
+	Ref string `json:"$ref,omitempty"`
+
+	// Defs holds reusable schema definitions referenced by Ref.
+	Defs map[string]*JSONSchema `json:"$defs,omitempty"`
 }
 
 // NewObjectSchema creates a new object schema.
@@ -0,0 +1,318 @@
+package types
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"path/filepath"
+)
+
+// ImageSize is the size of a generated image, as "<width>x<height>".
+type ImageSize string
+
+const (
+	// ImageSize256 is a 256x256 image (dall-e-2).
+	ImageSize256 ImageSize = "256x256"
+
+	// ImageSize512 is a 512x512 image (dall-e-2).
+	ImageSize512 ImageSize = "512x512"
+
+	// ImageSize1024 is a 1024x1024 image.
+	ImageSize1024 ImageSize = "1024x1024"
+
+	// ImageSize1792x1024 is a landscape image (dall-e-3).
+	ImageSize1792x1024 ImageSize = "1792x1024"
+
+	// ImageSize1024x1792 is a portrait image (dall-e-3).
+	ImageSize1024x1792 ImageSize = "1024x1792"
+
+	// ImageSize1536x1024 is a landscape image (gpt-image-1).
+	ImageSize1536x1024 ImageSize = "1536x1024"
+
+	// ImageSize1024x1536 is a portrait image (gpt-image-1).
+	ImageSize1024x1536 ImageSize = "1024x1536"
+
+	// ImageSizeAuto lets the model choose the size.
+	ImageSizeAuto ImageSize = "auto"
+)
+
+// String returns the string representation of the ImageSize.
+func (s ImageSize) String() string {
+	return string(s)
+}
+
+// ImageQuality is the quality of a generated image. Supported values depend
+// on the model.
+type ImageQuality string
+
+const (
+	// ImageQualityStandard is the default quality (dall-e-3).
+	ImageQualityStandard ImageQuality = "standard"
+
+	// ImageQualityHD has finer detail (dall-e-3).
+	ImageQualityHD ImageQuality = "hd"
+
+	// ImageQualityLow is the fastest quality (gpt-image-1).
+	ImageQualityLow ImageQuality = "low"
+
+	// ImageQualityMedium is a balanced quality (gpt-image-1).
+	ImageQualityMedium ImageQuality = "medium"
+
+	// ImageQualityHigh is the best quality (gpt-image-1).
+	ImageQualityHigh ImageQuality = "high"
+
+	// ImageQualityAuto lets the model choose the quality.
+	ImageQualityAuto ImageQuality = "auto"
+)
+
+// String returns the string representation of the ImageQuality.
+func (q ImageQuality) String() string {
+	return string(q)
+}
+
+// ImageResponseFormat selects how generated images are returned.
+type ImageResponseFormat string
+
+const (
+	// ImageResponseFormatURL returns a temporary URL for each image.
+	ImageResponseFormatURL ImageResponseFormat = "url"
+
+	// ImageResponseFormatB64JSON returns each image base64-encoded.
+	ImageResponseFormatB64JSON ImageResponseFormat = "b64_json"
+)
+
+// String returns the string representation of the ImageResponseFormat.
+func (f ImageResponseFormat) String() string {
+	return string(f)
+}
+
+// IsValid checks if the response format is valid.
+func (f ImageResponseFormat) IsValid() bool {
+	switch f {
+	case ImageResponseFormatURL, ImageResponseFormatB64JSON:
+		return true
+	default:
+		return false
+	}
+}
+
+// File is an uploaded file, such as an image to edit or audio to
+// transcribe.
+type File struct {
+	// Name is the file name sent to the provider. Providers often infer the
+	// format from its extension.
+	Name string `json:"name"`
+
+	// Data is the file content.
+	Data []byte `json:"data"`
+
+	// ContentType is the MIME type of the file. If empty, it is detected
+	// from Name or Data.
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// NewFile creates a File.
+func NewFile(name string, data []byte) *File {
+	return &File{Name: name, Data: data}
+}
+
+// MimeType returns ContentType, or the type detected from the extension of
+// Name or from Data if it is empty.
+func (f *File) MimeType() string {
+	if f.ContentType != "" {
+		return f.ContentType
+	}
+	switch filepath.Ext(f.Name) {
+	case ".png":
+		return "image/png"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".webp":
+		return "image/webp"
+	case ".mp3", ".mpga", ".mpeg":
+		return "audio/mpeg"
+	case ".m4a", ".mp4":
+		return "audio/mp4"
+	case ".wav":
+		return "audio/wav"
+	case ".ogg", ".oga":
+		return "audio/ogg"
+	case ".flac":
+		return "audio/flac"
+	case ".webm":
+		return "audio/webm"
+	}
+	return http.DetectContentType(f.Data)
+}
+
+// ImageGenerationRequest represents a request to generate images from a
+// prompt, or to edit images when Images is set.
+type ImageGenerationRequest struct {
+	// Model is the ID of the model to use.
+	Model string `json:"model,omitempty"`
+
+	// Prompt describes the desired image or edit.
+	Prompt string `json:"prompt"`
+
+	// N is the number of images to generate. Zero uses the provider default
+	// of one.
+	N int `json:"n,omitempty"`
+
+	// Size is the size of the generated images.
+	Size ImageSize `json:"size,omitempty"`
+
+	// Quality is the quality of the generated images.
+	Quality ImageQuality `json:"quality,omitempty"`
+
+	// Style is the style of the generated images, such as "vivid" or
+	// "natural" (dall-e-3).
+	Style string `json:"style,omitempty"`
+
+	// ResponseFormat selects URLs or base64 data. Some models always return
+	// base64 data.
+	ResponseFormat ImageResponseFormat `json:"response_format,omitempty"`
+
+	// OutputFormat is the encoding of the generated images, such as "png",
+	// "jpeg" or "webp", if the model supports it.
+	OutputFormat string `json:"output_format,omitempty"`
+
+	// Background is "transparent", "opaque" or "auto", if the model
+	// supports it.
+	Background string `json:"background,omitempty"`
+
+	// Images are the images to edit. Setting it makes the request an edit.
+	Images []*File `json:"images,omitempty"`
+
+	// Mask marks the areas of the first image to edit with fully
+	// transparent pixels. It must have the same size as the image.
+	Mask *File `json:"mask,omitempty"`
+
+	// User is a unique identifier for the end-user.
+	User string `json:"user,omitempty"`
+
+	// Metadata contains additional request metadata.
+	Metadata *RequestMetadata `json:"metadata,omitempty"`
+}
+
+// ImageGenerationResponse represents a response from image generation or
+// editing.
+type ImageGenerationResponse struct {
+	// Created is the Unix timestamp when the images were created.
+	Created int64 `json:"created"`
+
+	// Data contains the generated images.
+	Data []*GeneratedImage `json:"data"`
+
+	// Usage contains token usage information, if the model reports it.
+	Usage *Usage `json:"usage,omitempty"`
+
+	// Metadata contains additional response metadata.
+	Metadata *ResponseMetadata `json:"metadata,omitempty"`
+}
+
+// GeneratedImage is one generated image. Exactly one of URL and B64JSON is
+// set.
+type GeneratedImage struct {
+	// URL is a temporary URL of the image.
+	URL string `json:"url,omitempty"`
+
+	// B64JSON is the base64-encoded image.
+	B64JSON string `json:"b64_json,omitempty"`
+
+	// RevisedPrompt is the prompt the model used, if it rewrote the
+	// original.
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+// Bytes decodes B64JSON. It returns a ValidationError if the image was
+// returned as a URL or is not valid base64.
+func (g *GeneratedImage) Bytes() ([]byte, error) {
+	if g.B64JSON == "" {
+		return nil, NewValidationError("b64_json", "image has no base64 data")
+	}
+	data, err := base64.StdEncoding.DecodeString(g.B64JSON)
+	if err != nil {
+		return nil, &ValidationError{Field: "b64_json", Message: "invalid base64: " + err.Error()}
+	}
+	return data, nil
+}
+
+// NewImageGenerationRequest creates a new ImageGenerationRequest.
+func NewImageGenerationRequest(model, prompt string) *ImageGenerationRequest {
+	return &ImageGenerationRequest{
+		Model:  model,
+		Prompt: prompt,
+	}
+}
+
+// NewImageEditRequest creates a request to edit images according to prompt.
+func NewImageEditRequest(model, prompt string, images ...*File) *ImageGenerationRequest {
+	return &ImageGenerationRequest{
+		Model:  model,
+		Prompt: prompt,
+		Images: images,
+	}
+}
+
+// WithN sets the number of images to generate.
+func (r *ImageGenerationRequest) WithN(n int) *ImageGenerationRequest {
+	r.N = n
+	return r
+}
+
+// WithSize sets the size of the generated images.
+func (r *ImageGenerationRequest) WithSize(size ImageSize) *ImageGenerationRequest {
+	r.Size = size
+	return r
+}
+
+// WithQuality sets the quality of the generated images.
+func (r *ImageGenerationRequest) WithQuality(quality ImageQuality) *ImageGenerationRequest {
+	r.Quality = quality
+	return r
+}
+
+// WithResponseFormat sets how the images are returned.
+func (r *ImageGenerationRequest) WithResponseFormat(format ImageResponseFormat) *ImageGenerationRequest {
+	r.ResponseFormat = format
+	return r
+}
+
+// WithMask sets the edit mask.
+func (r *ImageGenerationRequest) WithMask(mask *File) *ImageGenerationRequest {
+	r.Mask = mask
+	return r
+}
+
+// IsEdit reports whether the request edits images.
+func (r *ImageGenerationRequest) IsEdit() bool {
+	return len(r.Images) > 0
+}
+
+// Validate checks that the request has a prompt, valid counts and formats,
+// and that a mask is only set on edits.
+func (r *ImageGenerationRequest) Validate() error {
+	if r.Prompt == "" {
+		return NewValidationError("prompt", "prompt is required")
+	}
+	if r.N < 0 {
+		return &ValidationError{Field: "n", Message: "must not be negative", Value: r.N}
+	}
+	if r.ResponseFormat != "" && !r.ResponseFormat.IsValid() {
+		return &ValidationError{Field: "response_format", Message: "must be \"url\" or \"b64_json\"", Value: r.ResponseFormat}
+	}
+	for i, img := range r.Images {
+		if img == nil || len(img.Data) == 0 {
+			return NewValidationError("images", fmt.Sprintf("image %d is empty", i))
+		}
+	}
+	if r.Mask != nil {
+		if !r.IsEdit() {
+			return NewValidationError("mask", "mask requires an image to edit")
+		}
+		if len(r.Mask.Data) == 0 {
+			return NewValidationError("mask", "mask is empty")
+		}
+	}
+	return nil
+}
@@ -35,6 +35,10 @@ type Message struct {
 	// FunctionCall contains a function call (legacy, use ToolCalls).
 	FunctionCall *FunctionCall `json:"function_call,omitempty"`
 
+	// Refusal contains the model's refusal message, if it declined to respond.
+	// Only present when Role is RoleAssistant.
+	Refusal string `json:"refusal,omitempty"`
+
 	// Metadata contains additional message metadata.
 	Metadata *MessageMetadata `json:"metadata,omitempty"`
 }
@@ -0,0 +1,134 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RerankRequest represents a request to order documents by relevance to a
+// query.
+type RerankRequest struct {
+	// Model is the ID of the reranking model to use.
+	Model string `json:"model"`
+
+	// Query is the text the documents are ranked against.
+	Query string `json:"query"`
+
+	// Documents are the texts to rank.
+	Documents []string `json:"documents"`
+
+	// TopN is the number of results to return. Zero returns every document.
+	TopN int `json:"top_n,omitempty"`
+
+	// ReturnDocuments includes the document text in each result.
+	ReturnDocuments bool `json:"return_documents,omitempty"`
+
+	// MaxTokensPerDoc truncates long documents to this many tokens, if the
+	// provider supports it.
+	MaxTokensPerDoc int `json:"max_tokens_per_doc,omitempty"`
+
+	// Metadata contains additional request metadata.
+	Metadata *RequestMetadata `json:"metadata,omitempty"`
+}
+
+// RerankResponse represents a response from reranking.
+type RerankResponse struct {
+	// ID is a unique identifier for the response.
+	ID string `json:"id,omitempty"`
+
+	// Model is the model used to rank the documents.
+	Model string `json:"model"`
+
+	// Results holds the ranked documents, most relevant first.
+	Results []*RerankResult `json:"results"`
+
+	// Usage contains token usage information, if the provider reports it.
+	Usage *Usage `json:"usage,omitempty"`
+
+	// SearchUnits is the number of billed search units, if the provider
+	// bills by search unit rather than by token.
+	SearchUnits int `json:"search_units,omitempty"`
+
+	// Metadata contains additional response metadata.
+	Metadata *ResponseMetadata `json:"metadata,omitempty"`
+}
+
+// RerankResult is one ranked document.
+type RerankResult struct {
+	// Index is the position of the document in RerankRequest.Documents.
+	Index int `json:"index"`
+
+	// RelevanceScore is the relevance of the document to the query. Higher
+	// is more relevant; the scale depends on the model.
+	RelevanceScore float64 `json:"relevance_score"`
+
+	// Document is the document text, set when ReturnDocuments was requested.
+	Document string `json:"document,omitempty"`
+}
+
+// NewRerankRequest creates a new RerankRequest.
+func NewRerankRequest(model, query string, documents []string) *RerankRequest {
+	return &RerankRequest{
+		Model:     model,
+		Query:     query,
+		Documents: documents,
+	}
+}
+
+// WithTopN sets the number of results to return.
+func (r *RerankRequest) WithTopN(n int) *RerankRequest {
+	r.TopN = n
+	return r
+}
+
+// WithReturnDocuments includes the document text in each result.
+func (r *RerankRequest) WithReturnDocuments(enabled bool) *RerankRequest {
+	r.ReturnDocuments = enabled
+	return r
+}
+
+// Validate checks that the request has a query and documents and that
+// TopN is not negative.
+func (r *RerankRequest) Validate() error {
+	if r.Query == "" {
+		return NewValidationError("query", "query is required")
+	}
+	if len(r.Documents) == 0 {
+		return NewValidationError("documents", "documents must not be empty")
+	}
+	if r.TopN < 0 {
+		return &ValidationError{Field: "top_n", Message: "must not be negative", Value: r.TopN}
+	}
+	if r.MaxTokensPerDoc < 0 {
+		return &ValidationError{Field: "max_tokens_per_doc", Message: "must not be negative", Value: r.MaxTokensPerDoc}
+	}
+	return nil
+}
+
+// SortRerankResults orders results by descending relevance, breaking ties by
+// index, and keeps the first topN. A topN of zero keeps every result.
+func SortRerankResults(results []*RerankResult, topN int) []*RerankResult {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].RelevanceScore != results[j].RelevanceScore {
+			return results[i].RelevanceScore > results[j].RelevanceScore
+		}
+		return results[i].Index < results[j].Index
+	})
+	if topN > 0 && topN < len(results) {
+		results = results[:topN]
+	}
+	return results
+}
+
+// Reorder returns documents in the order of the results. It returns a
+// ValidationError if a result index is out of range.
+func (r *RerankResponse) Reorder(documents []string) ([]string, error) {
+	out := make([]string, len(r.Results))
+	for i, res := range r.Results {
+		if res.Index < 0 || res.Index >= len(documents) {
+			return nil, &ValidationError{Field: "index", Message: fmt.Sprintf("result %d index out of range", i), Value: res.Index}
+		}
+		out[i] = documents[res.Index]
+	}
+	return out, nil
+}
@@ -1,5 +1,7 @@
 package types
 
+import "sort"
+
 // StreamChunk represents a chunk of data in a streaming response.
 type StreamChunk interface {
 	// GetID returns the unique identifier for the stream.
@@ -37,6 +39,10 @@ type ChatStreamChunk struct {
 
 	// SystemFingerprint is a fingerprint of the system configuration.
 	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+
+	// EventID is the server-sent event ID that carried this chunk, if the
+	// provider assigns one. It can be used to resume an interrupted stream.
+	EventID string `json:"-"`
 }
 
 // GetID returns the stream ID.
@@ -68,6 +74,16 @@ func (c *ChatStreamChunk) IsComplete() bool {
 	return false
 }
 
+// GetUsage returns the usage reported by the chunk, or nil.
+func (c *ChatStreamChunk) GetUsage() *Usage {
+	return c.Usage
+}
+
+// GetEventID returns the server-sent event ID of the chunk, or "".
+func (c *ChatStreamChunk) GetEventID() string {
+	return c.EventID
+}
+
 // GetFirstDelta returns the delta from the first choice.
 func (c *ChatStreamChunk) GetFirstDelta() *MessageDelta {
 	if len(c.Choices) > 0 {
@@ -108,6 +124,9 @@ type MessageDelta struct {
 	// Content is the incremental text content.
 	Content string `json:"content,omitempty"`
 
+	// Refusal is the incremental refusal message.
+	Refusal string `json:"refusal,omitempty"`
+
 	// ToolCalls contains incremental tool call updates.
 	ToolCalls []*ToolCallDelta `json:"tool_calls,omitempty"`
 
@@ -171,6 +190,81 @@ func (e *StreamError) Error() string {
 	return e.Type.String() + ": " + e.Message
 }
 
+// ErrorChunk is a StreamChunk reporting an error that ended the stream, such
+// as a network failure or an error event from the provider. Producers send it
+// as the last chunk before closing the channel, so consumers can tell an
+// interrupted stream from a completed one.
+type ErrorChunk struct {
+	// ID is the stream ID, if known.
+	ID string
+
+	// Model is the model name, if known.
+	Model string
+
+	// Err is the error that ended the stream.
+	Err error
+}
+
+// NewErrorChunk creates an ErrorChunk for err.
+func NewErrorChunk(err error) *ErrorChunk {
+	return &ErrorChunk{Err: err}
+}
+
+// GetID returns the stream ID.
+func (c *ErrorChunk) GetID() string {
+	return c.ID
+}
+
+// GetModel returns the model name.
+func (c *ErrorChunk) GetModel() string {
+	return c.Model
+}
+
+// GetChoices returns nil; an ErrorChunk carries no choices.
+func (c *ErrorChunk) GetChoices() []*StreamChoice {
+	return nil
+}
+
+// IsComplete returns false; an ErrorChunk ends the stream unsuccessfully.
+func (c *ErrorChunk) IsComplete() bool {
+	return false
+}
+
+// Error implements the error interface.
+func (c *ErrorChunk) Error() string {
+	return "stream error: " + c.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (c *ErrorChunk) Unwrap() error {
+	return c.Err
+}
+
+// EventIDChunk is implemented by StreamChunk types that record the
+// server-sent event ID they were delivered with.
+type EventIDChunk interface {
+	// GetEventID returns the event ID, or "" if none was sent.
+	GetEventID() string
+}
+
+// AccumulatingChunk is implemented by custom StreamChunk types that need to
+// control how they are merged into a StreamAccumulator, for example to carry
+// provider-specific fields. StreamAccumulator.Add calls Accumulate instead of
+// its default handling; implementations typically use AddChoice and
+// MergeUsage.
+type AccumulatingChunk interface {
+	StreamChunk
+
+	// Accumulate merges the chunk into a.
+	Accumulate(a *StreamAccumulator)
+}
+
+// UsageChunk is implemented by StreamChunk types that can carry token usage.
+type UsageChunk interface {
+	// GetUsage returns the usage reported by the chunk, or nil.
+	GetUsage() *Usage
+}
+
 // StreamAccumulator helps accumulate streaming chunks into a complete response.
 type StreamAccumulator struct {
 	// ID is the stream ID.
@@ -203,9 +297,18 @@ type AccumulatedChoice struct {
 	// Content accumulates the text content.
 	Content string
 
+	// Refusal accumulates the refusal message.
+	Refusal string
+
 	// ToolCalls accumulates tool calls.
 	ToolCalls map[int]*AccumulatedToolCall
 
+	// FunctionCall accumulates a legacy function call.
+	FunctionCall *FunctionCall
+
+	// LogProbs accumulates log probabilities (if requested).
+	LogProbs *LogProbability
+
 	// FinishReason is the finish reason (set in final chunk).
 	FinishReason FinishReason
 }
@@ -236,92 +339,177 @@ func NewStreamAccumulator() *StreamAccumulator {
 }
 
 // Add processes a stream chunk and updates the accumulator.
+//
+// Chunks implementing AccumulatingChunk merge themselves. Other chunk types
+// are merged through the StreamChunk interface; usage is read from chunks
+// implementing UsageChunk.
 func (a *StreamAccumulator) Add(chunk StreamChunk) {
+	if chunk == nil {
+		return
+	}
+	if c, ok := chunk.(AccumulatingChunk); ok {
+		c.Accumulate(a)
+		return
+	}
+
+	if id := chunk.GetID(); id != "" {
+		a.ID = id
+	}
+	if model := chunk.GetModel(); model != "" {
+		a.Model = model
+	}
 	if c, ok := chunk.(*ChatStreamChunk); ok {
-		a.ID = c.ID
-		a.Model = c.Model
-		a.Created = c.Created
-		a.SystemFingerprint = c.SystemFingerprint
-
-		if c.Usage != nil {
-			if a.Usage == nil {
-				a.Usage = &Usage{}
-			}
-			a.Usage.Add(c.Usage)
+		if c.Created != 0 {
+			a.Created = c.Created
+		}
+		if c.SystemFingerprint != "" {
+			a.SystemFingerprint = c.SystemFingerprint
 		}
+	}
+	if c, ok := chunk.(UsageChunk); ok {
+		a.MergeUsage(c.GetUsage())
+	}
 
-		for _, choice := range c.Choices {
-			if choice.Delta == nil {
-				continue
-			}
+	for _, choice := range chunk.GetChoices() {
+		a.AddChoice(choice)
+	}
+}
 
-			idx := choice.Index
-			if _, exists := a.Choices[idx]; !exists {
-				a.Choices[idx] = &AccumulatedChoice{
-					Index:     idx,
-					ToolCalls: make(map[int]*AccumulatedToolCall),
-				}
-			}
+// AddChoice merges a streamed choice into the accumulated choice with the same index.
+func (a *StreamAccumulator) AddChoice(choice *StreamChoice) {
+	if choice == nil {
+		return
+	}
+	if a.Choices == nil {
+		a.Choices = make(map[int]*AccumulatedChoice)
+	}
+
+	accChoice, exists := a.Choices[choice.Index]
+	if !exists {
+		accChoice = &AccumulatedChoice{
+			Index:     choice.Index,
+			ToolCalls: make(map[int]*AccumulatedToolCall),
+		}
+		a.Choices[choice.Index] = accChoice
+	}
 
-			accChoice := a.Choices[idx]
+	if delta := choice.Delta; delta != nil {
+		// Accumulate role (typically only in first chunk)
+		if delta.Role != "" {
+			accChoice.Role = delta.Role
+		}
 
-			// Accumulate role (typically only in first chunk)
-			if choice.Delta.Role != "" {
-				accChoice.Role = choice.Delta.Role
-			}
+		accChoice.Content += delta.Content
+		accChoice.Refusal += delta.Refusal
 
-			// Accumulate content
-			if choice.Delta.Content != "" {
-				accChoice.Content += choice.Delta.Content
-			}
+		for _, toolCallDelta := range delta.ToolCalls {
+			accChoice.addToolCall(toolCallDelta)
+		}
 
-			// Accumulate tool calls
-			for _, toolCallDelta := range choice.Delta.ToolCalls {
-				if _, exists := accChoice.ToolCalls[toolCallDelta.Index]; !exists {
-					accChoice.ToolCalls[toolCallDelta.Index] = &AccumulatedToolCall{
-						Index: toolCallDelta.Index,
-					}
-				}
-
-				accTool := accChoice.ToolCalls[toolCallDelta.Index]
-
-				if toolCallDelta.ID != "" {
-					accTool.ID = toolCallDelta.ID
-				}
-				if toolCallDelta.Type != "" {
-					accTool.Type = toolCallDelta.Type
-				}
-				if toolCallDelta.Function != nil {
-					if toolCallDelta.Function.Name != "" {
-						accTool.FunctionName = toolCallDelta.Function.Name
-					}
-					if toolCallDelta.Function.Arguments != "" {
-						accTool.Arguments += toolCallDelta.Function.Arguments
-					}
-				}
+		if fc := delta.FunctionCall; fc != nil {
+			if accChoice.FunctionCall == nil {
+				accChoice.FunctionCall = &FunctionCall{}
 			}
-
-			// Set finish reason
-			if choice.FinishReason != "" && choice.FinishReason != FinishReasonNull {
-				accChoice.FinishReason = choice.FinishReason
+			if fc.Name != "" {
+				accChoice.FunctionCall.Name = fc.Name
 			}
+			accChoice.FunctionCall.Arguments += fc.Arguments
+		}
+	}
+
+	if lp := choice.LogProbs; lp != nil {
+		if accChoice.LogProbs == nil {
+			accChoice.LogProbs = &LogProbability{}
 		}
+		accChoice.LogProbs.Content = append(accChoice.LogProbs.Content, lp.Content...)
+		accChoice.LogProbs.Refusal = append(accChoice.LogProbs.Refusal, lp.Refusal...)
+	}
+
+	// Set finish reason
+	if choice.FinishReason != "" && choice.FinishReason != FinishReasonNull {
+		accChoice.FinishReason = choice.FinishReason
 	}
 }
 
+// addToolCall merges a tool call delta into the tool call with the same index.
+func (c *AccumulatedChoice) addToolCall(delta *ToolCallDelta) {
+	if delta == nil {
+		return
+	}
+	if c.ToolCalls == nil {
+		c.ToolCalls = make(map[int]*AccumulatedToolCall)
+	}
+
+	accTool, exists := c.ToolCalls[delta.Index]
+	if !exists {
+		accTool = &AccumulatedToolCall{Index: delta.Index}
+		c.ToolCalls[delta.Index] = accTool
+	}
+
+	if delta.ID != "" {
+		accTool.ID = delta.ID
+	}
+	if delta.Type != "" {
+		accTool.Type = delta.Type
+	}
+	if delta.Function != nil {
+		if delta.Function.Name != "" {
+			accTool.FunctionName = delta.Function.Name
+		}
+		accTool.Arguments += delta.Function.Arguments
+	}
+}
+
+// MergeUsage merges usage reported by a chunk.
+//
+// Providers report streaming usage cumulatively: OpenAI sends it once in the
+// final chunk, while others repeat running totals (e.g., input tokens at the
+// start and output tokens at the end). Each field therefore keeps the largest
+// value seen rather than being summed, so repeated or overlapping reports are
+// not double-counted.
+func (a *StreamAccumulator) MergeUsage(usage *Usage) {
+	if usage == nil {
+		return
+	}
+	if a.Usage == nil {
+		a.Usage = &Usage{}
+	}
+	a.Usage.PromptTokens = max(a.Usage.PromptTokens, usage.PromptTokens)
+	a.Usage.CompletionTokens = max(a.Usage.CompletionTokens, usage.CompletionTokens)
+	a.Usage.TotalTokens = max(a.Usage.TotalTokens, usage.TotalTokens, a.Usage.PromptTokens+a.Usage.CompletionTokens)
+	a.Usage.CachedTokens = max(a.Usage.CachedTokens, usage.CachedTokens)
+	a.Usage.ReasoningTokens = max(a.Usage.ReasoningTokens, usage.ReasoningTokens)
+}
+
 // ToChatResponse converts the accumulated data to a ChatResponse.
+// Choices and their tool calls are ordered by index.
 func (a *StreamAccumulator) ToChatResponse() *ChatResponse {
+	choiceIndexes := make([]int, 0, len(a.Choices))
+	for idx := range a.Choices {
+		choiceIndexes = append(choiceIndexes, idx)
+	}
+	sort.Ints(choiceIndexes)
+
 	choices := make([]*Choice, 0, len(a.Choices))
-	for _, accChoice := range a.Choices {
+	for _, idx := range choiceIndexes {
+		accChoice := a.Choices[idx]
 		message := &Message{
 			Role:    accChoice.Role,
 			Content: NewTextContent(accChoice.Content),
+			Refusal: accChoice.Refusal,
 		}
 
 		// Convert tool calls
 		if len(accChoice.ToolCalls) > 0 {
+			toolIndexes := make([]int, 0, len(accChoice.ToolCalls))
+			for toolIdx := range accChoice.ToolCalls {
+				toolIndexes = append(toolIndexes, toolIdx)
+			}
+			sort.Ints(toolIndexes)
+
 			toolCalls := make([]*ToolCall, 0, len(accChoice.ToolCalls))
-			for _, accTool := range accChoice.ToolCalls {
+			for _, toolIdx := range toolIndexes {
+				accTool := accChoice.ToolCalls[toolIdx]
 				toolCalls = append(toolCalls, &ToolCall{
 					ID:   accTool.ID,
 					Type: accTool.Type,
@@ -335,20 +523,32 @@ func (a *StreamAccumulator) ToChatResponse() *ChatResponse {
 			message.ToolCalls = toolCalls
 		}
 
+		if accChoice.FunctionCall != nil {
+			fc := *accChoice.FunctionCall
+			message.FunctionCall = &fc
+		}
+
 		choices = append(choices, &Choice{
 			Index:        accChoice.Index,
 			Message:      message,
 			FinishReason: accChoice.FinishReason,
+			LogProbs:     accChoice.LogProbs,
 		})
 	}
 
+	var usage *Usage
+	if a.Usage != nil {
+		u := *a.Usage
+		usage = &u
+	}
+
 	return &ChatResponse{
 		ID:                a.ID,
 		Object:            "chat.completion",
 		Created:           a.Created,
 		Model:             a.Model,
 		Choices:           choices,
-		Usage:             a.Usage,
+		Usage:             usage,
 		SystemFingerprint: a.SystemFingerprint,
 	}
 }
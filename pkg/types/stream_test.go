@@ -0,0 +1,215 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+)
+
+// deltaChunk returns a chunk with one choice carrying delta.
+func deltaChunk(index int, delta *MessageDelta, reason FinishReason) *ChatStreamChunk {
+	return &ChatStreamChunk{ID: "chatcmpl-1", Model: "m", Choices: []*StreamChoice{{Index: index, Delta: delta, FinishReason: reason}}}
+}
+
+// toolDelta returns a tool call delta for the function at index.
+func toolDelta(index int, id, name, arguments string) *ToolCallDelta {
+	d := &ToolCallDelta{Index: index, ID: id, Function: &FunctionCallDelta{Name: name, Arguments: arguments}}
+	if id != "" {
+		d.Type = ToolTypeFunction
+	}
+	return d
+}
+
+func TestStreamAccumulatorUsage(t *testing.T) {
+	tests := []struct {
+		name   string
+		usages []*Usage
+		want   *Usage
+	}{
+		{
+			name: "no usage",
+		},
+		{
+			name:   "single final report",
+			usages: []*Usage{{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}},
+			want:   &Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		},
+		{
+			name:   "prompt at the start and completion at the end",
+			usages: []*Usage{{PromptTokens: 10}, {CompletionTokens: 5}},
+			want:   &Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		},
+		{
+			name:   "repeated running totals are not summed",
+			usages: []*Usage{{PromptTokens: 10, CompletionTokens: 1, TotalTokens: 11}, {PromptTokens: 10, CompletionTokens: 3, TotalTokens: 13}, {PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}},
+			want:   &Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		},
+		{
+			name:   "largest value of each field is kept",
+			usages: []*Usage{{PromptTokens: 10, CachedTokens: 4}, {PromptTokens: 8, CompletionTokens: 5, ReasoningTokens: 2}},
+			want:   &Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, CachedTokens: 4, ReasoningTokens: 2},
+		},
+		{
+			name:   "reported total above the sum is kept",
+			usages: []*Usage{{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 20}},
+			want:   &Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 20},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acc := NewStreamAccumulator()
+			acc.Add(deltaChunk(0, &MessageDelta{Role: RoleAssistant, Content: "hi"}, ""))
+			for _, u := range tt.usages {
+				acc.Add(&ChatStreamChunk{Choices: []*StreamChoice{}, Usage: u})
+			}
+			got := acc.ToChatResponse().Usage
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("Usage = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || *got != *tt.want {
+				t.Errorf("Usage = %+v, want %+v", got, tt.want)
+			}
+			if got == acc.Usage {
+				t.Error("ToChatResponse() returned the accumulator's Usage, want a copy")
+			}
+		})
+	}
+}
+
+func TestStreamAccumulatorToolCalls(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []*ChatStreamChunk
+		want   string
+	}{
+		{
+			name: "arguments are concatenated per index",
+			chunks: []*ChatStreamChunk{
+				deltaChunk(0, &MessageDelta{ToolCalls: []*ToolCallDelta{toolDelta(0, "a", "add", `{"x":`)}}, ""),
+				deltaChunk(0, &MessageDelta{ToolCalls: []*ToolCallDelta{toolDelta(0, "", "", `1}`)}}, FinishReasonToolCalls),
+			},
+			want: `[a:add({"x":1})@0]`,
+		},
+		{
+			name: "out-of-order indices are sorted",
+			chunks: []*ChatStreamChunk{
+				deltaChunk(0, &MessageDelta{ToolCalls: []*ToolCallDelta{toolDelta(2, "c", "third", `{}`)}}, ""),
+				deltaChunk(0, &MessageDelta{ToolCalls: []*ToolCallDelta{toolDelta(0, "a", "first", `{"`)}}, ""),
+				deltaChunk(0, &MessageDelta{ToolCalls: []*ToolCallDelta{toolDelta(1, "b", "second", `[]`), toolDelta(0, "", "", `k":1}`)}}, FinishReasonToolCalls),
+			},
+			want: `[a:first({"k":1})@0 b:second([])@1 c:third({})@2]`,
+		},
+		{
+			name: "interleaved deltas for two calls",
+			chunks: []*ChatStreamChunk{
+				deltaChunk(0, &MessageDelta{ToolCalls: []*ToolCallDelta{toolDelta(1, "b", "two", `{"b"`), toolDelta(0, "a", "one", `{"a"`)}}, ""),
+				deltaChunk(0, &MessageDelta{ToolCalls: []*ToolCallDelta{toolDelta(0, "", "", `:0}`), toolDelta(1, "", "", `:1}`)}}, FinishReasonToolCalls),
+			},
+			want: `[a:one({"a":0})@0 b:two({"b":1})@1]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acc := NewStreamAccumulator()
+			for _, c := range tt.chunks {
+				acc.Add(c)
+			}
+			resp := acc.ToChatResponse()
+			var got []string
+			for _, call := range resp.GetToolCalls() {
+				if call.Type != ToolTypeFunction {
+					t.Errorf("tool call %s has Type %q, want function", call.ID, call.Type)
+				}
+				got = append(got, fmt.Sprintf("%s:%s(%s)@%d", call.ID, call.Function.Name, call.Function.Arguments, call.Index))
+			}
+			if fmt.Sprint(got) != tt.want {
+				t.Errorf("tool calls = %v, want %s", got, tt.want)
+			}
+			if resp.GetFirstFinishReason() != FinishReasonToolCalls {
+				t.Errorf("FinishReason = %q, want tool_calls", resp.GetFirstFinishReason())
+			}
+		})
+	}
+}
+
+func TestStreamAccumulatorChoices(t *testing.T) {
+	acc := NewStreamAccumulator()
+	chunks := []StreamChunk{
+		&ChatStreamChunk{ID: "chatcmpl-1", Model: "m", Created: 42, SystemFingerprint: "fp", Choices: []*StreamChoice{
+			{Index: 1, Delta: &MessageDelta{Role: RoleAssistant, Content: "B"}},
+			{Index: 0, Delta: &MessageDelta{Role: RoleAssistant, Content: "A"}, LogProbs: &LogProbability{Content: []*TokenLogProb{{Token: "A"}}}},
+		}},
+		deltaChunk(0, &MessageDelta{Content: "a"}, ""),
+		&ChatStreamChunk{Choices: []*StreamChoice{{Index: 0, Delta: &MessageDelta{}, LogProbs: &LogProbability{Content: []*TokenLogProb{{Token: "a"}}}}}},
+		deltaChunk(1, &MessageDelta{Refusal: "can't "}, ""),
+		deltaChunk(1, &MessageDelta{Refusal: "help"}, FinishReasonContentFilter),
+		deltaChunk(0, &MessageDelta{FunctionCall: &FunctionCallDelta{Name: "legacy", Arguments: "{"}}, ""),
+		deltaChunk(0, &MessageDelta{FunctionCall: &FunctionCallDelta{Arguments: "}"}}, FinishReasonStop),
+		deltaChunk(0, &MessageDelta{}, FinishReasonNull),
+		nil,
+	}
+	for _, c := range chunks {
+		acc.Add(c)
+	}
+
+	resp := acc.ToChatResponse()
+	if resp.ID != "chatcmpl-1" || resp.Model != "m" || resp.Created != 42 || resp.SystemFingerprint != "fp" || resp.Object != "chat.completion" {
+		t.Errorf("response header = %+v", resp)
+	}
+	if len(resp.Choices) != 2 || resp.Choices[0].Index != 0 || resp.Choices[1].Index != 1 {
+		t.Fatalf("choices = %+v, want indices 0 and 1 in order", resp.Choices)
+	}
+
+	first, second := resp.Choices[0], resp.Choices[1]
+	if got := first.Message.Content.String(); got != "Aa" {
+		t.Errorf("choice 0 content = %q, want %q", got, "Aa")
+	}
+	if first.FinishReason != FinishReasonStop {
+		t.Errorf("choice 0 FinishReason = %q, want stop (a later null must not clear it)", first.FinishReason)
+	}
+	if fc := first.Message.FunctionCall; fc == nil || fc.Name != "legacy" || fc.Arguments != "{}" {
+		t.Errorf("choice 0 FunctionCall = %+v", fc)
+	}
+	if lp := first.LogProbs; lp == nil || len(lp.Content) != 2 || lp.Content[0].Token != "A" || lp.Content[1].Token != "a" {
+		t.Errorf("choice 0 LogProbs = %+v, want tokens A, a", lp)
+	}
+
+	if second.Message.Role != RoleAssistant || second.Message.Content.String() != "B" {
+		t.Errorf("choice 1 message = %+v", second.Message)
+	}
+	if second.Message.Refusal != "can't help" || second.FinishReason != FinishReasonContentFilter {
+		t.Errorf("choice 1 refusal = %q, finish = %q", second.Message.Refusal, second.FinishReason)
+	}
+	if second.LogProbs != nil {
+		t.Errorf("choice 1 LogProbs = %+v, want nil", second.LogProbs)
+	}
+}
+
+// customChunk merges itself with a fixed usage.
+type customChunk struct {
+	ChatStreamChunk
+}
+
+func (c *customChunk) Accumulate(a *StreamAccumulator) {
+	for _, choice := range c.Choices {
+		a.AddChoice(choice)
+	}
+	a.MergeUsage(&Usage{PromptTokens: 1, CompletionTokens: 1})
+}
+
+func TestStreamAccumulatorAccumulatingChunk(t *testing.T) {
+	acc := &StreamAccumulator{}
+	acc.Add(&customChunk{ChatStreamChunk{ID: "ignored", Choices: []*StreamChoice{{Delta: &MessageDelta{Content: "x"}}}}})
+
+	resp := acc.ToChatResponse()
+	if resp.ID != "" {
+		t.Errorf("ID = %q, want the custom chunk to control merging", resp.ID)
+	}
+	if resp.GetFirstContent() != "x" || resp.Usage == nil || resp.Usage.TotalTokens != 2 {
+		t.Errorf("response = content %q, usage %+v", resp.GetFirstContent(), resp.Usage)
+	}
+}
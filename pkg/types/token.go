@@ -27,6 +27,97 @@ type TokenCounter interface {
 	EstimateRequestTokens(req *ChatRequest) *TokenEstimate
 }
 
+// DefaultCharsPerToken is the characters-per-token ratio used by
+// HeuristicTokenCounter when none is configured.
+const DefaultCharsPerToken = 4.0
+
+// DefaultMessageOverheadTokens is the per-message token overhead used by
+// HeuristicTokenCounter to account for role markers and separators.
+const DefaultMessageOverheadTokens = 4
+
+// HeuristicTokenCounter is a provider-agnostic TokenCounter that estimates
+// token counts from character length.
+//
+// It is intended as a fallback when no tokenizer for the target model is
+// available. Estimates are approximate and tend to be conservative for English text.
+type HeuristicTokenCounter struct {
+	// CharsPerToken is the average number of characters per token.
+	// Defaults to DefaultCharsPerToken if zero.
+	CharsPerToken float64
+
+	// MessageOverhead is the number of tokens added per message.
+	// Defaults to DefaultMessageOverheadTokens if zero.
+	MessageOverhead int
+}
+
+// NewHeuristicTokenCounter creates a HeuristicTokenCounter with default settings.
+func NewHeuristicTokenCounter() *HeuristicTokenCounter {
+	return &HeuristicTokenCounter{
+		CharsPerToken:   DefaultCharsPerToken,
+		MessageOverhead: DefaultMessageOverheadTokens,
+	}
+}
+
+// CountTokens estimates the number of tokens in the given text.
+func (c *HeuristicTokenCounter) CountTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	ratio := c.CharsPerToken
+	if ratio <= 0 {
+		ratio = DefaultCharsPerToken
+	}
+	count := int(float64(len([]rune(text)))/ratio + 0.5)
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+// CountMessagesTokens estimates the tokens in a list of messages, including
+// tool call names and arguments.
+func (c *HeuristicTokenCounter) CountMessagesTokens(messages []*Message) int {
+	overhead := c.MessageOverhead
+	if overhead == 0 {
+		overhead = DefaultMessageOverheadTokens
+	}
+
+	total := 0
+	for _, msg := range messages {
+		if msg == nil {
+			continue
+		}
+		total += overhead
+		if msg.Content != nil {
+			total += c.CountTokens(msg.Content.String())
+		}
+		for _, call := range msg.ToolCalls {
+			if call != nil {
+				total += c.CountTokens(call.Function.Name) + c.CountTokens(call.Function.Arguments)
+			}
+		}
+		if msg.FunctionCall != nil {
+			total += c.CountTokens(msg.FunctionCall.Name) + c.CountTokens(msg.FunctionCall.Arguments)
+		}
+	}
+	return total
+}
+
+// EstimateRequestTokens estimates the tokens for a chat request.
+// The completion estimate is the request's MaxTokens.
+func (c *HeuristicTokenCounter) EstimateRequestTokens(req *ChatRequest) *TokenEstimate {
+	if req == nil {
+		return &TokenEstimate{Method: "heuristic"}
+	}
+	prompt := c.CountMessagesTokens(req.Messages)
+	return &TokenEstimate{
+		PromptTokens:     prompt,
+		CompletionTokens: req.MaxTokens,
+		TotalTokens:      prompt + req.MaxTokens,
+		Method:           "heuristic",
+	}
+}
+
 // TokenLimit represents token limits for a model.
 type TokenLimit struct {
 	// Model is the model ID.
@@ -0,0 +1,21 @@
+// Package validators provides validation functions for library types and
+// model output.
+//
+// ValidateSchemaCompliance checks a decoded JSON value against a
+// types.JSONSchema. Validation is structural only: it checks types, required
+// properties, additional properties, enums and array items. It does not
+// validate semantics or business rules.
+//
+// Example usage:
+//
+//	var value interface{}
+//	if err := json.Unmarshal([]byte(resp.GetFirstContent()), &value); err != nil {
+//	    return err
+//	}
+//	if err := validators.ValidateSchemaCompliance(schema, value); err != nil {
+//	    var vErr *types.ValidationError
+//	    if errors.As(err, &vErr) {
+//	        log.Printf("invalid field %s: %s", vErr.Field, vErr.Message)
+//	    }
+//	}
+package validators
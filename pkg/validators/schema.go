@@ -0,0 +1,341 @@
+package validators
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// ValidateSchemaCompliance checks that value conforms to schema.
+//
+// value is expected to be the result of decoding JSON into an interface{}
+// (maps, slices, strings, float64 or json.Number, bools and nil).
+//
+// Returns nil if the value is valid, or a *types.ValidationError whose Field
+// is the path of the first offending value (e.g., "items[2].name").
+func ValidateSchemaCompliance(schema *types.JSONSchema, value interface{}) error {
+	v := &schemaValidator{root: schema}
+	return v.validate(schema, value, "")
+}
+
+// PartialNumber is a number that may still be extended, such as a number
+// at the end of a partially streamed document: "1" may yet become "10" or
+// "1.5". ValidatePartialSchemaCompliance checks that a number is allowed
+// where it appears, but not its value.
+type PartialNumber string
+
+// PartialString is a string that may still be extended, such as an
+// unterminated string at the end of a partially streamed document.
+// ValidatePartialSchemaCompliance only requires an enum value to start with
+// it and does not check its format.
+type PartialString string
+
+// ValidatePartialSchemaCompliance checks a value that may be the prefix of a
+// document still being generated, such as a partially streamed tool call.
+//
+// Required properties may be missing, and a PartialString or PartialNumber is
+// only checked for values it could still become, but types, additional
+// properties, formats and the enums of complete values are validated as usual.
+func ValidatePartialSchemaCompliance(schema *types.JSONSchema, value interface{}) error {
+	v := &schemaValidator{root: schema, partial: true}
+	return v.validate(schema, value, "")
+}
+
+// ValidateJSONSchemaCompliance decodes data and checks it against schema.
+// Returns a *types.ValidationError if data is not valid JSON or does not conform.
+func ValidateJSONSchemaCompliance(schema *types.JSONSchema, data []byte) error {
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return &types.ValidationError{Message: "invalid JSON: " + err.Error()}
+	}
+	if dec.More() {
+		return &types.ValidationError{Message: "invalid JSON: unexpected data after top-level value"}
+	}
+	return ValidateSchemaCompliance(schema, value)
+}
+
+// schemaValidator validates values against a schema, resolving $ref against root.
+type schemaValidator struct {
+	root *types.JSONSchema
+
+	// partial tolerates missing required properties.
+	partial bool
+}
+
+// validate checks value against s. path is the location of value in the document.
+func (sv *schemaValidator) validate(s *types.JSONSchema, value interface{}, path string) error {
+	if s == nil {
+		return nil
+	}
+
+	if s.Ref != "" {
+		target, err := sv.resolve(s.Ref)
+		if err != nil {
+			return schemaError(path, err.Error(), nil)
+		}
+		if err := sv.validate(target, value, path); err != nil {
+			return err
+		}
+	}
+
+	if len(s.AnyOf) > 0 {
+		if err := sv.validateAnyOf(s.AnyOf, value, path); err != nil {
+			return err
+		}
+	}
+
+	if err := sv.validateType(s, value, path); err != nil {
+		return err
+	}
+
+	if len(s.Enum) > 0 && !enumContains(s.Enum, value) {
+		return schemaError(path, fmt.Sprintf("value must be one of %v", s.Enum), value)
+	}
+	return nil
+}
+
+// validateAnyOf checks that value matches at least one alternative.
+func (sv *schemaValidator) validateAnyOf(alternatives []*types.JSONSchema, value interface{}, path string) error {
+	var firstErr error
+	for _, alt := range alternatives {
+		err := sv.validate(alt, value, path)
+		if err == nil {
+			return nil
+		}
+		// Prefer the error from a non-null alternative: it is the informative one.
+		if firstErr == nil && (alt == nil || alt.Type != "null") {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	return schemaError(path, "value does not match any allowed schema", value)
+}
+
+// validateType checks the JSON type of value and recurses into objects and arrays.
+func (sv *schemaValidator) validateType(s *types.JSONSchema, value interface{}, path string) error {
+	switch s.Type {
+	case "":
+		return nil
+
+	case "null":
+		if value != nil {
+			return typeError(path, s.Type, value)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return typeError(path, s.Type, value)
+		}
+
+	case "string":
+		if _, incomplete := value.(PartialString); incomplete {
+			break
+		}
+		str, ok := value.(string)
+		if !ok {
+			return typeError(path, s.Type, value)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				return schemaError(path, "value must be an RFC 3339 date-time", value)
+			}
+		}
+
+	case "number":
+		if _, ok := toFloat(value); !ok {
+			return typeError(path, s.Type, value)
+		}
+
+	case "integer":
+		f, ok := toFloat(value)
+		if _, incomplete := value.(PartialNumber); incomplete && ok {
+			// "1.5" may still become "1.5e1".
+			break
+		}
+		if !ok || f != math.Trunc(f) {
+			return typeError(path, s.Type, value)
+		}
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return typeError(path, s.Type, value)
+		}
+		for i, item := range items {
+			if err := sv.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return typeError(path, s.Type, value)
+		}
+		return sv.validateObject(s, obj, path)
+
+	default:
+		return schemaError(path, "unsupported schema type: "+s.Type, nil)
+	}
+	return nil
+}
+
+// validateObject checks required, declared and additional properties.
+func (sv *schemaValidator) validateObject(s *types.JSONSchema, obj map[string]interface{}, path string) error {
+	if !sv.partial {
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return schemaError(joinPath(path, name), "required property is missing", nil)
+			}
+		}
+	}
+
+	for name, prop := range obj {
+		fieldPath := joinPath(path, name)
+		if propSchema, ok := s.Properties[name]; ok {
+			if err := sv.validate(propSchema, prop, fieldPath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch additional := s.AdditionalProperties.(type) {
+		case bool:
+			if !additional {
+				return schemaError(fieldPath, "additional property is not allowed", prop)
+			}
+		case *types.JSONSchema:
+			if err := sv.validate(additional, prop, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolve returns the schema referenced by ref. Only local references to the
+// root ("#") and to root definitions ("#/$defs/Name") are supported.
+func (sv *schemaValidator) resolve(ref string) (*types.JSONSchema, error) {
+	if ref == "#" {
+		return sv.root, nil
+	}
+	const prefix = "#/$defs/"
+	if strings.HasPrefix(ref, prefix) && sv.root != nil {
+		if def, ok := sv.root.Defs[strings.TrimPrefix(ref, prefix)]; ok {
+			return def, nil
+		}
+	}
+	return nil, fmt.Errorf("unresolvable schema reference %q", ref)
+}
+
+// enumContains reports whether value equals one of the allowed values.
+// Numbers are compared by value regardless of their Go type. A PartialString
+// matches any allowed string that starts with it, and a PartialNumber matches
+// any allowed number.
+func enumContains(allowed []interface{}, value interface{}) bool {
+	vf, vIsNum := toFloat(value)
+	vs, vIsPartialStr := value.(PartialString)
+	_, vIsPartialNum := value.(PartialNumber)
+	for _, a := range allowed {
+		if as, ok := a.(string); ok && vIsPartialStr && strings.HasPrefix(as, string(vs)) {
+			return true
+		}
+		if af, ok := toFloat(a); ok && vIsNum {
+			if af == vf || vIsPartialNum {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(a, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// toFloat converts numeric values to float64.
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := strconv.ParseFloat(string(n), 64)
+		return f, err == nil
+	case PartialNumber:
+		f, err := strconv.ParseFloat(string(n), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// jsonTypeName returns the JSON type name of a decoded value.
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string, PartialString:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	if _, ok := toFloat(v); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// joinPath appends a property name to a path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// typeError creates a ValidationError for a type mismatch.
+func typeError(path, want string, value interface{}) error {
+	return schemaError(path, fmt.Sprintf("expected %s, got %s", want, jsonTypeName(value)), value)
+}
+
+// schemaError creates a ValidationError at path.
+func schemaError(path, message string, value interface{}) error {
+	return &types.ValidationError{Field: path, Message: message, Value: value}
+}
@@ -0,0 +1,40 @@
+package validators
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+func TestValidatePartialSchemaCompliance(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  *types.JSONSchema
+		value   interface{}
+		wantErr bool
+	}{
+		{name: "partial string prefix of enum", schema: &types.JSONSchema{Type: "string", Enum: []interface{}{"celsius"}}, value: PartialString("cel")},
+		{name: "partial string not a prefix of enum", schema: &types.JSONSchema{Type: "string", Enum: []interface{}{"celsius"}}, value: PartialString("kel"), wantErr: true},
+		{name: "complete string prefix of enum", schema: &types.JSONSchema{Type: "string", Enum: []interface{}{"celsius"}}, value: "cel", wantErr: true},
+		{name: "partial string where number expected", schema: &types.JSONSchema{Type: "number"}, value: PartialString("1"), wantErr: true},
+		{name: "partial string format not checked", schema: &types.JSONSchema{Type: "string", Format: "date-time"}, value: PartialString("2024-")},
+		{name: "complete string format checked", schema: &types.JSONSchema{Type: "string", Format: "date-time"}, value: "2024-", wantErr: true},
+		{name: "partial number matches numeric enum", schema: &types.JSONSchema{Type: "integer", Enum: []interface{}{10, 20}}, value: PartialNumber("1")},
+		{name: "partial number against string enum", schema: &types.JSONSchema{Enum: []interface{}{"a", "b"}}, value: PartialNumber("1"), wantErr: true},
+		{name: "partial number where string expected", schema: &types.JSONSchema{Type: "string"}, value: PartialNumber("1"), wantErr: true},
+		{name: "partial decimal where integer expected", schema: &types.JSONSchema{Type: "integer"}, value: PartialNumber("1.5")},
+		{name: "complete number not in enum", schema: &types.JSONSchema{Type: "integer", Enum: []interface{}{10, 20}}, value: json.Number("1"), wantErr: true},
+		{name: "complete decimal where integer expected", schema: &types.JSONSchema{Type: "integer"}, value: json.Number("1.5"), wantErr: true},
+		{name: "missing required property", schema: &types.JSONSchema{Type: "object", Required: []string{"a"}}, value: map[string]interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePartialSchemaCompliance(tt.schema, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePartialSchemaCompliance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Package vector provides similarity math for embedding vectors.
+//
+// Every function is generic over float32 and float64, so vectors decoded
+// with types.Embedding.Float32 can be used without widening them. Loops are
+// unrolled with independent accumulators and hoisted bounds checks, which
+// shortens the dependency chain between additions.
+//
+// Dot, Cosine and L2Distance compare two vectors of the same length and
+// panic otherwise, like a slice index out of range. Normalize scales a
+// vector to unit length, after which Dot equals Cosine, and Truncate
+// shortens a Matryoshka embedding to fewer dimensions and renormalizes it.
+//
+// TopK selects the best matches for a query from a set of candidates, and
+// Matrix computes every pairwise similarity between two sets. Float32s and
+// Float64s take a *types.EmbeddingResponse directly.
+//
+// Example usage:
+//
+//	docs, err := vector.Float32s(docResp)
+//	if err != nil {
+//	    return err
+//	}
+//	query, err := vector.Float32s(queryResp)
+//	if err != nil {
+//	    return err
+//	}
+//	vector.NormalizeAll(docs)
+//	vector.NormalizeAll(query)
+//
+//	for _, m := range vector.TopK(query[0], docs, 5, vector.Dot[float32]) {
+//	    fmt.Printf("doc %d: %.3f\n", m.Index, m.Score)
+//	}
+package vector
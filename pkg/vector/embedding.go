@@ -0,0 +1,48 @@
+package vector
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// Float32s decodes every embedding in resp, in Index order, using
+// types.Embedding.Float32. It fails if an embedding cannot be decoded or
+// the embeddings differ in length. Vectors already stored as []float32 are
+// returned without copying, so normalizing them in place modifies resp.
+func Float32s(resp *types.EmbeddingResponse) ([][]float32, error) {
+	return decode(resp, (*types.Embedding).Float32)
+}
+
+// Float64s is Float32s for float64 vectors.
+func Float64s(resp *types.EmbeddingResponse) ([][]float64, error) {
+	return decode(resp, (*types.Embedding).Float64)
+}
+
+func decode[T Float](resp *types.EmbeddingResponse, fn func(*types.Embedding) ([]T, error)) ([][]T, error) {
+	if resp == nil {
+		return nil, nil
+	}
+	data := make([]*types.Embedding, 0, len(resp.Data))
+	for _, emb := range resp.Data {
+		if emb != nil {
+			data = append(data, emb)
+		}
+	}
+	sort.SliceStable(data, func(i, j int) bool { return data[i].Index < data[j].Index })
+
+	out := make([][]T, len(data))
+	for i, emb := range data {
+		v, err := fn(emb)
+		if err != nil {
+			return nil, fmt.Errorf("vector: embedding %d: %w", emb.Index, err)
+		}
+		if i > 0 && len(v) != len(out[0]) {
+			return nil, fmt.Errorf("vector: embedding %d has %d dimensions, embedding %d has %d",
+				emb.Index, len(v), data[0].Index, len(out[0]))
+		}
+		out[i] = v
+	}
+	return out, nil
+}
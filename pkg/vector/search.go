@@ -0,0 +1,108 @@
+package vector
+
+import (
+	"container/heap"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Match is a candidate selected by TopK.
+type Match struct {
+	// Index is the candidate's position in the slice passed to TopK.
+	Index int
+
+	// Score is the candidate's similarity to the query.
+	Score float64
+}
+
+// TopK returns the k candidates most similar to query, best first. Ties are
+// broken by lower index. Passing Dot on normalized vectors is equivalent to,
+// and faster than, passing Cosine.
+func TopK[T Float](query []T, candidates [][]T, k int, sim Similarity[T]) []Match {
+	if k <= 0 {
+		return nil
+	}
+	if k > len(candidates) {
+		k = len(candidates)
+	}
+
+	h := make(matchHeap, 0, k)
+	for i, c := range candidates {
+		m := Match{Index: i, Score: float64(sim(query, c))}
+		if len(h) < k {
+			heap.Push(&h, m)
+		} else if better(m, h[0]) {
+			h[0] = m
+			heap.Fix(&h, 0)
+		}
+	}
+
+	sort.Slice(h, func(i, j int) bool { return better(h[i], h[j]) })
+	return h
+}
+
+// Matrix returns the similarity of every vector in a to every vector in b:
+// out[i][j] is sim(a[i], b[j]). Rows are computed in parallel.
+func Matrix[T Float](a, b [][]T, sim Similarity[T]) [][]T {
+	out := make([][]T, len(a))
+	workers := min(runtime.GOMAXPROCS(0), len(a))
+
+	var wg sync.WaitGroup
+	rows := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range rows {
+				row := make([]T, len(b))
+				for j, v := range b {
+					row[j] = sim(a[i], v)
+				}
+				out[i] = row
+			}
+		}()
+	}
+	for i := range a {
+		rows <- i
+	}
+	close(rows)
+	wg.Wait()
+	return out
+}
+
+// CosineMatrix is Matrix with cosine similarity. It normalizes copies of
+// both sets once rather than once per pair.
+func CosineMatrix[T Float](a, b [][]T) [][]T {
+	return Matrix(normalizedAll(a), normalizedAll(b), Dot[T])
+}
+
+func normalizedAll[T Float](vs [][]T) [][]T {
+	out := make([][]T, len(vs))
+	for i, v := range vs {
+		out[i] = Normalized(v)
+	}
+	return out
+}
+
+// better orders matches by descending score, then ascending index.
+func better(a, b Match) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.Index < b.Index
+}
+
+// matchHeap is a min-heap with the worst kept match at the root.
+type matchHeap []Match
+
+func (h matchHeap) Len() int           { return len(h) }
+func (h matchHeap) Less(i, j int) bool { return better(h[j], h[i]) }
+func (h matchHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *matchHeap) Push(x any)        { *h = append(*h, x.(Match)) }
+func (h *matchHeap) Pop() any {
+	old := *h
+	m := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return m
+}
@@ -0,0 +1,171 @@
+package vector
+
+import (
+	"fmt"
+	"math"
+)
+
+// Float is the element type of a vector.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Similarity scores two vectors of the same length; higher is more similar.
+type Similarity[T Float] func(a, b []T) T
+
+// Dot returns the dot product of a and b.
+func Dot[T Float](a, b []T) T {
+	checkLen(len(a), len(b))
+	b = b[:len(a)]
+
+	var s0, s1, s2, s3 T
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		s0 += a[i] * b[i]
+		s1 += a[i+1] * b[i+1]
+		s2 += a[i+2] * b[i+2]
+		s3 += a[i+3] * b[i+3]
+	}
+	for ; i < len(a); i++ {
+		s0 += a[i] * b[i]
+	}
+	return s0 + s1 + s2 + s3
+}
+
+// Norm returns the Euclidean length of v.
+func Norm[T Float](v []T) T {
+	return T(math.Sqrt(float64(Dot(v, v))))
+}
+
+// Cosine returns the cosine similarity of a and b, in [-1, 1]. It is 0 if
+// either vector is all zeros.
+func Cosine[T Float](a, b []T) T {
+	checkLen(len(a), len(b))
+	b = b[:len(a)]
+
+	var dot, na, nb T
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return T(float64(dot) / (math.Sqrt(float64(na)) * math.Sqrt(float64(nb))))
+}
+
+// L2Distance returns the Euclidean distance between a and b.
+func L2Distance[T Float](a, b []T) T {
+	return T(math.Sqrt(float64(SquaredL2Distance(a, b))))
+}
+
+// SquaredL2Distance returns the squared Euclidean distance between a and b,
+// which ranks vectors the same as L2Distance without the square root.
+func SquaredL2Distance[T Float](a, b []T) T {
+	checkLen(len(a), len(b))
+	b = b[:len(a)]
+
+	var s0, s1, s2, s3 T
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		d0 := a[i] - b[i]
+		d1 := a[i+1] - b[i+1]
+		d2 := a[i+2] - b[i+2]
+		d3 := a[i+3] - b[i+3]
+		s0 += d0 * d0
+		s1 += d1 * d1
+		s2 += d2 * d2
+		s3 += d3 * d3
+	}
+	for ; i < len(a); i++ {
+		d := a[i] - b[i]
+		s0 += d * d
+	}
+	return s0 + s1 + s2 + s3
+}
+
+// Normalize scales v in place to unit length and returns it. A zero vector
+// is left unchanged.
+func Normalize[T Float](v []T) []T {
+	n := Norm(v)
+	if n == 0 {
+		return v
+	}
+	Scale(v, 1/n)
+	return v
+}
+
+// Normalized returns a unit-length copy of v.
+func Normalized[T Float](v []T) []T {
+	return Normalize(append([]T(nil), v...))
+}
+
+// NormalizeAll normalizes every vector in place.
+func NormalizeAll[T Float](vs [][]T) {
+	for _, v := range vs {
+		Normalize(v)
+	}
+}
+
+// Scale multiplies v in place by s.
+func Scale[T Float](v []T, s T) {
+	i := 0
+	for ; i+4 <= len(v); i += 4 {
+		v[i] *= s
+		v[i+1] *= s
+		v[i+2] *= s
+		v[i+3] *= s
+	}
+	for ; i < len(v); i++ {
+		v[i] *= s
+	}
+}
+
+// Truncate returns the first dims values of a Matryoshka embedding as a new
+// unit-length vector. Models trained this way keep the most information in
+// the leading dimensions, so the result can stand in for an embedding
+// requested with EmbeddingRequest.Dimensions set to dims. If dims is not
+// less than len(v), a normalized copy of v is returned.
+func Truncate[T Float](v []T, dims int) []T {
+	if dims <= 0 {
+		panic(fmt.Sprintf("vector: truncate to %d dimensions", dims))
+	}
+	if dims > len(v) {
+		dims = len(v)
+	}
+	return Normalized(v[:dims])
+}
+
+// TruncateAll truncates every vector. See Truncate.
+func TruncateAll[T Float](vs [][]T, dims int) [][]T {
+	out := make([][]T, len(vs))
+	for i, v := range vs {
+		out[i] = Truncate(v, dims)
+	}
+	return out
+}
+
+// Float64 converts v to float64.
+func Float64[T Float](v []T) []float64 {
+	out := make([]float64, len(v))
+	for i, f := range v {
+		out[i] = float64(f)
+	}
+	return out
+}
+
+// Float32 converts v to float32.
+func Float32[T Float](v []T) []float32 {
+	out := make([]float32, len(v))
+	for i, f := range v {
+		out[i] = float32(f)
+	}
+	return out
+}
+
+func checkLen(a, b int) {
+	if a != b {
+		panic(fmt.Sprintf("vector: length mismatch: %d != %d", a, b))
+	}
+}
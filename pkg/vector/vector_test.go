@@ -0,0 +1,164 @@
+package vector
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+)
+
+// approx reports whether every value of got is within 1e-6 of want.
+func approx[T Float](got, want []T) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if math.Abs(float64(got[i]-want[i])) > 1e-6 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTopK(t *testing.T) {
+	candidates := [][]float64{{1}, {3}, {2}, {3}, {1}}
+	tests := []struct {
+		name string
+		k    int
+		want string
+	}{
+		{name: "best first", k: 3, want: "[{1 3} {3 3} {2 2}]"},
+		{name: "ties broken by lower index", k: 1, want: "[{1 3}]"},
+		{name: "tie at the cut keeps the lower index", k: 4, want: "[{1 3} {3 3} {2 2} {0 1}]"},
+		{name: "k above the candidates", k: 10, want: "[{1 3} {3 3} {2 2} {0 1} {4 1}]"},
+		{name: "k of zero", k: 0, want: "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TopK([]float64{1}, candidates, tt.k, Dot[float64])
+			if fmt.Sprint(got) != tt.want {
+				t.Errorf("TopK() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		v    []float32
+		dims int
+		want []float32
+	}{
+		{name: "leading dimensions renormalized", v: []float32{3, 4, 12}, dims: 2, want: []float32{0.6, 0.8}},
+		{name: "dims at the length", v: []float32{0, 2}, dims: 2, want: []float32{0, 1}},
+		{name: "dims above the length", v: []float32{0, 0, 5}, dims: 8, want: []float32{0, 0, 1}},
+		{name: "zero prefix stays zero", v: []float32{0, 0, 1}, dims: 2, want: []float32{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := append([]float32(nil), tt.v...)
+			got := Truncate(tt.v, tt.dims)
+			if !approx(got, tt.want) {
+				t.Errorf("Truncate(%v, %d) = %v, want %v", tt.v, tt.dims, got, tt.want)
+			}
+			if !approx(tt.v, orig) {
+				t.Errorf("Truncate() modified its input to %v", tt.v)
+			}
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Truncate() to 0 dimensions did not panic")
+		}
+	}()
+	Truncate([]float32{1}, 0)
+}
+
+func TestCosineMatrix(t *testing.T) {
+	a := [][]float64{{1, 0}, {0, 2}, {3, 3}}
+	b := [][]float64{{5, 0}, {-1, -1}}
+	aCopy := fmt.Sprint(a)
+
+	got := CosineMatrix(a, b)
+	if len(got) != len(a) {
+		t.Fatalf("CosineMatrix() has %d rows, want %d", len(got), len(a))
+	}
+	for i := range a {
+		for j := range b {
+			if want := Cosine(a[i], b[j]); math.Abs(got[i][j]-want) > 1e-9 {
+				t.Errorf("CosineMatrix()[%d][%d] = %v, want %v", i, j, got[i][j], want)
+			}
+		}
+	}
+	if fmt.Sprint(a) != aCopy {
+		t.Errorf("CosineMatrix() modified its input to %v", a)
+	}
+	if got := CosineMatrix(nil, b); len(got) != 0 {
+		t.Errorf("CosineMatrix(nil) = %v, want no rows", got)
+	}
+}
+
+func TestFloat32s(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    *types.EmbeddingResponse
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "sorted by Index",
+			resp: &types.EmbeddingResponse{Data: []*types.Embedding{
+				{Index: 2, Embedding: []float64{3, 3}},
+				{Index: 0, Embedding: types.EncodeFloat32Base64([]float32{1, 1})},
+				{Index: 1, Embedding: []float32{2, 2}},
+			}},
+			want: "[[1 1] [2 2] [3 3]]",
+		},
+		{
+			name: "equal indices keep their order",
+			resp: &types.EmbeddingResponse{Data: []*types.Embedding{
+				{Index: 1, Embedding: []float64{2}},
+				{Index: 0, Embedding: []float64{0}},
+				{Index: 1, Embedding: []float64{1}},
+			}},
+			want: "[[0] [2] [1]]",
+		},
+		{
+			name: "nil response",
+			want: "[]",
+		},
+		{
+			name:    "differing lengths",
+			resp:    &types.EmbeddingResponse{Data: []*types.Embedding{{Index: 0, Embedding: []float64{1}}, {Index: 1, Embedding: []float64{1, 2}}}},
+			wantErr: true,
+		},
+		{
+			name:    "undecodable embedding",
+			resp:    &types.EmbeddingResponse{Data: []*types.Embedding{{Index: 0, Embedding: "not base64!"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Float32s(tt.resp)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Float32s() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Float32s() error = %v", err)
+			}
+			if fmt.Sprint(got) != tt.want {
+				t.Errorf("Float32s() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package benchmarks
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/zacw/go-ai-types/pkg/types"
+	"github.com/zacw/go-ai-types/pkg/vector"
+)
+
+const benchDims = 1536
+
+func randomVectors(n, dims int) [][]float32 {
+	r := rand.New(rand.NewSource(1))
+	vs := make([][]float32, n)
+	for i := range vs {
+		vs[i] = make([]float32, dims)
+		for j := range vs[i] {
+			vs[i][j] = r.Float32()*2 - 1
+		}
+	}
+	return vs
+}
+
+func BenchmarkDotFloat32(b *testing.B) {
+	vs := randomVectors(2, benchDims)
+	b.SetBytes(benchDims * 4 * 2)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vector.Dot(vs[0], vs[1])
+	}
+}
+
+func BenchmarkDotFloat64(b *testing.B) {
+	vs := randomVectors(2, benchDims)
+	x, y := vector.Float64(vs[0]), vector.Float64(vs[1])
+	b.SetBytes(benchDims * 8 * 2)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vector.Dot(x, y)
+	}
+}
+
+func BenchmarkCosineFloat32(b *testing.B) {
+	vs := randomVectors(2, benchDims)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vector.Cosine(vs[0], vs[1])
+	}
+}
+
+func BenchmarkNormalizeFloat32(b *testing.B) {
+	vs := randomVectors(1, benchDims)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vector.Normalize(vs[0])
+	}
+}
+
+func BenchmarkTruncateFloat32(b *testing.B) {
+	vs := randomVectors(1, benchDims)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vector.Truncate(vs[0], 256)
+	}
+}
+
+func BenchmarkTopK10Of10000(b *testing.B) {
+	docs := randomVectors(10000, 256)
+	vector.NormalizeAll(docs)
+	query := vector.Normalized(randomVectors(1, 256)[0])
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vector.TopK(query, docs, 10, vector.Dot[float32])
+	}
+}
+
+func BenchmarkCosineMatrix100x1000(b *testing.B) {
+	queries := randomVectors(100, 256)
+	docs := randomVectors(1000, 256)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vector.CosineMatrix(queries, docs)
+	}
+}
+
+func BenchmarkFloat32sBase64(b *testing.B) {
+	resp := &types.EmbeddingResponse{}
+	for i, v := range randomVectors(100, benchDims) {
+		resp.Data = append(resp.Data, &types.Embedding{Index: i, Embedding: types.EncodeFloat32Base64(v)})
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := vector.Float32s(resp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFloat32sJSONFloats(b *testing.B) {
+	resp := &types.EmbeddingResponse{}
+	for i, v := range randomVectors(100, benchDims) {
+		values := make([]interface{}, len(v))
+		for j, f := range v {
+			values[j] = float64(f)
+		}
+		resp.Data = append(resp.Data, &types.Embedding{Index: i, Embedding: values})
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := vector.Float32s(resp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}